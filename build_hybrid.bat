@@ -66,10 +66,10 @@ if exist "fast_vision.zig" (
 :: Test Go module
 echo.
 echo Testing Go module compilation...
-go build fast_network.go
+go build ./cmd/vrchat-proximity
 if %errorlevel% equ 0 (
     echo SUCCESS: Go module compiled
-    del fast_network.exe >nul 2>nul
+    del vrchat-proximity.exe >nul 2>nul
 ) else (
     echo ERROR: Go module compilation failed
     pause
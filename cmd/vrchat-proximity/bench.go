@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"vrchat-proximity/pkg/capture"
+	"vrchat-proximity/pkg/engine"
+	"vrchat-proximity/pkg/transport"
+)
+
+// benchSource signals when the wrapped source runs out of frames
+type benchSource struct {
+	capture.FrameSource
+	done chan struct{}
+	once sync.Once
+}
+
+// NextFrame forwards to the wrapped source and closes done at io.EOF
+func (b *benchSource) NextFrame(ctx context.Context) (capture.Frame, error) {
+	frame, err := b.FrameSource.NextFrame(ctx)
+	if errors.Is(err, io.EOF) {
+		b.once.Do(func() { close(b.done) })
+	}
+	return frame, err
+}
+
+// runBenchmark pushes synthetic frames through capture, detection, and
+// broadcast, then prints per-stage latency percentiles
+func runBenchmark(config capture.SyntheticConfig) error {
+	fmt.Printf("Benchmark: %d frames at %dx%d with %d moving objects\n",
+		config.Frames, config.Width, config.Height, config.Objects)
+
+	source := &benchSource{
+		FrameSource: capture.NewSyntheticSource(config),
+		done:        make(chan struct{}),
+	}
+
+	pe := engine.NewProximityEngine()
+	pe.SetFrameSource(source)
+	pe.SetTargetFPS(120)
+
+	// Serialize broadcasts exactly as a live server would, without listening
+	transport.NewServer(pe, transport.DefaultServerConfig())
+
+	start := time.Now()
+	if err := pe.Start(); err != nil {
+		return err
+	}
+	<-source.done
+	elapsed := time.Since(start)
+
+	// Let the last batches drain through the detection hooks
+	time.Sleep(100 * time.Millisecond)
+	status := pe.Status()
+	latencies := pe.StageLatencies()
+	pe.Stop()
+
+	fmt.Println()
+	fmt.Printf("%-10s %8s %10s %10s %10s %10s\n", "stage", "samples", "p50 ms", "p95 ms", "p99 ms", "max ms")
+	for _, stage := range []string{engine.StageCapture, engine.StageDetect, engine.StageConvert, engine.StageBroadcast} {
+		l := latencies[stage]
+		fmt.Printf("%-10s %8d %10.3f %10.3f %10.3f %10.3f\n", stage, l.Count, l.P50MS, l.P95MS, l.P99MS, l.MaxMS)
+	}
+
+	fmt.Println()
+	fmt.Printf("Frames processed:      %d in %s (%.1f FPS)\n",
+		status.FramesProcessed, elapsed.Round(time.Millisecond), float64(status.FramesProcessed)/elapsed.Seconds())
+	if status.FramesProcessed > 1 {
+		fmt.Printf("Detections per frame:  %.2f (expected %d)\n",
+			float64(status.TotalDetections)/float64(status.FramesProcessed-1), config.Objects)
+	}
+	if status.TotalDetections == 0 {
+		fmt.Println("WARNING: no detections; the Zig motion detector may not be built correctly")
+	}
+	return nil
+}
@@ -0,0 +1,811 @@
+// Command vrchat-proximity runs the proximity engine with its HTTP API and integrations.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"syscall"
+
+	"vrchat-proximity/pkg/capture"
+	"vrchat-proximity/pkg/config"
+	"vrchat-proximity/pkg/engine"
+	"vrchat-proximity/pkg/history"
+	"vrchat-proximity/pkg/input"
+	"vrchat-proximity/pkg/logging"
+	"vrchat-proximity/pkg/overlay"
+	"vrchat-proximity/pkg/scripting"
+	"vrchat-proximity/pkg/transport"
+)
+
+// mainLog is the "main" subsystem logger
+var mainLog = logging.For("main")
+
+// fatal logs err and exits
+func fatal(message string, err error) {
+	mainLog.Error(message, "error", err)
+	os.Exit(1)
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// dashboardURL returns the local URL of the dashboard served on addr
+func dashboardURL(addr string, secure bool) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		host, port = addr, "80"
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "localhost"
+	}
+	scheme := "http"
+	if secure {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s/", scheme, net.JoinHostPort(host, port))
+}
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "service" {
+		if err := runServiceCommand(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if runningAsService() {
+		if err := runAsService(run); err != nil {
+			fatal("Service failed", err)
+		}
+		return
+	}
+
+	// Cancelled on interrupt so background watchers wind down with deferred cleanup
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	run(ctx)
+}
+
+// run starts the engine from the command-line flags and blocks until ctx is cancelled
+func run(ctx context.Context) {
+	fmt.Println("VRChat Fast Proximity Engine (Go + Zig)")
+	fmt.Println("=======================================")
+
+	// Flags bind into settings; a -config file is layered underneath them after parsing
+	settings := config.Default()
+	configPath := flag.String("config", "", "YAML settings file, hot-reloaded on change; explicit flags override it")
+	serverConfig := &settings.Server
+	flag.StringVar(&serverConfig.Addr, "addr", serverConfig.Addr, "HTTP and WebSocket listen address")
+	flag.Float64Var(&serverConfig.NearestRate, "nearest-rate", serverConfig.NearestRate, "Nearest-detection messages per second on /ws and /events (0 disables)")
+
+	oscConfig := &settings.Integrations.OSC
+	flag.BoolVar(&oscConfig.Enabled, "osc", oscConfig.Enabled, "Send proximity avatar parameters to VRChat over OSC")
+	flag.StringVar(&oscConfig.SendAddr, "osc-send", oscConfig.SendAddr, "VRChat OSC input address")
+	flag.StringVar(&oscConfig.ListenAddr, "osc-listen", oscConfig.ListenAddr, "Local OSC input address for control messages")
+	flag.BoolVar(&oscConfig.OSCQuery, "oscquery", oscConfig.OSCQuery, "Advertise OSC endpoints via OSCQuery and mDNS")
+	flag.StringVar(&oscConfig.OSCQueryAddr, "oscquery-addr", oscConfig.OSCQueryAddr, "OSCQuery HTTP address")
+
+	hapticsConfig := &settings.Integrations.Haptics
+	hapticsCurves := flag.String("haptics-curves", "", "JSON object of per-category haptic curves")
+	flag.BoolVar(&hapticsConfig.Enabled, "haptics", hapticsConfig.Enabled, "Drive bHaptics devices from nearby detections")
+	flag.StringVar(&hapticsConfig.PlayerURL, "haptics-url", hapticsConfig.PlayerURL, "bHaptics Player WebSocket URL")
+
+	notifyConfig := &settings.Integrations.Notifications
+	notifyTemplates := flag.String("notify-templates", "", "JSON object of per-category notification templates")
+	flag.BoolVar(&notifyConfig.XSOverlay, "xsoverlay", notifyConfig.XSOverlay, "Send zone_enter notifications to XSOverlay")
+	flag.BoolVar(&notifyConfig.OVRToolkit, "ovrtoolkit", notifyConfig.OVRToolkit, "Send zone_enter notifications to OVR Toolkit")
+	flag.DurationVar(&notifyConfig.MinInterval, "notify-interval", notifyConfig.MinInterval, "Minimum time between notifications")
+
+	speechConfig := &settings.Integrations.Speech
+	flag.BoolVar(&speechConfig.Enabled, "speech", speechConfig.Enabled, "Announce nearby events with text-to-speech (SAPI, espeak, or say)")
+	flag.StringVar(&speechConfig.Voice, "speech-voice", speechConfig.Voice, "Text-to-speech voice name")
+
+	audioConfig := &settings.Integrations.Audio
+	flag.BoolVar(&audioConfig.Enabled, "audio-cues", audioConfig.Enabled, "Beep toward the nearest detection's side of the screen, louder when closer")
+
+	toastConfig := &settings.Integrations.Toasts
+	flag.BoolVar(&toastConfig.Enabled, "toasts", toastConfig.Enabled, "Raise desktop notifications for fast approaches and Very Close in public instances")
+
+	overlayConfig := &settings.Integrations.Overlay
+	flag.BoolVar(&overlayConfig.Enabled, "overlay", overlayConfig.Enabled, "Show a proximity radar in the headset as a SteamVR overlay (Windows)")
+
+	controllerConfig := &settings.Integrations.Controllers
+	flag.BoolVar(&controllerConfig.Enabled, "controller-haptics", controllerConfig.Enabled, "Pulse the VR controllers for close detections through SteamVR (Windows)")
+
+	lightingConfig := &settings.Integrations.Lighting
+	flag.BoolVar(&lightingConfig.Enabled, "lighting", lightingConfig.Enabled, "Drive WLED or Hue lights from the nearest category and crowd size")
+	flag.StringVar(&lightingConfig.Driver, "lighting-driver", lightingConfig.Driver, "Lighting driver: wled or hue")
+
+	intifaceConfig := &settings.Integrations.Intiface
+	flag.BoolVar(&intifaceConfig.Enabled, "intiface", intifaceConfig.Enabled, "Vibrate Buttplug devices through Intiface Central by proximity")
+	flag.StringVar(&intifaceConfig.URL, "intiface-url", intifaceConfig.URL, "Intiface server WebSocket address")
+
+	discordConfig := &settings.Integrations.Discord
+	flag.BoolVar(&discordConfig.RichPresence, "discord-presence", discordConfig.RichPresence, "Show the nearby player count as Discord Rich Presence (needs a client_id)")
+	flag.StringVar(&discordConfig.SummaryWebhook, "discord-summary", discordConfig.SummaryWebhook, "Discord webhook URL for end-of-session summaries")
+
+	previewConfig := &settings.Preview
+	flag.BoolVar(&previewConfig.Enabled, "preview", previewConfig.Enabled, "Serve the MJPEG preview stream at /preview.mjpeg")
+	flag.IntVar(&previewConfig.FPS, "preview-fps", previewConfig.FPS, "Maximum preview frames per second")
+	flag.IntVar(&previewConfig.MaxWidth, "preview-width", previewConfig.MaxWidth, "Maximum preview frame width")
+	privacyConfig := &settings.Privacy
+	flag.BoolVar(&privacyConfig.Enabled, "privacy", privacyConfig.Enabled, "Never save frames or player names and obscure the preview")
+	statsConfig := &settings.Stats
+	flag.StringVar(&statsConfig.Path, "stats-file", statsConfig.Path, "File keeping lifetime frame, detection, and session totals across restarts (empty disables)")
+	clipConfig := &settings.Clips
+	flag.BoolVar(&clipConfig.Enabled, "clips", clipConfig.Enabled, "Save an annotated GIF around each high-priority alert")
+	flag.StringVar(&clipConfig.Dir, "clips-dir", clipConfig.Dir, "Directory for alert clips")
+
+	recorderConfig := engine.DefaultRecorderConfig()
+	recordPath := flag.String("record", "", "Record detections to this file")
+	flag.BoolVar(&recorderConfig.Keyframes, "record-keyframes", recorderConfig.Keyframes, "Also record downscaled preview keyframes")
+	replayPath := flag.String("replay", "", "Replay a recording instead of capturing the screen")
+	replaySpeed := flag.Float64("replay-speed", 1, "Replay speed multiplier")
+	replayLoop := flag.Bool("replay-loop", false, "Restart the replay when it ends")
+
+	videoConfig := capture.DefaultVideoConfig()
+	videoPath := flag.String("video", "", "Detect on a recorded video file instead of the screen (requires ffmpeg)")
+	imagePattern := flag.String("images", "", "Detect on an image sequence glob, e.g. frames/*.png, instead of the screen")
+	flag.StringVar(&videoConfig.FFmpeg, "ffmpeg", videoConfig.FFmpeg, "ffmpeg binary used to decode -video")
+	flag.IntVar(&videoConfig.MaxSize, "video-max-size", videoConfig.MaxSize, "Downscale -video frames to at most this many pixels per side")
+	flag.BoolVar(&videoConfig.Loop, "source-loop", videoConfig.Loop, "Restart -video or -images when they end")
+	flag.StringVar(&settings.Capture.Backend, "capture-backend", settings.Capture.Backend, "Screen capture backend: zig, dxgi for GPU-downscaled Desktop Duplication, ndi for an NDI stream, spout for a Spout sender (Windows), network for -stream-url, quest for a standalone Quest over ADB, x11/pipewire (Linux), or screencapturekit (macOS); zig picks the native one on Linux and macOS")
+	flag.IntVar(&settings.Capture.DXGI.Output, "dxgi-output", settings.Capture.DXGI.Output, "Monitor captured by -capture-backend dxgi")
+	flag.StringVar(&settings.Capture.NDI.Source, "ndi-source", settings.Capture.NDI.Source, "NDI stream received by -capture-backend ndi, or part of its name; empty takes the first found")
+	flag.StringVar(&settings.Capture.Network.URL, "stream-url", settings.Capture.Network.URL, "RTSP/RTMP stream decoded by -capture-backend network, e.g. rtsp://192.168.1.20:8554/live (requires ffmpeg)")
+	flag.StringVar(&settings.Capture.Quest.Serial, "quest-serial", settings.Capture.Quest.Serial, "Headset mirrored by -capture-backend quest: its ADB serial, or ip:port for wireless ADB")
+	flag.StringVar(&settings.Capture.Spout.Sender, "spout-sender", settings.Capture.Spout.Sender, "Spout sender read by -capture-backend spout; empty follows the active sender")
+	flag.IntVar(&settings.Capture.DXGI.MaxWidth, "dxgi-max-width", settings.Capture.DXGI.MaxWidth, "Halve dxgi frames on the GPU until they are at most this wide (0 keeps the native size)")
+	flag.StringVar(&settings.Capture.Algorithm, "algorithm", settings.Capture.Algorithm, "Motion algorithm: frame_diff, mog2, or optical_flow")
+	flag.IntVar(&settings.Capture.Processing.Width, "process-width", settings.Capture.Processing.Width, "Downscale frames to at most this wide before detection (0 keeps the captured size)")
+	flag.IntVar(&settings.Capture.Processing.Height, "process-height", settings.Capture.Processing.Height, "Downscale frames to at most this tall before detection (0 keeps the captured size)")
+	flag.IntVar(&settings.Capture.Tiling.Tiles, "tiles", settings.Capture.Tiling.Tiles, "Split frames into about this many tiles detected in parallel (0 or 1 disables)")
+	flag.DurationVar(&settings.Capture.Watchdog, "watchdog", settings.Capture.Watchdog, "Restart capture when no frame arrives for this long (0 disables)")
+	followConfig := &settings.Capture.FollowProcess
+	flag.BoolVar(&followConfig.Enabled, "follow-process", followConfig.Enabled, "Pause capture while the game process is not running")
+	flag.StringVar(&followConfig.Process, "follow-process-name", followConfig.Process, "Executable name watched by -follow-process")
+	focusConfig := &settings.Capture.FocusOnly
+	flag.BoolVar(&focusConfig.Enabled, "focus-only", focusConfig.Enabled, "Pause capture while another window has focus (Windows)")
+
+	ocrConfig := &settings.OCR
+	flag.BoolVar(&ocrConfig.Enabled, "ocr", ocrConfig.Enabled, "Read player names from nameplates above detections (requires tesseract)")
+	flag.StringVar(&ocrConfig.Tesseract, "tesseract", ocrConfig.Tesseract, "tesseract binary used by -ocr")
+
+	scriptConfig := &settings.Script
+	flag.StringVar(&scriptConfig.Path, "script", scriptConfig.Path, "Lua script with onDetection/onZoneEvent pipeline hooks")
+
+	vrchatConfig := &settings.Integrations.VRChat
+	flag.BoolVar(&vrchatConfig.Enabled, "vrchat-api", vrchatConfig.Enabled, "Look up friends in the current instance with the VRChat API (cookie from VRCHAT_AUTH_COOKIE or the config file)")
+	flag.BoolVar(&vrchatConfig.RequireFriends, "require-friends", vrchatConfig.RequireFriends, "Mute notifications and haptics in instances without friends (needs -vrchat-api)")
+	vrchatLogConfig := &settings.Integrations.VRChatLog
+	flag.BoolVar(&vrchatLogConfig.Enabled, "vrchat-log", vrchatLogConfig.Enabled, "Follow the VRChat output log for the current instance and its type")
+
+	historyConfig := &settings.Integrations.History
+	flag.BoolVar(&historyConfig.Enabled, "history", historyConfig.Enabled, "Store detections and zone events in SQLite")
+	flag.StringVar(&historyConfig.Path, "history-db", historyConfig.Path, "SQLite history database path")
+	flag.DurationVar(&historyConfig.Retention, "history-retention", historyConfig.Retention, "Delete history older than this (0 keeps everything)")
+	exportConfig := &settings.Integrations.Export
+	exportFile := flag.String("export-file", "", "Append every detection to this JSONL file, rotating it by size")
+
+	webhookConfig := &settings.Integrations.Webhooks
+	webhookTargets := flag.String("webhooks", "", "JSON array of webhook targets")
+
+	mqttConfig := &settings.Integrations.MQTT
+	flag.BoolVar(&mqttConfig.Enabled, "mqtt", mqttConfig.Enabled, "Publish detection summaries and events to MQTT")
+	flag.StringVar(&mqttConfig.Broker, "mqtt-broker", mqttConfig.Broker, "MQTT broker URL")
+	flag.StringVar(&mqttConfig.Username, "mqtt-user", mqttConfig.Username, "MQTT username")
+	flag.StringVar(&mqttConfig.Password, "mqtt-password", mqttConfig.Password, "MQTT password")
+	flag.StringVar(&mqttConfig.TopicPrefix, "mqtt-prefix", mqttConfig.TopicPrefix, "MQTT topic prefix")
+
+	flag.StringVar(&settings.Integrations.GRPC, "grpc", settings.Integrations.GRPC, "Serve the gRPC API on this address, e.g. :8081")
+
+	tracingConfig := &settings.Integrations.Tracing
+	flag.BoolVar(&tracingConfig.Enabled, "tracing", tracingConfig.Enabled, "Export frame pipeline traces over OTLP/HTTP")
+	flag.StringVar(&tracingConfig.Endpoint, "otlp-endpoint", tracingConfig.Endpoint, "OTLP/HTTP collector address for -tracing")
+	flag.Float64Var(&tracingConfig.SampleRatio, "trace-sample", tracingConfig.SampleRatio, "Fraction of frames traced; frames slower than tracing.slow_frame always are")
+
+	statsdConfig := &settings.Integrations.StatsD
+	flag.BoolVar(&statsdConfig.Enabled, "statsd", statsdConfig.Enabled, "Send metrics to StatsD, e.g. Telegraf or Graphite")
+	flag.StringVar(&statsdConfig.Addr, "statsd-addr", statsdConfig.Addr, "StatsD UDP address for -statsd")
+	flag.StringVar(&statsdConfig.Prefix, "statsd-prefix", statsdConfig.Prefix, "Prefix of every StatsD metric name")
+	flag.DurationVar(&statsdConfig.Interval, "statsd-interval", statsdConfig.Interval, "Time between StatsD flushes")
+
+	trayConfig := &settings.Integrations.Tray
+	flag.BoolVar(&trayConfig.Enabled, "tray", trayConfig.Enabled, "Show a system tray icon with pause and dashboard controls")
+
+	hotkeyConfig := &settings.Integrations.Hotkeys
+	flag.BoolVar(&hotkeyConfig.Enabled, "hotkeys", hotkeyConfig.Enabled, "Register global hotkeys for pause and sensitivity (Windows)")
+	flag.StringVar(&hotkeyConfig.Pause, "hotkey-pause", hotkeyConfig.Pause, "Hotkey that pauses or resumes capture")
+
+	apiKeys := flag.String("api-keys", "", "Comma-separated API keys required for HTTP and WebSocket access")
+	allowedOrigins := flag.String("cors-origins", "", "Comma-separated browser origins allowed to use the API (* for any)")
+
+	tlsConfig := &settings.TLS
+	tlsHosts := flag.String("tls-hosts", "", "Comma-separated extra hosts for the self-signed certificate")
+	flag.BoolVar(&tlsConfig.Enabled, "tls", tlsConfig.Enabled, "Serve HTTPS and WSS")
+	flag.StringVar(&tlsConfig.CertFile, "tls-cert", tlsConfig.CertFile, "TLS certificate file")
+	flag.StringVar(&tlsConfig.KeyFile, "tls-key", tlsConfig.KeyFile, "TLS private key file")
+	flag.BoolVar(&tlsConfig.SelfSigned, "tls-self-signed", tlsConfig.SelfSigned, "Generate a self-signed certificate if none exists")
+
+	benchConfig := capture.DefaultSyntheticConfig()
+	bench := flag.Bool("bench", false, "Run a synthetic benchmark of the detection pipeline and exit")
+	flag.IntVar(&benchConfig.Frames, "bench-frames", benchConfig.Frames, "Frames to generate for -bench")
+	flag.IntVar(&benchConfig.Objects, "bench-objects", benchConfig.Objects, "Moving objects per -bench frame")
+	flag.IntVar(&benchConfig.Width, "bench-width", benchConfig.Width, "Width of -bench frames")
+	flag.IntVar(&benchConfig.Height, "bench-height", benchConfig.Height, "Height of -bench frames")
+
+	logConfig := &settings.Log
+	flag.StringVar(&logConfig.Level, "log-level", logConfig.Level, "Minimum log level: debug, info, warn, or error")
+	flag.StringVar(&logConfig.Format, "log-format", logConfig.Format, "Log output format: text or json")
+	flag.StringVar(&logConfig.CrashFile, "crash-file", logConfig.CrashFile, "File recovered panics are appended to; empty only logs them")
+	daemon := flag.Bool("daemon", false, "Run in the background, detached from the terminal (not on Windows; use the service subcommand)")
+	pidFile := flag.String("pidfile", "", "Write the process ID to this file while running")
+	daemonLog := flag.String("daemon-log", "vrchat-proximity.log", "Log file for -daemon output")
+	flag.Parse()
+
+	// Layer the file under the flags: defaults, then the file, then explicit flags
+	var loaded config.Settings
+	if *configPath != "" {
+		loaded = config.Default()
+		if err := config.Load(*configPath, &loaded); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		explicit := map[string]string{}
+		flag.Visit(func(f *flag.Flag) {
+			explicit[f.Name] = f.Value.String()
+		})
+		settings = loaded
+		for name, value := range explicit {
+			flag.Set(name, value)
+		}
+	}
+	if err := previewConfig.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, "preview:", err)
+		os.Exit(2)
+	}
+
+	if *daemon && os.Getenv(daemonEnv) == "" {
+		if err := startDaemon(os.Args[1:], *pidFile, *daemonLog); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if *pidFile != "" {
+		if err := writePIDFile(*pidFile); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer os.Remove(*pidFile)
+	}
+
+	logs, err := logging.Setup(*logConfig, os.Stderr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if *bench {
+		if err := runBenchmark(benchConfig); err != nil {
+			fatal("Benchmark failed", err)
+		}
+		return
+	}
+
+	if *apiKeys != "" {
+		serverConfig.Security.APIKeys = splitList(*apiKeys)
+	}
+	if *allowedOrigins != "" {
+		serverConfig.Security.AllowedOrigins = splitList(*allowedOrigins)
+	}
+	if *tlsHosts != "" {
+		tlsConfig.Hosts = splitList(*tlsHosts)
+	}
+
+	if *hapticsCurves != "" {
+		hapticsConfig.Curves = nil
+		if err := json.Unmarshal([]byte(*hapticsCurves), &hapticsConfig.Curves); err != nil {
+			fatal("Invalid -haptics-curves", err)
+		}
+	}
+
+	if *notifyTemplates != "" {
+		notifyConfig.Templates = nil
+		if err := json.Unmarshal([]byte(*notifyTemplates), &notifyConfig.Templates); err != nil {
+			fatal("Invalid -notify-templates", err)
+		}
+	}
+
+	if *webhookTargets != "" {
+		if err := json.Unmarshal([]byte(*webhookTargets), &webhookConfig.Targets); err != nil {
+			fatal("Invalid -webhooks", err)
+		}
+	}
+
+	if *exportFile != "" {
+		exportConfig.Enabled, exportConfig.Path = true, *exportFile
+	}
+
+	if tlsConfig.Enabled {
+		serverTLS, err := transport.LoadTLS(*tlsConfig)
+		if err != nil {
+			fatal("TLS setup failed", err)
+		}
+		serverConfig.TLS = serverTLS
+	}
+
+	pe := engine.NewProximityEngine()
+	pe.SetTargetFPS(settings.Capture.TargetFPS)
+	pe.SetSensitivity(settings.Capture.Sensitivity)
+	if err := pe.SetMotionAlgorithm(settings.Capture.Algorithm); err != nil {
+		fatal("Invalid -algorithm", err)
+	}
+	pe.SetProcessingConfig(settings.Capture.Processing)
+	pe.SetTilingConfig(settings.Capture.Tiling)
+	pe.SetOverflowConfig(settings.Capture.Overflow)
+	pe.SetStereoConfig(settings.Capture.Stereo)
+	pe.SetCameraConfig(settings.Camera)
+	if err := pe.SetDistanceBands(settings.Categories); err != nil {
+		fatal("Invalid categories", err)
+	}
+	pe.SetDetectionConfig(settings.Detection)
+	pe.SetCalibrationConfig(settings.Calibration)
+	if settings.Calibration.Path != "" {
+		if err := pe.LoadCalibration(settings.Calibration.Path); err != nil {
+			mainLog.Warn("Confidence calibration starts from no feedback", "error", err)
+		}
+	}
+	pe.SetFeedbackConfig(settings.Feedback)
+	if settings.Feedback.Path != "" {
+		if err := pe.LoadFeedback(settings.Feedback.Path); err != nil {
+			mainLog.Warn("Feedback learning starts from no examples", "error", err)
+		}
+	}
+	pe.SetTrackingConfig(settings.Tracking)
+	pe.SetCrowdConfig(settings.Crowd)
+	pe.SetSceneConfig(settings.Scene)
+	pe.SetMirrorConfig(settings.Mirror)
+	pe.SetZoneExitTimeout(settings.Zones.ExitTimeout)
+	pe.SetMasks(settings.Masks)
+	pe.SetColorProfiles(settings.Profiles)
+	pe.SetOCRConfig(*ocrConfig)
+	pe.SetPlayerRules(settings.Players)
+	pe.SetRules(settings.Rules)
+	pe.SetAlertConfig(settings.Alerts)
+	if scriptConfig.Path != "" {
+		script, err := scripting.LoadLuaScript(*scriptConfig)
+		if err != nil {
+			mainLog.Warn("Pipeline script disabled", "error", err)
+		} else {
+			pe.SetScript(script)
+			defer script.Close()
+		}
+	}
+	pe.SetPreviewConfig(*previewConfig)
+	pe.SetPrivacyConfig(*privacyConfig)
+	if err := pe.SetClipConfig(*clipConfig); err != nil {
+		mainLog.Warn("Alert clips disabled", "error", err)
+	}
+	pe.SetWatchdogTimeout(settings.Capture.Watchdog)
+	pe.SetPerformanceConfig(settings.Performance)
+	pe.SetBudgetConfig(settings.Budget)
+	pe.SetCrashFile(settings.Log.CrashFile)
+	if statsConfig.Path != "" {
+		if err := pe.LoadLifetimeStats(statsConfig.Path); err != nil {
+			mainLog.Warn("Lifetime statistics start from zero", "error", err)
+		}
+		defer func() {
+			if err := pe.SaveLifetimeStats(statsConfig.Path); err != nil {
+				mainLog.Warn("Saving lifetime statistics failed", "error", err)
+			}
+		}()
+		go pe.KeepLifetimeStats(ctx, statsConfig.Path, statsConfig.SaveInterval)
+	}
+	server := transport.NewServer(pe, *serverConfig)
+	server.API().Handle("/logs", logs)
+
+	if *configPath != "" {
+		reloader := config.NewReloader(*configPath, pe, loaded)
+		server.API().Handle("/config/reload", reloader)
+
+		if err := reloader.Watch(ctx); err != nil {
+			mainLog.Warn("Config hot reload disabled", "error", err)
+		}
+	}
+
+	backend := settings.Capture.Backend
+	switch {
+	case backend == capture.BackendZig && runtime.GOOS == "linux":
+		backend = capture.LinuxBackend()
+	case backend == capture.BackendZig && runtime.GOOS == "darwin":
+		backend = capture.BackendMacOS
+	}
+	switch {
+	case *videoPath != "":
+		video := capture.NewVideoSource(*videoPath, videoConfig)
+		pe.SetFrameSource(video)
+		defer video.Close()
+	case *imagePattern != "":
+		images, err := capture.NewImageSequenceSource(*imagePattern, videoConfig.Loop)
+		if err != nil {
+			fatal("Invalid -images", err)
+		}
+		pe.SetFrameSource(images)
+	case backend == capture.BackendDXGI:
+		dxgi, err := capture.NewDXGISource(settings.Capture.DXGI)
+		if err != nil {
+			mainLog.Warn("DXGI capture unavailable, using Zig capture", "error", err)
+		} else {
+			pe.SetFrameSource(dxgi)
+			defer dxgi.Close()
+		}
+	case backend == capture.BackendNDI:
+		ndi, err := capture.NewNDISource(settings.Capture.NDI)
+		if err != nil {
+			mainLog.Warn("NDI capture unavailable, using Zig capture", "error", err)
+		} else {
+			pe.SetFrameSource(ndi)
+			defer ndi.Close()
+		}
+	case backend == capture.BackendSpout:
+		spout, err := capture.NewSpoutSource(settings.Capture.Spout)
+		if err != nil {
+			mainLog.Warn("Spout capture unavailable, using Zig capture", "error", err)
+		} else {
+			pe.SetFrameSource(spout)
+			defer spout.Close()
+		}
+	case backend == capture.BackendNetwork:
+		network := capture.NewNetworkSource(settings.Capture.Network)
+		pe.SetFrameSource(network)
+		defer network.Close()
+	case backend == capture.BackendQuest:
+		quest := capture.NewQuestSource(settings.Capture.Quest)
+		pe.SetFrameSource(quest)
+		defer quest.Close()
+
+		// A standalone headset runs VRChat itself, so OSC goes back over Wi-Fi
+		host, port, err := net.SplitHostPort(oscConfig.SendAddr)
+		if wireless := settings.Capture.Quest.WirelessHost(); wireless != "" && err == nil && (host == "localhost" || net.ParseIP(host).IsLoopback()) {
+			oscConfig.SendAddr = net.JoinHostPort(wireless, port)
+			mainLog.Info("Sending OSC to the headset", "addr", oscConfig.SendAddr)
+		}
+	case backend == capture.BackendX11:
+		x11, err := capture.NewX11Source(settings.Capture.Linux)
+		if err != nil {
+			mainLog.Warn("X11 capture unavailable", "error", err)
+		} else {
+			pe.SetFrameSource(x11)
+			defer x11.Close()
+		}
+	case backend == capture.BackendPipeWire:
+		pipewire, err := capture.NewPipeWireSource(settings.Capture.Linux)
+		if err != nil {
+			mainLog.Warn("PipeWire capture unavailable", "error", err)
+		} else {
+			pe.SetFrameSource(pipewire)
+			defer pipewire.Close()
+		}
+	case backend == capture.BackendMacOS:
+		sck, err := capture.NewScreenCaptureKitSource(settings.Capture.MacOS)
+		if err != nil {
+			mainLog.Warn("ScreenCaptureKit capture unavailable", "error", err)
+		} else {
+			pe.SetFrameSource(sck)
+			defer sck.Close()
+		}
+	}
+
+	// Several sources crop the main capture, or their own DXGI monitor, and
+	// run concurrently
+	if len(settings.Capture.Sources) > 0 {
+		shared := capture.NewSharedSource(pe.FrameSource())
+		var sources []engine.NamedSource
+		for _, sourceConfig := range settings.Capture.Sources {
+			base := shared
+			if sourceConfig.Output != nil {
+				dxgiConfig := settings.Capture.DXGI
+				dxgiConfig.Output = *sourceConfig.Output
+				dxgi, err := capture.NewDXGISource(dxgiConfig)
+				if err != nil {
+					fatal("Capture source "+sourceConfig.ID+" unavailable", err)
+				}
+				defer dxgi.Close()
+				base = capture.NewSharedSource(dxgi)
+			}
+			sources = append(sources, engine.NamedSource{ID: sourceConfig.ID, Source: base.Region(sourceConfig.Region)})
+		}
+		pe.SetFrameSources(sources)
+	}
+
+	var radar *overlay.Radar
+	if overlayConfig.Enabled {
+		var err error
+		if radar, err = overlay.NewRadar(pe, *overlayConfig); err != nil {
+			fatal("Invalid overlay config", err)
+		}
+		pe.Subscribe("overlay", engine.BusBufferLatest, engine.Subscriber{Detections: radar.PublishDetections})
+		radar.Start()
+		defer radar.Stop()
+	}
+
+	if controllerConfig.Enabled {
+		controllers, err := overlay.NewControllerHaptics(pe, *controllerConfig)
+		if err != nil {
+			fatal("Invalid controller haptics config", err)
+		}
+		controllers.Start()
+		pe.Subscribe("controller_haptics", engine.BusBufferLatest, engine.Subscriber{Detections: controllers.PublishDetections, Event: controllers.HandleEvent, Haptics: true})
+		defer controllers.Stop()
+	}
+
+	if oscConfig.Enabled {
+		bridge := transport.NewOSCBridge(pe, *oscConfig)
+		if radar != nil {
+			bridge.SetOverlayHandler(radar.SetVisible)
+		}
+		if err := bridge.Start(); err != nil {
+			mainLog.Warn("OSC disabled", "error", err)
+		} else {
+			pe.Subscribe("osc", engine.BusBufferLatest, engine.Subscriber{Detections: bridge.PublishDetections})
+			defer bridge.Stop()
+		}
+	}
+
+	if hapticsConfig.Enabled {
+		haptics := transport.NewHapticsOutput(*hapticsConfig)
+		haptics.Start()
+		pe.Subscribe("haptics", engine.BusBufferLatest, engine.Subscriber{Detections: haptics.PublishDetections, Event: haptics.HandleEvent, Haptics: true})
+		defer haptics.Stop()
+	}
+
+	if notifyConfig.XSOverlay || notifyConfig.OVRToolkit {
+		notifier, err := transport.NewNotifier(*notifyConfig)
+		if err != nil {
+			fatal("Invalid notification config", err)
+		}
+		pe.Subscribe("notifications", engine.BusBuffer, engine.Subscriber{Event: notifier.HandleEvent, Alerts: true})
+		defer notifier.Stop()
+	}
+
+	if speechConfig.Enabled {
+		speaker, err := transport.NewSpeaker(*speechConfig)
+		if err != nil {
+			mainLog.Warn("Speech disabled", "error", err)
+		} else {
+			speaker.Start()
+			pe.Subscribe("speech", engine.BusBuffer, engine.Subscriber{Event: speaker.HandleEvent, Alerts: true})
+			defer speaker.Stop()
+		}
+	}
+
+	if audioConfig.Enabled {
+		cues, err := transport.NewAudioCues(pe, *audioConfig)
+		if err != nil {
+			mainLog.Warn("Audio cues disabled", "error", err)
+		} else {
+			pe.Subscribe("audio", engine.BusBufferLatest, engine.Subscriber{Detections: cues.PublishDetections, Alerts: true})
+			defer cues.Stop()
+		}
+	}
+
+	if toastConfig.Enabled {
+		if toastConfig.DashboardURL == "" {
+			toastConfig.DashboardURL = dashboardURL(serverConfig.Addr, serverConfig.TLS != nil)
+		}
+		toaster := transport.NewToaster(pe, *toastConfig)
+		toaster.Start()
+		pe.Subscribe("toasts", engine.BusBuffer, engine.Subscriber{Event: toaster.HandleEvent, Alerts: true})
+		defer toaster.Stop()
+	}
+
+	if intifaceConfig.Enabled {
+		intiface := transport.NewIntiface(*intifaceConfig)
+		intiface.RegisterHandlers(server.API())
+		intiface.Start()
+		pe.Subscribe("intiface", engine.BusBufferLatest, engine.Subscriber{Detections: intiface.PublishDetections, Haptics: true})
+		defer intiface.Stop()
+	}
+
+	if lightingConfig.Enabled {
+		lighting, err := transport.NewLighting(pe, *lightingConfig)
+		if err != nil {
+			mainLog.Warn("Lighting disabled", "error", err)
+		} else {
+			lighting.Start()
+			pe.Subscribe("lighting", engine.BusBufferLatest, engine.Subscriber{Detections: lighting.PublishDetections})
+			defer lighting.Stop()
+		}
+	}
+
+	if discordConfig.RichPresence || discordConfig.SummaryWebhook != "" {
+		discord, err := transport.NewDiscord(pe, *discordConfig)
+		if err != nil {
+			mainLog.Warn("Discord integration disabled", "error", err)
+		} else {
+			pe.Subscribe("discord", engine.BusBuffer, engine.Subscriber{Detections: discord.PublishDetections, Event: discord.HandleEvent})
+			discord.Start()
+			defer discord.Stop()
+		}
+	}
+
+	if *recordPath != "" {
+		recorder, err := engine.NewRecorder(*recordPath, recorderConfig)
+		if err != nil {
+			fatal("Failed to start recording", err)
+		}
+		recorder.Attach(pe)
+		defer recorder.Close()
+	}
+
+	if vrchatConfig.Enabled {
+		watcher, err := transport.NewVRChatWatcher(pe, *vrchatConfig)
+		if err != nil {
+			mainLog.Warn("VRChat API disabled", "error", err)
+		} else {
+			watcher.Start()
+			defer watcher.Stop()
+		}
+	}
+
+	if vrchatLogConfig.Enabled {
+		logWatcher := transport.NewVRChatLogWatcher(pe, *vrchatLogConfig)
+		logWatcher.Start()
+		defer logWatcher.Stop()
+	}
+
+	if len(webhookConfig.Targets) > 0 {
+		webhooks := transport.NewWebhookDispatcher(*webhookConfig)
+		pe.Subscribe("webhooks", engine.BusBuffer, engine.Subscriber{Event: webhooks.HandleEvent})
+		defer webhooks.Close()
+	}
+
+	if mqttConfig.Enabled {
+		publisher := transport.NewMQTTPublisher(*mqttConfig)
+		publisher.SetEngineState(pe.State)
+		if err := publisher.Start(); err != nil {
+			mainLog.Warn("MQTT disabled", "error", err)
+		} else {
+			pe.Subscribe("mqtt", engine.BusBuffer, engine.Subscriber{Detections: publisher.PublishDetections, Event: publisher.HandleEvent})
+			defer publisher.Stop()
+		}
+	}
+
+	if settings.Integrations.GRPC != "" {
+		grpcServer := transport.NewGRPCServer(pe, settings.Integrations.GRPC)
+		if err := grpcServer.Start(); err != nil {
+			mainLog.Warn("gRPC disabled", "error", err)
+		} else {
+			defer grpcServer.Stop()
+		}
+	}
+
+	if historyConfig.Enabled {
+		store, err := history.OpenHistoryStore(*historyConfig)
+		if err != nil {
+			fatal("Failed to open history", err)
+		}
+		store.Attach(pe)
+		store.RegisterHandlers(server.API())
+		defer store.Close()
+	}
+
+	if exportConfig.Enabled {
+		exporter, err := history.OpenExporter(*exportConfig)
+		if err != nil {
+			fatal("Failed to open export file", err)
+		}
+		exporter.Attach(pe)
+		defer exporter.Close()
+	}
+
+	if tracingConfig.Enabled {
+		tracer, err := transport.NewTracer(*tracingConfig)
+		if err != nil {
+			mainLog.Warn("Tracing disabled", "error", err)
+		} else {
+			tracer.Start(pe)
+			defer tracer.Stop()
+		}
+	}
+
+	if statsdConfig.Enabled {
+		statsd, err := transport.NewStatsDEmitter(pe, *statsdConfig)
+		if err != nil {
+			mainLog.Warn("StatsD disabled", "error", err)
+		} else {
+			pe.Subscribe("statsd", engine.BusBuffer, engine.Subscriber{Event: statsd.HandleEvent})
+			statsd.Start()
+			defer statsd.Stop()
+		}
+	}
+
+	if *replayPath != "" {
+		pe.DisableCapture()
+	}
+
+	if err := pe.Start(); err != nil {
+		fatal("Failed to start engine", err)
+	}
+	if err := server.Start(); err != nil {
+		fatal("Failed to start server", err)
+	}
+	defer server.Stop()
+
+	if trayConfig.Enabled {
+		if trayConfig.DashboardURL == "" {
+			trayConfig.DashboardURL = dashboardURL(serverConfig.Addr, serverConfig.TLS != nil)
+		}
+		tray := transport.NewTray(pe, *trayConfig)
+		pe.Subscribe("tray", engine.BusBuffer, engine.Subscriber{Event: tray.HandleEvent})
+		tray.Start()
+		defer tray.Stop()
+
+		// Quitting from the tray shuts down like an interrupt
+		var quit context.CancelFunc
+		ctx, quit = context.WithCancel(ctx)
+		defer quit()
+		go func() {
+			select {
+			case <-tray.Done():
+				quit()
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	if hotkeyConfig.Enabled {
+		hotkeys, err := input.NewHotkeys(pe, *hotkeyConfig)
+		if err != nil {
+			fatal("Invalid hotkey config", err)
+		}
+		if radar != nil {
+			hotkeys.SetOverlayToggle(radar.Toggle)
+		}
+		if err := hotkeys.Start(); err != nil {
+			mainLog.Warn("Hotkeys disabled", "error", err)
+		} else {
+			defer hotkeys.Stop()
+		}
+	}
+
+	if followConfig.Enabled && *replayPath == "" {
+		go pe.FollowProcess(ctx, *followConfig)
+	}
+	if focusConfig.Enabled && *replayPath == "" {
+		go pe.FollowFocus(ctx, *focusConfig)
+	}
+
+	if *replayPath != "" {
+		player := engine.NewPlayer(*replayPath)
+		player.Speed = *replaySpeed
+		player.Loop = *replayLoop
+		go func() {
+			if err := player.Play(context.Background(), pe); err != nil {
+				mainLog.Error("Replay failed", "error", err)
+			}
+			mainLog.Info("Replay finished")
+		}()
+	}
+
+	// Keep running until interrupted or stopped by the service manager so deferred cleanup can run
+	<-ctx.Done()
+
+	pe.Stop()
+}
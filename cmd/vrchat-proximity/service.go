@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Service identity registered with the Windows service manager or systemd
+const (
+	serviceName        = "VRChatProximity"
+	serviceDisplayName = "VRChat Proximity Engine"
+	serviceDescription = "Detects nearby players in VRChat and publishes proximity events"
+)
+
+// daemonEnv marks the re-executed child of -daemon so it doesn't detach again
+const daemonEnv = "VRCHAT_PROXIMITY_DAEMON"
+
+// serviceUsage describes the service subcommand
+const serviceUsage = `usage: vrchat-proximity service <command> [flags]
+
+  install [flags]    Register the engine to start with the machine; flags are passed to the engine
+  uninstall          Remove the registration
+  start [flags]      Start the installed service (Windows) or a background daemon (Linux, macOS)
+  stop [-pidfile f]  Stop the service or daemon`
+
+// runServiceCommand handles "vrchat-proximity service install|uninstall|start|stop"
+func runServiceCommand(args []string) error {
+	if len(args) == 0 {
+		return errors.New(serviceUsage)
+	}
+
+	command, rest := args[0], args[1:]
+	switch command {
+	case "install":
+		return installService(rest)
+	case "uninstall":
+		return uninstallService()
+	case "start":
+		return startService(rest)
+	case "stop":
+		return stopService(rest)
+	default:
+		return fmt.Errorf("unknown service command %q\n\n%s", command, serviceUsage)
+	}
+}
+
+// defaultPIDFile is where "service start" records the daemon's process ID
+func defaultPIDFile() string {
+	return filepath.Join(os.TempDir(), "vrchat-proximity.pid")
+}
+
+// writePIDFile records the current process ID
+func writePIDFile(path string) error {
+	if err := os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())+"\n"), 0o644); err != nil {
+		return fmt.Errorf("write pidfile: %w", err)
+	}
+	return nil
+}
+
+// readPIDFile returns the process ID recorded by writePIDFile
+func readPIDFile(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("read pidfile: %w", err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid pidfile %s: %w", path, err)
+	}
+	return pid, nil
+}
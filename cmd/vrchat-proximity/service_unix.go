@@ -0,0 +1,198 @@
+//go:build unix
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// runningAsService is always false outside Windows; systemd runs the engine in the foreground
+func runningAsService() bool {
+	return false
+}
+
+// runAsService is only used on Windows
+func runAsService(run func(context.Context)) error {
+	return errors.New("not running under the Windows service manager")
+}
+
+// startDaemon re-executes the engine detached from the terminal with its
+// output appended to logPath, and returns once the child has started
+func startDaemon(args []string, pidFile, logPath string) error {
+	if pidFile != "" {
+		if pid, err := readPIDFile(pidFile); err == nil && processAlive(pid) {
+			return fmt.Errorf("already running with pid %d (%s)", pid, pidFile)
+		}
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	output, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open daemon log: %w", err)
+	}
+	defer output.Close()
+
+	cmd := exec.Command(exe, args...)
+	cmd.Env = append(os.Environ(), daemonEnv+"=1")
+	cmd.Stdout, cmd.Stderr = output, output
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start daemon: %w", err)
+	}
+
+	fmt.Printf("Started in the background with pid %d, logging to %s\n", cmd.Process.Pid, logPath)
+	return cmd.Process.Release()
+}
+
+// processAlive reports whether a process with pid exists
+func processAlive(pid int) bool {
+	return syscall.Kill(pid, 0) == nil
+}
+
+// systemdUnitPath is the per-user unit written by "service install"
+func systemdUnitPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "systemd", "user", "vrchat-proximity.service"), nil
+}
+
+// installService writes a systemd user unit that runs the engine with args
+func installService(args []string) error {
+	if runtime.GOOS != "linux" {
+		return errors.New("service install requires systemd; use \"service start\" or -daemon instead")
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	path, err := systemdUnitPath()
+	if err != nil {
+		return err
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	command := []string{systemdQuote(exe)}
+	for _, arg := range args {
+		command = append(command, systemdQuote(arg))
+	}
+	unit := fmt.Sprintf(`[Unit]
+Description=%s
+After=network.target
+
+[Service]
+ExecStart=%s
+WorkingDirectory=%s
+Restart=on-failure
+
+[Install]
+WantedBy=default.target
+`, serviceDescription, strings.Join(command, " "), systemdQuote(dir))
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("install service: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(unit), 0o644); err != nil {
+		return fmt.Errorf("install service: %w", err)
+	}
+
+	fmt.Printf("Wrote %s\nEnable it with: systemctl --user daemon-reload && systemctl --user enable --now vrchat-proximity\n", path)
+	return nil
+}
+
+// systemdQuote quotes an ExecStart argument when it contains spaces or quotes
+func systemdQuote(arg string) string {
+	if !strings.ContainsAny(arg, " \t\"'\\") {
+		return arg
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
+
+// uninstallService removes the systemd user unit
+func uninstallService() error {
+	path, err := systemdUnitPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("uninstall service: %w", err)
+	}
+	fmt.Printf("Removed %s\nRun: systemctl --user daemon-reload\n", path)
+	return nil
+}
+
+// startService starts a background daemon with the -pidfile in args, or
+// the default pidfile when args has none
+func startService(args []string) error {
+	pidFile := pidFileArg(args)
+	if pidFile == "" {
+		pidFile = defaultPIDFile()
+		args = append([]string{"-pidfile", pidFile}, args...)
+	}
+	return startDaemon(args, pidFile, "vrchat-proximity.log")
+}
+
+// pidFileArg returns the last -pidfile given in the engine flags args, or
+// "" when there is none
+func pidFileArg(args []string) string {
+	pidFile := ""
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(strings.TrimPrefix(args[i], "-"), "-"), "=")
+		switch {
+		case args[i] == "--":
+			return pidFile
+		case name != "pidfile" || !strings.HasPrefix(args[i], "-"):
+			continue
+		case hasValue:
+			pidFile = value
+		case i+1 < len(args):
+			i++
+			pidFile = args[i]
+		}
+	}
+	return pidFile
+}
+
+// stopService sends SIGTERM to the daemon in the pidfile and waits up to 10 seconds
+func stopService(args []string) error {
+	flags := flag.NewFlagSet("service stop", flag.ContinueOnError)
+	pidFile := flags.String("pidfile", defaultPIDFile(), "pidfile of the daemon to stop")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	pid, err := readPIDFile(*pidFile)
+	if err != nil {
+		return err
+	}
+	if err := syscall.Kill(pid, syscall.SIGTERM); err != nil {
+		return fmt.Errorf("stop pid %d: %w", pid, err)
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for processAlive(pid) {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("pid %d did not stop in time", pid)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	fmt.Printf("Stopped pid %d\n", pid)
+	return nil
+}
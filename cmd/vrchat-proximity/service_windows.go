@@ -0,0 +1,176 @@
+//go:build windows
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// runningAsService reports whether the service manager launched this process
+func runningAsService() bool {
+	ok, err := svc.IsWindowsService()
+	return err == nil && ok
+}
+
+// serviceHandler runs the engine under the Windows service manager
+type serviceHandler struct {
+	run func(context.Context)
+}
+
+// Execute runs the engine until the service manager asks it to stop
+func (h *serviceHandler) Execute(args []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.run(ctx)
+	}()
+
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for {
+		select {
+		case <-done:
+			return false, 0
+		case request := <-requests:
+			switch request.Cmd {
+			case svc.Interrogate:
+				status <- request.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+				cancel()
+				<-done
+				return false, 0
+			}
+		}
+	}
+}
+
+// runAsService runs the engine as a Windows service. Services start in
+// System32 without a console, so relative paths resolve next to the
+// executable and output goes to vrchat-proximity.log there.
+func runAsService(run func(context.Context)) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(exe)
+	if err := os.Chdir(dir); err != nil {
+		return err
+	}
+	if output, err := os.OpenFile(filepath.Join(dir, "vrchat-proximity.log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644); err == nil {
+		os.Stdout, os.Stderr = output, output
+		defer output.Close()
+	}
+
+	return svc.Run(serviceName, &serviceHandler{run: run})
+}
+
+// startDaemon is not available on Windows, which uses the service manager instead
+func startDaemon(args []string, pidFile, logPath string) error {
+	return errors.New("-daemon is not supported on Windows; use \"service install\" and \"service start\"")
+}
+
+// installService registers an automatically started service running the
+// current executable with args
+func installService(args []string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("connect to service manager: %w", err)
+	}
+	defer m.Disconnect()
+
+	if s, err := m.OpenService(serviceName); err == nil {
+		s.Close()
+		return fmt.Errorf("service %s is already installed", serviceName)
+	}
+	s, err := m.CreateService(serviceName, exe, mgr.Config{
+		DisplayName: serviceDisplayName,
+		Description: serviceDescription,
+		StartType:   mgr.StartAutomatic,
+	}, args...)
+	if err != nil {
+		return fmt.Errorf("install service: %w", err)
+	}
+	defer s.Close()
+
+	fmt.Printf("Installed service %s; start it with \"vrchat-proximity service start\"\n", serviceName)
+	return nil
+}
+
+// uninstallService removes the service registration
+func uninstallService() error {
+	return withService(func(s *mgr.Service) error {
+		if err := s.Delete(); err != nil {
+			return fmt.Errorf("uninstall service: %w", err)
+		}
+		fmt.Printf("Uninstalled service %s\n", serviceName)
+		return nil
+	})
+}
+
+// startService starts the installed service; its flags were fixed at install time
+func startService(args []string) error {
+	if len(args) > 0 {
+		return errors.New("engine flags are set by \"service install\" on Windows")
+	}
+	return withService(func(s *mgr.Service) error {
+		if err := s.Start(); err != nil {
+			return fmt.Errorf("start service: %w", err)
+		}
+		fmt.Printf("Started service %s\n", serviceName)
+		return nil
+	})
+}
+
+// stopService asks the service to stop and waits up to 10 seconds for it
+func stopService(args []string) error {
+	return withService(func(s *mgr.Service) error {
+		status, err := s.Control(svc.Stop)
+		if err != nil {
+			return fmt.Errorf("stop service: %w", err)
+		}
+		deadline := time.Now().Add(10 * time.Second)
+		for status.State != svc.Stopped {
+			if time.Now().After(deadline) {
+				return fmt.Errorf("service %s did not stop in time", serviceName)
+			}
+			time.Sleep(300 * time.Millisecond)
+			if status, err = s.Query(); err != nil {
+				return fmt.Errorf("query service: %w", err)
+			}
+		}
+		fmt.Printf("Stopped service %s\n", serviceName)
+		return nil
+	})
+}
+
+// withService opens the installed service and passes it to fn
+func withService(fn func(*mgr.Service) error) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("connect to service manager: %w", err)
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(serviceName)
+	if err != nil {
+		return fmt.Errorf("service %s is not installed", serviceName)
+	}
+	defer s.Close()
+	return fn(s)
+}
@@ -87,15 +87,36 @@ const Image = struct {
 
 // Ultra-fast Windows screen capture
 pub fn captureVRChatWindow(allocator: Allocator, window_title: []const u8) !?Image {
-    // Find VRChat window
     const hwnd = findWindowByTitle(window_title) orelse return null;
+    const size = windowSize(hwnd) orelse return null;
     
-    // Get window rect
+    var image = try Image.init(allocator, size.width, size.height, 3);
+    if (!captureWindowInto(hwnd, image.data, size.width, size.height)) {
+        image.deinit(allocator);
+        return null;
+    }
+    return image;
+}
+
+const WindowSize = struct {
+    width: u32,
+    height: u32,
+};
+
+fn windowSize(hwnd: c.HWND) ?WindowSize {
     var rect: c.RECT = undefined;
     if (c.GetWindowRect(hwnd, &rect) == 0) return null;
+    if (rect.right <= rect.left or rect.bottom <= rect.top) return null;
     
-    const width = @as(u32, @intCast(rect.right - rect.left));
-    const height = @as(u32, @intCast(rect.bottom - rect.top));
+    return WindowSize{
+        .width = @as(u32, @intCast(rect.right - rect.left)),
+        .height = @as(u32, @intCast(rect.bottom - rect.top)),
+    };
+}
+
+// Copy the window into a caller-owned BGR buffer of at least width*height*3 bytes
+fn captureWindowInto(hwnd: c.HWND, buffer: []u8, width: u32, height: u32) bool {
+    if (buffer.len < width * height * 3) return false;
     
     // Create device contexts
     const hdcWindow = c.GetDC(hwnd);
@@ -117,18 +138,21 @@ pub fn captureVRChatWindow(allocator: Allocator, window_title: []const u8) !?Ima
     bitmap_info.bmiHeader.biBitCount = 24;
     bitmap_info.bmiHeader.biCompression = c.BI_RGB;
     
-    var image = try Image.init(allocator, width, height, 3);
-    
-    _ = c.GetDIBits(hdcMemDC, hbmScreen, 0, height, image.data.ptr, &bitmap_info, c.DIB_RGB_COLORS);
+    const lines = c.GetDIBits(hdcMemDC, hbmScreen, 0, height, buffer.ptr, &bitmap_info, c.DIB_RGB_COLORS);
     
     // Cleanup
     _ = c.DeleteObject(hbmScreen);
     _ = c.DeleteDC(hdcMemDC);
     _ = c.ReleaseDC(hwnd, hdcWindow);
     
-    return image;
+    return lines != 0;
 }
 
+// Frame ring shared with Go: slots are allocated once and refilled in place,
+// so steady-state capture neither allocates nor copies across CGo
+const ring_allocator = std.heap.page_allocator;
+var frame_ring: [][]u8 = &[_][]u8{};
+
 fn findWindowByTitle(title: []const u8) ?c.HWND {
     const title_w = std.unicode.utf8ToUtf16LeStringLiteral(title) catch return null;
     return c.FindWindowW(null, title_w);
@@ -356,7 +380,49 @@ export fn zig_capture_screen(width: *u32, height: *u32, data: **u8) bool {
     return false;
 }
 
-export fn zig_detect_motion(current_data: [*]u8, previous_data: [*]u8, width: u32, height: u32, detections: **Detection, count: *u32) bool {
+export fn zig_frame_ring_init(slot_count: u32, slot_bytes: u32) bool {
+    zig_frame_ring_free();
+    
+    const slots = ring_allocator.alloc([]u8, slot_count) catch return false;
+    for (slots, 0..) |*slot, i| {
+        slot.* = ring_allocator.alloc(u8, slot_bytes) catch {
+            for (slots[0..i]) |allocated| ring_allocator.free(allocated);
+            ring_allocator.free(slots);
+            return false;
+        };
+    }
+    frame_ring = slots;
+    return true;
+}
+
+export fn zig_frame_ring_free() void {
+    for (frame_ring) |slot| ring_allocator.free(slot);
+    if (frame_ring.len > 0) ring_allocator.free(frame_ring);
+    frame_ring = &[_][]u8{};
+}
+
+// Capture into a ring slot, growing it only when the window got bigger
+export fn zig_capture_into_slot(slot: u32, width: *u32, height: *u32, data: **u8, grew: *bool) bool {
+    grew.* = false;
+    if (slot >= frame_ring.len) return false;
+    
+    const hwnd = findWindowByTitle("VRChat") orelse return false;
+    const size = windowSize(hwnd) orelse return false;
+    const needed = size.width * size.height * 3;
+    
+    if (frame_ring[slot].len < needed) {
+        frame_ring[slot] = ring_allocator.realloc(frame_ring[slot], needed) catch return false;
+        grew.* = true;
+    }
+    if (!captureWindowInto(hwnd, frame_ring[slot], size.width, size.height)) return false;
+    
+    width.* = size.width;
+    height.* = size.height;
+    data.* = &frame_ring[slot][0];
+    return true;
+}
+
+export fn zig_detect_motion(current_data: [*]u8, previous_data: [*]u8, width: u32, height: u32, threshold: u8, detections: **Detection, count: *u32) bool {
     var gpa = std.heap.GeneralPurposeAllocator(.{}){};
     defer _ = gpa.deinit();
     const allocator = gpa.allocator();
@@ -375,7 +441,7 @@ export fn zig_detect_motion(current_data: [*]u8, previous_data: [*]u8, width: u3
         .channels = 3,
     };
     
-    if (detectMotion(allocator, &current, &previous, 30)) |results| {
+    if (detectMotion(allocator, &current, &previous, threshold)) |results| {
         detections.* = results.ptr;
         count.* = @intCast(results.len);
         return true;
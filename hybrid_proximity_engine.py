@@ -122,10 +122,10 @@ class HybridProximityEngine:
 
     def prepare_go_module(self):
         """Prepare the Go networking module"""
-        go_file = Path("fast_network.go")
+        go_file = Path("cmd/vrchat-proximity/main.go")
         
         if not go_file.exists():
-            print("✗ fast_network.go not found")
+            print("✗ cmd/vrchat-proximity not found")
             return
         
         print("Preparing Go networking module...")
@@ -142,7 +142,7 @@ class HybridProximityEngine:
             
             # Build and run Go module
             self.go_process = subprocess.Popen(
-                ["go", "run", "fast_network.go"],
+                ["go", "run", "./cmd/vrchat-proximity"],
                 stdout=subprocess.PIPE,
                 stderr=subprocess.PIPE,
                 text=True
@@ -0,0 +1,233 @@
+// Package capture grabs frames and runs the Zig motion detector.
+package capture
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// #cgo CFLAGS: -I${SRCDIR}/../..
+// #cgo LDFLAGS: -L${SRCDIR}/../.. -lfast_vision
+// #include <stdint.h>
+// #include <stdbool.h>
+//
+// // Zig function declarations
+// bool zig_capture_screen(uint32_t* width, uint32_t* height, uint8_t** data);
+// bool zig_frame_ring_init(uint32_t slot_count, uint32_t slot_bytes);
+// void zig_frame_ring_free(void);
+// bool zig_capture_into_slot(uint32_t slot, uint32_t* width, uint32_t* height, uint8_t** data, bool* grew);
+// bool zig_detect_motion(uint8_t* current_data, uint8_t* previous_data, uint32_t width, uint32_t height, uint8_t threshold, void** detections, uint32_t* count);
+//
+// typedef struct {
+//     int32_t x, y, width, height;
+// } BoundingBox;
+//
+// typedef struct {
+//     BoundingBox bbox;
+//     float confidence;
+//     uint8_t detection_type;
+//     float area;
+// } Detection;
+import "C"
+
+// ErrNoFrame is returned when a source has no frame available right now,
+// e.g. the VRChat window is minimized
+var ErrNoFrame = errors.New("no frame available")
+
+// Frame is a packed 24-bit BGR image
+type Frame struct {
+	Data      []byte // width*height*3 bytes; ScreenSource frames are only valid for the next RingSlots-1 captures
+	Width     int
+	Height    int
+	Timestamp time.Time
+}
+
+// Valid reports whether the frame holds pixel data matching its size
+func (f Frame) Valid() bool {
+	return f.Width > 0 && f.Height > 0 && len(f.Data) >= f.Width*f.Height*3
+}
+
+// FrameSource produces frames for the detection pipeline
+type FrameSource interface {
+	NextFrame(ctx context.Context) (Frame, error)
+}
+
+// RawDetection is a motion blob as reported by the Zig detector
+type RawDetection struct {
+	X, Y, Width, Height int32
+	Confidence          float32
+	Type                uint8 // 0 motion, 1 color, 2 shape
+	Area                float32
+}
+
+// RingSlots is how many Zig-owned frame buffers ScreenSource cycles through.
+// The pipeline holds the current and previous frame; the third slot is
+// being filled while the preview still reads the current one.
+const RingSlots = 3
+
+// ringSlotBytes is the initial slot size, enough for 1080p; slots grow in place
+const ringSlotBytes = 1920 * 1080 * 3
+
+// BufferStats counts how ScreenSource frames reached Go
+type BufferStats struct {
+	Slots       int   `json:"slots"`         // Ring slots, 0 when the ring is unavailable
+	Reused      int64 `json:"reused"`        // Frames captured into an existing slot without copying
+	Grown       int64 `json:"grown"`         // Captures that had to enlarge their slot
+	Copied      int64 `json:"copied"`        // Frames copied into Go memory by the fallback path
+	SlotBytes   int64 `json:"slot_bytes"`    // Bytes reserved across all slots
+	LastFrameKB int64 `json:"last_frame_kb"` // Size of the most recent frame
+}
+
+// ScreenSource captures the VRChat window using Zig. Frames point straight
+// into a ring of Zig-owned buffers, so the hot path makes no copies.
+type ScreenSource struct {
+	mu        sync.Mutex
+	ring      bool
+	next      uint32
+	slotBytes []int64
+
+	reused, grown, copied atomic.Int64
+	lastFrame             atomic.Int64
+}
+
+// NewScreenSource creates a source for the VRChat window, falling back to
+// copying each frame when the shared ring can't be allocated
+func NewScreenSource() *ScreenSource {
+	s := &ScreenSource{}
+	if C.zig_frame_ring_init(RingSlots, ringSlotBytes) {
+		s.ring = true
+		s.slotBytes = make([]int64, RingSlots)
+		for i := range s.slotBytes {
+			s.slotBytes[i] = ringSlotBytes
+		}
+	}
+	return s
+}
+
+// NextFrame captures the window into the next ring slot
+func (s *ScreenSource) NextFrame(ctx context.Context) (Frame, error) {
+	if err := ctx.Err(); err != nil {
+		return Frame{}, err
+	}
+	if !s.ring {
+		return s.copyFrame()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var width, height C.uint32_t
+	var data *C.uint8_t
+	var grew C.bool
+	slot := s.next
+	if !C.zig_capture_into_slot(C.uint32_t(slot), &width, &height, &data, &grew) {
+		return Frame{}, ErrNoFrame
+	}
+	s.next = (slot + 1) % RingSlots
+
+	size := int(width) * int(height) * 3
+	if grew {
+		s.grown.Add(1)
+		s.slotBytes[slot] = int64(size)
+	} else {
+		s.reused.Add(1)
+	}
+	s.lastFrame.Store(int64(size))
+	return Frame{
+		Data:      unsafe.Slice((*byte)(unsafe.Pointer(data)), size),
+		Width:     int(width),
+		Height:    int(height),
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// BufferStats reports ring reuse and fallback copies
+func (s *ScreenSource) BufferStats() BufferStats {
+	stats := BufferStats{
+		Reused:      s.reused.Load(),
+		Grown:       s.grown.Load(),
+		Copied:      s.copied.Load(),
+		LastFrameKB: s.lastFrame.Load() / 1024,
+	}
+	s.mu.Lock()
+	if s.ring {
+		stats.Slots = RingSlots
+		for _, n := range s.slotBytes {
+			stats.SlotBytes += n
+		}
+	}
+	s.mu.Unlock()
+	return stats
+}
+
+// copyFrame captures the window into Go-owned memory
+func (s *ScreenSource) copyFrame() (Frame, error) {
+	var width, height C.uint32_t
+	var data *C.uint8_t
+	if !C.zig_capture_screen(&width, &height, &data) {
+		return Frame{}, ErrNoFrame
+	}
+
+	size := C.int(width) * C.int(height) * 3
+	s.copied.Add(1)
+	s.lastFrame.Store(int64(size))
+	return Frame{
+		Data:      C.GoBytes(unsafe.Pointer(data), size),
+		Width:     int(width),
+		Height:    int(height),
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// Probe checks that the Zig library answers by running the motion
+// detector on two blank frames
+func Probe() error {
+	const size = 8
+	current, previous := make([]byte, size*size*3), make([]byte, size*size*3)
+	var zigDetections *C.Detection
+	var count C.uint32_t
+	if !C.zig_detect_motion((*C.uint8_t)(unsafe.Pointer(&current[0])), (*C.uint8_t)(unsafe.Pointer(&previous[0])),
+		size, size, 30, (*unsafe.Pointer)(unsafe.Pointer(&zigDetections)), &count) {
+		return errors.New("zig motion detector failed")
+	}
+	return nil
+}
+
+// DetectMotion compares two frames of the same size and returns the moving
+// regions. Lower thresholds detect fainter motion.
+func DetectMotion(current, previous Frame, threshold uint8) []RawDetection {
+	if !current.Valid() || !previous.Valid() || current.Width != previous.Width || current.Height != previous.Height {
+		return nil
+	}
+
+	var zigDetections *C.Detection
+	var count C.uint32_t
+	if !C.zig_detect_motion((*C.uint8_t)(unsafe.Pointer(&current.Data[0])), (*C.uint8_t)(unsafe.Pointer(&previous.Data[0])),
+		C.uint32_t(current.Width), C.uint32_t(current.Height), C.uint8_t(threshold),
+		(*unsafe.Pointer)(unsafe.Pointer(&zigDetections)), &count) {
+		return nil
+	}
+	if count == 0 {
+		return nil
+	}
+
+	// Copy the C array into Go structs
+	detections := make([]RawDetection, int(count))
+	cArray := unsafe.Slice(zigDetections, int(count))
+	for i, cDet := range cArray {
+		detections[i] = RawDetection{
+			X:          int32(cDet.bbox.x),
+			Y:          int32(cDet.bbox.y),
+			Width:      int32(cDet.bbox.width),
+			Height:     int32(cDet.bbox.height),
+			Confidence: float32(cDet.confidence),
+			Type:       uint8(cDet.detection_type),
+			Area:       float32(cDet.area),
+		}
+	}
+	return detections
+}
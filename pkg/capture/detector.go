@@ -0,0 +1,139 @@
+package capture
+
+import "fmt"
+
+// Motion algorithms selectable with NewDetector
+const (
+	AlgorithmFrameDiff   = "frame_diff"   // Zig difference against the previous frame; cheapest
+	AlgorithmMOG2        = "mog2"         // Per-pixel Gaussian mixture background model
+	AlgorithmOpticalFlow = "optical_flow" // Block Lucas-Kanade flow; ignores flicker and lighting changes
+)
+
+// Algorithms lists every motion algorithm, cheapest first
+var Algorithms = []string{AlgorithmFrameDiff, AlgorithmMOG2, AlgorithmOpticalFlow}
+
+// Detector finds moving regions in the newest frame. previous is the frame
+// before it; detectors that keep their own background model may ignore it.
+// Lower thresholds detect fainter motion.
+type Detector interface {
+	Detect(current, previous Frame, threshold uint8) []RawDetection
+}
+
+// FrameDiff is the Zig frame-difference detector as a Detector
+type FrameDiff struct{}
+
+// Detect runs DetectMotion
+func (FrameDiff) Detect(current, previous Frame, threshold uint8) []RawDetection {
+	return DetectMotion(current, previous, threshold)
+}
+
+// NewDetector creates a detector for one of Algorithms
+func NewDetector(algorithm string) (Detector, error) {
+	switch algorithm {
+	case AlgorithmFrameDiff:
+		return FrameDiff{}, nil
+	case AlgorithmMOG2:
+		return NewMOG2Detector(), nil
+	case AlgorithmOpticalFlow:
+		return NewFlowDetector(), nil
+	default:
+		return nil, fmt.Errorf("unknown motion algorithm %q", algorithm)
+	}
+}
+
+// luma converts a BGR frame to grayscale, reusing buf
+func luma(frame Frame, buf []float32) []float32 {
+	n := frame.Width * frame.Height
+	if cap(buf) < n {
+		buf = make([]float32, n)
+	}
+	buf = buf[:n]
+	for i := range buf {
+		p := frame.Data[i*3 : i*3+3]
+		buf[i] = (float32(p[0]) + 2*float32(p[1]) + float32(p[2])) / 4
+	}
+	return buf
+}
+
+// cellGrid counts moving pixels in square cells and turns connected runs
+// of busy cells into detections
+type cellGrid struct {
+	cell          int
+	cols, rows    int
+	width, height int
+	counts        []int
+}
+
+// reset sizes the grid for a frame and clears the counts
+func (g *cellGrid) reset(width, height, cell int) {
+	g.cell, g.width, g.height = cell, width, height
+	g.cols, g.rows = (width+cell-1)/cell, (height+cell-1)/cell
+	if cap(g.counts) < g.cols*g.rows {
+		g.counts = make([]int, g.cols*g.rows)
+	}
+	g.counts = g.counts[:g.cols*g.rows]
+	clear(g.counts)
+}
+
+// mark counts n moving pixels at frame pixel x, y
+func (g *cellGrid) mark(x, y, n int) {
+	g.counts[(y/g.cell)*g.cols+x/g.cell] += n
+}
+
+// blobs joins 8-connected cells holding at least minCount moving pixels
+func (g *cellGrid) blobs(minCount int) []RawDetection {
+	parent := make([]int, len(g.counts))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+
+	busy := func(c, r int) bool {
+		return c >= 0 && r >= 0 && c < g.cols && r < g.rows && g.counts[r*g.cols+c] >= minCount
+	}
+	for r := 0; r < g.rows; r++ {
+		for c := 0; c < g.cols; c++ {
+			if !busy(c, r) {
+				continue
+			}
+			// Earlier neighbors are already labelled
+			for _, n := range [][2]int{{-1, 0}, {-1, -1}, {0, -1}, {1, -1}} {
+				if busy(c+n[0], r+n[1]) {
+					parent[find((r+n[1])*g.cols+c+n[0])] = find(r*g.cols + c)
+				}
+			}
+		}
+	}
+
+	index := make(map[int]int)
+	var detections []RawDetection
+	for r := 0; r < g.rows; r++ {
+		for c := 0; c < g.cols; c++ {
+			if !busy(c, r) {
+				continue
+			}
+			x, y := int32(c*g.cell), int32(r*g.cell)
+			w, h := int32(min(g.cell, g.width-c*g.cell)), int32(min(g.cell, g.height-r*g.cell))
+			cell := RawDetection{X: x, Y: y, Width: w, Height: h, Area: float32(g.counts[r*g.cols+c])}
+			root := find(r*g.cols + c)
+			if i, ok := index[root]; ok {
+				detections[i] = union(detections[i], cell)
+			} else {
+				index[root] = len(detections)
+				detections = append(detections, cell)
+			}
+		}
+	}
+	for i := range detections {
+		d := &detections[i]
+		d.Confidence = min(d.Area/float32(d.Width*d.Height), 1)
+	}
+	return detections
+}
@@ -0,0 +1,39 @@
+package capture
+
+// Capture backends for the screen source
+const (
+	BackendZig      = "zig"              // Zig window capture, the default
+	BackendDXGI     = "dxgi"             // DXGI Desktop Duplication with GPU downscaling (Windows)
+	BackendNDI      = "ndi"              // An NDI stream, e.g. from OBS or VRChat's stream camera (Windows)
+	BackendSpout    = "spout"            // A Spout sender's shared texture (Windows)
+	BackendNetwork  = "network"          // An RTSP/RTMP stream from another machine, decoded with ffmpeg
+	BackendQuest    = "quest"            // A standalone Quest's display over ADB with scrcpy
+	BackendX11      = "x11"              // An X11 window via MIT-SHM (Linux); zig picks it on X11 sessions
+	BackendPipeWire = "pipewire"         // The Wayland ScreenCast portal over PipeWire (Linux); zig picks it on Wayland
+	BackendMacOS    = "screencapturekit" // ScreenCaptureKit (macOS 12.3+); zig picks it on macOS
+)
+
+// Backends lists the capture backends
+var Backends = []string{BackendZig, BackendDXGI, BackendNDI, BackendSpout, BackendNetwork, BackendQuest, BackendX11, BackendPipeWire, BackendMacOS}
+
+// DXGIConfig configures the Desktop Duplication backend
+type DXGIConfig struct {
+	Output   int       `yaml:"output"`    // Monitor index on the primary GPU
+	MaxWidth int       `yaml:"max_width"` // Halve the frame on the GPU until it fits; 0 keeps the native size
+	HDR      HDRConfig `yaml:"hdr"`       // Tone mapping of HDR monitors
+}
+
+// DefaultDXGIConfig captures the first monitor at no more than 1920 wide,
+// tone-mapping it when Windows HDR is on
+func DefaultDXGIConfig() DXGIConfig {
+	return DXGIConfig{MaxWidth: 1920, HDR: DefaultHDRConfig()}
+}
+
+// dxgiLevel returns how many times width must be halved to fit maxWidth
+func dxgiLevel(width, maxWidth int) int {
+	level := 0
+	for maxWidth > 0 && width>>level > maxWidth && width>>(level+1) > 0 {
+		level++
+	}
+	return level
+}
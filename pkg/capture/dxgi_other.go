@@ -0,0 +1,26 @@
+//go:build !windows
+
+package capture
+
+import (
+	"context"
+	"errors"
+)
+
+// DXGISource captures a monitor with DXGI Desktop Duplication; Windows only
+type DXGISource struct{}
+
+// NewDXGISource fails outside Windows
+func NewDXGISource(config DXGIConfig) (*DXGISource, error) {
+	return nil, errors.New("DXGI capture is only supported on Windows")
+}
+
+// NextFrame always fails outside Windows
+func (s *DXGISource) NextFrame(ctx context.Context) (Frame, error) {
+	return Frame{}, ErrNoFrame
+}
+
+// Close does nothing outside Windows
+func (s *DXGISource) Close() error {
+	return nil
+}
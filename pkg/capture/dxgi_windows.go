@@ -0,0 +1,442 @@
+//go:build windows
+
+package capture
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var (
+	d3d11                 = syscall.NewLazyDLL("d3d11.dll")
+	procD3D11CreateDevice = d3d11.NewProc("D3D11CreateDevice")
+)
+
+var (
+	iidIDXGIDevice     = syscall.GUID{Data1: 0x54ec77fa, Data2: 0x1377, Data3: 0x44e6, Data4: [8]byte{0x8c, 0x32, 0x88, 0xfd, 0x5f, 0x44, 0xc8, 0x4c}}
+	iidIDXGIOutput1    = syscall.GUID{Data1: 0x00cddea8, Data2: 0x939b, Data3: 0x4b83, Data4: [8]byte{0xa3, 0x40, 0xa6, 0x85, 0x22, 0x66, 0x66, 0xcc}}
+	iidIDXGIOutput5    = syscall.GUID{Data1: 0x80a07424, Data2: 0xab52, Data3: 0x42eb, Data4: [8]byte{0x83, 0x3c, 0x0c, 0x42, 0xfd, 0x28, 0x2d, 0x98}}
+	iidIDXGIOutput6    = syscall.GUID{Data1: 0x068346e8, Data2: 0xaaec, Data3: 0x4b84, Data4: [8]byte{0xad, 0xd7, 0x13, 0x7f, 0x51, 0x3f, 0x77, 0xa1}}
+	iidID3D11Texture2D = syscall.GUID{Data1: 0x6f15aaf2, Data2: 0xd208, Data3: 0x4e89, Data4: [8]byte{0x9a, 0xb4, 0x48, 0x95, 0x35, 0xd3, 0x4f, 0x9c}}
+)
+
+// COM vtable slots, counting the inherited IUnknown/IDXGIObject methods
+const (
+	methodQueryInterface        = 0
+	methodRelease               = 2
+	methodGetAdapter            = 7  // IDXGIDevice
+	methodEnumOutputs           = 7  // IDXGIAdapter
+	methodDuplicateOutput       = 22 // IDXGIOutput1
+	methodDuplicateOutput1      = 26 // IDXGIOutput5
+	methodGetOutputDesc1        = 27 // IDXGIOutput6
+	methodGetDuplicationDesc    = 7  // IDXGIOutputDuplication
+	methodAcquireNextFrame      = 8
+	methodReleaseFrame          = 14
+	methodCreateTexture2D       = 5 // ID3D11Device
+	methodCreateShaderView      = 7
+	methodMap                   = 14 // ID3D11DeviceContext
+	methodUnmap                 = 15
+	methodCopySubresourceRegion = 46
+	methodCopyResource          = 47
+	methodGenerateMips          = 54
+	methodOpenSharedResource    = 28 // ID3D11Device
+	methodGetTextureDesc        = 10 // ID3D11Texture2D
+)
+
+// D3D11 and DXGI constants used by the capture path
+const (
+	d3dDriverTypeHardware         = 1
+	d3d11CreateDeviceBGRASupport  = 0x20
+	d3d11SDKVersion               = 7
+	dxgiFormatR16G16B16A16Float   = 10
+	dxgiFormatR10G10B10A2         = 24
+	dxgiFormatR8G8B8A8            = 28
+	dxgiFormatR8G8B8A8SRGB        = 29
+	dxgiFormatB8G8R8A8            = 87
+	dxgiFormatB8G8R8X8            = 88
+	dxgiFormatB8G8R8A8SRGB        = 91
+	d3d11UsageStaging             = 3
+	d3d11BindShaderResource       = 0x8
+	d3d11BindRenderTarget         = 0x20
+	d3d11CPUAccessRead            = 0x20000
+	d3d11ResourceMiscGenerateMips = 0x1
+	d3d11MapRead                  = 1
+
+	dxgiColorSpaceHDR10 = 12 // DXGI_COLOR_SPACE_RGB_FULL_G2084_NONE_P2020
+
+	dxgiErrorWaitTimeout = 0x887A0027
+	dxgiErrorAccessLost  = 0x887A0026
+)
+
+// dxgiMaxLevel caps the mip chain; 256x smaller is far below any useful size
+const dxgiMaxLevel = 8
+
+// comObject is a COM interface pointer
+type comObject struct {
+	vtbl *[64]uintptr
+}
+
+// call invokes a vtable method and returns its HRESULT
+func (o *comObject) call(method int, args ...uintptr) uint32 {
+	r, _, _ := syscall.SyscallN(o.vtbl[method], append([]uintptr{uintptr(unsafe.Pointer(o))}, args...)...)
+	return uint32(r)
+}
+
+// queryInterface returns the iid interface of o
+func (o *comObject) queryInterface(iid *syscall.GUID) (*comObject, error) {
+	var out *comObject
+	if hr := o.call(methodQueryInterface, uintptr(unsafe.Pointer(iid)), uintptr(unsafe.Pointer(&out))); failed(hr) {
+		return nil, hresultError("QueryInterface", hr)
+	}
+	return out, nil
+}
+
+// release drops a reference; nil objects are ignored
+func (o *comObject) release() {
+	if o != nil {
+		o.call(methodRelease)
+	}
+}
+
+// foreignPointer converts an address returned by a system call into a
+// pointer; the memory belongs to the OS or a DLL, not the Go heap
+func foreignPointer(address uintptr) unsafe.Pointer {
+	return *(*unsafe.Pointer)(unsafe.Pointer(&address))
+}
+
+// failed reports whether an HRESULT is an error
+func failed(hr uint32) bool {
+	return int32(hr) < 0
+}
+
+// hresultError formats a failed call
+func hresultError(op string, hr uint32) error {
+	return fmt.Errorf("dxgi: %s failed: 0x%08X", op, hr)
+}
+
+// texture2DDesc is D3D11_TEXTURE2D_DESC
+type texture2DDesc struct {
+	Width, Height, MipLevels, ArraySize uint32
+	Format                              uint32
+	SampleCount, SampleQuality          uint32
+	Usage, BindFlags, CPUAccessFlags    uint32
+	MiscFlags                           uint32
+}
+
+// mappedSubresource is D3D11_MAPPED_SUBRESOURCE
+type mappedSubresource struct {
+	Data       unsafe.Pointer
+	RowPitch   uint32
+	DepthPitch uint32
+}
+
+// outduplDesc is DXGI_OUTDUPL_DESC
+type outduplDesc struct {
+	Width, Height                uint32
+	RefreshNumerator, RefreshDen uint32
+	Format, Scanline, Scaling    uint32
+	Rotation                     uint32
+	DesktopInSystemMemory        int32
+}
+
+// outputDesc1 is DXGI_OUTPUT_DESC1
+type outputDesc1 struct {
+	DeviceName               [32]uint16
+	Left, Top, Right, Bottom int32
+	AttachedToDesktop        int32
+	Rotation                 uint32
+	Monitor                  uintptr
+	BitsPerColor             uint32
+	ColorSpace               uint32
+	Primaries                [8]float32
+	MinLuminance             float32
+	MaxLuminance             float32
+	MaxFullFrameLuminance    float32
+}
+
+// outduplFrameInfo is DXGI_OUTDUPL_FRAME_INFO
+type outduplFrameInfo struct {
+	LastPresentTime           int64
+	LastMouseUpdateTime       int64
+	AccumulatedFrames         uint32
+	RectsCoalesced            int32
+	ProtectedContentMaskedOut int32
+	PointerX, PointerY        int32
+	PointerVisible            int32
+	TotalMetadataBufferSize   uint32
+	PointerShapeBufferSize    uint32
+}
+
+// DXGISource captures a whole monitor with DXGI Desktop Duplication. The
+// desktop texture never leaves the GPU at full size: it's reduced through a
+// mip chain to fit MaxWidth and only that level is read back and packed to BGR.
+// An HDR monitor is duplicated as 16-bit float scRGB and tone-mapped, as
+// its 8-bit duplicate has washed-out, clipped colors.
+type DXGISource struct {
+	config DXGIConfig
+
+	mu          sync.Mutex
+	device      *comObject
+	context     *comObject
+	duplication *comObject
+	mips        *comObject // Full-size texture whose mip chain does the downscale; nil at level 0
+	mipsView    *comObject
+	staging     *comObject
+	level       int
+	width       int
+	height      int
+	format      uint32   // Texture format, 8-bit BGRA or 16-bit float on HDR monitors
+	toneMap     *toneMap // Set for 16-bit float
+
+	buffers [RingSlots][]byte
+	next    int
+	last    Frame
+}
+
+// NewDXGISource opens Desktop Duplication on the configured monitor
+func NewDXGISource(config DXGIConfig) (*DXGISource, error) {
+	if err := d3d11.Load(); err != nil {
+		return nil, fmt.Errorf("dxgi: %w", err)
+	}
+	s := &DXGISource{config: config}
+	if err := s.open(); err != nil {
+		s.close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// NextFrame returns the newest desktop image. When nothing on screen has
+// changed since the last call the previous frame is returned again.
+func (s *DXGISource) NextFrame(ctx context.Context) (Frame, error) {
+	if err := ctx.Err(); err != nil {
+		return Frame{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.duplication == nil {
+		if err := s.open(); err != nil {
+			s.close()
+			return Frame{}, err
+		}
+	}
+
+	// A zero timeout returns at once when no new frame has been presented
+	var info outduplFrameInfo
+	var resource *comObject
+	hr := s.duplication.call(methodAcquireNextFrame, 0,
+		uintptr(unsafe.Pointer(&info)), uintptr(unsafe.Pointer(&resource)))
+	switch {
+	case hr == dxgiErrorWaitTimeout:
+		return s.repeat()
+	case hr == dxgiErrorAccessLost:
+		// Mode change, UAC prompt, or fullscreen switch; reopen on the next call
+		s.close()
+		return Frame{}, ErrNoFrame
+	case failed(hr):
+		return Frame{}, hresultError("AcquireNextFrame", hr)
+	}
+
+	// LastPresentTime is zero when only the mouse moved
+	if info.LastPresentTime == 0 && s.last.Valid() {
+		resource.release()
+		s.duplication.call(methodReleaseFrame)
+		return s.repeat()
+	}
+
+	texture, err := resource.queryInterface(&iidID3D11Texture2D)
+	resource.release()
+	if err != nil {
+		s.duplication.call(methodReleaseFrame)
+		return Frame{}, err
+	}
+	if s.mips != nil {
+		s.copySubresource(s.mips, 0, texture, 0)
+		s.context.call(methodGenerateMips, uintptr(unsafe.Pointer(s.mipsView)))
+		s.copySubresource(s.staging, 0, s.mips, s.level)
+	} else {
+		s.copySubresource(s.staging, 0, texture, 0)
+	}
+	texture.release()
+	s.duplication.call(methodReleaseFrame)
+
+	return s.readStaging()
+}
+
+// Close releases the duplication and the D3D11 device
+func (s *DXGISource) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.close()
+	return nil
+}
+
+// open creates the device, duplication, and textures; mu must be held or
+// the source not yet shared
+func (s *DXGISource) open() error {
+	hr, _, _ := procD3D11CreateDevice.Call(0, d3dDriverTypeHardware, 0, d3d11CreateDeviceBGRASupport, 0, 0,
+		d3d11SDKVersion, uintptr(unsafe.Pointer(&s.device)), 0, uintptr(unsafe.Pointer(&s.context)))
+	if failed(uint32(hr)) {
+		return hresultError("D3D11CreateDevice", uint32(hr))
+	}
+
+	dxgiDevice, err := s.device.queryInterface(&iidIDXGIDevice)
+	if err != nil {
+		return err
+	}
+	defer dxgiDevice.release()
+
+	var adapter *comObject
+	if hr := dxgiDevice.call(methodGetAdapter, uintptr(unsafe.Pointer(&adapter))); failed(hr) {
+		return hresultError("GetAdapter", hr)
+	}
+	defer adapter.release()
+
+	var output *comObject
+	if hr := adapter.call(methodEnumOutputs, uintptr(s.config.Output), uintptr(unsafe.Pointer(&output))); failed(hr) {
+		return fmt.Errorf("dxgi: monitor %d not found: 0x%08X", s.config.Output, hr)
+	}
+	defer output.release()
+
+	output1, err := output.queryInterface(&iidIDXGIOutput1)
+	if err != nil {
+		return err
+	}
+	defer output1.release()
+
+	s.format, s.toneMap = dxgiFormatB8G8R8A8, nil
+	if peak, hdr := s.hdrPeak(output); hdr {
+		if err := s.duplicateHDR(output, peak); err != nil {
+			return err
+		}
+	} else if hr := output1.call(methodDuplicateOutput, uintptr(unsafe.Pointer(s.device)), uintptr(unsafe.Pointer(&s.duplication))); failed(hr) {
+		return hresultError("DuplicateOutput", hr)
+	}
+
+	var desc outduplDesc
+	s.duplication.call(methodGetDuplicationDesc, uintptr(unsafe.Pointer(&desc)))
+	s.level = min(dxgiLevel(int(desc.Width), s.config.MaxWidth), dxgiMaxLevel)
+	s.width = max(int(desc.Width)>>s.level, 1)
+	s.height = max(int(desc.Height)>>s.level, 1)
+
+	if s.level > 0 {
+		mipsDesc := texture2DDesc{
+			Width: desc.Width, Height: desc.Height, MipLevels: uint32(s.level + 1), ArraySize: 1,
+			Format: s.format, SampleCount: 1,
+			BindFlags: d3d11BindShaderResource | d3d11BindRenderTarget,
+			MiscFlags: d3d11ResourceMiscGenerateMips,
+		}
+		if hr := s.device.call(methodCreateTexture2D, uintptr(unsafe.Pointer(&mipsDesc)), 0, uintptr(unsafe.Pointer(&s.mips))); failed(hr) {
+			return hresultError("CreateTexture2D", hr)
+		}
+		if hr := s.device.call(methodCreateShaderView, uintptr(unsafe.Pointer(s.mips)), 0, uintptr(unsafe.Pointer(&s.mipsView))); failed(hr) {
+			return hresultError("CreateShaderResourceView", hr)
+		}
+	}
+
+	stagingDesc := texture2DDesc{
+		Width: uint32(s.width), Height: uint32(s.height), MipLevels: 1, ArraySize: 1,
+		Format: s.format, SampleCount: 1,
+		Usage: d3d11UsageStaging, CPUAccessFlags: d3d11CPUAccessRead,
+	}
+	if hr := s.device.call(methodCreateTexture2D, uintptr(unsafe.Pointer(&stagingDesc)), 0, uintptr(unsafe.Pointer(&s.staging))); failed(hr) {
+		return hresultError("CreateTexture2D", hr)
+	}
+	return nil
+}
+
+// hdrPeak reports whether HDR capture applies to the monitor, and its peak
+// brightness in nits
+func (s *DXGISource) hdrPeak(output *comObject) (float64, bool) {
+	if s.config.HDR.Mode == HDROff {
+		return 0, false
+	}
+	// IDXGIOutput6 is missing before Windows 10 1803, which has no HDR desktop
+	output6, err := output.queryInterface(&iidIDXGIOutput6)
+	if err != nil {
+		return 0, false
+	}
+	defer output6.release()
+	var desc outputDesc1
+	if hr := output6.call(methodGetOutputDesc1, uintptr(unsafe.Pointer(&desc))); failed(hr) || desc.ColorSpace != dxgiColorSpaceHDR10 {
+		return 0, false
+	}
+	return float64(desc.MaxLuminance), true
+}
+
+// duplicateHDR duplicates the monitor as 16-bit float scRGB
+func (s *DXGISource) duplicateHDR(output *comObject, peak float64) error {
+	output5, err := output.queryInterface(&iidIDXGIOutput5)
+	if err != nil {
+		return err
+	}
+	defer output5.release()
+	formats := []uint32{dxgiFormatR16G16B16A16Float}
+	if hr := output5.call(methodDuplicateOutput1, uintptr(unsafe.Pointer(s.device)), 0, uintptr(len(formats)),
+		uintptr(unsafe.Pointer(&formats[0])), uintptr(unsafe.Pointer(&s.duplication))); failed(hr) {
+		return hresultError("DuplicateOutput1", hr)
+	}
+	if s.config.HDR.Peak > 0 {
+		peak = s.config.HDR.Peak
+	}
+	s.format, s.toneMap = dxgiFormatR16G16B16A16Float, newToneMap(s.config.HDR.WhiteLevel, peak)
+	return nil
+}
+
+// close releases every COM object; mu must be held
+func (s *DXGISource) close() {
+	for _, o := range []**comObject{&s.staging, &s.mipsView, &s.mips, &s.duplication, &s.context, &s.device} {
+		(*o).release()
+		*o = nil
+	}
+}
+
+// copySubresource copies one whole subresource between textures on the GPU
+func (s *DXGISource) copySubresource(dst *comObject, dstLevel int, src *comObject, srcLevel int) {
+	s.context.call(methodCopySubresourceRegion, uintptr(unsafe.Pointer(dst)), uintptr(dstLevel), 0, 0, 0,
+		uintptr(unsafe.Pointer(src)), uintptr(srcLevel), 0)
+}
+
+// readStaging maps the staging texture and packs its BGRA rows into the next BGR buffer
+func (s *DXGISource) readStaging() (Frame, error) {
+	var mapped mappedSubresource
+	if hr := s.context.call(methodMap, uintptr(unsafe.Pointer(s.staging)), 0, d3d11MapRead, 0, uintptr(unsafe.Pointer(&mapped))); failed(hr) {
+		return Frame{}, hresultError("Map", hr)
+	}
+	defer s.context.call(methodUnmap, uintptr(unsafe.Pointer(s.staging)), 0)
+
+	size := s.width * s.height * 3
+	data := s.buffers[s.next]
+	if len(data) != size {
+		data = make([]byte, size)
+		s.buffers[s.next] = data
+	}
+	s.next = (s.next + 1) % RingSlots
+
+	pitch := int(mapped.RowPitch)
+	src := unsafe.Slice((*byte)(mapped.Data), pitch*s.height)
+	if s.toneMap != nil {
+		packFP16(data, src, pitch, s.width, s.height, s.toneMap)
+	} else {
+		packBGRA(data, src, pitch, s.width, s.height, false)
+	}
+
+	s.last = Frame{Data: data, Width: s.width, Height: s.height, Timestamp: time.Now()}
+	return s.last, nil
+}
+
+// repeat returns the last frame with a fresh timestamp, or ErrNoFrame before the first
+func (s *DXGISource) repeat() (Frame, error) {
+	if !s.last.Valid() {
+		return Frame{}, ErrNoFrame
+	}
+	frame := s.last
+	frame.Timestamp = time.Now()
+	return frame, nil
+}
@@ -0,0 +1,87 @@
+package capture
+
+import (
+	"math"
+	"sync"
+)
+
+// Block Lucas-Kanade parameters
+const (
+	flowBlock     = 16  // Block size in pixels; one flow vector per block
+	flowMinSpeed  = 0.5 // Pixels per frame a block must move to count
+	flowMinDet    = 1e3 // Below this the block has too little texture to solve for flow
+	flowMinBlocks = 1   // Busy blocks needed for a blob
+)
+
+// FlowDetector estimates optical flow per block with Lucas-Kanade and
+// reports blocks that actually move. Brightness changes without motion,
+// such as flicker or a light turning on, produce no flow and are ignored.
+type FlowDetector struct {
+	mu             sync.Mutex
+	current, prior []float32
+	grid           cellGrid
+}
+
+// NewFlowDetector creates an optical flow detector
+func NewFlowDetector() *FlowDetector {
+	return &FlowDetector{}
+}
+
+// Detect returns blobs of blocks whose flow between previous and current
+// exceeds half a pixel and whose mean brightness change exceeds threshold/4
+func (f *FlowDetector) Detect(current, previous Frame, threshold uint8) []RawDetection {
+	if !current.Valid() || !previous.Valid() || current.Width != previous.Width || current.Height != previous.Height {
+		return nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	width, height := current.Width, current.Height
+	f.current = luma(current, f.current)
+	f.prior = luma(previous, f.prior)
+	f.grid.reset(width, height, flowBlock)
+
+	minChange := float64(threshold) / 4
+	for by := 0; by+flowBlock <= height; by += flowBlock {
+		for bx := 0; bx+flowBlock <= width; bx += flowBlock {
+			if speed, ok := f.blockFlow(bx, by, width, height, minChange); ok && speed >= flowMinSpeed {
+				f.grid.mark(bx, by, flowBlock*flowBlock)
+			}
+		}
+	}
+	return f.grid.blobs(flowBlock * flowBlock * flowMinBlocks)
+}
+
+// blockFlow solves the Lucas-Kanade equations over one block, sampling every
+// other pixel, and returns the flow speed in pixels per frame
+func (f *FlowDetector) blockFlow(bx, by, width, height int, minChange float64) (float64, bool) {
+	var ixx, ixy, iyy, ixt, iyt, change float64
+	var n int
+	for y := max(by, 1); y < min(by+flowBlock, height-1); y += 2 {
+		for x := max(bx, 1); x < min(bx+flowBlock, width-1); x += 2 {
+			i := y*width + x
+			ix := float64(f.current[i+1]-f.current[i-1]) / 2
+			iy := float64(f.current[i+width]-f.current[i-width]) / 2
+			it := float64(f.current[i] - f.prior[i])
+			ixx += ix * ix
+			ixy += ix * iy
+			iyy += iy * iy
+			ixt += ix * it
+			iyt += iy * it
+			change += math.Abs(it)
+			n++
+		}
+	}
+	if n == 0 || change/float64(n) < minChange {
+		return 0, false
+	}
+
+	det := ixx*iyy - ixy*ixy
+	if det < flowMinDet {
+		return 0, false
+	}
+	vx := (-iyy*ixt + ixy*iyt) / det
+	vy := (ixy*ixt - ixx*iyt) / det
+	return math.Hypot(vx, vy), true
+}
@@ -0,0 +1,12 @@
+//go:build !windows
+
+package capture
+
+import (
+	"errors"
+)
+
+// ForegroundWindowTitle returns the title of the window that has keyboard focus
+func ForegroundWindowTitle() (string, error) {
+	return "", errors.New("foreground window detection is only supported on Windows")
+}
@@ -0,0 +1,27 @@
+//go:build windows
+
+package capture
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var (
+	user32                  = syscall.NewLazyDLL("user32.dll")
+	procGetForegroundWindow = user32.NewProc("GetForegroundWindow")
+	procGetWindowTextW      = user32.NewProc("GetWindowTextW")
+)
+
+// ForegroundWindowTitle returns the title of the window that has keyboard focus
+func ForegroundWindowTitle() (string, error) {
+	hwnd, _, _ := procGetForegroundWindow.Call()
+	if hwnd == 0 {
+		// No foreground window, e.g. while the desktop is locked
+		return "", nil
+	}
+
+	buf := make([]uint16, 256)
+	n, _, _ := procGetWindowTextW.Call(hwnd, uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+	return syscall.UTF16ToString(buf[:n]), nil
+}
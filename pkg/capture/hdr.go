@@ -0,0 +1,125 @@
+package capture
+
+import (
+	"errors"
+	"math"
+)
+
+// HDR capture modes
+const (
+	HDRAuto = "auto" // Capture HDR displays in 16-bit float and tone-map them
+	HDROff  = "off"  // Always capture 8-bit, as Windows hands it out
+)
+
+// scRGBWhite is the nits of 1.0 in scRGB, the linear HDR desktop format
+const scRGBWhite = 80
+
+// toneMapKnee is the fraction of white below which pixels pass unchanged
+const toneMapKnee = 0.8
+
+// HDRConfig configures how HDR frames are mapped to the 8-bit frames the
+// detector was tuned on
+type HDRConfig struct {
+	Mode       string  `yaml:"mode"`        // auto or off
+	WhiteLevel float64 `yaml:"white_level"` // Nits shown as full white, Windows' SDR content brightness
+	Peak       float64 `yaml:"peak"`        // Brightest nits kept distinguishable; 0 uses the display's peak
+}
+
+// DefaultHDRConfig tone-maps HDR displays with a 200 nit white
+func DefaultHDRConfig() HDRConfig {
+	return HDRConfig{Mode: HDRAuto, WhiteLevel: 200}
+}
+
+// Validate checks the HDR settings
+func (c HDRConfig) Validate() error {
+	if c.Mode != HDRAuto && c.Mode != HDROff {
+		return errors.New(`mode must be "auto" or "off"`)
+	}
+	if c.WhiteLevel < scRGBWhite || c.WhiteLevel > 1000 {
+		return errors.New("white_level must be between 80 and 1000 nits")
+	}
+	if c.Peak < 0 {
+		return errors.New("peak must not be negative")
+	}
+	return nil
+}
+
+// toneMap maps every 16-bit float scRGB channel value to 8-bit sRGB
+type toneMap [1 << 16]byte
+
+// newToneMap builds the table for a white level and display peak in nits.
+// Values up to the knee below white keep their SDR look; brighter ones are
+// rolled off so the peak lands on full white instead of everything above
+// white clipping into one flat area the detector can't see motion in.
+func newToneMap(white, peak float64) *toneMap {
+	m := math.Max(peak/white, 1)
+	span := (m - toneMapKnee) / (1 - toneMapKnee)
+	var table toneMap
+	for bits := range table {
+		v := float64(halfToFloat(uint16(bits))) * scRGBWhite / white
+		if math.IsNaN(v) || v <= 0 {
+			continue
+		}
+		if v > toneMapKnee {
+			x := (v - toneMapKnee) / (1 - toneMapKnee)
+			v = toneMapKnee + (1-toneMapKnee)*x*(1+x/(span*span))/(1+x)
+		}
+		table[bits] = byte(math.Round(srgbEncode(math.Min(v, 1)) * 255))
+	}
+	return &table
+}
+
+// srgbEncode applies the sRGB transfer function to a linear value
+func srgbEncode(v float64) float64 {
+	if v <= 0.0031308 {
+		return 12.92 * v
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+// halfToFloat widens an IEEE 754 half-precision value
+func halfToFloat(h uint16) float32 {
+	sign := uint32(h>>15) << 31
+	exponent := uint32(h>>10) & 0x1f
+	mantissa := uint32(h) & 0x3ff
+	switch {
+	case exponent == 0x1f:
+		return math.Float32frombits(sign | 0xff<<23 | mantissa<<13)
+	case exponent == 0 && mantissa == 0:
+		return math.Float32frombits(sign)
+	case exponent == 0:
+		// Subnormal: renormalize
+		for mantissa&0x400 == 0 {
+			mantissa <<= 1
+			exponent--
+		}
+		exponent++
+		mantissa &= 0x3ff
+	}
+	return math.Float32frombits(sign | (exponent+112)<<23 | mantissa<<13)
+}
+
+// packFP16 tone-maps 64-bit RGBA half-float rows, pitch bytes apart, into
+// 24-bit BGR
+func packFP16(dst, src []byte, pitch, width, height int, table *toneMap) {
+	for y := 0; y < height; y++ {
+		row, out := src[y*pitch:], dst[y*width*3:]
+		for x := 0; x < width; x++ {
+			p := row[x*8:]
+			out[x*3] = table[uint16(p[4])|uint16(p[5])<<8]
+			out[x*3+1] = table[uint16(p[2])|uint16(p[3])<<8]
+			out[x*3+2] = table[uint16(p[0])|uint16(p[1])<<8]
+		}
+	}
+}
+
+// packRGB10 keeps the top 8 bits of 10-bit RGB rows (R10G10B10A2) as BGR
+func packRGB10(dst, src []byte, pitch, width, height int) {
+	for y := 0; y < height; y++ {
+		row, out := src[y*pitch:], dst[y*width*3:]
+		for x := 0; x < width; x++ {
+			p := uint32(row[x*4]) | uint32(row[x*4+1])<<8 | uint32(row[x*4+2])<<16 | uint32(row[x*4+3])<<24
+			out[x*3], out[x*3+1], out[x*3+2] = byte(p>>22), byte(p>>12), byte(p>>2)
+		}
+	}
+}
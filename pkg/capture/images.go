@@ -0,0 +1,88 @@
+package capture
+
+import (
+	"context"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ImageSequenceSource reads numbered PNG or JPEG frames in filename order
+type ImageSequenceSource struct {
+	paths []string
+	loop  bool
+
+	mu    sync.Mutex
+	index int
+}
+
+// NewImageSequenceSource creates a source from a glob pattern such as "frames/*.png"
+func NewImageSequenceSource(pattern string, loop bool) (*ImageSequenceSource, error) {
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no images match %s", pattern)
+	}
+	sort.Strings(paths)
+	return &ImageSequenceSource{paths: paths, loop: loop}, nil
+}
+
+// NextFrame decodes the next image, or returns io.EOF after the last one
+func (s *ImageSequenceSource) NextFrame(ctx context.Context) (Frame, error) {
+	if err := ctx.Err(); err != nil {
+		return Frame{}, err
+	}
+
+	s.mu.Lock()
+	if s.index >= len(s.paths) {
+		if !s.loop {
+			s.mu.Unlock()
+			return Frame{}, io.EOF
+		}
+		s.index = 0
+	}
+	path := s.paths[s.index]
+	s.index++
+	s.mu.Unlock()
+
+	file, err := os.Open(path)
+	if err != nil {
+		return Frame{}, err
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return Frame{}, fmt.Errorf("decode %s: %w", path, err)
+	}
+	return FrameFromImage(img), nil
+}
+
+// FrameFromImage converts an image to a packed BGR frame
+func FrameFromImage(img image.Image) Frame {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	data := make([]byte, width*height*3)
+
+	i := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			data[i] = byte(b >> 8)
+			data[i+1] = byte(g >> 8)
+			data[i+2] = byte(r >> 8)
+			i += 3
+		}
+	}
+
+	return Frame{Data: data, Width: width, Height: height, Timestamp: time.Now()}
+}
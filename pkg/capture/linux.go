@@ -0,0 +1,24 @@
+package capture
+
+import "os"
+
+// LinuxConfig configures the Linux backends
+type LinuxConfig struct {
+	WindowTitle string `yaml:"window_title"` // X11 window captured; empty captures the whole screen
+	GStreamer   string `yaml:"gstreamer"`    // gst-launch-1.0 binary reading the PipeWire stream
+	MaxSize     int    `yaml:"max_size"`     // Scale PipeWire frames so neither side exceeds this; 0 keeps the stream size
+}
+
+// LinuxBackend picks PipeWire on a Wayland session and X11 otherwise; the
+// Zig backend only captures on Windows
+func LinuxBackend() string {
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		return BackendPipeWire
+	}
+	return BackendX11
+}
+
+// DefaultLinuxConfig captures the VRChat window, which Proton titles like on Windows
+func DefaultLinuxConfig() LinuxConfig {
+	return LinuxConfig{WindowTitle: "VRChat", GStreamer: "gst-launch-1.0"}
+}
@@ -0,0 +1,44 @@
+//go:build !linux
+
+package capture
+
+import (
+	"context"
+	"errors"
+)
+
+// X11Source captures an X11 window with MIT-SHM; Linux only
+type X11Source struct{}
+
+// NewX11Source fails outside Linux
+func NewX11Source(config LinuxConfig) (*X11Source, error) {
+	return nil, errors.New("X11 capture is only supported on Linux")
+}
+
+// NextFrame always fails outside Linux
+func (s *X11Source) NextFrame(ctx context.Context) (Frame, error) {
+	return Frame{}, ErrNoFrame
+}
+
+// Close does nothing outside Linux
+func (s *X11Source) Close() error {
+	return nil
+}
+
+// PipeWireSource captures a Wayland desktop through the ScreenCast portal; Linux only
+type PipeWireSource struct{}
+
+// NewPipeWireSource fails outside Linux
+func NewPipeWireSource(config LinuxConfig) (*PipeWireSource, error) {
+	return nil, errors.New("PipeWire capture is only supported on Linux")
+}
+
+// NextFrame always fails outside Linux
+func (s *PipeWireSource) NextFrame(ctx context.Context) (Frame, error) {
+	return Frame{}, ErrNoFrame
+}
+
+// Close does nothing outside Linux
+func (s *PipeWireSource) Close() error {
+	return nil
+}
@@ -0,0 +1,13 @@
+package capture
+
+// MacOSConfig configures the ScreenCaptureKit backend
+type MacOSConfig struct {
+	WindowTitle string `yaml:"window_title"` // Window captured; empty captures Display
+	Display     int    `yaml:"display"`      // Display index when no window title is set
+	MaxWidth    int    `yaml:"max_width"`    // Scale frames down to this width; 0 keeps the window's size in points
+}
+
+// DefaultMacOSConfig captures the VRChat window at no more than 1920 wide
+func DefaultMacOSConfig() MacOSConfig {
+	return MacOSConfig{WindowTitle: "VRChat", MaxWidth: 1920}
+}
@@ -0,0 +1,124 @@
+package capture
+
+import "sync"
+
+// MOG2 model parameters, after Zivkovic's adaptive Gaussian mixture
+const (
+	mogComponents   = 3
+	mogLearningRate = 1.0 / 100 // Roughly how many frames a new background takes to settle
+	mogBackground   = 0.9       // Weight the background components cover
+	mogInitialVar   = 15 * 15
+	mogMinVar       = 4 * 4
+	mogMatchSigma2  = 2.5 * 2.5
+	mogCell         = 8
+	mogCellFraction = 4 // A cell with 1/mogCellFraction of its pixels in the foreground is busy
+	mogWarmupFrames = 10
+)
+
+// MOG2Detector models each pixel's luminance as a mixture of Gaussians and
+// reports pixels no background component explains. Unlike frame
+// differencing it keeps detecting someone who stops moving for a moment
+// and isn't fooled by slow lighting changes.
+type MOG2Detector struct {
+	mu            sync.Mutex
+	width, height int
+	frames        int
+	weight        []float32 // mogComponents per pixel, heaviest first
+	mean          []float32
+	variance      []float32
+	gray          []float32
+	grid          cellGrid
+}
+
+// NewMOG2Detector creates a detector that learns the background from the first frames
+func NewMOG2Detector() *MOG2Detector {
+	return &MOG2Detector{}
+}
+
+// Detect updates the background model with current and returns the foreground blobs.
+// previous is ignored.
+func (m *MOG2Detector) Detect(current, previous Frame, threshold uint8) []RawDetection {
+	if !current.Valid() {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if current.Width != m.width || current.Height != m.height {
+		m.init(current.Width, current.Height)
+	}
+	m.gray = luma(current, m.gray)
+	m.grid.reset(current.Width, current.Height, mogCell)
+	m.frames++
+
+	minDist2 := float32(threshold) * float32(threshold)
+	for p, x := range m.gray {
+		if m.update(p*mogComponents, x, minDist2) {
+			m.grid.mark(p%m.width, p/m.width, 1)
+		}
+	}
+	if m.frames < mogWarmupFrames {
+		return nil
+	}
+	return m.grid.blobs(mogCell * mogCell / mogCellFraction)
+}
+
+// init resets the model for a new frame size
+func (m *MOG2Detector) init(width, height int) {
+	n := width * height * mogComponents
+	m.width, m.height, m.frames = width, height, 0
+	m.weight = make([]float32, n)
+	m.mean = make([]float32, n)
+	m.variance = make([]float32, n)
+	m.gray = nil
+}
+
+// update folds luminance x into the pixel's components starting at base and
+// reports whether the pixel is foreground
+func (m *MOG2Detector) update(base int, x, minDist2 float32) bool {
+	w, mu, v := m.weight[base:base+mogComponents], m.mean[base:base+mogComponents], m.variance[base:base+mogComponents]
+
+	matched := -1
+	for k := 0; k < mogComponents && w[k] > 0; k++ {
+		d := x - mu[k]
+		if d*d < max(mogMatchSigma2*v[k], minDist2) {
+			matched = k
+			break
+		}
+	}
+
+	// Weight the components ahead of the match cover; past mogBackground the
+	// match is a foreground component
+	var ahead float32
+	for k := 0; k < matched; k++ {
+		ahead += w[k]
+	}
+	foreground := matched < 0 || ahead > mogBackground
+
+	for k := range w {
+		w[k] *= 1 - mogLearningRate
+	}
+	if matched < 0 {
+		// Replace the lightest component with one centered on x
+		matched = mogComponents - 1
+		w[matched], mu[matched], v[matched] = mogLearningRate, x, mogInitialVar
+		if m.frames == 1 {
+			w[matched] = 1
+		}
+	} else {
+		w[matched] += mogLearningRate
+		rho := mogLearningRate / w[matched]
+		d := x - mu[matched]
+		mu[matched] += rho * d
+		v[matched] = max(v[matched]+rho*(d*d-v[matched]), mogMinVar)
+	}
+
+	// Keep components ordered heaviest first
+	for k := matched; k > 0 && w[k] > w[k-1]; k-- {
+		w[k], w[k-1] = w[k-1], w[k]
+		mu[k], mu[k-1] = mu[k-1], mu[k]
+		v[k], v[k-1] = v[k-1], v[k]
+	}
+	return foreground
+}
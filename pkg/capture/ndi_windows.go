@@ -0,0 +1,251 @@
+//go:build windows
+
+package capture
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// ndiLibrary is the NDI runtime's DLL, installed with NDI Tools or the OBS
+// NDI plugin
+const ndiLibrary = "Processing.NDI.Lib.x64.dll"
+
+// ndiWait bounds how long NextFrame waits for a frame or for sources
+const ndiWait = 100 * time.Millisecond
+
+// NDI receiver constants
+const (
+	ndiColorBGRXBGRA   = 0
+	ndiBandwidthLowest = 0
+	ndiBandwidthHigh   = 100
+	ndiFrameVideo      = 1
+	ndiFrameError      = 4
+	ndiFourCCBGRA      = 'B' | 'G'<<8 | 'R'<<16 | 'A'<<24
+	ndiFourCCBGRX      = 'B' | 'G'<<8 | 'R'<<16 | 'X'<<24
+)
+
+// ndiSource is NDIlib_source_t
+type ndiSource struct {
+	Name, URL *byte
+}
+
+// ndiRecvCreate is NDIlib_recv_create_v3_t
+type ndiRecvCreate struct {
+	Source      ndiSource
+	ColorFormat int32
+	Bandwidth   int32
+	AllowFields bool
+	Name        *byte
+}
+
+// ndiVideoFrame is NDIlib_video_frame_v2_t
+type ndiVideoFrame struct {
+	Width, Height int32
+	FourCC        int32
+	RateN, RateD  int32
+	AspectRatio   float32
+	FrameFormat   int32
+	Timecode      int64
+	Data          unsafe.Pointer
+	LineStride    int32
+	Metadata      *byte
+	Timestamp     int64
+}
+
+// ndiLib holds the runtime's entry points
+type ndiLib struct {
+	initialize, findCreate, findWait, findSources, findDestroy *syscall.LazyProc
+	recvCreate, recvCapture, recvFreeVideo, recvDestroy        *syscall.LazyProc
+}
+
+var (
+	ndiOnce sync.Once
+	ndi     *ndiLib
+	ndiErr  error
+)
+
+// loadNDI loads and initializes the NDI runtime once
+func loadNDI() (*ndiLib, error) {
+	ndiOnce.Do(func() {
+		path := ndiLibrary
+		for _, env := range []string{"NDI_RUNTIME_DIR_V6", "NDI_RUNTIME_DIR_V5"} {
+			if dir := os.Getenv(env); dir != "" {
+				path = filepath.Join(dir, ndiLibrary)
+				break
+			}
+		}
+		dll := syscall.NewLazyDLL(path)
+		if err := dll.Load(); err != nil {
+			ndiErr = fmt.Errorf("ndi: runtime not installed: %w", err)
+			return
+		}
+		lib := &ndiLib{
+			initialize:    dll.NewProc("NDIlib_initialize"),
+			findCreate:    dll.NewProc("NDIlib_find_create_v2"),
+			findWait:      dll.NewProc("NDIlib_find_wait_for_sources"),
+			findSources:   dll.NewProc("NDIlib_find_get_current_sources"),
+			findDestroy:   dll.NewProc("NDIlib_find_destroy"),
+			recvCreate:    dll.NewProc("NDIlib_recv_create_v3"),
+			recvCapture:   dll.NewProc("NDIlib_recv_capture_v2"),
+			recvFreeVideo: dll.NewProc("NDIlib_recv_free_video_v2"),
+			recvDestroy:   dll.NewProc("NDIlib_recv_destroy"),
+		}
+		if ok, _, _ := lib.initialize.Call(); ok&0xff == 0 {
+			ndiErr = errors.New("ndi: this CPU is not supported by the NDI runtime")
+			return
+		}
+		ndi = lib
+	})
+	return ndi, ndiErr
+}
+
+// NDISource receives an NDI video stream, e.g. OBS's NDI output of the
+// VRChat game capture. It finds the configured stream on the network first
+// and keeps returning the last frame while no new one has arrived.
+type NDISource struct {
+	config NDIConfig
+	lib    *ndiLib
+
+	mu     sync.Mutex
+	finder uintptr
+	recv   uintptr
+
+	buffers [RingSlots][]byte
+	next    int
+	last    Frame
+}
+
+// NewNDISource loads the NDI runtime and starts looking for the stream
+func NewNDISource(config NDIConfig) (*NDISource, error) {
+	lib, err := loadNDI()
+	if err != nil {
+		return nil, err
+	}
+	finder, _, _ := lib.findCreate.Call(0)
+	if finder == 0 {
+		return nil, errors.New("ndi: could not start source discovery")
+	}
+	return &NDISource{config: config, lib: lib, finder: finder}, nil
+}
+
+// NextFrame returns the newest frame of the stream, or ErrNoFrame until
+// the stream is found and has sent one
+func (s *NDISource) NextFrame(ctx context.Context) (Frame, error) {
+	if err := ctx.Err(); err != nil {
+		return Frame{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.finder == 0 {
+		return Frame{}, errors.New("ndi: source closed")
+	}
+	if s.recv == 0 && !s.connect() {
+		return Frame{}, ErrNoFrame
+	}
+
+	var video ndiVideoFrame
+	kind, _, _ := s.lib.recvCapture.Call(s.recv, uintptr(unsafe.Pointer(&video)), 0, 0, uintptr(ndiWait/time.Millisecond))
+	switch kind {
+	case ndiFrameVideo:
+	case ndiFrameError:
+		// The sender went away; look for it again on the next call
+		s.lib.recvDestroy.Call(s.recv)
+		s.recv = 0
+		return Frame{}, ErrNoFrame
+	default:
+		return s.repeat()
+	}
+	defer s.lib.recvFreeVideo.Call(s.recv, uintptr(unsafe.Pointer(&video)))
+
+	if video.FourCC != ndiFourCCBGRA && video.FourCC != ndiFourCCBGRX {
+		return Frame{}, fmt.Errorf("ndi: unsupported pixel format 0x%08X", uint32(video.FourCC))
+	}
+	width, height, pitch := int(video.Width), int(video.Height), int(video.LineStride)
+	size := width * height * 3
+	data := s.buffers[s.next]
+	if len(data) != size {
+		data = make([]byte, size)
+		s.buffers[s.next] = data
+	}
+	s.next = (s.next + 1) % RingSlots
+	packBGRA(data, unsafe.Slice((*byte)(video.Data), pitch*height), pitch, width, height, false)
+
+	s.last = Frame{Data: data, Width: width, Height: height, Timestamp: time.Now()}
+	return s.last, nil
+}
+
+// Close disconnects and stops discovery
+func (s *NDISource) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.recv != 0 {
+		s.lib.recvDestroy.Call(s.recv)
+		s.recv = 0
+	}
+	if s.finder != 0 {
+		s.lib.findDestroy.Call(s.finder)
+		s.finder = 0
+	}
+	return nil
+}
+
+// connect creates a receiver for the configured stream once discovery has
+// seen it; mu must be held
+func (s *NDISource) connect() bool {
+	s.lib.findWait.Call(s.finder, uintptr(ndiWait/time.Millisecond))
+	var count uint32
+	list, _, _ := s.lib.findSources.Call(s.finder, uintptr(unsafe.Pointer(&count)))
+	if list == 0 || count == 0 {
+		return false
+	}
+
+	want := strings.ToLower(s.config.Source)
+	for _, source := range unsafe.Slice((*ndiSource)(foreignPointer(list)), count) {
+		name := cString(source.Name)
+		if want != "" && !strings.Contains(strings.ToLower(name), want) {
+			continue
+		}
+		bandwidth := int32(ndiBandwidthHigh)
+		if s.config.Lowest {
+			bandwidth = ndiBandwidthLowest
+		}
+		receiverName, _ := syscall.BytePtrFromString("VRChat Proximity")
+		create := ndiRecvCreate{Source: source, ColorFormat: ndiColorBGRXBGRA, Bandwidth: bandwidth, Name: receiverName}
+		s.recv, _, _ = s.lib.recvCreate.Call(uintptr(unsafe.Pointer(&create)))
+		return s.recv != 0
+	}
+	return false
+}
+
+// repeat returns the last frame with a fresh timestamp, or ErrNoFrame before the first
+func (s *NDISource) repeat() (Frame, error) {
+	if !s.last.Valid() {
+		return Frame{}, ErrNoFrame
+	}
+	frame := s.last
+	frame.Timestamp = time.Now()
+	return frame, nil
+}
+
+// cString copies a NUL-terminated C string
+func cString(p *byte) string {
+	if p == nil {
+		return ""
+	}
+	var n int
+	for *(*byte)(unsafe.Add(unsafe.Pointer(p), n)) != 0 {
+		n++
+	}
+	return string(unsafe.Slice(p, n))
+}
@@ -0,0 +1,221 @@
+package capture
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NetworkConfig configures the network backend, which decodes a live RTSP,
+// RTMP, SRT, or HTTP stream with ffmpeg
+type NetworkConfig struct {
+	URL       string        `yaml:"url"`       // e.g. rtsp://192.168.1.20:8554/live or rtmp://localhost/live/quest
+	FFmpeg    string        `yaml:"ffmpeg"`    // ffmpeg binary; ffprobe is expected next to it
+	Transport string        `yaml:"transport"` // RTSP transport: tcp or udp
+	MaxSize   int           `yaml:"max_size"`  // Downscale so neither side exceeds this (0 keeps the original size)
+	Reconnect time.Duration `yaml:"reconnect"` // Wait this long before reconnecting after the stream drops
+}
+
+// DefaultNetworkConfig uses ffmpeg from PATH over TCP, reconnecting after 2s
+func DefaultNetworkConfig() NetworkConfig {
+	return NetworkConfig{FFmpeg: "ffmpeg", Transport: "tcp", Reconnect: 2 * time.Second}
+}
+
+// Validate checks the network stream settings
+func (c NetworkConfig) Validate() error {
+	if c.Transport != "tcp" && c.Transport != "udp" {
+		return errors.New(`transport must be "tcp" or "udp"`)
+	}
+	if c.MaxSize < 0 || c.Reconnect < 0 {
+		return errors.New("max_size and reconnect must not be negative")
+	}
+	return nil
+}
+
+// NetworkSource decodes a live stream from another machine, e.g. a second
+// PC streaming its desktop or a Quest casting its view
+type NetworkSource struct {
+	liveSource
+	config NetworkConfig
+}
+
+// NewNetworkSource creates a source for config.URL; it connects on the first frame
+func NewNetworkSource(config NetworkConfig) *NetworkSource {
+	if config.FFmpeg == "" {
+		config.FFmpeg = "ffmpeg"
+	}
+	n := &NetworkSource{config: config}
+	n.liveSource.reconnect, n.liveSource.decode = config.Reconnect, n.decode
+	return n
+}
+
+// decode runs one ffmpeg session, publishing every frame it decodes
+func (n *NetworkSource) decode(ctx context.Context) error {
+	width, height, err := n.probe(ctx)
+	if err != nil {
+		return err
+	}
+	width, height = scaledSize(width, height, n.config.MaxSize)
+
+	args := append([]string{"-v", "error"}, n.inputArgs()...)
+	args = append(args, "-i", n.config.URL, "-an")
+	if n.config.MaxSize > 0 {
+		args = append(args, "-vf", fmt.Sprintf("scale=%d:%d", width, height))
+	}
+	args = append(args, "-f", "rawvideo", "-pix_fmt", "bgr24", "-")
+
+	cmd := exec.CommandContext(ctx, n.config.FFmpeg, args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start ffmpeg: %w", err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+	return n.readFrames(ctx, stdout, width, height, "stream "+n.config.URL)
+}
+
+// inputArgs are the ffmpeg and ffprobe input options that keep latency low
+func (n *NetworkSource) inputArgs() []string {
+	args := []string{"-fflags", "nobuffer", "-flags", "low_delay"}
+	if strings.HasPrefix(n.config.URL, "rtsp://") || strings.HasPrefix(n.config.URL, "rtsps://") {
+		args = append(args, "-rtsp_transport", n.config.Transport)
+	}
+	return args
+}
+
+// probe reads the stream's frame size with ffprobe
+func (n *NetworkSource) probe(ctx context.Context) (int, int, error) {
+	probe := &VideoSource{path: n.config.URL, config: VideoConfig{FFmpeg: n.config.FFmpeg}}
+	return probe.probe(ctx, n.inputArgs()...)
+}
+
+// liveSource serves the newest frame of a live feed decoded in the
+// background, so a slow detector never falls behind the feed. decode runs
+// one session and is restarted after reconnect whenever it returns.
+type liveSource struct {
+	reconnect time.Duration
+	decode    func(ctx context.Context) error
+
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	frame   Frame // Newest decoded frame
+	given   bool  // Whether frame has been returned by NextFrame
+	err     error
+	started bool
+
+	// Buffers returned by NextFrame stay untouched until RingSlots newer
+	// ones have been; sessions decode into free ones
+	handed [][]byte
+	free   [][]byte
+}
+
+// NextFrame returns the newest frame of the feed, or ErrNoFrame while
+// connecting. The same frame is returned again until a new one is decoded.
+func (l *liveSource) NextFrame(ctx context.Context) (Frame, error) {
+	if err := ctx.Err(); err != nil {
+		return Frame{}, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.started {
+		l.started = true
+		runCtx, cancel := context.WithCancel(context.Background())
+		l.cancel = cancel
+		go l.run(runCtx)
+	}
+	if !l.frame.Valid() {
+		if l.err != nil {
+			return Frame{}, l.err
+		}
+		return Frame{}, ErrNoFrame
+	}
+	if !l.given {
+		l.given = true
+		l.handed = append(l.handed, l.frame.Data)
+		if len(l.handed) > RingSlots {
+			l.free = append(l.free, l.handed[0])
+			l.handed = l.handed[1:]
+		}
+	}
+	return l.frame, nil
+}
+
+// Close stops decoding
+func (l *liveSource) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.cancel != nil {
+		l.cancel()
+	}
+	return nil
+}
+
+// run decodes sessions until ctx ends, reconnecting after errors
+func (l *liveSource) run(ctx context.Context) {
+	for ctx.Err() == nil {
+		err := l.decode(ctx)
+		l.mu.Lock()
+		l.publish(Frame{})
+		l.err = err
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(l.reconnect):
+		}
+	}
+}
+
+// readFrames publishes each raw BGR frame read from r until it fails
+func (l *liveSource) readFrames(ctx context.Context, r io.Reader, width, height int, name string) error {
+	reader := bufio.NewReaderSize(r, width*height*3)
+	for {
+		data := l.buffer(width * height * 3)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("%s ended: %w", name, err)
+		}
+		l.mu.Lock()
+		l.publish(Frame{Data: data, Width: width, Height: height, Timestamp: time.Now()})
+		l.err = nil
+		l.mu.Unlock()
+	}
+}
+
+// buffer returns a free frame buffer of size bytes
+func (l *liveSource) buffer(size int) []byte {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for len(l.free) > 0 {
+		data := l.free[len(l.free)-1]
+		l.free = l.free[:len(l.free)-1]
+		if len(data) == size {
+			return data
+		}
+	}
+	return make([]byte, size)
+}
+
+// publish replaces the newest frame, recycling the old one if NextFrame
+// never returned it; mu must be held
+func (l *liveSource) publish(frame Frame) {
+	if l.frame.Valid() && !l.given {
+		l.free = append(l.free, l.frame.Data)
+	}
+	l.frame, l.given = frame, false
+}
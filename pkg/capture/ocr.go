@@ -0,0 +1,66 @@
+package capture
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"os/exec"
+	"strings"
+	"unicode"
+)
+
+// Tesseract reads text from images with the tesseract command-line tool
+type Tesseract struct {
+	Binary string // tesseract executable; defaults to "tesseract" on PATH
+}
+
+// ReadLine recognizes a single line of text, as on a nameplate
+func (t Tesseract) ReadLine(ctx context.Context, img image.Image) (string, error) {
+	binary := t.Binary
+	if binary == "" {
+		binary = "tesseract"
+	}
+
+	var input bytes.Buffer
+	if err := png.Encode(&input, img); err != nil {
+		return "", err
+	}
+	// --psm 7 treats the image as one text line
+	cmd := exec.CommandContext(ctx, binary, "stdin", "stdout", "--psm", "7")
+	cmd.Stdin = &input
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("tesseract: %w", err)
+	}
+	return cleanLine(string(out)), nil
+}
+
+// cleanLine trims OCR output to its printable characters
+func cleanLine(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsPrint(r) {
+			return r
+		}
+		return -1
+	}, s)
+	return strings.TrimSpace(s)
+}
+
+// Crop copies a region of frame into a grayscale image, enlarging it by
+// scale so small text is easier to recognize. The copy stays valid after
+// the frame's buffer is reused.
+func Crop(frame Frame, region image.Rectangle, scale int) *image.Gray {
+	region = region.Intersect(image.Rect(0, 0, frame.Width, frame.Height))
+	scale = max(scale, 1)
+	img := image.NewGray(image.Rect(0, 0, region.Dx()*scale, region.Dy()*scale))
+	for y := 0; y < img.Rect.Dy(); y++ {
+		for x := 0; x < img.Rect.Dx(); x++ {
+			i := ((region.Min.Y+y/scale)*frame.Width + region.Min.X + x/scale) * 3
+			p := frame.Data[i : i+3]
+			img.Pix[y*img.Stride+x] = byte((int(p[0]) + 2*int(p[1]) + int(p[2])) / 4)
+		}
+	}
+	return img
+}
@@ -0,0 +1,195 @@
+//go:build linux
+
+package capture
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// The xdg-desktop-portal ScreenCast interface
+const (
+	portalService  = "org.freedesktop.portal.Desktop"
+	portalPath     = "/org/freedesktop/portal/desktop"
+	portalCast     = "org.freedesktop.portal.ScreenCast"
+	portalRequest  = "org.freedesktop.portal.Request"
+	portalMonitor  = 1
+	portalWindow   = 2
+	portalPickWait = 2 * time.Minute // The user picks what to share in a dialog
+)
+
+// PipeWireSource captures a Wayland desktop through the ScreenCast portal.
+// The compositor asks once which window or monitor to share, then streams
+// it over PipeWire; gst-launch-1.0's pipewiresrc converts the stream to BGR.
+type PipeWireSource struct {
+	liveSource
+	config LinuxConfig
+
+	sessionMu sync.Mutex // Guards the portal session
+	bus       *dbus.Conn
+	session   dbus.ObjectPath
+	node      uint32
+	width     int
+	height    int
+	token     int
+}
+
+// NewPipeWireSource connects to the session bus; sharing is requested on
+// the first frame
+func NewPipeWireSource(config LinuxConfig) (*PipeWireSource, error) {
+	bus, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("pipewire: session bus: %w", err)
+	}
+	s := &PipeWireSource{config: config, bus: bus}
+	s.liveSource.reconnect, s.liveSource.decode = 2*time.Second, s.decode
+	return s, nil
+}
+
+// Close stops the stream and ends the portal session
+func (s *PipeWireSource) Close() error {
+	s.liveSource.Close()
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+	return s.bus.Close()
+}
+
+// decode runs one GStreamer session on the shared stream
+func (s *PipeWireSource) decode(ctx context.Context) error {
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+	if s.session == "" {
+		if err := s.start(ctx); err != nil {
+			return err
+		}
+	}
+
+	var fd dbus.UnixFD
+	portal := s.bus.Object(portalService, portalPath)
+	if err := portal.CallWithContext(ctx, portalCast+".OpenPipeWireRemote", 0, s.session, map[string]dbus.Variant{}).Store(&fd); err != nil {
+		// The session ended, e.g. sharing was stopped from the desktop
+		s.session = ""
+		return fmt.Errorf("pipewire: open remote: %w", err)
+	}
+	remote := os.NewFile(uintptr(fd), "pipewire")
+	defer remote.Close()
+
+	width, height := scaledSize(s.width, s.height, s.config.MaxSize)
+	gst := exec.CommandContext(ctx, s.config.GStreamer, "-q",
+		"pipewiresrc", "fd=3", "path="+strconv.Itoa(int(s.node)), "always-copy=true", "!",
+		"videoconvert", "!", "videoscale", "!",
+		fmt.Sprintf("video/x-raw,format=BGR,width=%d,height=%d", width, height), "!",
+		"fdsink", "fd=1", "sync=false")
+	gst.ExtraFiles = []*os.File{remote}
+	stdout, err := gst.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := gst.Start(); err != nil {
+		return fmt.Errorf("start gstreamer: %w", err)
+	}
+	defer func() {
+		gst.Process.Kill()
+		gst.Wait()
+	}()
+	return s.readFrames(ctx, stdout, width, height, "pipewire stream")
+}
+
+// start creates a portal session and asks the user what to share; sessionMu must be held
+func (s *PipeWireSource) start(ctx context.Context) error {
+	results, err := s.request(ctx, "CreateSession", map[string]dbus.Variant{
+		"session_handle_token": dbus.MakeVariant("vrchat_proximity"),
+	})
+	if err != nil {
+		return err
+	}
+	// Older portals send the handle as a string, newer ones as an object path
+	var session dbus.ObjectPath
+	switch handle := results["session_handle"].Value().(type) {
+	case string:
+		session = dbus.ObjectPath(handle)
+	case dbus.ObjectPath:
+		session = handle
+	default:
+		return errors.New("pipewire: CreateSession returned no session")
+	}
+
+	if _, err := s.request(ctx, "SelectSources", map[string]dbus.Variant{
+		"types":    dbus.MakeVariant(uint32(portalMonitor | portalWindow)),
+		"multiple": dbus.MakeVariant(false),
+	}, session); err != nil {
+		return err
+	}
+	results, err = s.request(ctx, "Start", map[string]dbus.Variant{}, session, "")
+	if err != nil {
+		return err
+	}
+
+	var streams []struct {
+		Node       uint32
+		Properties map[string]dbus.Variant
+	}
+	if err := results["streams"].Store(&streams); err != nil || len(streams) == 0 {
+		return errors.New("pipewire: the portal returned no stream")
+	}
+	var size struct{ Width, Height int32 }
+	if err := streams[0].Properties["size"].Store(&size); err != nil || size.Width <= 0 || size.Height <= 0 {
+		return errors.New("pipewire: the portal did not report the stream size")
+	}
+	s.session, s.node, s.width, s.height = session, streams[0].Node, int(size.Width), int(size.Height)
+	return nil
+}
+
+// request calls a portal method whose result arrives as a Response signal
+// on a request object. options is the method's trailing vardict.
+func (s *PipeWireSource) request(ctx context.Context, method string, options map[string]dbus.Variant, args ...interface{}) (map[string]dbus.Variant, error) {
+	s.token++
+	token := "vrchat_proximity_" + strconv.Itoa(s.token)
+	options["handle_token"] = dbus.MakeVariant(token)
+	sender := strings.ReplaceAll(strings.TrimPrefix(s.bus.Names()[0], ":"), ".", "_")
+	path := dbus.ObjectPath(portalPath + "/request/" + sender + "/" + token)
+
+	// Subscribe before calling so the response can't be missed
+	if err := s.bus.AddMatchSignal(dbus.WithMatchObjectPath(path), dbus.WithMatchInterface(portalRequest), dbus.WithMatchMember("Response")); err != nil {
+		return nil, fmt.Errorf("pipewire: %s: %w", method, err)
+	}
+	defer s.bus.RemoveMatchSignal(dbus.WithMatchObjectPath(path), dbus.WithMatchInterface(portalRequest), dbus.WithMatchMember("Response"))
+	signals := make(chan *dbus.Signal, 4)
+	s.bus.Signal(signals)
+	defer s.bus.RemoveSignal(signals)
+
+	portal := s.bus.Object(portalService, portalPath)
+	if err := portal.CallWithContext(ctx, portalCast+"."+method, 0, append(args, options)...).Err; err != nil {
+		return nil, fmt.Errorf("pipewire: %s: %w", method, err)
+	}
+
+	timeout := time.NewTimer(portalPickWait)
+	defer timeout.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timeout.C:
+			return nil, fmt.Errorf("pipewire: %s: no response from the portal", method)
+		case signal := <-signals:
+			if signal.Path != path || len(signal.Body) < 2 {
+				continue
+			}
+			response, _ := signal.Body[0].(uint32)
+			results, _ := signal.Body[1].(map[string]dbus.Variant)
+			if response != 0 {
+				return nil, fmt.Errorf("pipewire: %s: sharing was cancelled", method)
+			}
+			return results, nil
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package capture
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// questServerPath is where the scrcpy server is pushed on the headset
+const questServerPath = "/data/local/tmp/scrcpy-server.jar"
+
+// questConnectWait bounds how long the scrcpy server may take to start
+const questConnectWait = 10 * time.Second
+
+// QuestConfig configures the quest backend, which mirrors a standalone
+// Quest's display over ADB with the scrcpy server
+type QuestConfig struct {
+	ADB       string        `yaml:"adb"`       // adb binary
+	Serial    string        `yaml:"serial"`    // Device serial, or ip:port for wireless ADB; empty uses the only device
+	Server    string        `yaml:"server"`    // scrcpy-server file from a scrcpy release
+	Version   string        `yaml:"version"`   // scrcpy version of that file; the server refuses any other
+	MaxSize   int           `yaml:"max_size"`  // Longest side the headset encodes; 0 keeps the panel size
+	BitRate   int           `yaml:"bit_rate"`  // Encoder bit rate in bits per second
+	Port      int           `yaml:"port"`      // Local port forwarded to the headset
+	FFmpeg    string        `yaml:"ffmpeg"`    // ffmpeg binary decoding the H.264 stream
+	Reconnect time.Duration `yaml:"reconnect"` // Wait this long before reconnecting after the stream drops
+}
+
+// DefaultQuestConfig mirrors the only connected headset at 1024 pixels
+func DefaultQuestConfig() QuestConfig {
+	return QuestConfig{
+		ADB:       "adb",
+		Server:    "scrcpy-server",
+		Version:   "2.4",
+		MaxSize:   1024,
+		BitRate:   8_000_000,
+		Port:      27183,
+		FFmpeg:    "ffmpeg",
+		Reconnect: 2 * time.Second,
+	}
+}
+
+// Validate checks the headset stream settings
+func (c QuestConfig) Validate() error {
+	if c.Server == "" || c.Version == "" {
+		return errors.New("server and version must be set")
+	}
+	if c.MaxSize < 0 || c.BitRate <= 0 || c.Reconnect < 0 {
+		return errors.New("max_size and reconnect must not be negative and bit_rate must be positive")
+	}
+	if c.Port < 1 || c.Port > 65535 {
+		return errors.New("port must be between 1 and 65535")
+	}
+	return nil
+}
+
+// WirelessHost returns the headset's address when it's connected over
+// wireless ADB, so OSC can be sent back to it
+func (c QuestConfig) WirelessHost() string {
+	host, _, err := net.SplitHostPort(c.Serial)
+	if err != nil || net.ParseIP(host) == nil {
+		return ""
+	}
+	return host
+}
+
+// QuestSource mirrors a Quest's display: it pushes the scrcpy server over
+// ADB, forwards its socket, and decodes the raw H.264 stream with ffmpeg.
+// Frames show both eyes side by side, as the headset renders them.
+type QuestSource struct {
+	liveSource
+	config QuestConfig
+}
+
+// NewQuestSource creates a source for the configured headset; it connects
+// on the first frame
+func NewQuestSource(config QuestConfig) *QuestSource {
+	q := &QuestSource{config: config}
+	q.liveSource.reconnect, q.liveSource.decode = config.Reconnect, q.decode
+	return q
+}
+
+// decode runs one scrcpy session
+func (q *QuestSource) decode(ctx context.Context) error {
+	if q.config.WirelessHost() != "" {
+		// Harmless when already connected
+		exec.CommandContext(ctx, q.config.ADB, "connect", q.config.Serial).Run()
+	}
+	if out, err := q.adb(ctx, "push", q.config.Server, questServerPath).CombinedOutput(); err != nil {
+		return fmt.Errorf("quest: push scrcpy server: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	// The scid names the server's socket so sessions don't collide
+	scid := fmt.Sprintf("%08x", rand.Int31())
+	local := "tcp:" + strconv.Itoa(q.config.Port)
+	if out, err := q.adb(ctx, "forward", local, "localabstract:scrcpy_"+scid).CombinedOutput(); err != nil {
+		return fmt.Errorf("quest: forward port: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	defer q.adb(context.Background(), "forward", "--remove", local).Run()
+
+	server := q.adb(ctx, "shell", "CLASSPATH="+questServerPath, "app_process", "/", "com.genymobile.scrcpy.Server", q.config.Version,
+		"scid="+scid, "log_level=warn", "tunnel_forward=true", "audio=false", "control=false", "cleanup=false",
+		"video_codec=h264", "max_size="+strconv.Itoa(q.config.MaxSize), "video_bit_rate="+strconv.Itoa(q.config.BitRate),
+		"send_device_meta=false", "send_frame_meta=false")
+	if err := server.Start(); err != nil {
+		return fmt.Errorf("quest: start scrcpy server: %w", err)
+	}
+	defer func() {
+		server.Process.Kill()
+		server.Wait()
+	}()
+
+	conn, err := q.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Codec metadata: the codec's four characters, then width and height
+	var meta [12]byte
+	if _, err := io.ReadFull(conn, meta[:]); err != nil {
+		return fmt.Errorf("quest: read stream header: %w", err)
+	}
+	width, height := int(binary.BigEndian.Uint32(meta[4:8])), int(binary.BigEndian.Uint32(meta[8:12]))
+	if string(meta[:4]) != "h264" || width <= 0 || height <= 0 {
+		return fmt.Errorf("quest: unexpected stream header %q", meta[:])
+	}
+
+	decoder := exec.CommandContext(ctx, q.config.FFmpeg, "-v", "error", "-fflags", "nobuffer", "-flags", "low_delay",
+		"-f", "h264", "-i", "-", "-f", "rawvideo", "-pix_fmt", "bgr24", "-")
+	decoder.Stdin = conn
+	stdout, err := decoder.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := decoder.Start(); err != nil {
+		return fmt.Errorf("start ffmpeg: %w", err)
+	}
+	defer func() {
+		decoder.Process.Kill()
+		decoder.Wait()
+	}()
+	return q.readFrames(ctx, stdout, width, height, "quest stream")
+}
+
+// connect dials the forwarded socket until the server accepts. Through the
+// forward a dial always succeeds, so readiness is the server's dummy byte.
+func (q *QuestSource) connect(ctx context.Context) (net.Conn, error) {
+	deadline := time.Now().Add(questConnectWait)
+	for {
+		conn, err := net.Dial("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(q.config.Port)))
+		if err == nil {
+			var dummy [1]byte
+			if _, err = conn.Read(dummy[:]); err == nil {
+				return conn, nil
+			}
+			conn.Close()
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("quest: scrcpy server did not start: %w", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// adb builds an adb command for the configured device
+func (q *QuestSource) adb(ctx context.Context, args ...string) *exec.Cmd {
+	if q.config.Serial != "" {
+		args = append([]string{"-s", q.config.Serial}, args...)
+	}
+	return exec.CommandContext(ctx, q.config.ADB, args...)
+}
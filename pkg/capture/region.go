@@ -0,0 +1,106 @@
+package capture
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Region is part of a frame in fractions of its size; the zero Region is
+// the whole frame
+type Region struct {
+	X      float64 `yaml:"x"`
+	Y      float64 `yaml:"y"`
+	Width  float64 `yaml:"width"`
+	Height float64 `yaml:"height"`
+}
+
+// Validate checks the region lies within the frame
+func (r Region) Validate() error {
+	if r == (Region{}) {
+		return nil
+	}
+	if r.X < 0 || r.Y < 0 || r.Width <= 0 || r.Height <= 0 || r.X+r.Width > 1 || r.Y+r.Height > 1 {
+		return errors.New("region must lie within the frame, as fractions from 0 to 1")
+	}
+	return nil
+}
+
+// bounds returns the region in pixels of a width x height frame
+func (r Region) bounds(width, height int) (x, y, w, h int) {
+	if r == (Region{}) {
+		return 0, 0, width, height
+	}
+	x, y = int(r.X*float64(width)), int(r.Y*float64(height))
+	w = max(1, min(int(r.Width*float64(width)), width-x))
+	h = max(1, min(int(r.Height*float64(height)), height-y))
+	return x, y, w, h
+}
+
+// SharedSource lets several region sources crop the same captured frames,
+// so one capture feeds e.g. both eyes of a VR mirror window. A frame is
+// captured when a region has already seen the latest one.
+type SharedSource struct {
+	source FrameSource
+
+	mu    sync.Mutex
+	frame Frame
+	seq   uint64
+}
+
+// NewSharedSource shares source between regions
+func NewSharedSource(source FrameSource) *SharedSource {
+	return &SharedSource{source: source}
+}
+
+// Region returns a source of one part of the shared frames
+func (s *SharedSource) Region(region Region) *RegionSource {
+	return &RegionSource{shared: s, region: region}
+}
+
+// RegionSource crops each shared frame into its own buffers. Like
+// Downscaler it keeps RingSlots of them, so a frame stays valid while the
+// next RingSlots-1 are cropped.
+type RegionSource struct {
+	shared *SharedSource
+	region Region
+
+	seen    uint64
+	buffers [RingSlots][]byte
+	next    int
+}
+
+// NextFrame returns the region of the newest shared frame, capturing a new
+// one if this region has seen it already
+func (r *RegionSource) NextFrame(ctx context.Context) (Frame, error) {
+	s := r.shared
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.seq == 0 || s.seq == r.seen {
+		frame, err := s.source.NextFrame(ctx)
+		if err != nil {
+			return Frame{}, err
+		}
+		s.frame = frame
+		s.seq++
+	}
+	r.seen = s.seq
+
+	frame := s.frame
+	if !frame.Valid() {
+		return frame, nil
+	}
+	x, y, w, h := r.region.bounds(frame.Width, frame.Height)
+	buf := r.buffers[r.next]
+	if cap(buf) < w*h*3 {
+		buf = make([]byte, w*h*3)
+	}
+	buf = buf[:w*h*3]
+	r.buffers[r.next] = buf
+	r.next = (r.next + 1) % RingSlots
+	for row := 0; row < h; row++ {
+		src := ((y+row)*frame.Width + x) * 3
+		copy(buf[row*w*3:(row+1)*w*3], frame.Data[src:src+w*3])
+	}
+	return Frame{Data: buf, Width: w, Height: h, Timestamp: frame.Timestamp}, nil
+}
@@ -0,0 +1,125 @@
+package capture
+
+import "sync"
+
+// Downscaler box-filters frames down to fit a processing resolution. It
+// keeps RingSlots output buffers so a scaled frame stays valid while the
+// next RingSlots-1 frames are scaled.
+type Downscaler struct {
+	width, height int
+
+	mu      sync.Mutex
+	buffers [RingSlots][]byte
+	next    int
+	columns []int // Output column of each source column
+	rows    []int // Output row of each source row
+	sums    []int // Per-channel totals for the output row being built
+	counts  []int
+}
+
+// NewDownscaler creates a scaler fitting frames within width x height; a
+// zero side is unconstrained
+func NewDownscaler(width, height int) *Downscaler {
+	return &Downscaler{width: width, height: height}
+}
+
+// Size returns the scaled size of a width x height frame, keeping the aspect
+// ratio and never enlarging
+func (d *Downscaler) Size(width, height int) (int, int) {
+	scale := 1.0
+	if d.width > 0 && width > d.width {
+		scale = float64(d.width) / float64(width)
+	}
+	if d.height > 0 && height > d.height {
+		scale = min(scale, float64(d.height)/float64(height))
+	}
+	if scale == 1 {
+		return width, height
+	}
+	return max(int(float64(width)*scale), 1), max(int(float64(height)*scale), 1)
+}
+
+// Scale returns frame reduced to fit, or frame itself when it already fits.
+// Each output pixel averages the source pixels it covers, sampled on a grid.
+func (d *Downscaler) Scale(frame Frame) Frame {
+	if !frame.Valid() {
+		return frame
+	}
+	width, height := d.Size(frame.Width, frame.Height)
+	if width == frame.Width && height == frame.Height {
+		return frame
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.columns = owners(d.columns, frame.Width, width)
+	d.rows = owners(d.rows, frame.Height, height)
+	if len(d.sums) != width*3 {
+		d.sums = make([]int, width*3)
+		d.counts = make([]int, width)
+	}
+
+	size := width * height * 3
+	out := d.buffers[d.next]
+	if len(out) != size {
+		out = make([]byte, size)
+		d.buffers[d.next] = out
+	}
+	d.next = (d.next + 1) % RingSlots
+
+	// Sum each run of source rows into per-column totals, then average them
+	// into one output row. Large reductions sample about 2x2 pixels per
+	// output pixel rather than every one, which is plenty to suppress
+	// aliasing and keeps the cost independent of the capture size.
+	stepX, stepY := max(frame.Width/width/2, 1), max(frame.Height/height/2, 1)
+	stride := frame.Width * 3
+	for sy := 0; sy < frame.Height; sy += stepY {
+		row := frame.Data[sy*stride : sy*stride+stride]
+		for sx := 0; sx < frame.Width; sx += stepX {
+			x, i := d.columns[sx], sx*3
+			d.sums[x*3] += int(row[i])
+			d.sums[x*3+1] += int(row[i+1])
+			d.sums[x*3+2] += int(row[i+2])
+			d.counts[x]++
+		}
+		if next := sy + stepY; next < frame.Height && d.rows[next] == d.rows[sy] {
+			continue
+		}
+		o := out[d.rows[sy]*width*3:]
+		for x, n := range d.counts {
+			o[x*3] = byte(d.sums[x*3] / n)
+			o[x*3+1] = byte(d.sums[x*3+1] / n)
+			o[x*3+2] = byte(d.sums[x*3+2] / n)
+			d.sums[x*3], d.sums[x*3+1], d.sums[x*3+2], d.counts[x] = 0, 0, 0, 0
+		}
+	}
+	return Frame{Data: out, Width: width, Height: height, Timestamp: frame.Timestamp}
+}
+
+// owners maps each of n source pixels to the output pixel covering it, reusing buf
+func owners(buf []int, n, size int) []int {
+	buf = buf[:0]
+	for i := 0; i < n; i++ {
+		buf = append(buf, i*size/n)
+	}
+	return buf
+}
+
+// ScaleDetections maps boxes found on a scaled frame back onto the original
+func ScaleDetections(detections []RawDetection, scaled, original Frame) []RawDetection {
+	if scaled.Width == original.Width && scaled.Height == original.Height {
+		return detections
+	}
+	sx := float64(original.Width) / float64(scaled.Width)
+	sy := float64(original.Height) / float64(scaled.Height)
+	for i := range detections {
+		d := &detections[i]
+		d.X = int32(float64(d.X) * sx)
+		d.Y = int32(float64(d.Y) * sy)
+		d.Width = int32(float64(d.Width) * sx)
+		d.Height = int32(float64(d.Height) * sy)
+		d.Area = float32(float64(d.Area) * sx * sy)
+	}
+	return detections
+}
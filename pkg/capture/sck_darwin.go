@@ -0,0 +1,254 @@
+//go:build darwin
+
+package capture
+
+// #cgo CFLAGS: -x objective-c -fobjc-arc
+// #cgo LDFLAGS: -framework ScreenCaptureKit -framework CoreMedia -framework CoreVideo -framework Foundation
+// #include <stdbool.h>
+// #include <stdint.h>
+// #include <stdlib.h>
+// #include <string.h>
+// #include <pthread.h>
+// #import <ScreenCaptureKit/ScreenCaptureKit.h>
+// #import <CoreMedia/CoreMedia.h>
+// #import <CoreVideo/CoreVideo.h>
+//
+// // The newest frame, copied out of the stream's pixel buffer
+// static pthread_mutex_t sck_mutex = PTHREAD_MUTEX_INITIALIZER;
+// static uint8_t *sck_data;
+// static size_t sck_capacity;
+// static int sck_width, sck_height, sck_stride;
+// static uint64_t sck_seq;
+// static bool sck_stopped;
+//
+// API_AVAILABLE(macos(12.3))
+// @interface SCKOutput : NSObject <SCStreamOutput, SCStreamDelegate>
+// @end
+//
+// @implementation SCKOutput
+// - (void)stream:(SCStream *)stream didOutputSampleBuffer:(CMSampleBufferRef)sampleBuffer ofType:(SCStreamOutputType)type {
+//     // Idle and blank status frames carry no pixels
+//     CVImageBufferRef pixels = CMSampleBufferGetImageBuffer(sampleBuffer);
+//     if (type != SCStreamOutputTypeScreen || pixels == NULL) return;
+//     CVPixelBufferLockBaseAddress(pixels, kCVPixelBufferLock_ReadOnly);
+//     size_t stride = CVPixelBufferGetBytesPerRow(pixels), height = CVPixelBufferGetHeight(pixels);
+//     pthread_mutex_lock(&sck_mutex);
+//     if (sck_capacity < stride * height) {
+//         free(sck_data);
+//         sck_data = malloc(stride * height);
+//         sck_capacity = sck_data ? stride * height : 0;
+//     }
+//     if (sck_data) {
+//         memcpy(sck_data, CVPixelBufferGetBaseAddress(pixels), stride * height);
+//         sck_width = (int)CVPixelBufferGetWidth(pixels);
+//         sck_height = (int)height;
+//         sck_stride = (int)stride;
+//         sck_seq++;
+//     }
+//     pthread_mutex_unlock(&sck_mutex);
+//     CVPixelBufferUnlockBaseAddress(pixels, kCVPixelBufferLock_ReadOnly);
+// }
+//
+// - (void)stream:(SCStream *)stream didStopWithError:(NSError *)error {
+//     pthread_mutex_lock(&sck_mutex);
+//     sck_stopped = true;
+//     pthread_mutex_unlock(&sck_mutex);
+// }
+// @end
+//
+// static id sck_stream;
+// static id sck_output;
+//
+// // sck_open starts capturing a window or display; it returns an error
+// // message for the caller to free, or NULL
+// API_AVAILABLE(macos(12.3))
+// static char *sck_open(const char *title, int display, int max_width) {
+//     dispatch_semaphore_t done = dispatch_semaphore_create(0);
+//     __block SCShareableContent *content = nil;
+//     __block NSError *failure = nil;
+//     [SCShareableContent getShareableContentExcludingDesktopWindows:YES onScreenWindowsOnly:YES
+//         completionHandler:^(SCShareableContent *c, NSError *e) { content = c; failure = e; dispatch_semaphore_signal(done); }];
+//     dispatch_semaphore_wait(done, DISPATCH_TIME_FOREVER);
+//     if (content == nil) {
+//         return strdup(failure ? failure.localizedDescription.UTF8String : "screen recording is not allowed for this app");
+//     }
+//
+//     SCContentFilter *filter = nil;
+//     CGFloat width = 0, height = 0;
+//     if (title[0] != 0) {
+//         NSString *want = [NSString stringWithUTF8String:title];
+//         for (SCWindow *window in content.windows) {
+//             if ([window.title isEqualToString:want]) {
+//                 filter = [[SCContentFilter alloc] initWithDesktopIndependentWindow:window];
+//                 width = window.frame.size.width;
+//                 height = window.frame.size.height;
+//                 break;
+//             }
+//         }
+//         if (filter == nil) return strdup("window not found");
+//     } else {
+//         if (display < 0 || display >= (int)content.displays.count) return strdup("display not found");
+//         SCDisplay *screen = content.displays[display];
+//         filter = [[SCContentFilter alloc] initWithDisplay:screen excludingWindows:@[]];
+//         width = screen.width;
+//         height = screen.height;
+//     }
+//     if (max_width > 0 && width > max_width) {
+//         height = height * max_width / width;
+//         width = max_width;
+//     }
+//
+//     SCStreamConfiguration *config = [[SCStreamConfiguration alloc] init];
+//     config.width = (size_t)width;
+//     config.height = (size_t)height;
+//     config.pixelFormat = kCVPixelFormatType_32BGRA;
+//     config.minimumFrameInterval = CMTimeMake(1, 60);
+//     config.queueDepth = 3;
+//     config.showsCursor = NO;
+//
+//     SCKOutput *output = [[SCKOutput alloc] init];
+//     SCStream *stream = [[SCStream alloc] initWithFilter:filter configuration:config delegate:output];
+//     NSError *error = nil;
+//     dispatch_queue_t queue = dispatch_queue_create("vrchat-proximity.capture", DISPATCH_QUEUE_SERIAL);
+//     if (![stream addStreamOutput:output type:SCStreamOutputTypeScreen sampleHandlerQueue:queue error:&error]) {
+//         return strdup(error.localizedDescription.UTF8String);
+//     }
+//     __block NSError *startError = nil;
+//     [stream startCaptureWithCompletionHandler:^(NSError *e) { startError = e; dispatch_semaphore_signal(done); }];
+//     dispatch_semaphore_wait(done, DISPATCH_TIME_FOREVER);
+//     if (startError != nil) return strdup(startError.localizedDescription.UTF8String);
+//
+//     pthread_mutex_lock(&sck_mutex);
+//     sck_stopped = false;
+//     pthread_mutex_unlock(&sck_mutex);
+//     sck_stream = stream;
+//     sck_output = output;
+//     return NULL;
+// }
+//
+// static char *sck_start(const char *title, int display, int max_width) {
+//     if (@available(macOS 12.3, *)) {
+//         return sck_open(title, display, max_width);
+//     }
+//     return strdup("ScreenCaptureKit needs macOS 12.3 or later");
+// }
+//
+// static void sck_stop(void) {
+//     if (@available(macOS 12.3, *)) {
+//         [(SCStream *)sck_stream stopCaptureWithCompletionHandler:nil];
+//     }
+//     sck_stream = nil;
+//     sck_output = nil;
+// }
+//
+// // sck_lock holds the newest frame still until sck_unlock
+// static void sck_lock(uint8_t **data, int *width, int *height, int *stride, uint64_t *seq, bool *stopped) {
+//     pthread_mutex_lock(&sck_mutex);
+//     *data = sck_data;
+//     *width = sck_width;
+//     *height = sck_height;
+//     *stride = sck_stride;
+//     *seq = sck_seq;
+//     *stopped = sck_stopped;
+// }
+//
+// static void sck_unlock(void) {
+//     pthread_mutex_unlock(&sck_mutex);
+// }
+import "C"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// sckActive allows one ScreenCaptureKit stream per process
+var sckActive sync.Mutex
+
+// ScreenCaptureKitSource captures a window or display on macOS 12.3+ with
+// ScreenCaptureKit, which scales frames on the GPU and delivers them as they
+// change. The app needs the Screen Recording permission.
+type ScreenCaptureKitSource struct {
+	mu     sync.Mutex
+	seq    uint64
+	closed bool
+
+	buffers [RingSlots][]byte
+	next    int
+	last    Frame
+}
+
+// NewScreenCaptureKitSource starts the stream; only one may run at a time
+func NewScreenCaptureKitSource(config MacOSConfig) (*ScreenCaptureKitSource, error) {
+	if !sckActive.TryLock() {
+		return nil, errors.New("screencapturekit: a source is already running")
+	}
+	title := C.CString(config.WindowTitle)
+	defer C.free(unsafe.Pointer(title))
+	if message := C.sck_start(title, C.int(config.Display), C.int(config.MaxWidth)); message != nil {
+		defer C.free(unsafe.Pointer(message))
+		sckActive.Unlock()
+		return nil, fmt.Errorf("screencapturekit: %s", C.GoString(message))
+	}
+	return &ScreenCaptureKitSource{}, nil
+}
+
+// NextFrame returns the newest frame, repeating the last one while the
+// window is unchanged
+func (s *ScreenCaptureKitSource) NextFrame(ctx context.Context) (Frame, error) {
+	if err := ctx.Err(); err != nil {
+		return Frame{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return Frame{}, errors.New("screencapturekit: source closed")
+	}
+
+	var data *C.uint8_t
+	var width, height, stride C.int
+	var seq C.uint64_t
+	var stopped C.bool
+	C.sck_lock(&data, &width, &height, &stride, &seq, &stopped)
+	defer C.sck_unlock()
+	switch {
+	case bool(stopped):
+		return Frame{}, errors.New("screencapturekit: the stream stopped, e.g. the window closed")
+	case data == nil:
+		return Frame{}, ErrNoFrame
+	case uint64(seq) == s.seq:
+		s.last.Timestamp = time.Now()
+		return s.last, nil
+	}
+	s.seq = uint64(seq)
+
+	w, h, pitch := int(width), int(height), int(stride)
+	size := w * h * 3
+	buf := s.buffers[s.next]
+	if len(buf) != size {
+		buf = make([]byte, size)
+		s.buffers[s.next] = buf
+	}
+	s.next = (s.next + 1) % RingSlots
+	packBGRA(buf, unsafe.Slice((*byte)(unsafe.Pointer(data)), pitch*h), pitch, w, h, false)
+
+	s.last = Frame{Data: buf, Width: w, Height: h, Timestamp: time.Now()}
+	return s.last, nil
+}
+
+// Close stops the stream
+func (s *ScreenCaptureKitSource) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		C.sck_stop()
+		sckActive.Unlock()
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+//go:build !darwin
+
+package capture
+
+import (
+	"context"
+	"errors"
+)
+
+// ScreenCaptureKitSource captures with ScreenCaptureKit; macOS only
+type ScreenCaptureKitSource struct{}
+
+// NewScreenCaptureKitSource fails outside macOS
+func NewScreenCaptureKitSource(config MacOSConfig) (*ScreenCaptureKitSource, error) {
+	return nil, errors.New("ScreenCaptureKit capture is only supported on macOS")
+}
+
+// NextFrame always fails outside macOS
+func (s *ScreenCaptureKitSource) NextFrame(ctx context.Context) (Frame, error) {
+	return Frame{}, ErrNoFrame
+}
+
+// Close does nothing outside macOS
+func (s *ScreenCaptureKitSource) Close() error {
+	return nil
+}
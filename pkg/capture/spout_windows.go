@@ -0,0 +1,267 @@
+//go:build windows
+
+package capture
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var (
+	kernel32             = syscall.NewLazyDLL("kernel32.dll")
+	procOpenFileMappingA = kernel32.NewProc("OpenFileMappingA")
+	procOpenMutexA       = kernel32.NewProc("OpenMutexA")
+	procReleaseMutex     = kernel32.NewProc("ReleaseMutex")
+)
+
+// Spout shared memory and Win32 constants
+const (
+	spoutActiveSender = "ActiveSenderName"
+	spoutNameSize     = 256
+	spoutAccessMutex  = "_SpoutAccessMutex"
+	spoutLockWait     = 10 // Milliseconds to wait for the sender to finish drawing
+
+	fileMapRead      = 0x0004
+	mutexSynchronize = 0x00100000
+	waitObject0      = 0
+	waitAbandoned    = 0x80
+)
+
+// spoutTextureInfo is Spout's SharedTextureInfo, the shared memory
+// published under each sender's name
+type spoutTextureInfo struct {
+	ShareHandle   uint32
+	Width, Height uint32
+	Format        uint32
+	Usage         uint32
+	Description   [128]uint16
+	PartnerID     uint32
+}
+
+// SpoutSource reads a Spout sender's shared D3D11 texture, e.g. VRChat's
+// stream camera through a Spout plugin or OBS's Spout output. Each frame is
+// copied on the GPU into a staging texture and packed to BGR.
+type SpoutSource struct {
+	config SpoutConfig
+
+	mu      sync.Mutex
+	device  *comObject
+	context *comObject
+	shared  *comObject // The sender's texture, opened from its share handle
+	staging *comObject
+	handle  uint32
+	mutex   syscall.Handle
+	format  uint32
+	toneMap *toneMap // Set for 16-bit float senders
+	width   int
+	height  int
+
+	buffers [RingSlots][]byte
+	next    int
+	last    Frame
+}
+
+// NewSpoutSource creates the D3D11 device the sender's texture is opened on
+func NewSpoutSource(config SpoutConfig) (*SpoutSource, error) {
+	if err := d3d11.Load(); err != nil {
+		return nil, fmt.Errorf("spout: %w", err)
+	}
+	s := &SpoutSource{config: config}
+	hr, _, _ := procD3D11CreateDevice.Call(0, d3dDriverTypeHardware, 0, d3d11CreateDeviceBGRASupport, 0, 0,
+		d3d11SDKVersion, uintptr(unsafe.Pointer(&s.device)), 0, uintptr(unsafe.Pointer(&s.context)))
+	if failed(uint32(hr)) {
+		return nil, fmt.Errorf("spout: D3D11CreateDevice failed: 0x%08X", uint32(hr))
+	}
+	return s, nil
+}
+
+// NextFrame copies the sender's current texture, or returns ErrNoFrame
+// while no sender is running
+func (s *SpoutSource) NextFrame(ctx context.Context) (Frame, error) {
+	if err := ctx.Err(); err != nil {
+		return Frame{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.device == nil {
+		return Frame{}, errors.New("spout: source closed")
+	}
+	sender := s.config.Sender
+	if sender == "" {
+		sender = readSharedString(spoutActiveSender)
+	}
+	info, ok := readSpoutInfo(sender)
+	if !ok || info.ShareHandle == 0 {
+		s.closeTexture()
+		return Frame{}, ErrNoFrame
+	}
+	// A resized or restarted sender shares a new texture
+	if info.ShareHandle != s.handle {
+		s.closeTexture()
+		if err := s.openTexture(sender, info.ShareHandle); err != nil {
+			s.closeTexture()
+			return Frame{}, err
+		}
+	}
+
+	if s.mutex != 0 {
+		switch wait, _ := syscall.WaitForSingleObject(s.mutex, spoutLockWait); wait {
+		case waitObject0, waitAbandoned:
+			defer procReleaseMutex.Call(uintptr(s.mutex))
+		default:
+			return s.repeat()
+		}
+	}
+	s.context.call(methodCopyResource, uintptr(unsafe.Pointer(s.staging)), uintptr(unsafe.Pointer(s.shared)))
+	return s.readStaging()
+}
+
+// Close releases the texture and device
+func (s *SpoutSource) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closeTexture()
+	for _, o := range []**comObject{&s.context, &s.device} {
+		(*o).release()
+		*o = nil
+	}
+	return nil
+}
+
+// openTexture opens the sender's shared texture and a staging copy of it;
+// mu must be held
+func (s *SpoutSource) openTexture(sender string, handle uint32) error {
+	if hr := s.device.call(methodOpenSharedResource, uintptr(handle), uintptr(unsafe.Pointer(&iidID3D11Texture2D)), uintptr(unsafe.Pointer(&s.shared))); failed(hr) {
+		return fmt.Errorf("spout: opening the texture of %q failed: 0x%08X", sender, hr)
+	}
+	var desc texture2DDesc
+	s.shared.call(methodGetTextureDesc, uintptr(unsafe.Pointer(&desc)))
+	s.format, s.toneMap = desc.Format, nil
+	switch desc.Format {
+	case dxgiFormatB8G8R8A8, dxgiFormatB8G8R8X8, dxgiFormatB8G8R8A8SRGB, dxgiFormatR8G8B8A8, dxgiFormatR8G8B8A8SRGB, dxgiFormatR10G10B10A2:
+	case dxgiFormatR16G16B16A16Float:
+		// Linear scRGB, with 1.0 as white
+		s.toneMap = newToneMap(scRGBWhite, 0)
+	default:
+		return fmt.Errorf("spout: %q shares unsupported texture format %d", sender, desc.Format)
+	}
+
+	stagingDesc := texture2DDesc{
+		Width: desc.Width, Height: desc.Height, MipLevels: 1, ArraySize: 1,
+		Format: desc.Format, SampleCount: 1,
+		Usage: d3d11UsageStaging, CPUAccessFlags: d3d11CPUAccessRead,
+	}
+	if hr := s.device.call(methodCreateTexture2D, uintptr(unsafe.Pointer(&stagingDesc)), 0, uintptr(unsafe.Pointer(&s.staging))); failed(hr) {
+		return fmt.Errorf("spout: CreateTexture2D failed: 0x%08X", hr)
+	}
+	s.handle, s.width, s.height = handle, int(desc.Width), int(desc.Height)
+
+	// Older senders have no access mutex and are read unlocked
+	if name, err := syscall.BytePtrFromString(sender + spoutAccessMutex); err == nil {
+		mutex, _, _ := procOpenMutexA.Call(mutexSynchronize, 0, uintptr(unsafe.Pointer(name)))
+		s.mutex = syscall.Handle(mutex)
+	}
+	return nil
+}
+
+// closeTexture releases the sender's texture; mu must be held
+func (s *SpoutSource) closeTexture() {
+	for _, o := range []**comObject{&s.staging, &s.shared} {
+		(*o).release()
+		*o = nil
+	}
+	if s.mutex != 0 {
+		syscall.CloseHandle(s.mutex)
+		s.mutex = 0
+	}
+	s.handle = 0
+}
+
+// readStaging maps the staging texture and packs it into the next BGR buffer
+func (s *SpoutSource) readStaging() (Frame, error) {
+	var mapped mappedSubresource
+	if hr := s.context.call(methodMap, uintptr(unsafe.Pointer(s.staging)), 0, d3d11MapRead, 0, uintptr(unsafe.Pointer(&mapped))); failed(hr) {
+		return Frame{}, fmt.Errorf("spout: Map failed: 0x%08X", hr)
+	}
+	defer s.context.call(methodUnmap, uintptr(unsafe.Pointer(s.staging)), 0)
+
+	size := s.width * s.height * 3
+	data := s.buffers[s.next]
+	if len(data) != size {
+		data = make([]byte, size)
+		s.buffers[s.next] = data
+	}
+	s.next = (s.next + 1) % RingSlots
+
+	pitch := int(mapped.RowPitch)
+	src := unsafe.Slice((*byte)(mapped.Data), pitch*s.height)
+	switch s.format {
+	case dxgiFormatR16G16B16A16Float:
+		packFP16(data, src, pitch, s.width, s.height, s.toneMap)
+	case dxgiFormatR10G10B10A2:
+		packRGB10(data, src, pitch, s.width, s.height)
+	default:
+		packBGRA(data, src, pitch, s.width, s.height, s.format == dxgiFormatR8G8B8A8 || s.format == dxgiFormatR8G8B8A8SRGB)
+	}
+
+	s.last = Frame{Data: data, Width: s.width, Height: s.height, Timestamp: time.Now()}
+	return s.last, nil
+}
+
+// repeat returns the last frame with a fresh timestamp, or ErrNoFrame before the first
+func (s *SpoutSource) repeat() (Frame, error) {
+	if !s.last.Valid() {
+		return Frame{}, ErrNoFrame
+	}
+	frame := s.last
+	frame.Timestamp = time.Now()
+	return frame, nil
+}
+
+// mapShared maps the named shared memory read-only; the caller unmaps it
+func mapShared(name string) (uintptr, bool) {
+	p, err := syscall.BytePtrFromString(name)
+	if err != nil || name == "" {
+		return 0, false
+	}
+	mapping, _, _ := procOpenFileMappingA.Call(fileMapRead, 0, uintptr(unsafe.Pointer(p)))
+	if mapping == 0 {
+		return 0, false
+	}
+	defer syscall.CloseHandle(syscall.Handle(mapping))
+	view, err := syscall.MapViewOfFile(syscall.Handle(mapping), fileMapRead, 0, 0, 0)
+	return view, err == nil && view != 0
+}
+
+// readSpoutInfo reads the texture info a sender publishes
+func readSpoutInfo(sender string) (spoutTextureInfo, bool) {
+	view, ok := mapShared(sender)
+	if !ok {
+		return spoutTextureInfo{}, false
+	}
+	defer syscall.UnmapViewOfFile(view)
+	return *(*spoutTextureInfo)(foreignPointer(view)), true
+}
+
+// readSharedString reads a NUL-terminated name from shared memory
+func readSharedString(name string) string {
+	view, ok := mapShared(name)
+	if !ok {
+		return ""
+	}
+	defer syscall.UnmapViewOfFile(view)
+	raw := unsafe.Slice((*byte)(foreignPointer(view)), spoutNameSize)
+	for i, c := range raw {
+		if c == 0 {
+			return string(raw[:i])
+		}
+	}
+	return string(raw)
+}
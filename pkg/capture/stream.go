@@ -0,0 +1,37 @@
+package capture
+
+// NDIConfig configures the NDI backend
+type NDIConfig struct {
+	Source string `yaml:"source"` // Stream name, e.g. "GAMING-PC (OBS)"; a part of it is enough, empty takes the first found
+	Lowest bool   `yaml:"lowest"` // Receive the low-bandwidth preview stream instead of full quality
+}
+
+// DefaultNDIConfig receives the first stream found at full quality
+func DefaultNDIConfig() NDIConfig {
+	return NDIConfig{}
+}
+
+// SpoutConfig configures the Spout backend
+type SpoutConfig struct {
+	Sender string `yaml:"sender"` // Sender name; empty follows the active sender
+}
+
+// DefaultSpoutConfig follows the active sender
+func DefaultSpoutConfig() SpoutConfig {
+	return SpoutConfig{}
+}
+
+// packBGRA packs 32-bit rows, pitch bytes apart, into 24-bit BGR. rgba
+// swaps red and blue for RGBA sources.
+func packBGRA(dst, src []byte, pitch, width, height int, rgba bool) {
+	r, b := 2, 0
+	if rgba {
+		r, b = 0, 2
+	}
+	for y := 0; y < height; y++ {
+		row, out := src[y*pitch:], dst[y*width*3:]
+		for x := 0; x < width; x++ {
+			out[x*3], out[x*3+1], out[x*3+2] = row[x*4+b], row[x*4+1], row[x*4+r]
+		}
+	}
+}
@@ -0,0 +1,44 @@
+//go:build !windows
+
+package capture
+
+import (
+	"context"
+	"errors"
+)
+
+// NDISource receives an NDI video stream; Windows only
+type NDISource struct{}
+
+// NewNDISource fails outside Windows
+func NewNDISource(config NDIConfig) (*NDISource, error) {
+	return nil, errors.New("NDI capture is only supported on Windows")
+}
+
+// NextFrame always fails outside Windows
+func (s *NDISource) NextFrame(ctx context.Context) (Frame, error) {
+	return Frame{}, ErrNoFrame
+}
+
+// Close does nothing outside Windows
+func (s *NDISource) Close() error {
+	return nil
+}
+
+// SpoutSource reads a Spout sender's shared texture; Windows only
+type SpoutSource struct{}
+
+// NewSpoutSource fails outside Windows
+func NewSpoutSource(config SpoutConfig) (*SpoutSource, error) {
+	return nil, errors.New("Spout capture is only supported on Windows")
+}
+
+// NextFrame always fails outside Windows
+func (s *SpoutSource) NextFrame(ctx context.Context) (Frame, error) {
+	return Frame{}, ErrNoFrame
+}
+
+// Close does nothing outside Windows
+func (s *SpoutSource) Close() error {
+	return nil
+}
@@ -0,0 +1,77 @@
+package capture
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// SyntheticConfig configures generated benchmark frames
+type SyntheticConfig struct {
+	Width   int
+	Height  int
+	Objects int // Moving rectangles per frame
+	Frames  int // Frames before io.EOF (0 never ends)
+}
+
+// DefaultSyntheticConfig generates 720p frames with three moving objects
+func DefaultSyntheticConfig() SyntheticConfig {
+	return SyntheticConfig{Width: 1280, Height: 720, Objects: 3, Frames: 300}
+}
+
+// SyntheticSource generates frames with rectangles moving on a fixed
+// background, so every frame after the first has Objects regions of motion
+type SyntheticSource struct {
+	config SyntheticConfig
+
+	mu    sync.Mutex
+	frame int
+}
+
+// NewSyntheticSource creates a generator
+func NewSyntheticSource(config SyntheticConfig) *SyntheticSource {
+	return &SyntheticSource{config: config}
+}
+
+// NextFrame renders the next frame, or returns io.EOF after Frames frames
+func (s *SyntheticSource) NextFrame(ctx context.Context) (Frame, error) {
+	if err := ctx.Err(); err != nil {
+		return Frame{}, err
+	}
+
+	s.mu.Lock()
+	if s.config.Frames > 0 && s.frame >= s.config.Frames {
+		s.mu.Unlock()
+		return Frame{}, io.EOF
+	}
+	n := s.frame
+	s.frame++
+	s.mu.Unlock()
+
+	w, h := s.config.Width, s.config.Height
+	data := make([]byte, w*h*3)
+	for i := range data {
+		data[i] = 40
+	}
+
+	// Objects are spaced across the frame, grow taller with their index, and
+	// move horizontally by a few pixels each frame
+	for i := 0; i < s.config.Objects; i++ {
+		objW := w / 20
+		objH := h * (i + 2) / (s.config.Objects + 4)
+		lane := w / s.config.Objects
+		x0 := i*lane + (n*(4+i))%max(lane-objW, 1)
+		y0 := h - objH - h/20
+		for y := y0; y < y0+objH && y < h; y++ {
+			row := data[y*w*3:]
+			for x := x0; x < x0+objW && x < w; x++ {
+				row[x*3] = 220
+				row[x*3+1] = 200
+				row[x*3+2] = 180
+			}
+		}
+	}
+
+	return Frame{Data: data, Width: w, Height: h, Timestamp: time.Now()}, nil
+}
@@ -0,0 +1,185 @@
+package capture
+
+import (
+	"math"
+	"runtime"
+	"sync"
+)
+
+// TileDetector splits frames into a grid of overlapping tiles and runs the
+// Zig detector on them concurrently. Blobs cut by a seam come back as
+// overlapping pieces and are joined before returning.
+type TileDetector struct {
+	tiles   int
+	workers int
+	overlap int
+	buffers sync.Pool // *[]byte tile pixel buffers
+}
+
+// NewTileDetector creates a detector splitting frames into tiles cells using up to
+// workers goroutines; workers <= 0 uses every CPU. overlap is the margin in
+// pixels each tile extends into its neighbors.
+func NewTileDetector(tiles, workers, overlap int) *TileDetector {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	return &TileDetector{tiles: max(tiles, 1), workers: workers, overlap: max(overlap, 0)}
+}
+
+// tile is one grid cell in frame pixels, including its overlap margin
+type tile struct {
+	x, y, width, height int
+}
+
+// grid lays out the fewest tiles, at least t.tiles, covering a width x
+// height frame, preferring the most square tiles among equal counts
+func (t *TileDetector) grid(width, height int) []tile {
+	cols, rows := 1, t.tiles
+	best := math.Inf(1)
+	for c := 1; c <= t.tiles; c++ {
+		r := (t.tiles + c - 1) / c
+		// Tile count dominates; squareness breaks ties
+		score := float64(c*r)*100 + math.Abs(math.Log(float64(width*r)/float64(height*c)))
+		if score < best {
+			best, cols, rows = score, c, r
+		}
+	}
+
+	var tiles []tile
+	for r := 0; r < rows; r++ {
+		y0, y1 := r*height/rows, (r+1)*height/rows
+		for c := 0; c < cols; c++ {
+			x0, x1 := c*width/cols, (c+1)*width/cols
+			x0, y0m := max(x0-t.overlap, 0), max(y0-t.overlap, 0)
+			x1m, y1m := min(x1+t.overlap, width), min(y1+t.overlap, height)
+			tiles = append(tiles, tile{x0, y0m, x1m - x0, y1m - y0m})
+		}
+	}
+	return tiles
+}
+
+// Detect compares two frames of the same size tile by tile
+func (t *TileDetector) Detect(current, previous Frame, threshold uint8) []RawDetection {
+	if !current.Valid() || !previous.Valid() || current.Width != previous.Width || current.Height != previous.Height {
+		return nil
+	}
+	if t.tiles == 1 {
+		return DetectMotion(current, previous, threshold)
+	}
+
+	tiles := t.grid(current.Width, current.Height)
+	results := make([][]RawDetection, len(tiles))
+	jobs := make(chan int, len(tiles))
+	for i := range tiles {
+		jobs <- i
+	}
+	close(jobs)
+
+	var wg sync.WaitGroup
+	for w := 0; w < min(t.workers, len(tiles)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = t.detectTile(current, previous, tiles[i], threshold)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return joinSeams(results)
+}
+
+// detectTile copies one tile of both frames into pooled buffers and detects on it
+func (t *TileDetector) detectTile(current, previous Frame, region tile, threshold uint8) []RawDetection {
+	size := region.width * region.height * 3
+	cur, prev := t.buffer(size), t.buffer(size)
+	defer t.buffers.Put(cur)
+	defer t.buffers.Put(prev)
+
+	rowBytes := region.width * 3
+	for row := 0; row < region.height; row++ {
+		src := ((region.y+row)*current.Width + region.x) * 3
+		copy((*cur)[row*rowBytes:], current.Data[src:src+rowBytes])
+		copy((*prev)[row*rowBytes:], previous.Data[src:src+rowBytes])
+	}
+
+	detections := DetectMotion(
+		Frame{Data: (*cur)[:size], Width: region.width, Height: region.height},
+		Frame{Data: (*prev)[:size], Width: region.width, Height: region.height},
+		threshold)
+	for i := range detections {
+		detections[i].X += int32(region.x)
+		detections[i].Y += int32(region.y)
+	}
+	return detections
+}
+
+// buffer returns a pooled buffer of at least size bytes
+func (t *TileDetector) buffer(size int) *[]byte {
+	if b, ok := t.buffers.Get().(*[]byte); ok && cap(*b) >= size {
+		*b = (*b)[:size]
+		return b
+	}
+	b := make([]byte, size)
+	return &b
+}
+
+// joinSeams flattens per-tile results, unioning boxes from different tiles
+// that overlap, which is how a blob crossing a seam shows up
+func joinSeams(results [][]RawDetection) []RawDetection {
+	var detections []RawDetection
+	var tiles []map[int]bool // Tiles each detection was assembled from
+	for i, r := range results {
+		for _, d := range r {
+			detections = append(detections, d)
+			tiles = append(tiles, map[int]bool{i: true})
+		}
+	}
+
+	for joined := true; joined; {
+		joined = false
+		for i := 0; i < len(detections); i++ {
+			for j := i + 1; j < len(detections); j++ {
+				if !overlaps(detections[i], detections[j]) || sharesTile(tiles[i], tiles[j]) {
+					continue
+				}
+				detections[i] = union(detections[i], detections[j])
+				for t := range tiles[j] {
+					tiles[i][t] = true
+				}
+				detections = append(detections[:j], detections[j+1:]...)
+				tiles = append(tiles[:j], tiles[j+1:]...)
+				j--
+				joined = true
+			}
+		}
+	}
+	return detections
+}
+
+// sharesTile reports whether two pieces came from a common tile, in which
+// case the detector already kept them apart on purpose
+func sharesTile(a, b map[int]bool) bool {
+	for t := range a {
+		if b[t] {
+			return true
+		}
+	}
+	return false
+}
+
+// overlaps reports whether two boxes share any pixels
+func overlaps(a, b RawDetection) bool {
+	return a.X < b.X+b.Width && b.X < a.X+a.Width && a.Y < b.Y+b.Height && b.Y < a.Y+a.Height
+}
+
+// union covers both boxes, summing area and keeping the higher confidence
+func union(a, b RawDetection) RawDetection {
+	x0, y0 := min(a.X, b.X), min(a.Y, b.Y)
+	x1, y1 := max(a.X+a.Width, b.X+b.Width), max(a.Y+a.Height, b.Y+b.Height)
+	a.X, a.Y, a.Width, a.Height = x0, y0, x1-x0, y1-y0
+	a.Area += b.Area
+	a.Confidence = max(a.Confidence, b.Confidence)
+	return a
+}
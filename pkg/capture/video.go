@@ -0,0 +1,165 @@
+package capture
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// VideoConfig configures decoding of a recorded video file
+type VideoConfig struct {
+	FFmpeg  string // ffmpeg binary; ffprobe is expected next to it
+	Loop    bool   // Restart from the beginning at end of file
+	MaxSize int    // Downscale so neither side exceeds this (0 keeps the original size)
+}
+
+// DefaultVideoConfig uses ffmpeg from PATH and plays the file once
+func DefaultVideoConfig() VideoConfig {
+	return VideoConfig{FFmpeg: "ffmpeg"}
+}
+
+// VideoSource decodes a video file into frames with ffmpeg, one frame per NextFrame call
+type VideoSource struct {
+	path   string
+	config VideoConfig
+
+	mu     sync.Mutex
+	width  int
+	height int
+	cmd    *exec.Cmd
+	reader *bufio.Reader
+}
+
+// NewVideoSource creates a source reading path
+func NewVideoSource(path string, config VideoConfig) *VideoSource {
+	if config.FFmpeg == "" {
+		config.FFmpeg = "ffmpeg"
+	}
+	return &VideoSource{path: path, config: config}
+}
+
+// NextFrame returns the next decoded frame, or io.EOF when the file has ended
+func (v *VideoSource) NextFrame(ctx context.Context) (Frame, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.reader == nil {
+		if err := v.start(ctx); err != nil {
+			return Frame{}, err
+		}
+	}
+
+	data := make([]byte, v.width*v.height*3)
+	if _, err := io.ReadFull(v.reader, data); err != nil {
+		v.stop()
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			if v.config.Loop {
+				if err := v.start(ctx); err != nil {
+					return Frame{}, err
+				}
+				if _, err := io.ReadFull(v.reader, data); err != nil {
+					return Frame{}, fmt.Errorf("read video frame: %w", err)
+				}
+			} else {
+				return Frame{}, io.EOF
+			}
+		} else {
+			return Frame{}, fmt.Errorf("read video frame: %w", err)
+		}
+	}
+
+	return Frame{Data: data, Width: v.width, Height: v.height, Timestamp: time.Now()}, nil
+}
+
+// Close stops the decoder
+func (v *VideoSource) Close() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.stop()
+	return nil
+}
+
+// start probes the frame size and launches ffmpeg writing raw BGR frames to stdout
+func (v *VideoSource) start(ctx context.Context) error {
+	if v.width == 0 {
+		width, height, err := v.probe(ctx)
+		if err != nil {
+			return err
+		}
+		v.width, v.height = scaledSize(width, height, v.config.MaxSize)
+	}
+
+	args := []string{"-v", "error", "-i", v.path}
+	if v.config.MaxSize > 0 {
+		args = append(args, "-vf", fmt.Sprintf("scale=%d:%d", v.width, v.height))
+	}
+	args = append(args, "-f", "rawvideo", "-pix_fmt", "bgr24", "-")
+
+	cmd := exec.CommandContext(ctx, v.config.FFmpeg, args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start ffmpeg: %w", err)
+	}
+
+	v.cmd = cmd
+	v.reader = bufio.NewReaderSize(stdout, v.width*v.height*3)
+	return nil
+}
+
+// stop kills the running decoder, if any
+func (v *VideoSource) stop() {
+	if v.cmd != nil {
+		v.cmd.Process.Kill()
+		v.cmd.Wait()
+	}
+	v.cmd = nil
+	v.reader = nil
+}
+
+// probe reads the video dimensions with ffprobe; options go before the input
+func (v *VideoSource) probe(ctx context.Context, options ...string) (int, int, error) {
+	ffprobe := strings.Replace(v.config.FFmpeg, "ffmpeg", "ffprobe", 1)
+	args := append([]string{"-v", "error"}, options...)
+	args = append(args, "-select_streams", "v:0", "-show_entries", "stream=width,height", "-of", "csv=p=0:s=x", v.path)
+	out, err := exec.CommandContext(ctx, ffprobe, args...).Output()
+	if err != nil {
+		return 0, 0, fmt.Errorf("probe %s: %w", v.path, err)
+	}
+
+	size := strings.SplitN(string(bytes.TrimSpace(out)), "x", 2)
+	if len(size) != 2 {
+		return 0, 0, fmt.Errorf("probe %s: unexpected output %q", v.path, out)
+	}
+	width, err1 := strconv.Atoi(size[0])
+	height, err2 := strconv.Atoi(strings.TrimRight(size[1], "x"))
+	if err1 != nil || err2 != nil || width <= 0 || height <= 0 {
+		return 0, 0, fmt.Errorf("probe %s: unexpected output %q", v.path, out)
+	}
+	return width, height, nil
+}
+
+// scaledSize fits width and height within maxSize, keeping even dimensions for ffmpeg
+func scaledSize(width, height, maxSize int) (int, int) {
+	if maxSize <= 0 || (width <= maxSize && height <= maxSize) {
+		return width, height
+	}
+	if width >= height {
+		height = height * maxSize / width
+		width = maxSize
+	} else {
+		width = width * maxSize / height
+		height = maxSize
+	}
+	return width &^ 1, height &^ 1
+}
@@ -0,0 +1,517 @@
+//go:build linux
+
+package capture
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// X11 core and MIT-SHM request opcodes and constants
+const (
+	x11GetGeometry          = 14
+	x11InternAtom           = 16
+	x11GetProperty          = 20
+	x11TranslateCoordinates = 40
+	x11QueryExtension       = 98
+	x11ShmAttach            = 1
+	x11ShmDetach            = 2
+	x11ShmGetImage          = 4
+	x11ZPixmap              = 2
+	x11Reply                = 1
+	x11Error                = 0
+)
+
+// X11Source captures the game window on an X11 desktop, or XWayland under
+// Proton, with the MIT-SHM extension: the server copies the window's part of
+// the screen straight into shared memory. It speaks the X protocol itself,
+// so no X libraries are needed.
+type X11Source struct {
+	config LinuxConfig
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+	seq    uint16
+	nextID uint32
+	root   uint32
+	rootW  int
+	rootH  int
+	shm    uint8 // MIT-SHM major opcode
+	window uint32
+
+	seg    uint32 // Server-side segment ID
+	shmID  int
+	shmBuf []byte
+
+	buffers [RingSlots][]byte
+	next    int
+}
+
+// NewX11Source connects to $DISPLAY
+func NewX11Source(config LinuxConfig) (*X11Source, error) {
+	s := &X11Source{config: config}
+	if err := s.open(); err != nil {
+		s.close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// NextFrame copies the window's area of the screen, or the whole screen
+// without a window title. ErrNoFrame is returned while the window is missing.
+func (s *X11Source) NextFrame(ctx context.Context) (Frame, error) {
+	if err := ctx.Err(); err != nil {
+		return Frame{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		if err := s.open(); err != nil {
+			s.close()
+			return Frame{}, err
+		}
+	}
+	x, y, width, height, err := s.area()
+	if err != nil {
+		return Frame{}, err
+	}
+	if width <= 0 || height <= 0 {
+		return Frame{}, ErrNoFrame
+	}
+	if err := s.attach(width * height * 4); err != nil {
+		return Frame{}, err
+	}
+
+	req := x11Request(s.shm, x11ShmGetImage, 8)
+	req = binary.LittleEndian.AppendUint32(req, s.root)
+	req = binary.LittleEndian.AppendUint16(req, uint16(x))
+	req = binary.LittleEndian.AppendUint16(req, uint16(y))
+	req = binary.LittleEndian.AppendUint16(req, uint16(width))
+	req = binary.LittleEndian.AppendUint16(req, uint16(height))
+	req = binary.LittleEndian.AppendUint32(req, 0xffffffff)
+	req = append(req, x11ZPixmap, 0, 0, 0)
+	req = binary.LittleEndian.AppendUint32(req, s.seg)
+	req = binary.LittleEndian.AppendUint32(req, 0)
+	if _, err := s.roundTrip(req); err != nil {
+		// A broken connection is reopened on the next call
+		s.close()
+		return Frame{}, err
+	}
+
+	size := width * height * 3
+	data := s.buffers[s.next]
+	if len(data) != size {
+		data = make([]byte, size)
+		s.buffers[s.next] = data
+	}
+	s.next = (s.next + 1) % RingSlots
+	packBGRA(data, s.shmBuf, width*4, width, height, false)
+	return Frame{Data: data, Width: width, Height: height, Timestamp: time.Now()}, nil
+}
+
+// Close detaches the shared memory and disconnects
+func (s *X11Source) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.close()
+	return nil
+}
+
+// open connects, authenticates, and looks up MIT-SHM; mu must be held or
+// the source not yet shared
+func (s *X11Source) open() error {
+	display := os.Getenv("DISPLAY")
+	if display == "" {
+		return errors.New("x11: DISPLAY is not set")
+	}
+	host, number, screen, err := parseDisplay(display)
+	if err != nil {
+		return err
+	}
+	if host == "" || host == "unix" {
+		s.conn, err = net.Dial("unix", "/tmp/.X11-unix/X"+number)
+	} else {
+		port, _ := strconv.Atoi(number)
+		s.conn, err = net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(6000+port)))
+	}
+	if err != nil {
+		return fmt.Errorf("x11: connect to %s: %w", display, err)
+	}
+	s.reader = bufio.NewReader(s.conn)
+	s.seq = 0
+	if err := s.setup(xauthCookie(host, number), screen); err != nil {
+		return err
+	}
+
+	reply, err := s.roundTrip(x11StringRequest(x11QueryExtension, 0, "MIT-SHM"))
+	if err != nil {
+		return err
+	}
+	if reply[8] == 0 {
+		return errors.New("x11: the server has no MIT-SHM extension")
+	}
+	s.shm = reply[9]
+	return nil
+}
+
+// setup performs the connection handshake and reads the screen's root window
+func (s *X11Source) setup(cookie []byte, screen int) error {
+	const authName = "MIT-MAGIC-COOKIE-1"
+	if cookie == nil {
+		cookie = []byte{}
+	}
+	name := ""
+	if len(cookie) > 0 {
+		name = authName
+	}
+	req := []byte{'l', 0, 11, 0, 0, 0}
+	req = binary.LittleEndian.AppendUint16(req, uint16(len(name)))
+	req = binary.LittleEndian.AppendUint16(req, uint16(len(cookie)))
+	req = append(req, 0, 0)
+	req = append(req, x11Pad([]byte(name))...)
+	req = append(req, x11Pad(cookie)...)
+	if _, err := s.conn.Write(req); err != nil {
+		return fmt.Errorf("x11: %w", err)
+	}
+
+	var head [8]byte
+	if _, err := io.ReadFull(s.reader, head[:]); err != nil {
+		return fmt.Errorf("x11: %w", err)
+	}
+	info := make([]byte, int(binary.LittleEndian.Uint16(head[6:]))*4)
+	if _, err := io.ReadFull(s.reader, info); err != nil {
+		return fmt.Errorf("x11: %w", err)
+	}
+	if head[0] != 1 {
+		reason := info
+		if int(head[1]) <= len(info) {
+			reason = info[:head[1]]
+		}
+		return fmt.Errorf("x11: connection refused: %s", strings.TrimSpace(string(reason)))
+	}
+
+	s.nextID = binary.LittleEndian.Uint32(info[4:])
+	vendor := int(binary.LittleEndian.Uint16(info[16:]))
+	screens, formats := int(info[20]), int(info[21])
+	if screen >= screens {
+		return fmt.Errorf("x11: screen %d not found", screen)
+	}
+	offset := 32 + (vendor+3)&^3 + formats*8
+	for i := 0; ; i++ {
+		if offset+40 > len(info) {
+			return errors.New("x11: truncated setup reply")
+		}
+		if i == screen {
+			s.root = binary.LittleEndian.Uint32(info[offset:])
+			s.rootW = int(binary.LittleEndian.Uint16(info[offset+20:]))
+			s.rootH = int(binary.LittleEndian.Uint16(info[offset+22:]))
+			return nil
+		}
+		// Skip the screen's depths and their visuals
+		depths := int(info[offset+39])
+		offset += 40
+		for d := 0; d < depths && offset+8 <= len(info); d++ {
+			offset += 8 + int(binary.LittleEndian.Uint16(info[offset+2:]))*24
+		}
+	}
+}
+
+// close drops the connection and shared memory; mu must be held
+func (s *X11Source) close() {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+	s.detach()
+	s.window = 0
+}
+
+// area returns the root-relative rectangle to capture, clipped to the screen
+func (s *X11Source) area() (x, y, width, height int, err error) {
+	if s.config.WindowTitle == "" {
+		return 0, 0, s.rootW, s.rootH, nil
+	}
+	if s.window == 0 {
+		if s.window, err = s.findWindow(s.config.WindowTitle); err != nil || s.window == 0 {
+			return 0, 0, 0, 0, err
+		}
+	}
+
+	req := x11Request(x11GetGeometry, 0, 2)
+	req = binary.LittleEndian.AppendUint32(req, s.window)
+	geometry, err := s.roundTrip(req)
+	if err != nil {
+		// The window was closed; look for it again next time
+		s.window = 0
+		return 0, 0, 0, 0, nil
+	}
+	width, height = int(binary.LittleEndian.Uint16(geometry[16:])), int(binary.LittleEndian.Uint16(geometry[18:]))
+
+	req = x11Request(x11TranslateCoordinates, 0, 4)
+	req = binary.LittleEndian.AppendUint32(req, s.window)
+	req = binary.LittleEndian.AppendUint32(req, s.root)
+	req = append(req, 0, 0, 0, 0)
+	translated, err := s.roundTrip(req)
+	if err != nil {
+		s.window = 0
+		return 0, 0, 0, 0, nil
+	}
+	x, y = int(int16(binary.LittleEndian.Uint16(translated[12:]))), int(int16(binary.LittleEndian.Uint16(translated[14:])))
+
+	// Only the visible part of the window can be read from the root
+	if x < 0 {
+		width, x = width+x, 0
+	}
+	if y < 0 {
+		height, y = height+y, 0
+	}
+	width, height = min(width, s.rootW-x), min(height, s.rootH-y)
+	return x, y, width, height, nil
+}
+
+// findWindow returns the top-level window whose title is title, or 0
+func (s *X11Source) findWindow(title string) (uint32, error) {
+	clients, err := s.atom("_NET_CLIENT_LIST")
+	if err != nil {
+		return 0, err
+	}
+	netName, err := s.atom("_NET_WM_NAME")
+	if err != nil {
+		return 0, err
+	}
+	list, err := s.property(s.root, clients)
+	if err != nil {
+		return 0, err
+	}
+	const wmName = 39 // Predefined WM_NAME atom
+	for i := 0; i+4 <= len(list); i += 4 {
+		window := binary.LittleEndian.Uint32(list[i:])
+		for _, property := range []uint32{netName, wmName} {
+			if name, err := s.property(window, property); err == nil && string(name) == title {
+				return window, nil
+			}
+		}
+	}
+	return 0, nil
+}
+
+// atom interns name
+func (s *X11Source) atom(name string) (uint32, error) {
+	reply, err := s.roundTrip(x11StringRequest(x11InternAtom, 0, name))
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(reply[8:]), nil
+}
+
+// property reads up to 64 KiB of a window property of any type
+func (s *X11Source) property(window, property uint32) ([]byte, error) {
+	req := x11Request(x11GetProperty, 0, 6)
+	req = binary.LittleEndian.AppendUint32(req, window)
+	req = binary.LittleEndian.AppendUint32(req, property)
+	req = binary.LittleEndian.AppendUint32(req, 0)
+	req = binary.LittleEndian.AppendUint32(req, 0)
+	req = binary.LittleEndian.AppendUint32(req, 1<<14)
+	reply, err := s.roundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	format, count := int(reply[1]), int(binary.LittleEndian.Uint32(reply[16:]))
+	size := count * format / 8
+	if 32+size > len(reply) {
+		return nil, errors.New("x11: truncated property")
+	}
+	return reply[32 : 32+size], nil
+}
+
+// attach makes sure a shared memory segment of at least size bytes is
+// attached on both sides
+func (s *X11Source) attach(size int) error {
+	if len(s.shmBuf) >= size {
+		return nil
+	}
+	s.detach()
+	id, err := unix.SysvShmGet(unix.IPC_PRIVATE, size, unix.IPC_CREAT|0o600)
+	if err != nil {
+		return fmt.Errorf("x11: shmget: %w", err)
+	}
+	buf, err := unix.SysvShmAttach(id, 0, 0)
+	if err != nil {
+		unix.SysvShmCtl(id, unix.IPC_RMID, nil)
+		return fmt.Errorf("x11: shmat: %w", err)
+	}
+	s.shmID, s.shmBuf = id, buf
+
+	s.seg = s.nextID
+	s.nextID++
+	req := x11Request(s.shm, x11ShmAttach, 4)
+	req = binary.LittleEndian.AppendUint32(req, s.seg)
+	req = binary.LittleEndian.AppendUint32(req, uint32(id))
+	req = append(req, 0, 0, 0, 0)
+	if err := s.send(req); err != nil {
+		return err
+	}
+	// Linux keeps a removed segment until both sides detach, so it can't leak
+	// once the server has attached, which the next round trip guarantees
+	if _, err := s.roundTrip(x11StringRequest(x11QueryExtension, 0, "MIT-SHM")); err != nil {
+		return err
+	}
+	unix.SysvShmCtl(id, unix.IPC_RMID, nil)
+	return nil
+}
+
+// detach releases the shared memory segment
+func (s *X11Source) detach() {
+	if s.shmBuf == nil {
+		return
+	}
+	if s.conn != nil {
+		req := x11Request(s.shm, x11ShmDetach, 2)
+		s.send(binary.LittleEndian.AppendUint32(req, s.seg))
+	}
+	unix.SysvShmDetach(s.shmBuf)
+	unix.SysvShmCtl(s.shmID, unix.IPC_RMID, nil)
+	s.shmBuf = nil
+}
+
+// send writes a request without waiting for a reply
+func (s *X11Source) send(req []byte) error {
+	s.seq++
+	if _, err := s.conn.Write(req); err != nil {
+		return fmt.Errorf("x11: %w", err)
+	}
+	return nil
+}
+
+// roundTrip sends a request and returns its reply, skipping events and
+// failing on an error for it or an earlier request
+func (s *X11Source) roundTrip(req []byte) ([]byte, error) {
+	if err := s.send(req); err != nil {
+		return nil, err
+	}
+	for {
+		packet := make([]byte, 32)
+		if _, err := io.ReadFull(s.reader, packet); err != nil {
+			return nil, fmt.Errorf("x11: %w", err)
+		}
+		switch packet[0] {
+		case x11Error:
+			return nil, fmt.Errorf("x11: request failed with error %d", packet[1])
+		case x11Reply:
+			if extra := int(binary.LittleEndian.Uint32(packet[4:])) * 4; extra > 0 {
+				packet = append(packet, make([]byte, extra)...)
+				if _, err := io.ReadFull(s.reader, packet[32:]); err != nil {
+					return nil, fmt.Errorf("x11: %w", err)
+				}
+			}
+			if binary.LittleEndian.Uint16(packet[2:]) == s.seq {
+				return packet, nil
+			}
+		}
+	}
+}
+
+// x11Request starts a request of length 4-byte units
+func x11Request(opcode, data uint8, length int) []byte {
+	req := make([]byte, 4, length*4)
+	req[0], req[1] = opcode, data
+	binary.LittleEndian.PutUint16(req[2:], uint16(length))
+	return req
+}
+
+// x11StringRequest builds InternAtom and QueryExtension, which carry a name
+func x11StringRequest(opcode, data uint8, name string) []byte {
+	padded := x11Pad([]byte(name))
+	req := x11Request(opcode, data, 2+len(padded)/4)
+	req = binary.LittleEndian.AppendUint16(req, uint16(len(name)))
+	req = append(req, 0, 0)
+	return append(req, padded...)
+}
+
+// x11Pad pads b to a multiple of four bytes
+func x11Pad(b []byte) []byte {
+	return append(b, make([]byte, (4-len(b)%4)%4)...)
+}
+
+// parseDisplay splits a DISPLAY value like :0, :1.0, or host:0
+func parseDisplay(display string) (host, number string, screen int, err error) {
+	colon := strings.LastIndexByte(display, ':')
+	if colon < 0 {
+		return "", "", 0, fmt.Errorf("x11: invalid DISPLAY %q", display)
+	}
+	host, number = display[:colon], display[colon+1:]
+	if dot := strings.IndexByte(number, '.'); dot >= 0 {
+		screen, _ = strconv.Atoi(number[dot+1:])
+		number = number[:dot]
+	}
+	if _, err := strconv.Atoi(number); err != nil {
+		return "", "", 0, fmt.Errorf("x11: invalid DISPLAY %q", display)
+	}
+	return host, number, screen, nil
+}
+
+// xauthCookie finds the MIT-MAGIC-COOKIE-1 for the display in the
+// Xauthority file, or nil when the server needs none
+func xauthCookie(host, number string) []byte {
+	path := os.Getenv("XAUTHORITY")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+		path = filepath.Join(home, ".Xauthority")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	if host == "" || host == "unix" {
+		host, _ = os.Hostname()
+	}
+
+	// Entries are a family and four length-prefixed big-endian strings
+	const familyLocal, familyWild = 256, 0xffff
+	var fallback []byte
+	for len(data) >= 2 {
+		family := binary.BigEndian.Uint16(data)
+		data = data[2:]
+		var fields [4][]byte
+		for i := range fields {
+			if len(data) < 2 {
+				return fallback
+			}
+			n := int(binary.BigEndian.Uint16(data))
+			if len(data) < 2+n {
+				return fallback
+			}
+			fields[i], data = data[2:2+n], data[2+n:]
+		}
+		address, display, name, cookie := string(fields[0]), string(fields[1]), string(fields[2]), fields[3]
+		if name != "MIT-MAGIC-COOKIE-1" || (display != number && display != "") {
+			continue
+		}
+		if family == familyWild || (family == familyLocal && address == host) {
+			return cookie
+		}
+		if fallback == nil {
+			fallback = cookie
+		}
+	}
+	return fallback
+}
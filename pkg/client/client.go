@@ -0,0 +1,349 @@
+// Package client connects to the vrchat-proximity WebSocket API and decodes
+// its messages into typed structs. It reconnects automatically, resuming
+// from the last sequence number so no events are missed, and has no cgo or
+// engine dependencies so tools can import it anywhere.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// protocolVersion is the WebSocket protocol version this package speaks
+const protocolVersion = 1
+
+// Options configures a connection
+type Options struct {
+	APIKey      string            // Sent as X-API-Key when the server requires one
+	Deltas      bool              // Request detections_delta and rebuild full detection lists locally
+	NearestOnly bool              // Request only "nearest" summaries
+	Trails      bool              // Request each track's recent positions with full detection lists
+	NoReconnect bool              // Stop after the first disconnect instead of redialing
+	MinBackoff  time.Duration     // First reconnect delay; defaults to 500ms
+	MaxBackoff  time.Duration     // Longest reconnect delay; defaults to 30s
+	Header      http.Header       // Extra handshake headers
+	Dialer      *websocket.Dialer // Defaults to an uncompressed dialer; set EnableCompression for remote links
+}
+
+// Client is a connection to a proximity server
+type Client struct {
+	url  string
+	opts Options
+
+	mu      sync.Mutex
+	subs    map[*subscription]bool
+	lastSeq int64
+	tracks  map[int64]Detection // Rebuilt detection state in delta mode
+	err     error
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// subscription is one Subscribe channel
+type subscription struct {
+	types map[string]bool // Empty accepts every type
+	ch    chan Message
+}
+
+// Dial connects to a server's /ws endpoint, e.g. ws://localhost:8080/ws.
+// The first connection attempt must succeed; later drops are retried in the
+// background until ctx is cancelled or Close is called.
+func Dial(ctx context.Context, rawURL string, opts Options) (*Client, error) {
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = 500 * time.Millisecond
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 30 * time.Second
+	}
+	if opts.Dialer == nil {
+		opts.Dialer = &websocket.Dialer{HandshakeTimeout: 10 * time.Second}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	c := &Client{
+		url:    rawURL,
+		opts:   opts,
+		subs:   make(map[*subscription]bool),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	conn, err := c.connect(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	go c.run(ctx, conn)
+	return c, nil
+}
+
+// Subscribe returns a channel of messages of the given types, or every type
+// when none are given, and a function that cancels the subscription. Slow
+// subscribers miss messages rather than stalling the connection.
+func (c *Client) Subscribe(types ...string) (<-chan Message, func()) {
+	sub := &subscription{types: make(map[string]bool), ch: make(chan Message, 64)}
+	for _, t := range types {
+		sub.types[t] = true
+	}
+
+	c.mu.Lock()
+	c.subs[sub] = true
+	c.mu.Unlock()
+
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() {
+			c.mu.Lock()
+			if c.subs[sub] {
+				delete(c.subs, sub)
+				close(sub.ch)
+			}
+			c.mu.Unlock()
+		})
+	}
+}
+
+// LastSeq returns the highest sequence number received
+func (c *Client) LastSeq() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lastSeq
+}
+
+// Done is closed once the client stops for good
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns why the client stopped, after Done is closed
+func (c *Client) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Close disconnects and stops reconnecting
+func (c *Client) Close() error {
+	c.cancel()
+	<-c.done
+	return nil
+}
+
+// connect dials the server, resuming after the last sequence number, and
+// sends a hello when features were requested
+func (c *Client) connect(ctx context.Context) (*websocket.Conn, error) {
+	u, err := url.Parse(c.url)
+	if err != nil {
+		return nil, fmt.Errorf("parse url: %w", err)
+	}
+	if seq := c.LastSeq(); seq > 0 {
+		query := u.Query()
+		query.Set("since", strconv.FormatInt(seq, 10))
+		u.RawQuery = query.Encode()
+	}
+
+	header := http.Header{}
+	for k, v := range c.opts.Header {
+		header[k] = v
+	}
+	if c.opts.APIKey != "" {
+		header.Set("X-API-Key", c.opts.APIKey)
+	}
+
+	conn, _, err := c.opts.Dialer.DialContext(ctx, u.String(), header)
+	if err != nil {
+		return nil, fmt.Errorf("dial %s: %w", c.url, err)
+	}
+
+	c.mu.Lock()
+	c.tracks = nil // The server starts each connection with a keyframe
+	c.mu.Unlock()
+
+	var features []string
+	if c.opts.Deltas {
+		features = append(features, "deltas")
+	}
+	if c.opts.NearestOnly {
+		features = append(features, "nearest_only")
+	}
+	if c.opts.Trails {
+		features = append(features, "trails")
+	}
+	if len(features) > 0 {
+		hello, _ := json.Marshal(map[string]interface{}{"type": "hello", "protocol": protocolVersion, "features": features})
+		if err := conn.WriteMessage(websocket.TextMessage, hello); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("send hello: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// run reads messages and reconnects with exponential backoff until ctx ends
+func (c *Client) run(ctx context.Context, conn *websocket.Conn) {
+	defer close(c.done)
+	defer c.closeSubscriptions()
+
+	for {
+		stop := make(chan struct{})
+		go func() {
+			select {
+			case <-ctx.Done():
+				conn.Close()
+			case <-stop:
+			}
+		}()
+		err := c.read(conn)
+		close(stop)
+		conn.Close()
+
+		if ctx.Err() != nil {
+			c.setErr(ctx.Err())
+			return
+		}
+		if c.opts.NoReconnect {
+			c.setErr(err)
+			return
+		}
+
+		backoff := c.opts.MinBackoff
+		for {
+			select {
+			case <-ctx.Done():
+				c.setErr(ctx.Err())
+				return
+			case <-time.After(backoff):
+			}
+			if conn, err = c.connect(ctx); err == nil {
+				break
+			}
+			backoff = min(backoff*2, c.opts.MaxBackoff)
+		}
+	}
+}
+
+// read dispatches messages until the connection fails
+func (c *Client) read(conn *websocket.Conn) error {
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		if message, ok := c.decode(data); ok {
+			c.dispatch(message)
+		}
+	}
+}
+
+// decode turns a raw message into a Message, applying deltas to the local state
+func (c *Client) decode(data []byte) (Message, bool) {
+	var header struct {
+		Type string `json:"type"`
+		Seq  int64  `json:"seq"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil || header.Type == "" {
+		return Message{}, false
+	}
+	message := Message{Type: header.Type, Seq: header.Seq, Raw: data}
+
+	c.mu.Lock()
+	c.lastSeq = max(c.lastSeq, header.Seq)
+	c.mu.Unlock()
+
+	var err error
+	switch header.Type {
+	case "detections":
+		message.Detections = &Detections{}
+		err = json.Unmarshal(data, message.Detections)
+	case "detections_delta":
+		var d delta
+		if err = json.Unmarshal(data, &d); err == nil {
+			message.Type = "detections"
+			message.Detections = c.applyDelta(d)
+		}
+	case "nearest":
+		message.Nearest = &Nearest{}
+		err = json.Unmarshal(data, message.Nearest)
+	case "hello":
+		message.Hello = &Hello{}
+		err = json.Unmarshal(data, message.Hello)
+	case "hello_ack", "replay_truncated":
+	default:
+		message.Event = &Event{}
+		err = json.Unmarshal(data, message.Event)
+	}
+	return message, err == nil
+}
+
+// applyDelta updates the rebuilt detection state and returns a snapshot of it
+func (c *Client) applyDelta(d delta) *Detections {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if d.Keyframe || c.tracks == nil {
+		c.tracks = make(map[int64]Detection, len(d.Detections))
+		for _, det := range d.Detections {
+			c.tracks[det.TrackID] = det
+		}
+	}
+	for _, det := range d.Added {
+		c.tracks[det.TrackID] = det
+	}
+	for _, det := range d.Updated {
+		c.tracks[det.TrackID] = det
+	}
+	for _, id := range d.Removed {
+		delete(c.tracks, id)
+	}
+
+	snapshot := &Detections{Seq: d.Seq, Timestamp: d.Timestamp, Detections: make([]Detection, 0, len(c.tracks))}
+	for _, det := range c.tracks {
+		snapshot.Detections = append(snapshot.Detections, det)
+	}
+	sort.Slice(snapshot.Detections, func(i, j int) bool {
+		return snapshot.Detections[i].Distance < snapshot.Detections[j].Distance
+	})
+	return snapshot
+}
+
+// dispatch hands a message to every matching subscriber without blocking
+func (c *Client) dispatch(message Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for sub := range c.subs {
+		if len(sub.types) > 0 && !sub.types[message.Type] {
+			continue
+		}
+		select {
+		case sub.ch <- message:
+		default:
+		}
+	}
+}
+
+// closeSubscriptions closes every subscriber channel when the client stops
+func (c *Client) closeSubscriptions() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for sub := range c.subs {
+		close(sub.ch)
+		delete(c.subs, sub)
+	}
+}
+
+// setErr records why the client stopped
+func (c *Client) setErr(err error) {
+	c.mu.Lock()
+	c.err = err
+	c.mu.Unlock()
+}
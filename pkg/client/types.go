@@ -0,0 +1,129 @@
+package client
+
+// BoundingBox is a detection's screen rectangle in pixels
+type BoundingBox struct {
+	X      int32 `json:"x"`
+	Y      int32 `json:"y"`
+	Width  int32 `json:"width"`
+	Height int32 `json:"height"`
+}
+
+// Detection is one detected object
+type Detection struct {
+	BBox           BoundingBox `json:"bbox"`
+	Confidence     float32     `json:"confidence"`
+	Type           string      `json:"type"`
+	Area           float32     `json:"area"`
+	Distance       float32     `json:"distance"`
+	Category       string      `json:"category"`
+	TrackID        int64       `json:"track_id,omitempty"`
+	Label          string      `json:"label,omitempty"`
+	Player         string      `json:"player,omitempty"`
+	FriendAdjacent bool        `json:"friend_adjacent,omitempty"`
+	VelocityX      float32     `json:"velocity_x,omitempty"`
+	VelocityY      float32     `json:"velocity_y,omitempty"`
+	ApproachRate   float32     `json:"approach_rate,omitempty"`
+	Bearing        float32     `json:"bearing"`   // Degrees right of the view center; negative is left
+	Elevation      float32     `json:"elevation"` // Approximate degrees above the view center
+}
+
+// Cluster is a group of detections close together on screen
+type Cluster struct {
+	X        float32 `json:"x"`
+	Y        float32 `json:"y"`
+	Count    int     `json:"count"`
+	Category string  `json:"category"`
+	Distance float32 `json:"distance"`
+}
+
+// Crowd summarizes how busy the scene is
+type Crowd struct {
+	Count    int            `json:"count"`
+	Density  map[string]int `json:"density"`
+	Clusters []Cluster      `json:"clusters"`
+	Crowded  bool           `json:"crowded"`
+}
+
+// TrailPoint is where a track was in an earlier frame
+type TrailPoint struct {
+	X           int32   `json:"x"`
+	Y           int32   `json:"y"`
+	Distance    float32 `json:"distance"`
+	Category    string  `json:"category"`
+	TimestampMS int64   `json:"timestamp_ms"`
+}
+
+// Detections is a "detections" message, or the current state rebuilt from
+// detections_delta messages when Options.Deltas is set
+type Detections struct {
+	Seq         int64                  `json:"seq"`
+	Timestamp   int64                  `json:"timestamp"`
+	Detections  []Detection            `json:"detections"`
+	Crowd       *Crowd                 `json:"crowd,omitempty"`
+	FrameCount  int64                  `json:"frame_count"`
+	FrameWidth  int                    `json:"frame_width"`
+	FrameHeight int                    `json:"frame_height"`
+	Trails      map[int64][]TrailPoint `json:"trails,omitempty"` // By track ID, oldest first, with Options.Trails
+}
+
+// Event is a proximity event such as zone_enter or fast_approach
+type Event struct {
+	Seq            int64      `json:"seq"`
+	Type           string     `json:"type"`
+	Timestamp      int64      `json:"timestamp"`
+	Priority       string     `json:"priority,omitempty"`
+	Category       string     `json:"category,omitempty"`
+	Previous       string     `json:"previous,omitempty"`
+	Distance       float32    `json:"distance,omitempty"`
+	ApproachRate   float32    `json:"approach_rate,omitempty"`
+	Detection      *Detection `json:"detection,omitempty"`
+	GapMS          int64      `json:"gap_ms,omitempty"`
+	Count          int        `json:"count,omitempty"`
+	Changed        float32    `json:"changed,omitempty"`
+	FriendAdjacent bool       `json:"friend_adjacent,omitempty"`
+	Rule           string     `json:"rule,omitempty"`
+	Muted          bool       `json:"muted,omitempty"`
+	Level          int        `json:"level,omitempty"`
+	Reason         string     `json:"reason,omitempty"`
+}
+
+// Nearest is a "nearest" summary of the closest detection
+type Nearest struct {
+	Seq       int64   `json:"seq"`
+	Timestamp int64   `json:"timestamp"`
+	Present   bool    `json:"present"`
+	Distance  float32 `json:"distance,omitempty"`
+	Category  string  `json:"category,omitempty"`
+	TrackID   int64   `json:"track_id,omitempty"`
+}
+
+// Hello is the server greeting
+type Hello struct {
+	Protocol int      `json:"protocol"`
+	Server   string   `json:"server"`
+	Features []string `json:"features"`
+}
+
+// delta is a detections_delta message
+type delta struct {
+	Seq        int64       `json:"seq"`
+	Timestamp  int64       `json:"timestamp"`
+	Keyframe   bool        `json:"keyframe"`
+	Detections []Detection `json:"detections"`
+	Added      []Detection `json:"added"`
+	Updated    []Detection `json:"updated"`
+	Removed    []int64     `json:"removed"`
+}
+
+// Message is one decoded server message; exactly one of the typed fields
+// is set for the message types this package knows, otherwise only Raw
+type Message struct {
+	Type string
+	Seq  int64
+	Raw  []byte
+
+	Detections *Detections
+	Event      *Event
+	Nearest    *Nearest
+	Hello      *Hello
+}
@@ -0,0 +1,401 @@
+// Package config loads engine, server, and integration settings from a YAML
+// file and reapplies the runtime-adjustable ones when the file changes.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"vrchat-proximity/pkg/capture"
+	"vrchat-proximity/pkg/engine"
+	"vrchat-proximity/pkg/history"
+	"vrchat-proximity/pkg/input"
+	"vrchat-proximity/pkg/logging"
+	"vrchat-proximity/pkg/overlay"
+	"vrchat-proximity/pkg/scripting"
+	"vrchat-proximity/pkg/transport"
+)
+
+// CaptureConfig configures the capture loop and motion detector
+type CaptureConfig struct {
+	TargetFPS   int           `yaml:"target_fps"`
+	Sensitivity int           `yaml:"sensitivity"` // 1-100, higher detects fainter motion
+	Watchdog    time.Duration `yaml:"watchdog"`    // Restart capture after this long without frames; 0 disables
+	Backend     string        `yaml:"backend"`     // One of capture.Backends
+	Algorithm   string        `yaml:"algorithm"`   // Motion algorithm, one of capture.Algorithms
+
+	DXGI          capture.DXGIConfig      `yaml:"dxgi"`           // Desktop Duplication settings for the dxgi backend
+	NDI           capture.NDIConfig       `yaml:"ndi"`            // Stream settings for the ndi backend
+	Spout         capture.SpoutConfig     `yaml:"spout"`          // Sender settings for the spout backend
+	Network       capture.NetworkConfig   `yaml:"network"`        // Stream settings for the network backend
+	Quest         capture.QuestConfig     `yaml:"quest"`          // Headset settings for the quest backend
+	Linux         capture.LinuxConfig     `yaml:"linux"`          // Window and stream settings for the x11 and pipewire backends
+	MacOS         capture.MacOSConfig     `yaml:"macos"`          // Window settings for the screencapturekit backend
+	Processing    engine.ProcessingConfig `yaml:"processing"`     // Downscale frames to this resolution before detection
+	Tiling        engine.TilingConfig     `yaml:"tiling"`         // Split frames into tiles detected in parallel
+	Overflow      engine.OverflowConfig   `yaml:"overflow"`       // What capture does when detection processing falls behind
+	FollowProcess engine.FollowConfig     `yaml:"follow_process"` // Pause capture while the game is closed
+	FocusOnly     engine.FocusConfig      `yaml:"focus_only"`     // Pause capture while another window has focus
+	Sources       []SourceConfig          `yaml:"sources"`        // Capture several sources concurrently; empty captures one
+	Stereo        engine.StereoConfig     `yaml:"stereo"`         // Distance from parallax between left and right eye sources
+}
+
+// SourceConfig is one of several concurrently captured sources
+type SourceConfig struct {
+	ID     string         `yaml:"id"`     // Tags the source's detections as source_id
+	Output *int           `yaml:"output"` // DXGI monitor index; unset crops the main capture
+	Region capture.Region `yaml:"region"` // Part of the frame in fractions; unset is all of it
+}
+
+// ZoneConfig configures zone_enter/zone_exit tracking
+type ZoneConfig struct {
+	ExitTimeout time.Duration `yaml:"exit_timeout"` // Emit zone_exit after this long without detections
+}
+
+// IntegrationsConfig configures the optional outputs
+type IntegrationsConfig struct {
+	OSC           transport.OSCConfig             `yaml:"osc"`
+	Haptics       transport.HapticsConfig         `yaml:"haptics"`
+	Notifications transport.NotificationConfig    `yaml:"notifications"`
+	Webhooks      transport.WebhookConfig         `yaml:"webhooks"`
+	MQTT          transport.MQTTConfig            `yaml:"mqtt"`
+	History       history.HistoryConfig           `yaml:"history"`
+	Export        history.ExportConfig            `yaml:"export"`
+	GRPC          string                          `yaml:"grpc"` // gRPC listen address; empty disables
+	Tray          transport.TrayConfig            `yaml:"tray"`
+	Hotkeys       input.HotkeyConfig              `yaml:"hotkeys"`
+	Speech        transport.SpeechConfig          `yaml:"speech"`
+	Audio         transport.AudioCueConfig        `yaml:"audio"`
+	Toasts        transport.ToastConfig           `yaml:"toasts"`
+	Discord       transport.DiscordConfig         `yaml:"discord"`
+	Lighting      transport.LightingConfig        `yaml:"lighting"`
+	Intiface      transport.IntifaceConfig        `yaml:"intiface"`
+	Overlay       overlay.OverlayConfig           `yaml:"overlay"`
+	Controllers   overlay.ControllerHapticsConfig `yaml:"controller_haptics"`
+	VRChat        transport.VRChatConfig          `yaml:"vrchat"`
+	VRChatLog     transport.VRChatLogConfig       `yaml:"vrchat_log"`
+	Tracing       transport.TracingConfig         `yaml:"tracing"`
+	StatsD        transport.StatsDConfig          `yaml:"statsd"`
+}
+
+// Settings is the contents of the config file
+type Settings struct {
+	Capture      CaptureConfig            `yaml:"capture"`
+	Performance  engine.PerformanceConfig `yaml:"performance"`
+	Budget       engine.BudgetConfig      `yaml:"budget"`
+	Camera       engine.CameraConfig      `yaml:"camera"`
+	Categories   []engine.DistanceBand    `yaml:"categories"`
+	Detection    engine.DetectionConfig   `yaml:"detection"`
+	Calibration  engine.CalibrationConfig `yaml:"calibration"`
+	Feedback     engine.FeedbackConfig    `yaml:"feedback"`
+	Tracking     engine.TrackingConfig    `yaml:"tracking"`
+	Crowd        engine.CrowdConfig       `yaml:"crowd"`
+	Scene        engine.SceneConfig       `yaml:"scene"`
+	Mirror       engine.MirrorConfig      `yaml:"mirror"`
+	Zones        ZoneConfig               `yaml:"zones"`
+	Masks        []engine.Mask            `yaml:"masks"`
+	Profiles     []engine.ColorProfile    `yaml:"color_profiles"`
+	OCR          engine.OCRConfig         `yaml:"ocr"`
+	Players      []engine.PlayerRule      `yaml:"player_rules"`
+	Rules        []engine.Rule            `yaml:"rules"`
+	Alerts       engine.AlertConfig       `yaml:"alerts"`
+	Script       scripting.ScriptConfig   `yaml:"script"`
+	Server       transport.ServerConfig   `yaml:"server"`
+	TLS          transport.TLSConfig      `yaml:"tls"`
+	Log          logging.Config           `yaml:"log"`
+	Preview      engine.PreviewConfig     `yaml:"preview"`
+	Clips        engine.ClipConfig        `yaml:"clips"`
+	Privacy      engine.PrivacyConfig     `yaml:"privacy"`
+	Stats        engine.StatsConfig       `yaml:"stats"`
+	Integrations IntegrationsConfig       `yaml:"integrations"`
+}
+
+// Default returns the settings used when neither the file nor a flag sets a value
+func Default() Settings {
+	return Settings{
+		Capture: CaptureConfig{
+			TargetFPS:   30,
+			Sensitivity: 50,
+			Watchdog:    10 * time.Second,
+			Backend:     capture.BackendZig,
+			Algorithm:   capture.AlgorithmFrameDiff,
+
+			DXGI:          capture.DefaultDXGIConfig(),
+			NDI:           capture.DefaultNDIConfig(),
+			Spout:         capture.DefaultSpoutConfig(),
+			Network:       capture.DefaultNetworkConfig(),
+			Quest:         capture.DefaultQuestConfig(),
+			Linux:         capture.DefaultLinuxConfig(),
+			MacOS:         capture.DefaultMacOSConfig(),
+			Processing:    engine.DefaultProcessingConfig(),
+			Tiling:        engine.DefaultTilingConfig(),
+			Overflow:      engine.DefaultOverflowConfig(),
+			Stereo:        engine.DefaultStereoConfig(),
+			FollowProcess: engine.DefaultFollowConfig(),
+			FocusOnly:     engine.DefaultFocusConfig(),
+		},
+		Performance: engine.DefaultPerformanceConfig(),
+		Budget:      engine.DefaultBudgetConfig(),
+		Camera:      engine.DefaultCameraConfig(),
+		Categories:  engine.DefaultDistanceBands(),
+		Detection:   engine.DefaultDetectionConfig(),
+		Calibration: engine.DefaultCalibrationConfig(),
+		Feedback:    engine.DefaultFeedbackConfig(),
+		Tracking:    engine.DefaultTrackingConfig(),
+		Crowd:       engine.DefaultCrowdConfig(),
+		Scene:       engine.DefaultSceneConfig(),
+		Mirror:      engine.DefaultMirrorConfig(),
+		OCR:         engine.DefaultOCRConfig(),
+		Script:      scripting.DefaultScriptConfig(),
+		Alerts:      engine.DefaultAlertConfig(),
+		Zones:       ZoneConfig{ExitTimeout: time.Second},
+		Server:      transport.DefaultServerConfig(),
+		TLS:         transport.DefaultTLSConfig(),
+		Log:         logging.DefaultConfig(),
+		Preview:     engine.DefaultPreviewConfig(),
+		Clips:       engine.DefaultClipConfig(),
+		Privacy:     engine.DefaultPrivacyConfig(),
+		Stats:       engine.DefaultStatsConfig(),
+		Integrations: IntegrationsConfig{
+			OSC:           transport.DefaultOSCConfig(),
+			Haptics:       transport.DefaultHapticsConfig(),
+			Notifications: transport.DefaultNotificationConfig(),
+			Webhooks:      transport.DefaultWebhookConfig(),
+			MQTT:          transport.DefaultMQTTConfig(),
+			History:       history.DefaultHistoryConfig(),
+			Export:        history.DefaultExportConfig(),
+			Hotkeys:       input.DefaultHotkeyConfig(),
+			Speech:        transport.DefaultSpeechConfig(),
+			Audio:         transport.DefaultAudioCueConfig(),
+			Toasts:        transport.DefaultToastConfig(),
+			Discord:       transport.DefaultDiscordConfig(),
+			Lighting:      transport.DefaultLightingConfig(),
+			Intiface:      transport.DefaultIntifaceConfig(),
+			Overlay:       overlay.DefaultOverlayConfig(),
+			Controllers:   overlay.DefaultControllerHapticsConfig(),
+			VRChat:        transport.DefaultVRChatConfig(),
+			VRChatLog:     transport.DefaultVRChatLogConfig(),
+			Tracing:       transport.DefaultTracingConfig(),
+			StatsD:        transport.DefaultStatsDConfig(),
+		},
+	}
+}
+
+// Load reads the YAML file at path over settings, so keys missing from the
+// file keep their current values. Unknown keys are rejected to catch typos.
+func Load(path string, settings *Settings) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config: %w", err)
+	}
+
+	// Decoding merges into existing maps, so clear the per-category tables
+	// first: a file listing curves, templates, phrases, colors, or levels replaces the defaults
+	integrations := &settings.Integrations
+	curves, templates, phrases := integrations.Haptics.Curves, integrations.Notifications.Templates, integrations.Speech.Phrases
+	integrations.Haptics.Curves, integrations.Notifications.Templates, integrations.Speech.Phrases = nil, nil, nil
+	controllerCurves, colors, levels := integrations.Controllers.Curves, integrations.Lighting.Colors, integrations.Intiface.Levels
+	integrations.Controllers.Curves, integrations.Lighting.Colors, integrations.Intiface.Levels = nil, nil, nil
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	err = decoder.Decode(settings)
+	if integrations.Haptics.Curves == nil {
+		integrations.Haptics.Curves = curves
+	}
+	if integrations.Notifications.Templates == nil {
+		integrations.Notifications.Templates = templates
+	}
+	if integrations.Speech.Phrases == nil {
+		integrations.Speech.Phrases = phrases
+	}
+	if integrations.Controllers.Curves == nil {
+		integrations.Controllers.Curves = controllerCurves
+	}
+	if integrations.Lighting.Colors == nil {
+		integrations.Lighting.Colors = colors
+	}
+	if integrations.Intiface.Levels == nil {
+		integrations.Intiface.Levels = levels
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parse config %s: %w", path, err)
+	}
+	return settings.Validate()
+}
+
+// Validate checks the ranges of the runtime-adjustable settings
+func (s *Settings) Validate() error {
+	if s.Capture.TargetFPS < 1 || s.Capture.TargetFPS > 120 {
+		return fmt.Errorf("capture.target_fps must be between 1 and 120")
+	}
+	if s.Capture.Sensitivity < 1 || s.Capture.Sensitivity > 100 {
+		return fmt.Errorf("capture.sensitivity must be between 1 and 100")
+	}
+	if !slices.Contains(capture.Backends, s.Capture.Backend) {
+		return fmt.Errorf("capture.backend must be one of %s", strings.Join(capture.Backends, ", "))
+	}
+	if !slices.Contains(capture.Algorithms, s.Capture.Algorithm) {
+		return fmt.Errorf("capture.algorithm must be one of %s", strings.Join(capture.Algorithms, ", "))
+	}
+	if s.Capture.DXGI.Output < 0 || s.Capture.DXGI.MaxWidth < 0 {
+		return fmt.Errorf("capture.dxgi output and max_width must not be negative")
+	}
+	if err := s.Capture.DXGI.HDR.Validate(); err != nil {
+		return fmt.Errorf("capture.dxgi.hdr: %w", err)
+	}
+	if err := s.Capture.Network.Validate(); err != nil {
+		return fmt.Errorf("capture.network: %w", err)
+	}
+	if err := s.Capture.Quest.Validate(); err != nil {
+		return fmt.Errorf("capture.quest: %w", err)
+	}
+	if s.Capture.Linux.MaxSize < 0 {
+		return fmt.Errorf("capture.linux.max_size must not be negative")
+	}
+	if s.Capture.MacOS.Display < 0 || s.Capture.MacOS.MaxWidth < 0 {
+		return fmt.Errorf("capture.macos display and max_width must not be negative")
+	}
+	if s.Capture.Backend == capture.BackendNetwork && s.Capture.Network.URL == "" {
+		return fmt.Errorf("capture.network.url must be set for the network backend")
+	}
+	ids := make(map[string]bool)
+	for i, source := range s.Capture.Sources {
+		if source.ID == "" || ids[source.ID] {
+			return fmt.Errorf("capture.sources[%d]: id must be set and unique", i)
+		}
+		ids[source.ID] = true
+		if source.Output != nil && *source.Output < 0 {
+			return fmt.Errorf("capture.sources[%d]: output must not be negative", i)
+		}
+		if err := source.Region.Validate(); err != nil {
+			return fmt.Errorf("capture.sources[%d]: %w", i, err)
+		}
+	}
+	if err := s.Capture.Stereo.Validate(); err != nil {
+		return fmt.Errorf("capture.stereo: %w", err)
+	}
+	if err := s.Capture.Processing.Validate(); err != nil {
+		return fmt.Errorf("capture.processing: %w", err)
+	}
+	if err := s.Capture.Tiling.Validate(); err != nil {
+		return fmt.Errorf("capture.tiling: %w", err)
+	}
+	if err := s.Capture.Overflow.Validate(); err != nil {
+		return fmt.Errorf("capture.overflow: %w", err)
+	}
+	if err := s.Performance.Validate(); err != nil {
+		return fmt.Errorf("performance: %w", err)
+	}
+	if err := s.Budget.Validate(); err != nil {
+		return fmt.Errorf("budget: %w", err)
+	}
+	if err := s.Camera.Validate(); err != nil {
+		return fmt.Errorf("camera: %w", err)
+	}
+	if err := s.Detection.Validate(); err != nil {
+		return fmt.Errorf("detection: %w", err)
+	}
+	if err := s.Calibration.Validate(); err != nil {
+		return fmt.Errorf("calibration: %w", err)
+	}
+	if err := s.Feedback.Validate(); err != nil {
+		return fmt.Errorf("feedback: %w", err)
+	}
+	if err := s.Tracking.Validate(); err != nil {
+		return fmt.Errorf("tracking: %w", err)
+	}
+	if err := s.Crowd.Validate(); err != nil {
+		return fmt.Errorf("crowd: %w", err)
+	}
+	if err := s.Scene.Validate(); err != nil {
+		return fmt.Errorf("scene: %w", err)
+	}
+	if err := s.Mirror.Validate(); err != nil {
+		return fmt.Errorf("mirror: %w", err)
+	}
+	for i := range s.Profiles {
+		if err := s.Profiles[i].Validate(); err != nil {
+			return fmt.Errorf("color_profiles[%d]: %w", i, err)
+		}
+	}
+	if err := s.OCR.Validate(); err != nil {
+		return fmt.Errorf("ocr: %w", err)
+	}
+	for i := range s.Players {
+		if err := s.Players[i].Validate(); err != nil {
+			return fmt.Errorf("player_rules[%d]: %w", i, err)
+		}
+	}
+	if err := s.Alerts.Validate(); err != nil {
+		return fmt.Errorf("alerts: %w", err)
+	}
+	webhooks := make(map[string]bool)
+	for _, target := range s.Integrations.Webhooks.Targets {
+		webhooks[target.Name] = target.Name != ""
+	}
+	if err := engine.ValidateDistanceBands(s.Categories); err != nil {
+		return fmt.Errorf("categories: %w", err)
+	}
+	categories := make([]string, len(s.Categories))
+	for i, band := range s.Categories {
+		categories[i] = band.Name
+	}
+	for i := range s.Rules {
+		if err := s.Rules[i].ValidateFor(categories); err != nil {
+			return fmt.Errorf("rules[%d]: %w", i, err)
+		}
+		for _, action := range s.Rules[i].Actions {
+			if action.Webhook != "" && !webhooks[action.Webhook] {
+				return fmt.Errorf("rules[%d]: no webhook target named %q", i, action.Webhook)
+			}
+		}
+	}
+	if err := s.Preview.Validate(); err != nil {
+		return fmt.Errorf("preview: %w", err)
+	}
+	if err := s.Privacy.Validate(); err != nil {
+		return fmt.Errorf("privacy: %w", err)
+	}
+	if err := s.Clips.Validate(); err != nil {
+		return fmt.Errorf("clips: %w", err)
+	}
+	if err := s.Stats.Validate(); err != nil {
+		return fmt.Errorf("stats: %w", err)
+	}
+	if err := s.Integrations.Overlay.Validate(); err != nil {
+		return fmt.Errorf("integrations.overlay: %w", err)
+	}
+	if err := s.Integrations.Intiface.Validate(); err != nil {
+		return fmt.Errorf("integrations.intiface: %w", err)
+	}
+	if err := s.Integrations.Controllers.Validate(); err != nil {
+		return fmt.Errorf("integrations.controller_haptics: %w", err)
+	}
+	if err := s.Integrations.Export.Validate(); err != nil {
+		return fmt.Errorf("integrations.export: %w", err)
+	}
+	if err := s.Integrations.Tracing.Validate(); err != nil {
+		return fmt.Errorf("integrations.tracing: %w", err)
+	}
+	if err := s.Integrations.StatsD.Validate(); err != nil {
+		return fmt.Errorf("integrations.statsd: %w", err)
+	}
+	if s.Zones.ExitTimeout <= 0 {
+		return fmt.Errorf("zones.exit_timeout must be positive")
+	}
+	for i, m := range s.Masks {
+		if m.Width <= 0 || m.Height <= 0 || m.X < 0 || m.Y < 0 || m.X+m.Width > 1 || m.Y+m.Height > 1 {
+			return fmt.Errorf("masks[%d] must be a non-empty region within 0-1", i)
+		}
+	}
+	return nil
+}
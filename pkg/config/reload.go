@@ -0,0 +1,314 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"vrchat-proximity/pkg/engine"
+	"vrchat-proximity/pkg/logging"
+)
+
+// configLog is the "config" subsystem logger
+var configLog = logging.For("config")
+
+// reloadDelay coalesces the burst of writes editors make when saving
+const reloadDelay = 250 * time.Millisecond
+
+// Reloader re-reads the config file and applies changed settings to a
+// running engine. FPS, sensitivity, motion algorithm, processing resolution,
+// tiling, detection overflow, stereo distance, distance categories, the
+// camera model, detection filters, confidence calibration, feedback
+// learning, tracking, crowd clustering, scene-change settling, the mirror
+// heuristic, zone timing, masks, color profiles, nameplate OCR, player rules,
+// automation rules, alert cooldowns and quiet hours, resource budgets,
+// preview on/off, privacy mode, and the log level apply immediately;
+// everything else needs a restart.
+type Reloader struct {
+	path   string
+	engine *engine.ProximityEngine
+	mu     sync.Mutex
+	loaded Settings // File contents as of the last load, without flag overrides
+}
+
+// reloadResult is the POST /config/reload response
+type reloadResult struct {
+	Applied         []string `json:"applied"`
+	RestartRequired []string `json:"restart_required"`
+}
+
+// NewReloader creates a reloader for path; loaded is what the file contained at startup
+func NewReloader(path string, pe *engine.ProximityEngine, loaded Settings) *Reloader {
+	return &Reloader{path: path, engine: pe, loaded: loaded}
+}
+
+// Reload re-reads the file and applies the settings that changed since the
+// last load. It returns the applied settings and the changed ones that only
+// take effect after a restart. An invalid file leaves everything unchanged.
+func (r *Reloader) Reload() (applied, restart []string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next := Default()
+	if err := Load(r.path, &next); err != nil {
+		return nil, nil, err
+	}
+	prev := r.loaded
+	r.loaded = next
+
+	if next.Capture.TargetFPS != prev.Capture.TargetFPS {
+		r.engine.SetTargetFPS(next.Capture.TargetFPS)
+		applied = append(applied, "capture.target_fps")
+	}
+	if next.Capture.Sensitivity != prev.Capture.Sensitivity {
+		r.engine.SetSensitivity(next.Capture.Sensitivity)
+		applied = append(applied, "capture.sensitivity")
+	}
+	if next.Capture.Algorithm != prev.Capture.Algorithm {
+		r.engine.SetMotionAlgorithm(next.Capture.Algorithm)
+		applied = append(applied, "capture.algorithm")
+	}
+	if next.Capture.Processing != prev.Capture.Processing {
+		r.engine.SetProcessingConfig(next.Capture.Processing)
+		applied = append(applied, "capture.processing")
+	}
+	if next.Capture.Tiling != prev.Capture.Tiling {
+		r.engine.SetTilingConfig(next.Capture.Tiling)
+		applied = append(applied, "capture.tiling")
+	}
+	if next.Capture.Overflow != prev.Capture.Overflow {
+		r.engine.SetOverflowConfig(next.Capture.Overflow)
+		applied = append(applied, "capture.overflow")
+	}
+	if next.Capture.Stereo != prev.Capture.Stereo {
+		r.engine.SetStereoConfig(next.Capture.Stereo)
+		applied = append(applied, "capture.stereo")
+	}
+	if !reflect.DeepEqual(next.Categories, prev.Categories) {
+		r.engine.SetDistanceBands(next.Categories)
+		applied = append(applied, "categories")
+	}
+	if next.Camera != prev.Camera {
+		r.engine.SetCameraConfig(next.Camera)
+		applied = append(applied, "camera")
+	}
+	if next.Detection != prev.Detection {
+		r.engine.SetDetectionConfig(next.Detection)
+		applied = append(applied, "detection")
+	}
+	if next.Calibration != prev.Calibration {
+		r.engine.SetCalibrationConfig(next.Calibration)
+		applied = append(applied, "calibration")
+	}
+	if next.Feedback != prev.Feedback {
+		r.engine.SetFeedbackConfig(next.Feedback)
+		applied = append(applied, "feedback")
+	}
+	if next.Tracking != prev.Tracking {
+		r.engine.SetTrackingConfig(next.Tracking)
+		applied = append(applied, "tracking")
+	}
+	if next.Crowd != prev.Crowd {
+		r.engine.SetCrowdConfig(next.Crowd)
+		applied = append(applied, "crowd")
+	}
+	if next.Scene != prev.Scene {
+		r.engine.SetSceneConfig(next.Scene)
+		applied = append(applied, "scene")
+	}
+	if next.Mirror != prev.Mirror {
+		r.engine.SetMirrorConfig(next.Mirror)
+		applied = append(applied, "mirror")
+	}
+	if next.Zones.ExitTimeout != prev.Zones.ExitTimeout {
+		r.engine.SetZoneExitTimeout(next.Zones.ExitTimeout)
+		applied = append(applied, "zones.exit_timeout")
+	}
+	if !reflect.DeepEqual(next.Masks, prev.Masks) {
+		r.engine.SetMasks(next.Masks)
+		applied = append(applied, "masks")
+	}
+	if !reflect.DeepEqual(next.Profiles, prev.Profiles) {
+		r.engine.SetColorProfiles(next.Profiles)
+		applied = append(applied, "color_profiles")
+	}
+	if next.OCR != prev.OCR {
+		r.engine.SetOCRConfig(next.OCR)
+		applied = append(applied, "ocr")
+	}
+	if !reflect.DeepEqual(next.Players, prev.Players) {
+		r.engine.SetPlayerRules(next.Players)
+		applied = append(applied, "player_rules")
+	}
+	if !reflect.DeepEqual(next.Rules, prev.Rules) {
+		r.engine.SetRules(next.Rules)
+		applied = append(applied, "rules")
+	}
+	if next.Alerts != prev.Alerts {
+		r.engine.SetAlertConfig(next.Alerts)
+		applied = append(applied, "alerts")
+	}
+	if next.Budget != prev.Budget {
+		r.engine.SetBudgetConfig(next.Budget)
+		applied = append(applied, "budget")
+	}
+	if next.Preview.Enabled != prev.Preview.Enabled {
+		r.engine.Preview().SetEnabled(next.Preview.Enabled)
+		applied = append(applied, "preview.enabled")
+	}
+	if next.Privacy != prev.Privacy {
+		r.engine.SetPrivacyConfig(next.Privacy)
+		applied = append(applied, "privacy")
+	}
+	if next.Log.Level != prev.Log.Level {
+		logging.SetLevel(next.Log.Level)
+		applied = append(applied, "log.level")
+	}
+
+	// Compare the rest with the live fields masked out
+	prevPreview, nextPreview := prev.Preview, next.Preview
+	prevPreview.Enabled, nextPreview.Enabled = false, false
+	prevLog, nextLog := prev.Log, next.Log
+	prevLog.Level, nextLog.Level = "", ""
+
+	sections := []struct {
+		name       string
+		prev, next interface{}
+	}{
+		{"capture.watchdog", prev.Capture.Watchdog, next.Capture.Watchdog},
+		{"capture.backend", prev.Capture.Backend, next.Capture.Backend},
+		{"capture.dxgi", prev.Capture.DXGI, next.Capture.DXGI},
+		{"capture.ndi", prev.Capture.NDI, next.Capture.NDI},
+		{"capture.spout", prev.Capture.Spout, next.Capture.Spout},
+		{"capture.network", prev.Capture.Network, next.Capture.Network},
+		{"capture.quest", prev.Capture.Quest, next.Capture.Quest},
+		{"capture.linux", prev.Capture.Linux, next.Capture.Linux},
+		{"capture.macos", prev.Capture.MacOS, next.Capture.MacOS},
+		{"performance", prev.Performance, next.Performance},
+		{"capture.follow_process", prev.Capture.FollowProcess, next.Capture.FollowProcess},
+		{"capture.focus_only", prev.Capture.FocusOnly, next.Capture.FocusOnly},
+		{"capture.sources", prev.Capture.Sources, next.Capture.Sources},
+		{"script", prev.Script, next.Script},
+		{"server", prev.Server, next.Server},
+		{"tls", prev.TLS, next.TLS},
+		{"log", prevLog, nextLog},
+		{"preview", prevPreview, nextPreview},
+		{"clips", prev.Clips, next.Clips},
+		{"stats", prev.Stats, next.Stats},
+		{"integrations.osc", prev.Integrations.OSC, next.Integrations.OSC},
+		{"integrations.haptics", prev.Integrations.Haptics, next.Integrations.Haptics},
+		{"integrations.notifications", prev.Integrations.Notifications, next.Integrations.Notifications},
+		{"integrations.webhooks", prev.Integrations.Webhooks, next.Integrations.Webhooks},
+		{"integrations.mqtt", prev.Integrations.MQTT, next.Integrations.MQTT},
+		{"integrations.history", prev.Integrations.History, next.Integrations.History},
+		{"integrations.export", prev.Integrations.Export, next.Integrations.Export},
+		{"integrations.grpc", prev.Integrations.GRPC, next.Integrations.GRPC},
+		{"integrations.tray", prev.Integrations.Tray, next.Integrations.Tray},
+		{"integrations.hotkeys", prev.Integrations.Hotkeys, next.Integrations.Hotkeys},
+		{"integrations.speech", prev.Integrations.Speech, next.Integrations.Speech},
+		{"integrations.audio", prev.Integrations.Audio, next.Integrations.Audio},
+		{"integrations.toasts", prev.Integrations.Toasts, next.Integrations.Toasts},
+		{"integrations.discord", prev.Integrations.Discord, next.Integrations.Discord},
+		{"integrations.lighting", prev.Integrations.Lighting, next.Integrations.Lighting},
+		{"integrations.intiface", prev.Integrations.Intiface, next.Integrations.Intiface},
+		{"integrations.overlay", prev.Integrations.Overlay, next.Integrations.Overlay},
+		{"integrations.controller_haptics", prev.Integrations.Controllers, next.Integrations.Controllers},
+		{"integrations.vrchat", prev.Integrations.VRChat, next.Integrations.VRChat},
+		{"integrations.vrchat_log", prev.Integrations.VRChatLog, next.Integrations.VRChatLog},
+		{"integrations.tracing", prev.Integrations.Tracing, next.Integrations.Tracing},
+		{"integrations.statsd", prev.Integrations.StatsD, next.Integrations.StatsD},
+	}
+	for _, section := range sections {
+		if !reflect.DeepEqual(section.prev, section.next) {
+			restart = append(restart, section.name)
+		}
+	}
+
+	configLog.Info("Config reloaded", "path", r.path, "applied", applied)
+	if len(restart) > 0 {
+		configLog.Warn("Changed settings take effect after a restart", "settings", restart)
+	}
+	return applied, restart, nil
+}
+
+// Watch reloads the file whenever it changes until ctx is cancelled. The
+// directory is watched so editors that save by renaming are picked up.
+func (r *Reloader) Watch(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("watch config: %w", err)
+	}
+	path, err := filepath.Abs(r.path)
+	if err != nil {
+		watcher.Close()
+		return fmt.Errorf("watch config: %w", err)
+	}
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return fmt.Errorf("watch config: %w", err)
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		timer := time.NewTimer(reloadDelay)
+		timer.Stop()
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != path || !event.Has(fsnotify.Write|fsnotify.Create|fsnotify.Rename) {
+					continue
+				}
+				timer.Reset(reloadDelay)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				configLog.Warn("Config watch error", "error", err)
+			case <-timer.C:
+				if _, _, err := r.Reload(); err != nil {
+					configLog.Error("Config reload failed, keeping previous settings", "error", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// ServeHTTP handles POST /config/reload
+func (r *Reloader) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	applied, restart, err := r.Reload()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result := reloadResult{Applied: applied, RestartRequired: restart}
+	if result.Applied == nil {
+		result.Applied = []string{}
+	}
+	if result.RestartRequired == nil {
+		result.RestartRequired = []string{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
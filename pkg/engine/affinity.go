@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+)
+
+// Process priorities, from the OS default down
+const (
+	PriorityNormal      = "normal"
+	PriorityBelowNormal = "below_normal"
+	PriorityIdle        = "idle"
+)
+
+// PerformanceConfig keeps the engine out of the game's way
+type PerformanceConfig struct {
+	Cores    []int  `json:"cores" yaml:"cores"`       // CPU cores the capture and detection threads run on; empty lets the OS choose
+	Priority string `json:"priority" yaml:"priority"` // Process priority: normal, below_normal, or idle
+}
+
+// DefaultPerformanceConfig leaves scheduling to the OS
+func DefaultPerformanceConfig() PerformanceConfig {
+	return PerformanceConfig{Priority: PriorityNormal}
+}
+
+// Validate checks the cores exist and the priority is known
+func (c PerformanceConfig) Validate() error {
+	for _, core := range c.Cores {
+		if core < 0 || core >= runtime.NumCPU() {
+			return fmt.Errorf("core %d does not exist; this machine has cores 0-%d", core, runtime.NumCPU()-1)
+		}
+	}
+	if !slices.Contains([]string{PriorityNormal, PriorityBelowNormal, PriorityIdle}, c.Priority) {
+		return fmt.Errorf("priority must be %q, %q, or %q", PriorityNormal, PriorityBelowNormal, PriorityIdle)
+	}
+	return nil
+}
+
+// SetPerformanceConfig pins the capture and detection threads to cores and
+// lowers the process priority; call before Start
+func (pe *ProximityEngine) SetPerformanceConfig(config PerformanceConfig) {
+	pe.performance = config
+	if config.Priority != PriorityNormal {
+		if err := setProcessPriority(config.Priority); err != nil {
+			engineLog.Warn("Process priority unchanged", "priority", config.Priority, "error", err)
+		} else {
+			engineLog.Info("Process priority lowered", "priority", config.Priority)
+		}
+	}
+	if len(config.Cores) > 0 {
+		engineLog.Info("Capture and detection pinned", "cores", config.Cores)
+	}
+}
+
+// pinThread locks the calling goroutine to its OS thread and restricts that
+// thread to the configured cores. The thread exits with the goroutine, so
+// the pinning never leaks to other goroutines.
+func (pe *ProximityEngine) pinThread() {
+	if len(pe.performance.Cores) == 0 {
+		return
+	}
+	runtime.LockOSThread()
+	if err := setThreadAffinity(pe.performance.Cores); err != nil {
+		engineLog.Warn("Thread affinity unchanged", "error", err)
+	}
+}
@@ -0,0 +1,40 @@
+//go:build linux
+
+package engine
+
+import (
+	"os"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// setThreadAffinity restricts the current thread to cores
+func setThreadAffinity(cores []int) error {
+	var set unix.CPUSet
+	for _, core := range cores {
+		set.Set(core)
+	}
+	return unix.SchedSetaffinity(0, &set)
+}
+
+// setProcessPriority renices every thread of the process. Linux keeps a nice
+// value per thread; threads started later inherit their creator's.
+func setProcessPriority(priority string) error {
+	nice := 10
+	if priority == PriorityIdle {
+		nice = 19
+	}
+	tasks, err := os.ReadDir("/proc/self/task")
+	if err != nil {
+		return unix.Setpriority(unix.PRIO_PROCESS, 0, nice)
+	}
+	for _, task := range tasks {
+		if tid, err := strconv.Atoi(task.Name()); err == nil {
+			if err := unix.Setpriority(unix.PRIO_PROCESS, tid, nice); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
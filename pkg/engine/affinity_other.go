@@ -0,0 +1,15 @@
+//go:build !windows && !linux
+
+package engine
+
+import "errors"
+
+// setThreadAffinity is unsupported; macOS offers only affinity hints
+func setThreadAffinity(cores []int) error {
+	return errors.New("thread affinity is only supported on Windows and Linux")
+}
+
+// setProcessPriority is unsupported
+func setProcessPriority(priority string) error {
+	return errors.New("process priority is only supported on Windows and Linux")
+}
@@ -0,0 +1,30 @@
+//go:build windows
+
+package engine
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+var procSetThreadAffinityMask = windows.NewLazySystemDLL("kernel32.dll").NewProc("SetThreadAffinityMask")
+
+// setThreadAffinity restricts the current thread to cores
+func setThreadAffinity(cores []int) error {
+	var mask uintptr
+	for _, core := range cores {
+		mask |= 1 << uint(core)
+	}
+	if previous, _, err := procSetThreadAffinityMask.Call(uintptr(windows.CurrentThread()), mask); previous == 0 {
+		return err
+	}
+	return nil
+}
+
+// setProcessPriority moves the process to a lower priority class
+func setProcessPriority(priority string) error {
+	class := uint32(windows.BELOW_NORMAL_PRIORITY_CLASS)
+	if priority == PriorityIdle {
+		class = windows.IDLE_PRIORITY_CLASS
+	}
+	return windows.SetPriorityClass(windows.CurrentProcess(), class)
+}
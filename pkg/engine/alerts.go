@@ -0,0 +1,141 @@
+package engine
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// QuietHours is a daily window, in local time, without alerts
+type QuietHours struct {
+	Start string `json:"start" yaml:"start"` // "23:00"; empty disables
+	End   string `json:"end" yaml:"end"`     // "07:00"; before Start spans midnight
+}
+
+// Validate checks that both ends are HH:MM, or both are empty
+func (q QuietHours) Validate() error {
+	if q.Start == "" && q.End == "" {
+		return nil
+	}
+	for _, clock := range []string{q.Start, q.End} {
+		if _, err := time.Parse("15:04", clock); err != nil {
+			return fmt.Errorf("start and end must both be HH:MM, got %q", clock)
+		}
+	}
+	return nil
+}
+
+// Active reports whether now falls inside the window
+func (q QuietHours) Active(now time.Time) bool {
+	start, err1 := time.Parse("15:04", q.Start)
+	end, err2 := time.Parse("15:04", q.End)
+	if err1 != nil || err2 != nil || start.Equal(end) {
+		return false
+	}
+	minute := now.Hour()*60 + now.Minute()
+	from, to := start.Hour()*60+start.Minute(), end.Hour()*60+end.Minute()
+	if from < to {
+		return minute >= from && minute < to
+	}
+	return minute >= from || minute < to
+}
+
+// AlertConfig limits how often alert hooks (notifications, haptics) fire.
+// Suppressed events still reach every other hook, marked muted.
+type AlertConfig struct {
+	Cooldown   time.Duration `json:"cooldown" yaml:"cooldown"` // Suppress repeats of the same event type and category for this long; 0 disables
+	QuietHours QuietHours    `json:"quiet_hours" yaml:"quiet_hours"`
+}
+
+// DefaultAlertConfig alerts on every event at any time
+func DefaultAlertConfig() AlertConfig {
+	return AlertConfig{}
+}
+
+// Validate checks the cooldown and quiet hours
+func (c AlertConfig) Validate() error {
+	if c.Cooldown < 0 {
+		return fmt.Errorf("cooldown must not be negative")
+	}
+	if err := c.QuietHours.Validate(); err != nil {
+		return fmt.Errorf("quiet_hours: %w", err)
+	}
+	return nil
+}
+
+// SetAlertConfig replaces the cooldown and quiet hours; safe to call while running
+func (pe *ProximityEngine) SetAlertConfig(config AlertConfig) {
+	pe.alertConfig.Store(&config)
+	detectLog.Info("Alert settings set", "cooldown", config.Cooldown, "quiet_start", config.QuietHours.Start, "quiet_end", config.QuietHours.End)
+}
+
+// AlertConfig returns the cooldown and quiet hours
+func (pe *ProximityEngine) AlertConfig() AlertConfig {
+	if config := pe.alertConfig.Load(); config != nil {
+		return *config
+	}
+	return DefaultAlertConfig()
+}
+
+// QuietHoursActive reports whether alerts are currently held for quiet hours
+func (pe *ProximityEngine) QuietHoursActive() bool {
+	return pe.AlertConfig().QuietHours.Active(time.Now())
+}
+
+// SetHapticsEnabled switches the haptic hooks on or off
+func (pe *ProximityEngine) SetHapticsEnabled(enabled bool) {
+	pe.hapticsOff.Store(!enabled)
+	detectLog.Info("Haptics switched", "enabled", enabled)
+}
+
+// HapticsEnabled reports whether haptic hooks run
+func (pe *ProximityEngine) HapticsEnabled() bool {
+	return !pe.hapticsOff.Load()
+}
+
+// OnHapticDetections registers an alert detection hook for outputs
+// touching the player. It sees empty batches while haptics are switched off.
+func (pe *ProximityEngine) OnHapticDetections(hook func([]Detection)) {
+	pe.OnAlertDetections(func(detections []Detection) {
+		if !pe.HapticsEnabled() {
+			detections = nil
+		}
+		hook(detections)
+	})
+}
+
+// OnHapticAlert registers an alert event hook that also stops while
+// haptics are switched off
+func (pe *ProximityEngine) OnHapticAlert(hook func(ProximityEvent)) {
+	pe.OnAlert(func(event ProximityEvent) {
+		if pe.HapticsEnabled() {
+			hook(event)
+		}
+	})
+}
+
+// alertCooldowns remembers when each kind of alert last went out
+type alertCooldowns struct {
+	mu   sync.Mutex
+	last map[string]time.Time // Keyed by event type, category, and rule
+}
+
+// cooling reports whether an alert of the same kind went out within
+// cooldown, and otherwise records this one
+func (c *alertCooldowns) cooling(event ProximityEvent, cooldown time.Duration, now time.Time) bool {
+	if cooldown <= 0 {
+		return false
+	}
+	key := event.Type + "|" + event.Category + "|" + event.Rule
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if now.Sub(c.last[key]) < cooldown {
+		return true
+	}
+	if c.last == nil {
+		c.last = make(map[string]time.Time)
+	}
+	c.last[key] = now
+	return false
+}
@@ -0,0 +1,31 @@
+package engine
+
+import (
+	"vrchat-proximity/pkg/capture"
+)
+
+// motionDetector is the selected motion algorithm
+type motionDetector struct {
+	algorithm string
+	detector  capture.Detector
+}
+
+// SetMotionAlgorithm switches to one of capture.Algorithms; safe to call
+// while running. Background models start learning from the next frame.
+func (pe *ProximityEngine) SetMotionAlgorithm(algorithm string) error {
+	detector, err := capture.NewDetector(algorithm)
+	if err != nil {
+		return err
+	}
+	pe.motion.Store(&motionDetector{algorithm: algorithm, detector: detector})
+	detectLog.Info("Motion algorithm set", "algorithm", algorithm)
+	return nil
+}
+
+// MotionAlgorithm returns the selected motion algorithm
+func (pe *ProximityEngine) MotionAlgorithm() string {
+	if motion := pe.motion.Load(); motion != nil {
+		return motion.algorithm
+	}
+	return capture.AlgorithmFrameDiff
+}
@@ -0,0 +1,200 @@
+package engine
+
+import (
+	"errors"
+	"time"
+)
+
+// Budget degradation steps, applied in order while over budget
+const (
+	degradeFPS = iota + 1
+	degradeResolution
+	degradePreview
+)
+
+// Reasons a degraded event reports
+const (
+	BudgetMemory    = "memory"
+	BudgetCPU       = "cpu"
+	BudgetRecovered = "recovered"
+)
+
+// BudgetConfig limits the engine's own memory and CPU use. While a limit is
+// exceeded for Sustain, the engine degrades one step at a time: half the
+// FPS, then half the processing resolution, then no preview. Each step is
+// undone after twice Sustain comfortably under the limits.
+type BudgetConfig struct {
+	MaxMemoryMB int           `json:"max_memory_mb" yaml:"max_memory_mb"` // Resident memory limit; 0 disables
+	MaxCPU      int           `json:"max_cpu" yaml:"max_cpu"`             // CPU limit in percent, 100 being one full core; 0 disables
+	Sustain     time.Duration `json:"sustain" yaml:"sustain"`             // How long a limit must be exceeded before each step
+}
+
+// DefaultBudgetConfig enforces no limits
+func DefaultBudgetConfig() BudgetConfig {
+	return BudgetConfig{Sustain: 30 * time.Second}
+}
+
+// Validate checks the limits
+func (c BudgetConfig) Validate() error {
+	if c.MaxMemoryMB < 0 || c.MaxCPU < 0 {
+		return errors.New("max_memory_mb and max_cpu must not be negative")
+	}
+	if c.Sustain < monitorInterval {
+		return errors.New("sustain must be at least 5s")
+	}
+	return nil
+}
+
+// enabled reports whether any limit is set
+func (c BudgetConfig) enabled() bool {
+	return c.MaxMemoryMB > 0 || c.MaxCPU > 0
+}
+
+// SetBudgetConfig replaces the resource budget; safe to call while running
+func (pe *ProximityEngine) SetBudgetConfig(config BudgetConfig) {
+	pe.budgetConfig.Store(&config)
+	engineLog.Info("Resource budget set", "max_memory_mb", config.MaxMemoryMB, "max_cpu", config.MaxCPU, "sustain", config.Sustain)
+}
+
+// BudgetConfig returns the resource budget
+func (pe *ProximityEngine) BudgetConfig() BudgetConfig {
+	if config := pe.budgetConfig.Load(); config != nil {
+		return *config
+	}
+	return DefaultBudgetConfig()
+}
+
+// DegradedLevel returns how many degradation steps are applied, 0 when
+// the engine runs as configured
+func (pe *ProximityEngine) DegradedLevel() int {
+	return int(pe.degraded.Load())
+}
+
+// budgetEnforcer tracks how long the engine has been over or under budget
+// and what each degradation step replaced. It is only touched by
+// monitorPerformance.
+type budgetEnforcer struct {
+	overSince  time.Time
+	underSince time.Time
+
+	// Settings before and after each step; a step is only undone if nothing
+	// else changed the setting since
+	fps, degradedFPS int
+	processing       ProcessingConfig
+	degradedScale    ProcessingConfig
+	preview          bool
+}
+
+// enforceBudget compares the latest usage sample with the budget and
+// applies or undoes one degradation step when it has been over or under
+// for long enough
+func (pe *ProximityEngine) enforceBudget(b *budgetEnforcer, now time.Time) {
+	config := pe.BudgetConfig()
+	level := pe.DegradedLevel()
+	if !config.enabled() {
+		if level > 0 {
+			pe.restoreBudget(b)
+			pe.reportDegraded(0, BudgetRecovered, now)
+		}
+		return
+	}
+
+	memoryMB, cpu := pe.memoryUsage.Load()/1024/1024, pe.cpuUsage.Load()
+	var reason string
+	switch {
+	case config.MaxMemoryMB > 0 && memoryMB > int64(config.MaxMemoryMB):
+		reason = BudgetMemory
+	case config.MaxCPU > 0 && cpu > int64(config.MaxCPU):
+		reason = BudgetCPU
+	}
+
+	// Only recover with headroom so one step doesn't flap on and off
+	under := (config.MaxMemoryMB == 0 || memoryMB*5 < int64(config.MaxMemoryMB)*4) &&
+		(config.MaxCPU == 0 || cpu*5 < int64(config.MaxCPU)*4)
+
+	switch {
+	case reason != "":
+		b.underSince = time.Time{}
+		if b.overSince.IsZero() {
+			b.overSince = now
+		}
+		if level < degradePreview && now.Sub(b.overSince) >= config.Sustain {
+			pe.degrade(b, level+1, reason, now)
+			b.overSince = now
+		}
+	case under && level > 0:
+		b.overSince = time.Time{}
+		if b.underSince.IsZero() {
+			b.underSince = now
+		}
+		if now.Sub(b.underSince) >= 2*config.Sustain {
+			pe.undegrade(b, level)
+			pe.reportDegraded(level-1, BudgetRecovered, now)
+			b.underSince = now
+		}
+	default:
+		b.overSince, b.underSince = time.Time{}, time.Time{}
+	}
+}
+
+// degrade applies one step and reports it
+func (pe *ProximityEngine) degrade(b *budgetEnforcer, step int, reason string, now time.Time) {
+	switch step {
+	case degradeFPS:
+		b.fps = pe.TargetFPS()
+		b.degradedFPS = max(5, b.fps/2)
+		pe.SetTargetFPS(b.degradedFPS)
+	case degradeResolution:
+		b.processing = pe.ProcessingConfig()
+		width, height := b.processing.Width, b.processing.Height
+		if width == 0 && height == 0 {
+			width, height = pe.FrameSize()
+		}
+		b.degradedScale = ProcessingConfig{Width: max(64, width/2), Height: max(36, height/2)}
+		pe.SetProcessingConfig(b.degradedScale)
+	case degradePreview:
+		b.preview = pe.preview.Enabled()
+		pe.preview.SetEnabled(false)
+	}
+	pe.degraded.Store(int32(step))
+	engineLog.Warn("Over resource budget, degrading", "reason", reason, "level", step,
+		"memory_mb", pe.memoryUsage.Load()/1024/1024, "cpu", pe.cpuUsage.Load())
+	pe.reportDegraded(step, reason, now)
+}
+
+// undegrade undoes one step, leaving settings changed since it was applied alone
+func (pe *ProximityEngine) undegrade(b *budgetEnforcer, step int) {
+	switch step {
+	case degradeFPS:
+		if pe.TargetFPS() == b.degradedFPS {
+			pe.SetTargetFPS(b.fps)
+		}
+	case degradeResolution:
+		if pe.ProcessingConfig() == b.degradedScale {
+			pe.SetProcessingConfig(b.processing)
+		}
+	case degradePreview:
+		if !pe.preview.Enabled() {
+			pe.preview.SetEnabled(b.preview)
+		}
+	}
+	pe.degraded.Store(int32(step - 1))
+	engineLog.Info("Back within resource budget", "level", step-1)
+}
+
+// restoreBudget undoes every step, e.g. when the budget is turned off or
+// the engine stops
+func (pe *ProximityEngine) restoreBudget(b *budgetEnforcer) {
+	for step := pe.DegradedLevel(); step > 0; step-- {
+		pe.undegrade(b, step)
+	}
+	*b = budgetEnforcer{}
+}
+
+// reportDegraded queues a degraded event for the event hooks
+func (pe *ProximityEngine) reportDegraded(level int, reason string, now time.Time) {
+	select {
+	case pe.eventChan <- ProximityEvent{Type: EventDegraded, Timestamp: now.Unix(), Level: level, Reason: reason}:
+	default:
+	}
+}
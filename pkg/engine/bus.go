@@ -0,0 +1,206 @@
+package engine
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Queue sizes for bus subscribers: outputs that show or send every batch
+// keep a longer backlog than ones that only act on the latest state
+const (
+	BusBuffer       = 64
+	BusBufferLatest = 2
+)
+
+// Subscriber is an output on the event bus. Either callback may be nil.
+type Subscriber struct {
+	Detections func([]Detection)
+	Event      func(ProximityEvent)
+
+	// Alerts delivers only unmuted events and the alert view of each batch,
+	// as OnAlert and OnAlertDetections do; Haptics additionally stops both
+	// while haptics are switched off
+	Alerts  bool
+	Haptics bool
+}
+
+// BusStats reports one subscriber's queue
+type BusStats struct {
+	Queued    int   `json:"queued"`
+	Delivered int64 `json:"delivered"`
+	Dropped   int64 `json:"dropped"`
+}
+
+// busItem is a queued detection batch or event
+type busItem struct {
+	detections []Detection
+	event      *ProximityEvent
+}
+
+// Subscription is one output's queue, drained by its own goroutine so a slow
+// output only holds up itself
+type Subscription struct {
+	name      string
+	sub       Subscriber
+	limit     int
+	mu        sync.Mutex
+	queue     []busItem
+	ready     chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	delivered atomic.Int64
+	dropped   atomic.Int64
+}
+
+// eventBus fans detection batches and events out to the subscriptions
+type eventBus struct {
+	mu   sync.RWMutex
+	subs []*Subscription
+}
+
+// Subscribe adds an output with a queue of up to buffer items, delivered in
+// order on the subscription's own goroutine. When the queue is full the
+// oldest queued batch makes room, so events are only lost to a queue full
+// of events. Panics in the callbacks are recovered and counted as broadcast
+// restarts.
+func (pe *ProximityEngine) Subscribe(name string, buffer int, sub Subscriber) *Subscription {
+	s := &Subscription{
+		name:  name,
+		sub:   sub,
+		limit: max(1, buffer),
+		ready: make(chan struct{}, 1),
+		done:  make(chan struct{}),
+	}
+	pe.bus.mu.Lock()
+	pe.bus.subs = append(pe.bus.subs, s)
+	pe.bus.mu.Unlock()
+	go s.run(pe)
+	return s
+}
+
+// Unsubscribe stops delivering to s and discards its queue
+func (pe *ProximityEngine) Unsubscribe(s *Subscription) {
+	pe.bus.mu.Lock()
+	for i, sub := range pe.bus.subs {
+		if sub == s {
+			pe.bus.subs = append(pe.bus.subs[:i], pe.bus.subs[i+1:]...)
+			break
+		}
+	}
+	pe.bus.mu.Unlock()
+	s.closeOnce.Do(func() { close(s.done) })
+}
+
+// BusStats returns the queue of each subscriber by name
+func (pe *ProximityEngine) BusStats() map[string]BusStats {
+	pe.bus.mu.RLock()
+	defer pe.bus.mu.RUnlock()
+	stats := make(map[string]BusStats, len(pe.bus.subs))
+	for _, s := range pe.bus.subs {
+		s.mu.Lock()
+		queued := len(s.queue)
+		s.mu.Unlock()
+		current := stats[s.name]
+		stats[s.name] = BusStats{
+			Queued:    current.Queued + queued,
+			Delivered: current.Delivered + s.delivered.Load(),
+			Dropped:   current.Dropped + s.dropped.Load(),
+		}
+	}
+	return stats
+}
+
+// publishDetections queues a processed batch for every subscriber
+func (pe *ProximityEngine) publishDetections(detections []Detection) {
+	var alerts []Detection
+	alertsDone := false
+	haptics := pe.HapticsEnabled()
+
+	pe.bus.mu.RLock()
+	defer pe.bus.mu.RUnlock()
+	for _, s := range pe.bus.subs {
+		if s.sub.Detections == nil {
+			continue
+		}
+		batch := detections
+		if s.sub.Alerts || s.sub.Haptics {
+			if !alertsDone {
+				alerts, alertsDone = pe.alertDetections(detections), true
+			}
+			batch = alerts
+			if s.sub.Haptics && !haptics {
+				batch = nil
+			}
+		}
+		s.push(busItem{detections: batch})
+	}
+}
+
+// publishToBus queues an event for every subscriber that wants it
+func (pe *ProximityEngine) publishToBus(event ProximityEvent) {
+	haptics := pe.HapticsEnabled()
+
+	pe.bus.mu.RLock()
+	defer pe.bus.mu.RUnlock()
+	for _, s := range pe.bus.subs {
+		if s.sub.Event == nil || ((s.sub.Alerts || s.sub.Haptics) && event.Muted) || (s.sub.Haptics && !haptics) {
+			continue
+		}
+		event := event
+		s.push(busItem{event: &event})
+	}
+}
+
+// push queues an item, dropping the oldest batch, or failing that the
+// oldest event, when the queue is full
+func (s *Subscription) push(item busItem) {
+	s.mu.Lock()
+	if len(s.queue) >= s.limit {
+		drop := 0
+		for i, queued := range s.queue {
+			if queued.event == nil {
+				drop = i
+				break
+			}
+		}
+		s.queue = append(s.queue[:drop], s.queue[drop+1:]...)
+		s.dropped.Add(1)
+	}
+	s.queue = append(s.queue, item)
+	s.mu.Unlock()
+
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+// run delivers queued items until the subscription is closed
+func (s *Subscription) run(pe *ProximityEngine) {
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-s.ready:
+		}
+		for {
+			s.mu.Lock()
+			if len(s.queue) == 0 {
+				s.mu.Unlock()
+				break
+			}
+			item := s.queue[0]
+			s.queue = s.queue[1:]
+			s.mu.Unlock()
+
+			pe.runRecovered(SubsystemBroadcast, func() {
+				if item.event != nil {
+					s.sub.Event(*item.event)
+				} else {
+					s.sub.Detections(item.detections)
+				}
+			})
+			s.delivered.Add(1)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// calibrationBins splits raw confidence into this many equal ranges, each
+// learning its own probability
+const calibrationBins = 10
+
+// CalibrationConfig configures the mapping from the detector's raw
+// confidence to the probability that a detection is a real person
+type CalibrationConfig struct {
+	Enabled bool    `json:"enabled" yaml:"enabled"` // Replace confidence with the calibrated probability before filtering
+	Path    string  `json:"path" yaml:"path"`       // JSON file the learned labels are kept in; empty keeps them for this run only
+	Prior   float32 `json:"prior" yaml:"prior"`     // Labels a confidence range needs before they outweigh the raw confidence
+
+	// Calibrated probabilities are scaled per detection type, e.g. to trust
+	// color matches over bare motion
+	MotionWeight float32 `json:"motion_weight" yaml:"motion_weight"`
+	ColorWeight  float32 `json:"color_weight" yaml:"color_weight"`
+	ShapeWeight  float32 `json:"shape_weight" yaml:"shape_weight"`
+}
+
+// DefaultCalibrationConfig keeps raw confidences, learning from feedback
+// into the working directory, with ten labels per range to move the curve
+func DefaultCalibrationConfig() CalibrationConfig {
+	return CalibrationConfig{
+		Path:         "proximity_calibration.json",
+		Prior:        10,
+		MotionWeight: 1,
+		ColorWeight:  1,
+		ShapeWeight:  1,
+	}
+}
+
+// Validate checks the prior and weights
+func (c CalibrationConfig) Validate() error {
+	if c.Prior <= 0 {
+		return fmt.Errorf("prior must be positive")
+	}
+	if c.MotionWeight < 0 || c.ColorWeight < 0 || c.ShapeWeight < 0 {
+		return fmt.Errorf("weights must not be negative")
+	}
+	return nil
+}
+
+// weight returns the scale for a detection type
+func (c CalibrationConfig) weight(detectionType string) float32 {
+	switch detectionType {
+	case "motion":
+		return c.MotionWeight
+	case "color":
+		return c.ColorWeight
+	case "shape":
+		return c.ShapeWeight
+	}
+	return 1
+}
+
+// CalibrationBin is the feedback on one range of raw confidence
+type CalibrationBin struct {
+	Min        float32 `json:"min"`
+	Max        float32 `json:"max"`
+	True       int     `json:"true"`       // Confirmed detections
+	False      int     `json:"false"`      // False positives
+	Calibrated float32 `json:"calibrated"` // Probability at the middle of the range, before weighting
+}
+
+// calibrator keeps the label counts per detection type
+type calibrator struct {
+	config atomic.Pointer[CalibrationConfig]
+	mu     sync.Mutex
+	counts map[string]*[calibrationBins][2]int // True and false labels per bin
+}
+
+// SetCalibrationConfig replaces the calibration settings; safe to call while running
+func (pe *ProximityEngine) SetCalibrationConfig(config CalibrationConfig) {
+	pe.calibration.config.Store(&config)
+	detectLog.Info("Confidence calibration set", "enabled", config.Enabled, "path", config.Path, "prior", config.Prior)
+}
+
+// CalibrationConfig returns the calibration settings
+func (pe *ProximityEngine) CalibrationConfig() CalibrationConfig {
+	if config := pe.calibration.config.Load(); config != nil {
+		return *config
+	}
+	return DefaultCalibrationConfig()
+}
+
+// Calibration returns the learned curve of each detection type with feedback
+func (pe *ProximityEngine) Calibration() map[string][]CalibrationBin {
+	prior := pe.CalibrationConfig().Prior
+	c := &pe.calibration
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	curves := make(map[string][]CalibrationBin, len(c.counts))
+	for detectionType, counts := range c.counts {
+		bins := make([]CalibrationBin, calibrationBins)
+		for i := range bins {
+			low, high := float32(i)/calibrationBins, float32(i+1)/calibrationBins
+			bins[i] = CalibrationBin{
+				Min:        low,
+				Max:        high,
+				True:       counts[i][0],
+				False:      counts[i][1],
+				Calibrated: calibrated((low+high)/2, counts[i], prior),
+			}
+		}
+		curves[detectionType] = bins
+	}
+	return curves
+}
+
+// calibrated blends the labels of a bin with raw, which counts as prior
+// labels of its own: with no feedback it is unchanged, and with plenty it
+// becomes the fraction of detections that were real
+func calibrated(raw float32, counts [2]int, prior float32) float32 {
+	return (float32(counts[0]) + prior*raw) / (float32(counts[0]+counts[1]) + prior)
+}
+
+// calibrationBin returns the bin of a raw confidence
+func calibrationBin(raw float32) int {
+	return min(max(int(raw*calibrationBins), 0), calibrationBins-1)
+}
+
+// rawConfidence returns the detector's own confidence, which is confidence
+// itself unless calibration moved it to raw
+func rawConfidence(confidence, raw float32) float32 {
+	if raw > 0 {
+		return raw
+	}
+	return confidence
+}
+
+// calibrate replaces each detection's confidence with its calibrated,
+// weighted probability, keeping the detector's own in RawConfidence
+func (pe *ProximityEngine) calibrate(detections []Detection) {
+	config := pe.CalibrationConfig()
+	if !config.Enabled {
+		return
+	}
+	c := &pe.calibration
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i := range detections {
+		d := &detections[i]
+		var counts [2]int
+		if learned := c.counts[d.Type]; learned != nil {
+			counts = learned[calibrationBin(d.Confidence)]
+		}
+		d.RawConfidence = d.Confidence
+		d.Confidence = min(calibrated(d.Confidence, counts, config.Prior)*config.weight(d.Type), 1)
+	}
+}
+
+// learn counts a label at a detection's raw confidence and saves the
+// calibration when it has a path
+func (pe *ProximityEngine) learn(d Detection, correct bool) error {
+	raw := rawConfidence(d.Confidence, d.RawConfidence)
+	label := 0
+	if !correct {
+		label = 1
+	}
+	c := &pe.calibration
+	c.mu.Lock()
+	if c.counts == nil {
+		c.counts = make(map[string]*[calibrationBins][2]int)
+	}
+	if c.counts[d.Type] == nil {
+		c.counts[d.Type] = &[calibrationBins][2]int{}
+	}
+	c.counts[d.Type][calibrationBin(raw)][label]++
+	c.mu.Unlock()
+
+	if path := pe.CalibrationConfig().Path; path != "" {
+		return pe.SaveCalibration(path)
+	}
+	return nil
+}
+
+// calibrationFile is the saved form of the label counts
+type calibrationFile struct {
+	Types map[string][]CalibrationBin `json:"types"`
+}
+
+// LoadCalibration restores the labels saved at path; a missing file starts
+// from none
+func (pe *ProximityEngine) LoadCalibration(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read calibration: %w", err)
+	}
+	var file calibrationFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("parse calibration %s: %w", path, err)
+	}
+
+	counts := make(map[string]*[calibrationBins][2]int, len(file.Types))
+	labels := 0
+	for detectionType, bins := range file.Types {
+		learned := &[calibrationBins][2]int{}
+		for _, bin := range bins {
+			i := calibrationBin(bin.Min + 0.5/calibrationBins)
+			learned[i][0] += bin.True
+			learned[i][1] += bin.False
+			labels += bin.True + bin.False
+		}
+		counts[detectionType] = learned
+	}
+	pe.calibration.mu.Lock()
+	pe.calibration.counts = counts
+	pe.calibration.mu.Unlock()
+	detectLog.Info("Confidence calibration loaded", "path", path, "labels", labels)
+	return nil
+}
+
+// SaveCalibration writes the labels to path
+func (pe *ProximityEngine) SaveCalibration(path string) error {
+	data, err := json.MarshalIndent(calibrationFile{Types: pe.Calibration()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return fmt.Errorf("save calibration: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package engine
+
+import (
+	"errors"
+	"math"
+)
+
+// CameraConfig describes the in-game camera so distance can follow from a
+// pinhole model: an avatar of AvatarHeight meters standing Z meters away
+// fills AvatarHeight/(2*Z*tan(FOV/2)) of the view height
+type CameraConfig struct {
+	Enabled      bool    `json:"enabled" yaml:"enabled"`             // Use the model instead of the height thresholds
+	FOV          float64 `json:"fov" yaml:"fov"`                     // Vertical field of view in degrees, VRChat's FOV setting
+	RenderWidth  int     `json:"render_width" yaml:"render_width"`   // Rendered view size; 0 assumes the captured frame is exactly the view
+	RenderHeight int     `json:"render_height" yaml:"render_height"` // Set both to ignore window borders the capture adds below or above the view
+	AvatarHeight float64 `json:"avatar_height" yaml:"avatar_height"` // Assumed height of other avatars in meters
+
+	// Nameplates are drawn the same size above every avatar, so a measured
+	// nameplate gives distance however tall or small the avatar is
+	Nameplates      bool    `json:"nameplates" yaml:"nameplates"`             // Measure distance from nameplates where one is found
+	NameplateHeight float64 `json:"nameplate_height" yaml:"nameplate_height"` // Height of a nameplate's text band in meters
+}
+
+// DefaultCameraConfig keeps the height thresholds, assuming VRChat's
+// default 60 degree FOV and 1.5 m avatars when the model is turned on, and
+// doesn't measure nameplates
+func DefaultCameraConfig() CameraConfig {
+	return CameraConfig{FOV: 60, AvatarHeight: 1.5, NameplateHeight: 0.12}
+}
+
+// Validate checks the camera model ranges
+func (c CameraConfig) Validate() error {
+	if c.FOV < 20 || c.FOV > 150 {
+		return errors.New("fov must be between 20 and 150 degrees")
+	}
+	if c.RenderWidth < 0 || c.RenderHeight < 0 || (c.RenderWidth == 0) != (c.RenderHeight == 0) {
+		return errors.New("render_width and render_height must both be set or both be 0")
+	}
+	if c.AvatarHeight < 0.1 || c.AvatarHeight > 20 {
+		return errors.New("avatar_height must be between 0.1 and 20 meters")
+	}
+	if c.NameplateHeight < 0.01 || c.NameplateHeight > 1 {
+		return errors.New("nameplate_height must be between 0.01 and 1 meters")
+	}
+	return nil
+}
+
+// SetCameraConfig replaces the camera model; safe to call while running
+func (pe *ProximityEngine) SetCameraConfig(config CameraConfig) {
+	pe.cameraConfig.Store(&config)
+	detectLog.Info("Camera model set", "enabled", config.Enabled, "fov", config.FOV, "avatar_height", config.AvatarHeight)
+}
+
+// CameraConfig returns the camera model settings
+func (pe *ProximityEngine) CameraConfig() CameraConfig {
+	if config := pe.cameraConfig.Load(); config != nil {
+		return *config
+	}
+	return DefaultCameraConfig()
+}
+
+// viewHeight is the height of the rendered view within the frame. The view
+// covers the full frame width; any extra height is window chrome.
+func (c CameraConfig) viewHeight(frameWidth, frameHeight int32) float64 {
+	if c.RenderWidth > 0 && c.RenderHeight > 0 {
+		return float64(frameWidth) * float64(c.RenderHeight) / float64(c.RenderWidth)
+	}
+	return float64(frameHeight)
+}
+
+// pinholeDistance estimates how far away an avatar-sized detection is
+func (c CameraConfig) pinholeDistance(detection Detection, frameWidth, frameHeight int32) float32 {
+	return c.distanceFor(float64(detection.BBox.Height), c.AvatarHeight, frameWidth, frameHeight)
+}
+
+// distanceFor estimates how far away something meters tall is when it
+// spans pixels rows of the view
+func (c CameraConfig) distanceFor(pixels, meters float64, frameWidth, frameHeight int32) float32 {
+	viewHeight := c.viewHeight(frameWidth, frameHeight)
+	if pixels <= 0 || viewHeight <= 0 {
+		return MaxEstimatedDistance
+	}
+	fraction := pixels / viewHeight
+	distance := meters / (2 * fraction * math.Tan(c.FOV/2*math.Pi/180))
+	return float32(math.Min(distance, MaxEstimatedDistance))
+}
+
+// heightAt is how many meters tall something spanning pixels rows of the
+// view is at distance meters away
+func (c CameraConfig) heightAt(pixels float64, distance float32, frameWidth, frameHeight int32) float32 {
+	viewHeight := c.viewHeight(frameWidth, frameHeight)
+	if viewHeight <= 0 {
+		return 0
+	}
+	return float32(pixels / viewHeight * 2 * float64(distance) * math.Tan(c.FOV/2*math.Pi/180))
+}
+
+// bearing returns the angles in degrees from the view center to the
+// detection's center: right of center is positive, as is above it. Window
+// chrome is assumed to sit above the view, like a title bar.
+func (c CameraConfig) bearing(detection Detection, frameWidth, frameHeight int32) (horizontal, vertical float32) {
+	viewHeight := c.viewHeight(frameWidth, frameHeight)
+	if frameWidth <= 0 || viewHeight <= 0 {
+		return 0, 0
+	}
+	focal := viewHeight / 2 / math.Tan(c.FOV/2*math.Pi/180)
+	viewTop := float64(frameHeight) - viewHeight
+	x := float64(detection.BBox.X) + float64(detection.BBox.Width)/2 - float64(frameWidth)/2
+	y := viewTop + viewHeight/2 - (float64(detection.BBox.Y) + float64(detection.BBox.Height)/2)
+	return float32(math.Atan2(x, focal) * 180 / math.Pi), float32(math.Atan2(y, focal) * 180 / math.Pi)
+}
+
+// setBearings fills in each detection's bearing and elevation from the
+// camera model's field of view, whether or not the model estimates distance
+func (pe *ProximityEngine) setBearings(detections []Detection, frameWidth, frameHeight int32) {
+	camera := pe.CameraConfig()
+	for i := range detections {
+		detections[i].Bearing, detections[i].Elevation = camera.bearing(detections[i], frameWidth, frameHeight)
+	}
+}
@@ -0,0 +1,154 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"strings"
+	"sync/atomic"
+)
+
+// DistanceBand is one distance category. Bands are listed nearest first;
+// the last one takes everything farther than the others.
+type DistanceBand struct {
+	Name        string  `json:"name" yaml:"name"`
+	HeightRatio float64 `json:"height_ratio" yaml:"height_ratio"`   // Height heuristic: boxes taller than this fraction of the frame
+	Distance    float64 `json:"distance" yaml:"distance"`           // Height heuristic: meters reported for boxes in the band
+	MaxDistance float64 `json:"max_distance" yaml:"max_distance"`   // Camera model and stereo: nearer than this many meters
+	Color       string  `json:"color" yaml:"color"`                 // #rrggbb for the dashboard, preview, and tray
+	Priority    string  `json:"priority,omitempty" yaml:"priority"` // Priority of zone_enter events into the band, e.g. high
+}
+
+// DefaultDistanceBands are the five original categories
+func DefaultDistanceBands() []DistanceBand {
+	return []DistanceBand{
+		{Name: "Very Close", HeightRatio: 0.8, Distance: 1, MaxDistance: 2, Color: "#ff4d4d"},
+		{Name: "Close", HeightRatio: 0.4, Distance: 3, MaxDistance: 6, Color: "#ff9f40"},
+		{Name: "Medium", HeightRatio: 0.2, Distance: 10, MaxDistance: 17, Color: "#ffd84d"},
+		{Name: "Far", HeightRatio: 0.1, Distance: 25, MaxDistance: 37, Color: "#4dc3ff"},
+		{Name: "Very Far", Distance: MaxEstimatedDistance, Color: "#8a8f99"},
+	}
+}
+
+// ValidateDistanceBands checks that the bands are named and ordered nearest first
+func ValidateDistanceBands(bands []DistanceBand) error {
+	if len(bands) < 2 {
+		return errors.New("at least two bands are required")
+	}
+	names := make(map[string]bool)
+	for i, band := range bands {
+		if band.Name == "" || names[band.Name] {
+			return fmt.Errorf("band %d: name must be set and unique", i)
+		}
+		names[band.Name] = true
+		if band.Distance <= 0 || band.Distance > MaxEstimatedDistance {
+			return fmt.Errorf("%s: distance must be above 0 and at most %v", band.Name, MaxEstimatedDistance)
+		}
+		if band.Color != "" {
+			if _, err := parseColor(band.Color); err != nil {
+				return fmt.Errorf("%s: %w", band.Name, err)
+			}
+		}
+		if band.Priority != "" && band.Priority != PriorityHigh {
+			return fmt.Errorf("%s: priority must be empty or %q", band.Name, PriorityHigh)
+		}
+		if i == len(bands)-1 {
+			break
+		}
+		if band.HeightRatio <= 0 || band.HeightRatio >= 1 || band.MaxDistance <= 0 {
+			return fmt.Errorf("%s: height_ratio must be between 0 and 1 and max_distance positive", band.Name)
+		}
+		if i > 0 && (band.HeightRatio >= bands[i-1].HeightRatio || band.MaxDistance <= bands[i-1].MaxDistance) {
+			return fmt.Errorf("%s: bands must be nearest first, with falling height_ratio and rising max_distance", band.Name)
+		}
+	}
+	return nil
+}
+
+// distanceBands is the category table shared by the whole process
+var distanceBands atomic.Pointer[[]DistanceBand]
+
+// SetDistanceBands replaces the category table of estimateDistance, zones,
+// and every output; safe to call while running
+func (pe *ProximityEngine) SetDistanceBands(bands []DistanceBand) error {
+	if err := ValidateDistanceBands(bands); err != nil {
+		return err
+	}
+	bands = append([]DistanceBand(nil), bands...)
+	distanceBands.Store(&bands)
+	detectLog.Info("Distance categories set", "categories", strings.Join(DistanceCategories(), ", "))
+	return nil
+}
+
+// DistanceBands returns the category table, nearest first
+func DistanceBands() []DistanceBand {
+	if bands := distanceBands.Load(); bands != nil {
+		return *bands
+	}
+	return DefaultDistanceBands()
+}
+
+// DistanceCategories lists the category names from nearest to farthest
+func DistanceCategories() []string {
+	bands := DistanceBands()
+	names := make([]string, len(bands))
+	for i, band := range bands {
+		names[i] = band.Name
+	}
+	return names
+}
+
+// CategoryRank returns the position of a category in DistanceCategories,
+// or the number of categories for an unknown one
+func CategoryRank(category string) int {
+	bands := DistanceBands()
+	for i, band := range bands {
+		if band.Name == category {
+			return i
+		}
+	}
+	return len(bands)
+}
+
+// CategoryColor returns the color of a category
+func CategoryColor(category string) (color.RGBA, bool) {
+	for _, band := range DistanceBands() {
+		if band.Name == category {
+			bgr, err := parseColor(band.Color)
+			return color.RGBA{bgr[2], bgr[1], bgr[0], 0xff}, err == nil
+		}
+	}
+	return color.RGBA{}, false
+}
+
+// categoryPriority returns the priority of zone_enter events into a category
+func categoryPriority(category string) string {
+	for _, band := range DistanceBands() {
+		if band.Name == category {
+			return band.Priority
+		}
+	}
+	return ""
+}
+
+// heightBand picks the band of a box filling heightRatio of the frame
+func heightBand(heightRatio float64) DistanceBand {
+	bands := DistanceBands()
+	for _, band := range bands[:len(bands)-1] {
+		if heightRatio > band.HeightRatio {
+			return band
+		}
+	}
+	return bands[len(bands)-1]
+}
+
+// categoryForDistance maps a metric distance onto the bands' max_distance
+func categoryForDistance(distance float32) string {
+	bands := DistanceBands()
+	for _, band := range bands[:len(bands)-1] {
+		if float64(distance) < band.MaxDistance {
+			return band.Name
+		}
+	}
+	return bands[len(bands)-1].Name
+}
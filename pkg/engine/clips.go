@@ -0,0 +1,252 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"vrchat-proximity/pkg/logging"
+)
+
+// clipLog is the "clips" subsystem logger
+var clipLog = logging.For("clips")
+
+// ClipURLPrefix is where the server publishes saved clips
+const ClipURLPrefix = "/clips/"
+
+// ClipConfig configures the GIF clips saved around alerts
+type ClipConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Dir      string        `yaml:"dir"`
+	Before   time.Duration `yaml:"before"`    // Footage kept from before the event
+	After    time.Duration `yaml:"after"`     // Footage recorded after it
+	FPS      int           `yaml:"fps"`       // Clip frame rate
+	MaxWidth int           `yaml:"max_width"` // Frames are downscaled to at most this width
+	MaxClips int           `yaml:"max_clips"` // Oldest clips are deleted beyond this; 0 keeps all
+	Events   []string      `yaml:"events"`    // Event types clipped besides high-priority ones
+}
+
+// DefaultClipConfig saves five seconds at 5 FPS around each high-priority event
+func DefaultClipConfig() ClipConfig {
+	return ClipConfig{
+		Dir:      "clips",
+		Before:   3 * time.Second,
+		After:    2 * time.Second,
+		FPS:      5,
+		MaxWidth: 320,
+		MaxClips: 200,
+	}
+}
+
+// Validate checks the clip settings
+func (c ClipConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Dir == "" {
+		return errors.New("dir is required")
+	}
+	if c.Before < 0 || c.After < 0 || c.Before+c.After <= 0 {
+		return errors.New("before and after must not be negative and must add up to more than zero")
+	}
+	if c.FPS < 1 || c.FPS > 30 {
+		return errors.New("fps must be between 1 and 30")
+	}
+	if c.MaxWidth < 32 {
+		return errors.New("max_width must be at least 32")
+	}
+	if c.MaxClips < 0 {
+		return errors.New("max_clips must not be negative")
+	}
+	return nil
+}
+
+// clipFrame is a downscaled copy of a captured frame; frames are shared
+// between the pre-roll and pending clips and never drawn on
+type clipFrame struct {
+	img        *image.RGBA
+	scale      float64 // Clip pixels per captured pixel
+	detections []Detection
+}
+
+// pendingClip is a clip still collecting frames after its event
+type pendingClip struct {
+	name   string
+	frames []clipFrame
+}
+
+// clipRecorder keeps a short pre-roll of frames and writes it, with the
+// frames that follow, to a GIF when an alert fires
+type clipRecorder struct {
+	config ClipConfig
+
+	mu        sync.Mutex
+	lastFrame time.Time
+	ring      []clipFrame // Pre-roll, oldest first
+	pending   *pendingClip
+}
+
+// newClipRecorder creates the clip directory
+func newClipRecorder(config ClipConfig) (*clipRecorder, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(config.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create clip directory: %w", err)
+	}
+	return &clipRecorder{config: config}, nil
+}
+
+// SetClipConfig turns clip saving on or off; call before Start
+func (pe *ProximityEngine) SetClipConfig(config ClipConfig) error {
+	if !config.Enabled {
+		pe.clips.Store(nil)
+		return nil
+	}
+	clips, err := newClipRecorder(config)
+	if err != nil {
+		return err
+	}
+	pe.clips.Store(clips)
+	clipLog.Info("Saving clips of alerts", "dir", config.Dir, "seconds", (config.Before + config.After).Seconds())
+	return nil
+}
+
+// ClipHandler serves saved clips under ClipURLPrefix
+func (pe *ProximityEngine) ClipHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clips := pe.clips.Load()
+		if clips == nil {
+			http.NotFound(w, r)
+			return
+		}
+		http.StripPrefix(ClipURLPrefix, http.FileServer(http.Dir(clips.config.Dir))).ServeHTTP(w, r)
+	})
+}
+
+// wants reports whether the capture loop should hand over the next frame
+func (c *clipRecorder) wants(now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return now.Sub(c.lastFrame) >= time.Second/time.Duration(c.config.FPS)
+}
+
+// offer copies a packed 24-bit BGR frame into the pre-roll and any pending
+// clip. It must be called from the capture goroutine while the frame is valid.
+func (c *clipRecorder) offer(frame []byte, width, height int, detections []Detection, now time.Time) {
+	if width <= 0 || height <= 0 || len(frame) < width*height*3 {
+		return
+	}
+	img, scale := downscaleBGR(frame, width, height, c.config.MaxWidth)
+	f := clipFrame{img: img, scale: scale, detections: append([]Detection(nil), detections...)}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastFrame = now
+	size := max(1, int(c.config.Before.Seconds()*float64(c.config.FPS)))
+	if len(c.ring) >= size {
+		c.ring = append(c.ring[:0], c.ring[len(c.ring)-size+1:]...)
+	}
+	c.ring = append(c.ring, f)
+	if c.pending != nil {
+		c.pending.frames = append(c.pending.frames, f)
+	}
+}
+
+// clips reports whether an event should be clipped
+func (c *clipRecorder) clips(event ProximityEvent) bool {
+	return event.Priority == PriorityHigh || slices.Contains(c.config.Events, event.Type)
+}
+
+// trigger starts a clip for an event and returns its URL. Events arriving
+// while a clip is still recording share it.
+func (c *clipRecorder) trigger(event ProximityEvent, now time.Time) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pending != nil {
+		return ClipURLPrefix + c.pending.name
+	}
+
+	name := fmt.Sprintf("%s-%03d-%s.gif", now.Format("20060102-150405"), now.Nanosecond()/int(time.Millisecond), event.Type)
+	clip := &pendingClip{name: name, frames: append([]clipFrame(nil), c.ring...)}
+	c.pending = clip
+	time.AfterFunc(c.config.After, func() {
+		c.mu.Lock()
+		if c.pending == clip {
+			c.pending = nil
+		}
+		c.mu.Unlock()
+		c.write(clip)
+	})
+	return ClipURLPrefix + name
+}
+
+// write encodes a finished clip with detection boxes and prunes old clips
+func (c *clipRecorder) write(clip *pendingClip) {
+	if len(clip.frames) == 0 {
+		clipLog.Warn("No frames captured for clip", "clip", clip.name)
+		return
+	}
+
+	anim := &gif.GIF{}
+	delay := 100 / c.config.FPS // Hundredths of a second
+	for _, f := range clip.frames {
+		annotated := image.NewRGBA(f.img.Bounds())
+		copy(annotated.Pix, f.img.Pix)
+		drawDetections(annotated, f.detections, f.scale)
+		paletted := image.NewPaletted(annotated.Bounds(), palette.Plan9)
+		draw.Draw(paletted, paletted.Bounds(), annotated, image.Point{}, draw.Src)
+		anim.Image = append(anim.Image, paletted)
+		anim.Delay = append(anim.Delay, delay)
+	}
+
+	path := filepath.Join(c.config.Dir, clip.name)
+	file, err := os.Create(path)
+	if err != nil {
+		clipLog.Error("Clip save failed", "error", err)
+		return
+	}
+	err = gif.EncodeAll(file, anim)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		clipLog.Error("Clip save failed", "error", err)
+		return
+	}
+	clipLog.Info("Clip saved", "path", path, "frames", len(anim.Image))
+	c.prune()
+}
+
+// prune deletes the oldest clips beyond max_clips; names sort by time
+func (c *clipRecorder) prune() {
+	if c.config.MaxClips <= 0 {
+		return
+	}
+	entries, err := os.ReadDir(c.config.Dir)
+	if err != nil {
+		return
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".gif") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names[:max(0, len(names)-c.config.MaxClips)] {
+		os.Remove(filepath.Join(c.config.Dir, name))
+	}
+}
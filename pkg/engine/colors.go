@@ -0,0 +1,191 @@
+package engine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"vrchat-proximity/pkg/capture"
+)
+
+// colorSamples is how many pixels per side of a box are checked against the profiles
+const colorSamples = 24
+
+// colorLabelHold keeps a track's label through brief frames where the colors aren't visible
+const colorLabelHold = 2 * time.Second
+
+// ColorProfile tags detections showing a color signature, such as a
+// friend's avatar palette, with a label
+type ColorProfile struct {
+	Name        string   `json:"name" yaml:"name"`
+	Label       string   `json:"label,omitempty" yaml:"label"`               // Attached to matching detections; defaults to Name
+	Colors      []string `json:"colors" yaml:"colors"`                       // "#rrggbb" colors of the signature
+	Tolerance   int      `json:"tolerance,omitempty" yaml:"tolerance"`       // Per-channel difference still counted as a match; default 40
+	MinCoverage float32  `json:"min_coverage,omitempty" yaml:"min_coverage"` // Fraction of the box that must match; default 0.05
+}
+
+// Validate checks the profile and fills in defaults
+func (p *ColorProfile) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(p.Colors) == 0 {
+		return fmt.Errorf("profile %q needs at least one color", p.Name)
+	}
+	for _, c := range p.Colors {
+		if _, err := parseColor(c); err != nil {
+			return fmt.Errorf("profile %q: %w", p.Name, err)
+		}
+	}
+	if p.Tolerance < 0 || p.Tolerance > 255 {
+		return fmt.Errorf("profile %q: tolerance must be between 0 and 255", p.Name)
+	}
+	if p.MinCoverage < 0 || p.MinCoverage > 1 {
+		return fmt.Errorf("profile %q: min_coverage must be between 0 and 1", p.Name)
+	}
+	if p.Label == "" {
+		p.Label = p.Name
+	}
+	if p.Tolerance == 0 {
+		p.Tolerance = 40
+	}
+	if p.MinCoverage == 0 {
+		p.MinCoverage = 0.05
+	}
+	return nil
+}
+
+// parseColor reads "#rrggbb" into BGR order to match frame pixels
+func parseColor(s string) ([3]byte, error) {
+	hex := strings.TrimPrefix(s, "#")
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 6 || err != nil {
+		return [3]byte{}, fmt.Errorf("color %q is not #rrggbb", s)
+	}
+	return [3]byte{byte(v), byte(v >> 8), byte(v >> 16)}, nil
+}
+
+// colorProfile is a validated profile with parsed colors
+type colorProfile struct {
+	ColorProfile
+	bgr [][3]byte
+}
+
+// colorTagger labels detections by color and holds labels per track
+type colorTagger struct {
+	mu       sync.Mutex
+	profiles []colorProfile
+	held     map[int64]heldLabel
+}
+
+// heldLabel is the last label matched on a track
+type heldLabel struct {
+	label string
+	seen  time.Time
+}
+
+// SetColorProfiles replaces the color profiles; safe to call while running
+func (pe *ProximityEngine) SetColorProfiles(profiles []ColorProfile) error {
+	parsed := make([]colorProfile, 0, len(profiles))
+	for _, p := range profiles {
+		if err := p.Validate(); err != nil {
+			return err
+		}
+		cp := colorProfile{ColorProfile: p}
+		cp.Colors = append([]string(nil), p.Colors...)
+		for _, c := range p.Colors {
+			bgr, _ := parseColor(c)
+			cp.bgr = append(cp.bgr, bgr)
+		}
+		parsed = append(parsed, cp)
+	}
+
+	pe.colors.mu.Lock()
+	pe.colors.profiles = parsed
+	pe.colors.held = make(map[int64]heldLabel)
+	pe.colors.mu.Unlock()
+	detectLog.Info("Color profiles set", "count", len(parsed))
+	return nil
+}
+
+// ColorProfiles returns the color profiles with defaults filled in
+func (pe *ProximityEngine) ColorProfiles() []ColorProfile {
+	pe.colors.mu.Lock()
+	defer pe.colors.mu.Unlock()
+	profiles := make([]ColorProfile, len(pe.colors.profiles))
+	for i, p := range pe.colors.profiles {
+		profiles[i] = p.ColorProfile
+	}
+	return profiles
+}
+
+// tag sets Label on detections whose box shows a profile's colors, picking
+// the profile with the best coverage. Tracked detections keep their label
+// for colorLabelHold after the colors were last seen.
+func (t *colorTagger) tag(detections []Detection, frame capture.Frame, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.profiles) == 0 || !frame.Valid() {
+		return
+	}
+
+	for i := range detections {
+		d := &detections[i]
+		best, bestCoverage := "", float32(0)
+		for _, p := range t.profiles {
+			if coverage := p.coverage(frame, d.BBox); coverage >= p.MinCoverage && coverage > bestCoverage {
+				best, bestCoverage = p.Label, coverage
+			}
+		}
+
+		switch {
+		case best != "":
+			d.Label = best
+			if d.TrackID != 0 {
+				t.held[d.TrackID] = heldLabel{label: best, seen: now}
+			}
+		case d.TrackID != 0:
+			if held, ok := t.held[d.TrackID]; ok && now.Sub(held.seen) < colorLabelHold {
+				d.Label = held.label
+			}
+		}
+	}
+	for id, held := range t.held {
+		if now.Sub(held.seen) >= colorLabelHold {
+			delete(t.held, id)
+		}
+	}
+}
+
+// coverage samples box on a grid and returns the fraction of pixels close to one of the colors
+func (p *colorProfile) coverage(frame capture.Frame, box BoundingBox) float32 {
+	x0, y0 := max(int(box.X), 0), max(int(box.Y), 0)
+	x1, y1 := min(int(box.X+box.Width), frame.Width), min(int(box.Y+box.Height), frame.Height)
+	if x1 <= x0 || y1 <= y0 {
+		return 0
+	}
+	stepX, stepY := max((x1-x0)/colorSamples, 1), max((y1-y0)/colorSamples, 1)
+
+	var matched, total int
+	for y := y0; y < y1; y += stepY {
+		for x := x0; x < x1; x += stepX {
+			px := frame.Data[(y*frame.Width+x)*3:]
+			total++
+			for _, c := range p.bgr {
+				if near(px[0], c[0], p.Tolerance) && near(px[1], c[1], p.Tolerance) && near(px[2], c[2], p.Tolerance) {
+					matched++
+					break
+				}
+			}
+		}
+	}
+	return float32(matched) / float32(total)
+}
+
+// near reports whether two channel values differ by at most tolerance
+func near(a, b byte, tolerance int) bool {
+	d := int(a) - int(b)
+	return d <= tolerance && d >= -tolerance
+}
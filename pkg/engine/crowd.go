@@ -0,0 +1,170 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// CrowdConfig configures detection clustering and the crowded signal
+type CrowdConfig struct {
+	ClusterDistance float32 `json:"cluster_distance" yaml:"cluster_distance"` // Max gap between neighbors, as a fraction of the frame diagonal
+	CrowdedCount    int     `json:"crowded_count" yaml:"crowded_count"`       // Detections that make the scene crowded; 0 disables the event
+}
+
+// DefaultCrowdConfig groups detections within a tenth of the frame and calls eight a crowd
+func DefaultCrowdConfig() CrowdConfig {
+	return CrowdConfig{ClusterDistance: 0.1, CrowdedCount: 8}
+}
+
+// Validate checks the crowd ranges
+func (c CrowdConfig) Validate() error {
+	if c.ClusterDistance <= 0 || c.ClusterDistance > 1 {
+		return fmt.Errorf("cluster_distance must be above 0 and at most 1")
+	}
+	if c.CrowdedCount < 0 {
+		return fmt.Errorf("crowded_count must not be negative")
+	}
+	return nil
+}
+
+// Cluster is a group of detections close together on screen
+type Cluster struct {
+	X        float32 `json:"x"` // Centroid as a fraction of the frame width
+	Y        float32 `json:"y"` // Centroid as a fraction of the frame height
+	Count    int     `json:"count"`
+	Category string  `json:"category"` // Category of the nearest member
+	Distance float32 `json:"distance"` // Distance of the nearest member
+}
+
+// Crowd summarizes how busy the scene is
+type Crowd struct {
+	Count    int            `json:"count"`
+	Density  map[string]int `json:"density"` // Detections per distance category
+	Clusters []Cluster      `json:"clusters"`
+	Crowded  bool           `json:"crowded"`
+}
+
+// SetCrowdConfig replaces the clustering settings; safe to call while running
+func (pe *ProximityEngine) SetCrowdConfig(config CrowdConfig) {
+	pe.crowdConfig.Store(&config)
+	detectLog.Info("Crowd settings set", "cluster_distance", config.ClusterDistance, "crowded_count", config.CrowdedCount)
+}
+
+// CrowdConfig returns the clustering settings
+func (pe *ProximityEngine) CrowdConfig() CrowdConfig {
+	if config := pe.crowdConfig.Load(); config != nil {
+		return *config
+	}
+	return DefaultCrowdConfig()
+}
+
+// Crowd returns the crowd summary of the last processed batch
+func (pe *ProximityEngine) Crowd() Crowd {
+	if crowd := pe.crowd.Load(); crowd != nil {
+		return *crowd
+	}
+	return Crowd{Density: map[string]int{}, Clusters: []Cluster{}}
+}
+
+// analyzeCrowd clusters detections by single linkage on their centers and
+// counts them per category. Unknown frame sizes fall back to the extent of
+// the detections.
+func analyzeCrowd(detections []Detection, frameWidth, frameHeight int, config CrowdConfig) Crowd {
+	crowd := Crowd{
+		Count:    len(detections),
+		Density:  make(map[string]int),
+		Clusters: []Cluster{},
+	}
+	if len(detections) == 0 {
+		return crowd
+	}
+
+	w, h := float64(frameWidth), float64(frameHeight)
+	if w <= 0 || h <= 0 {
+		for _, d := range detections {
+			w = math.Max(w, float64(d.BBox.X+d.BBox.Width))
+			h = math.Max(h, float64(d.BBox.Y+d.BBox.Height))
+		}
+		w, h = math.Max(w, 1), math.Max(h, 1)
+	}
+	limit := float64(config.ClusterDistance) * math.Hypot(w, h)
+
+	centers := make([][2]float64, len(detections))
+	for i, d := range detections {
+		centers[i] = [2]float64{
+			float64(d.BBox.X) + float64(d.BBox.Width)/2,
+			float64(d.BBox.Y) + float64(d.BBox.Height)/2,
+		}
+		crowd.Density[d.Category]++
+	}
+
+	// Union-find over every pair within the linkage distance
+	parent := make([]int, len(detections))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i := range centers {
+		for j := i + 1; j < len(centers); j++ {
+			if math.Hypot(centers[i][0]-centers[j][0], centers[i][1]-centers[j][1]) <= limit {
+				parent[find(i)] = find(j)
+			}
+		}
+	}
+
+	index := make(map[int]int)
+	sums := [][2]float64{}
+	for i, d := range detections {
+		root := find(i)
+		n, ok := index[root]
+		if !ok {
+			n = len(crowd.Clusters)
+			index[root] = n
+			crowd.Clusters = append(crowd.Clusters, Cluster{Category: d.Category, Distance: d.Distance})
+			sums = append(sums, [2]float64{})
+		}
+		c := &crowd.Clusters[n]
+		c.Count++
+		sums[n][0] += centers[i][0]
+		sums[n][1] += centers[i][1]
+		if d.Distance < c.Distance {
+			c.Category, c.Distance = d.Category, d.Distance
+		}
+	}
+	for n := range crowd.Clusters {
+		c := &crowd.Clusters[n]
+		c.X = float32(sums[n][0] / float64(c.Count) / w)
+		c.Y = float32(sums[n][1] / float64(c.Count) / h)
+	}
+	return crowd
+}
+
+// crowdMonitor emits crowded once the count reaches the threshold and
+// crowd_cleared once it falls below half of it
+type crowdMonitor struct {
+	crowded bool
+}
+
+// update sets crowd.Crowded and returns any resulting event
+func (m *crowdMonitor) update(crowd *Crowd, threshold int, now time.Time) []ProximityEvent {
+	switch {
+	case threshold <= 0:
+		m.crowded = false
+	case !m.crowded && crowd.Count >= threshold:
+		m.crowded = true
+		crowd.Crowded = true
+		return []ProximityEvent{{Type: EventCrowded, Timestamp: now.Unix(), Count: crowd.Count}}
+	case m.crowded && crowd.Count*2 < threshold:
+		m.crowded = false
+		return []ProximityEvent{{Type: EventCrowdCleared, Timestamp: now.Unix(), Count: crowd.Count}}
+	}
+	crowd.Crowded = m.crowded
+	return nil
+}
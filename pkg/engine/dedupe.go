@@ -0,0 +1,44 @@
+package engine
+
+import (
+	"hash/maphash"
+	"sync"
+
+	"vrchat-proximity/pkg/capture"
+)
+
+// frameDeduper recognizes a frame identical to the one before it, such as a
+// paused game or a static menu, so detection can be skipped
+type frameDeduper struct {
+	seed maphash.Seed
+
+	mu            sync.Mutex
+	last          uint64
+	width, height int
+}
+
+// newFrameDeduper creates a deduper that has seen no frame yet
+func newFrameDeduper() *frameDeduper {
+	return &frameDeduper{seed: maphash.MakeSeed()}
+}
+
+// duplicate hashes frame and reports whether it matches the previous frame
+func (d *frameDeduper) duplicate(frame capture.Frame) bool {
+	if !frame.Valid() {
+		return false
+	}
+	sum := maphash.Bytes(d.seed, frame.Data[:frame.Width*frame.Height*3])
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	same := sum == d.last && frame.Width == d.width && frame.Height == d.height
+	d.last, d.width, d.height = sum, frame.Width, frame.Height
+	return same
+}
+
+// reset forgets the previous frame
+func (d *frameDeduper) reset() {
+	d.mu.Lock()
+	d.width, d.height = 0, 0
+	d.mu.Unlock()
+}
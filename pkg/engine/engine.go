@@ -0,0 +1,847 @@
+// Package engine runs the capture, detection, and event pipeline.
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+	
+	"github.com/shirou/gopsutil/v3/process"
+	
+	"vrchat-proximity/pkg/capture"
+	"vrchat-proximity/pkg/logging"
+)
+
+// Detection represents a detected object
+type Detection struct {
+	BBox          BoundingBox `json:"bbox"`
+	Confidence    float32     `json:"confidence"`
+	RawConfidence float32     `json:"raw_confidence,omitempty"` // Detector's own confidence when Confidence is calibrated
+	Type          string      `json:"type"`
+	Area          float32     `json:"area"`
+	Distance      float32     `json:"distance"`
+	Category      string      `json:"category"`
+	TrackID       int64       `json:"track_id,omitempty"` // Stable across frames while the object stays in view
+	ID            int64       `json:"id,omitempty"`       // Unique to this detection, for labeling it with Feedback
+	Label         string      `json:"label,omitempty"`    // Color profile the detection matched
+	Player        string      `json:"player,omitempty"`   // Best-effort name read from the nameplate
+	
+	VelocityX      float32 `json:"velocity_x,omitempty"`      // Screen-space velocity in frame widths per second
+	VelocityY      float32 `json:"velocity_y,omitempty"`      // Screen-space velocity in frame heights per second
+	ApproachRate   float32 `json:"approach_rate,omitempty"`   // Meters per second closing in; negative when receding
+	FriendAdjacent bool    `json:"friend_adjacent,omitempty"` // Friends are in the same instance
+	SourceID       string  `json:"source_id,omitempty"`       // Capture source, when there are several
+	Stereo         bool    `json:"stereo,omitempty"`          // Distance is from parallax between the eyes
+	Bearing        float32 `json:"bearing"`                   // Degrees right of the view center; negative is left
+	Elevation      float32 `json:"elevation"`                 // Approximate degrees above the view center; negative is below
+	Behind         bool    `json:"behind,omitempty"`          // Seen in a mirror you face, so actually behind you
+	Nameplate      bool    `json:"nameplate,omitempty"`       // Distance is from the size of the nameplate
+	AvatarHeight   float32 `json:"avatar_height,omitempty"`   // Meters, measured against the nameplate
+}
+
+// BoundingBox represents object bounds
+type BoundingBox struct {
+	X      int32 `json:"x"`
+	Y      int32 `json:"y"`
+	Width  int32 `json:"width"`
+	Height int32 `json:"height"`
+}
+
+// MaxEstimatedDistance is the farthest distance reported, that of "Very Far" detections by default
+const MaxEstimatedDistance = 50.0
+
+// Pipeline stages reported to OnStageTiming
+const (
+	StageCapture   = "capture"
+	StageDetect    = "detect"
+	StageConvert   = "convert"
+	StageBroadcast = "broadcast"
+)
+
+// Subsystem loggers
+var (
+	engineLog  = logging.For("engine")
+	captureLog = logging.For("capture")
+	detectLog  = logging.For("detect")
+)
+
+// ProximityEngine handles high-performance detection
+type ProximityEngine struct {
+	running          atomic.Bool
+	paused           atomic.Bool
+	pauseReasons     map[string]bool // Guarded by lifecycleMutex
+	pausedReason     atomic.Value    // string, see PausedReason
+	transition       atomic.Value    // string, StateStarting or StateStopping while Start or Stop runs
+	lifecycleMutex   sync.Mutex      // Serializes Start, Stop, Pause, and Resume
+	processorDone    chan struct{}
+	frameCount       atomic.Int64
+	skippedFrames    atomic.Int64 // Duplicate frames that skipped detection
+	droppedFrames    atomic.Int64 // Batches dropped because detection processing fell behind
+	frameRate        rateCounter
+	detectionRate    rateCounter
+	dropRate         rateCounter
+	detectionsCount  atomic.Int64
+	processTime      atomic.Int64 // microseconds
+	createdAt        time.Time
+	lifetime         atomic.Pointer[CumulativeStats] // Earlier runs, see LoadLifetimeStats
+	detectionChan    chan detectionBatch
+	screenCaptureCtx context.Context
+	cancelCapture    context.CancelFunc
+	
+	// Capture loop supervision
+	loopMutex       sync.Mutex
+	cancelLoop      context.CancelFunc
+	watchdogTimeout time.Duration
+	supervisor      supervisor
+	performance     PerformanceConfig
+	lastNoFrame     atomic.Int64 // unix nanos of the last ErrNoFrame
+	lastFrameAt     atomic.Int64 // unix nanos of the last processed frame
+	sourceEnded     atomic.Bool
+	eventChan       chan ProximityEvent
+	
+	// Performance monitoring
+	cpuUsage     atomic.Int64
+	memoryUsage  atomic.Int64
+	budgetConfig atomic.Pointer[BudgetConfig]
+	degraded     atomic.Int32 // Budget degradation steps applied
+	
+	// Frame geometry of the last capture
+	frameWidth  atomic.Int32
+	frameHeight atomic.Int32
+	
+	// Configuration
+	source           capture.FrameSource // Primary source
+	sources          sourceSet
+	captureEnabled   bool // False when detections are injected, e.g. during replay
+	targetFPS        atomic.Int32
+	sensitivity      atomic.Int32 // 1-100, higher detects fainter motion
+	masks            atomic.Pointer[[]Mask]
+	detectionConfig  atomic.Pointer[DetectionConfig]
+	crowdConfig      atomic.Pointer[CrowdConfig]
+	mirrorConfig     atomic.Pointer[MirrorConfig]
+	tilingConfig     atomic.Pointer[TilingConfig]
+	tiler            atomic.Pointer[capture.TileDetector] // nil detects on the whole frame
+	motion           atomic.Pointer[motionDetector]       // nil uses frame differencing
+	processingConfig atomic.Pointer[ProcessingConfig]
+	overflowConfig   atomic.Pointer[OverflowConfig]
+	sceneConfig      atomic.Pointer[SceneConfig]
+	stereoConfig     atomic.Pointer[StereoConfig]
+	cameraConfig     atomic.Pointer[CameraConfig]
+	settleUntil      atomic.Int64                       // unix nanos; detections are suppressed until then after a scene change
+	downscaler       atomic.Pointer[capture.Downscaler] // nil detects at the captured resolution
+	script           atomic.Pointer[PipelineScript]
+	alertConfig      atomic.Pointer[AlertConfig]
+	hapticsOff       atomic.Bool // Haptic hooks are switched off
+	crowd            atomic.Pointer[Crowd]
+	detectionBuffer  []Detection
+	bufferMutex      sync.RWMutex
+	
+	// Output integrations
+	detectionHooks []func([]Detection)
+	eventHooks     []func(ProximityEvent)
+	stageHooks     []func(string, time.Duration)
+	traceHooks     []func(FrameTrace)
+	tracing        atomic.Bool // A trace hook is registered
+	hooksMutex     sync.RWMutex
+	bus            eventBus // Outputs with their own queues, see Subscribe
+	latencies      map[string]*latencyHistogram
+	zones          *zoneTracker
+	tracker        *tracker
+	dedupe         *frameDeduper
+	colors         colorTagger
+	nameplates     *nameplateReader
+	calibration    calibrator
+	feedback       feedbackStore
+	instance       instanceState
+	players        playerRules
+	rules          ruleSet
+	cooldowns      alertCooldowns
+	approach       *approachMonitor
+	crowds         crowdMonitor
+	sessions       sessionTracker
+	preview        *PreviewStream
+	clips          atomic.Pointer[clipRecorder] // nil while clips are off
+	privacyConfig  atomic.Pointer[PrivacyConfig]
+}
+
+// Status is a snapshot of engine state and counters
+type Status struct {
+	Running           bool
+	Paused            bool
+	PausedReason      string
+	FramesProcessed   int64
+	FramesSkipped     int64 // Frames identical to the previous one, not run through detection
+	FramesDropped     int64 // Detection batches dropped because processing fell behind
+	TotalDetections   int64
+	CurrentDetections int
+	AvgProcessTimeMS  float64
+	TargetFPS         int
+	Sensitivity       int
+	FrameWidth        int
+	FrameHeight       int
+	CPUUsage          int64
+	MemoryUsageMB     float64
+	DegradedLevel     int // Resource budget steps applied, 0 when running as configured
+}
+
+// NewProximityEngine creates a new high-performance engine
+func NewProximityEngine() *ProximityEngine {
+	pe := &ProximityEngine{
+		detectionChan:   make(chan detectionBatch, 100), // Buffered channel
+		eventChan:       make(chan ProximityEvent, 16),
+		watchdogTimeout: 10 * time.Second,
+		detectionBuffer: make([]Detection, 0, 100),
+		zones:           newZoneTracker(time.Second),
+		tracker:         newTracker(DefaultTrackingConfig()),
+		dedupe:          newFrameDeduper(),
+		nameplates:      newNameplateReader(),
+		approach:        newApproachMonitor(),
+		preview:         NewPreviewStream(DefaultPreviewConfig()),
+		source:          capture.NewScreenSource(),
+		captureEnabled:  true,
+		createdAt:       time.Now(),
+		latencies: map[string]*latencyHistogram{
+			StageCapture:   {},
+			StageDetect:    {},
+			StageConvert:   {},
+			StageBroadcast: {},
+		},
+	}
+	pe.targetFPS.Store(30) // Default 30 FPS
+	pe.sensitivity.Store(50)
+	
+	return pe
+}
+
+// Start begins the detection engine. A stopped engine can be started
+// again; starting a paused engine resumes capture.
+func (pe *ProximityEngine) Start() error {
+	pe.lifecycleMutex.Lock()
+	defer pe.lifecycleMutex.Unlock()
+	
+	if pe.running.Load() {
+		if pe.paused.Load() {
+			pe.resume()
+			return nil
+		}
+		return fmt.Errorf("engine already running")
+	}
+	pe.transition.Store(StateStarting)
+	defer pe.transition.Store("")
+	
+	ctx, cancel := context.WithCancel(context.Background())
+	pe.loopMutex.Lock()
+	pe.screenCaptureCtx = ctx
+	pe.cancelCapture = cancel
+	pe.loopMutex.Unlock()
+	
+	// Discard batches and events left over from a previous run
+	for len(pe.detectionChan) > 0 {
+		<-pe.detectionChan
+	}
+	for len(pe.eventChan) > 0 {
+		<-pe.eventChan
+	}
+	pe.sourceEnded.Store(false)
+	pe.lastNoFrame.Store(0)
+	pe.lastFrameAt.Store(0)
+	pe.resetSources()
+	pe.settleUntil.Store(0)
+	now := time.Now()
+	pe.frameRate.reset(now)
+	pe.detectionRate.reset(now)
+	pe.dropRate.reset(now)
+	pe.clearPause()
+	pe.running.Store(true)
+	
+	// Start performance monitoring
+	go pe.monitorPerformance(ctx)
+	
+	// Start screen capture and detection
+	if pe.captureEnabled {
+		pe.startCaptureLoop()
+		go pe.watchdog(ctx)
+	}
+	
+	// Start detection processing
+	done := make(chan struct{})
+	pe.processorDone = done
+	go func() {
+		defer close(done)
+		pe.Supervise(ctx.Done(), SubsystemDetection, func() { pe.processDetections(ctx) })
+	}()
+	
+	// Start preview rendering
+	go pe.preview.run(ctx)
+	
+	engineLog.Info("Proximity Engine started", "cpu_cores", runtime.NumCPU())
+	return nil
+}
+
+// Stop halts the detection engine and waits for pending hooks to finish
+func (pe *ProximityEngine) Stop() {
+	pe.lifecycleMutex.Lock()
+	defer pe.lifecycleMutex.Unlock()
+	
+	if !pe.running.Load() {
+		return
+	}
+	pe.transition.Store(StateStopping)
+	defer pe.transition.Store("")
+	
+	pe.running.Store(false)
+	pe.clearPause()
+	pe.cancelCapture()
+	<-pe.processorDone
+	
+	engineLog.Info("Proximity Engine stopped")
+}
+
+// captureAndDetectLoop runs one source's detection loop until ctx is cancelled
+func (pe *ProximityEngine) captureAndDetectLoop(ctx context.Context, p *sourcePipeline) {
+	pe.pinThread()
+	fps := pe.targetFPS.Load()
+	ticker := time.NewTicker(time.Duration(1000/fps) * time.Millisecond)
+	defer ticker.Stop()
+	
+	var previousFrame capture.Frame
+	
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !pe.running.Load() {
+				return
+			}
+			
+			// Pick up FPS changes made while running
+			if current := pe.targetFPS.Load(); current != fps {
+				fps = current
+				ticker.Reset(time.Duration(1000/fps) * time.Millisecond)
+			}
+			
+			// Capture and detect
+			startTime := time.Now()
+			trace := pe.newFrameTrace(p)
+			frame, detections, err := pe.captureAndDetect(ctx, p, previousFrame, trace)
+			processingTime := time.Since(startTime)
+			if ctx.Err() != nil {
+				// Replaced by the watchdog while capturing
+				return
+			}
+			if errors.Is(err, io.EOF) {
+				captureLog.Info("Frame source ended", "source", p.id)
+				pe.sourceEnd(p)
+				return
+			}
+			if errors.Is(err, capture.ErrNoFrame) {
+				pe.lastNoFrame.Store(time.Now().UnixNano())
+			}
+			if err != nil {
+				continue
+			}
+			previousFrame = frame
+			p.frames.Add(1)
+			pe.processTime.Store(processingTime.Microseconds())
+			detections, complete := pe.merge(p, detections, time.Now())
+			if !complete {
+				// The source finishing the round publishes it
+				pe.finishTrace(trace)
+				continue
+			}
+			
+			// Update metrics
+			pe.countFrame(detections)
+			
+			// Send detections to processing channel
+			pe.queueDetections(detections, trace)
+		}
+	}
+}
+
+// InjectDetections feeds externally produced detections into the pipeline
+func (pe *ProximityEngine) InjectDetections(detections []Detection) {
+	if !pe.running.Load() {
+		return
+	}
+	
+	pe.countFrame(detections)
+	pe.queueDetections(detections, nil)
+}
+
+// countFrame adds a processed frame and its detections to the counters and rates
+func (pe *ProximityEngine) countFrame(detections []Detection) {
+	now := time.Now()
+	pe.frameCount.Add(1)
+	pe.lastFrameAt.Store(now.UnixNano())
+	pe.detectionsCount.Add(int64(len(detections)))
+	pe.frameRate.add(now, 1)
+	pe.detectionRate.add(now, int64(len(detections)))
+}
+
+// DisableCapture stops Start from launching screen capture; call before Start
+func (pe *ProximityEngine) DisableCapture() {
+	pe.captureEnabled = false
+}
+
+// SetFrameSource replaces the screen capture source; call before Start
+func (pe *ProximityEngine) SetFrameSource(source capture.FrameSource) {
+	pe.SetFrameSources([]NamedSource{{Source: source}})
+}
+
+// captureAndDetect grabs the next frame and compares it with the previous
+// one. The returned frame is at the processing resolution, ready to be
+// passed back as previousFrame. Stages are added to trace unless it is nil.
+func (pe *ProximityEngine) captureAndDetect(ctx context.Context, p *sourcePipeline, previousFrame capture.Frame, trace *FrameTrace) (capture.Frame, []Detection, error) {
+	start := time.Now()
+	frame, err := p.source.NextFrame(ctx)
+	if err != nil {
+		return capture.Frame{}, nil, err
+	}
+	pe.setFrameSize(p, frame.Width, frame.Height)
+	captured := time.Now()
+	pe.recordStage(StageCapture, captured.Sub(start))
+	trace.add(StageCapture, start, captured)
+	
+	// An identical frame can't contain motion, so skip the detector
+	processed := p.downscale(pe, frame)
+	var raw []capture.RawDetection
+	if p.dedupe.duplicate(processed) {
+		pe.skippedFrames.Add(1)
+	} else if !pe.settling(processed, previousFrame, captured) {
+		raw = capture.ScaleDetections(pe.detectMotion(p, processed, previousFrame), processed, frame)
+	}
+	detected := time.Now()
+	pe.recordStage(StageDetect, detected.Sub(captured))
+	trace.add(StageDetect, captured, detected)
+	
+	detections := pe.convertDetections(raw, int32(frame.Width), int32(frame.Height))
+	detections = pe.applyMasks(detections, int32(frame.Width), int32(frame.Height))
+	detections = pe.mergeDetections(detections, int32(frame.Width), int32(frame.Height))
+	pe.calibrate(detections)
+	detections = pe.filterDetections(detections)
+	detections = pe.applyFeedback(p.id, detections, int32(frame.Width), int32(frame.Height))
+	pe.measureNameplates(detections, frame)
+	pe.stereoDistances(p, detections, captured)
+	detections = p.tracker.update(detections, int32(frame.Width), int32(frame.Height), captured)
+	pe.setBearings(detections, int32(frame.Width), int32(frame.Height))
+	detections, behind := p.mirror.update(detections, pe.MirrorConfig(), frame, captured)
+	for i := range behind {
+		select {
+		case pe.eventChan <- ProximityEvent{Type: EventBehindYou, Timestamp: captured.Unix(), Category: behind[i].Category, Distance: behind[i].Distance, Direction: DirectionRear, Detection: &behind[i]}:
+		default:
+		}
+	}
+	pe.colors.tag(detections, frame, captured)
+	pe.nameplates.tag(detections, frame, captured)
+	converted := time.Now()
+	pe.recordStage(StageConvert, converted.Sub(detected))
+	trace.add(StageConvert, detected, converted)
+	
+	// Hand the primary source's frame to the preview stream and clip recorder
+	if p.index > 0 {
+		return processed, detections, nil
+	}
+	if pe.preview.wants() {
+		pe.preview.offer(frame.Data, frame.Width, frame.Height, detections)
+	}
+	if clips := pe.clips.Load(); clips != nil && !pe.PrivacyMode() && clips.wants(captured) {
+		clips.offer(frame.Data, frame.Width, frame.Height, detections, captured)
+	}
+	
+	return processed, detections, nil
+}
+
+// motionThreshold maps sensitivity to the Zig pixel-difference threshold
+func (pe *ProximityEngine) motionThreshold() int {
+	// Sensitivity 50 gives the original threshold of 30
+	return 5 + int(100-pe.sensitivity.Load())/2
+}
+
+// convertDetections converts raw Zig detections to Detections with distance estimates
+func (pe *ProximityEngine) convertDetections(raw []capture.RawDetection, frameWidth, frameHeight int32) []Detection {
+	if len(raw) == 0 {
+		return nil
+	}
+	
+	detections := make([]Detection, len(raw))
+	for i, r := range raw {
+		detections[i] = Detection{
+			BBox: BoundingBox{
+				X:      r.X,
+				Y:      r.Y,
+				Width:  r.Width,
+				Height: r.Height,
+			},
+			Confidence: r.Confidence,
+			Type:       pe.getDetectionTypeString(r.Type),
+			Area:       r.Area,
+		}
+		
+		// Estimate distance and category
+		detections[i].Distance, detections[i].Category = pe.estimateDistance(detections[i], frameWidth, frameHeight)
+	}
+	
+	return detections
+}
+
+// getDetectionTypeString converts detection type to string
+func (pe *ProximityEngine) getDetectionTypeString(detType uint8) string {
+	switch detType {
+	case 0:
+		return "motion"
+	case 1:
+		return "color"
+	case 2:
+		return "shape"
+	default:
+		return "unknown"
+	}
+}
+
+// estimateDistance calculates distance based on object size, with the
+// camera model when it is configured or fixed height thresholds otherwise
+func (pe *ProximityEngine) estimateDistance(detection Detection, frameWidth, frameHeight int32) (float32, string) {
+	if camera := pe.CameraConfig(); camera.Enabled {
+		distance := camera.pinholeDistance(detection, frameWidth, frameHeight)
+		return distance, categoryForDistance(distance)
+	}
+	
+	// Calculate avatar height ratio and look up its band
+	heightRatio := float64(detection.BBox.Height) / float64(frameHeight)
+	band := heightBand(heightRatio)
+	distance, category := float32(band.Distance), band.Name
+	
+	// Adjust based on position (objects at bottom might be closer)
+	bottomRatio := float32(detection.BBox.Y+detection.BBox.Height) / float32(frameHeight)
+	if bottomRatio > 0.8 {
+		distance *= 0.7 // Closer than estimated
+	}
+	
+	return distance, category
+}
+
+// NearestDetection returns the detection with the smallest estimated distance
+func NearestDetection(detections []Detection) (Detection, bool) {
+	if len(detections) == 0 {
+		return Detection{}, false
+	}
+	nearest := detections[0]
+	for _, d := range detections[1:] {
+		if d.Distance < nearest.Distance {
+			nearest = d
+		}
+	}
+	return nearest, true
+}
+
+// processDetections handles detection results until ctx is cancelled
+func (pe *ProximityEngine) processDetections(ctx context.Context) {
+	pe.pinThread()
+	
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		
+		case batch := <-pe.detectionChan:
+			detections, trace := batch.detections, batch.trace
+			if trace != nil {
+				trace.add(StageQueue, trace.queued, time.Now())
+			}
+			if pe.friendAdjacent() {
+				for i := range detections {
+					detections[i].FriendAdjacent = true
+				}
+			}
+			detections, scriptEvents := pe.scriptDetections(detections)
+			pe.rememberDetections(detections, time.Now())
+			
+			pe.bufferMutex.Lock()
+			pe.detectionBuffer = detections
+			pe.bufferMutex.Unlock()
+			
+			now := time.Now()
+			width, height := pe.FrameSize()
+			crowdConfig := pe.CrowdConfig()
+			crowd := analyzeCrowd(detections, width, height, crowdConfig)
+			crowdEvents := pe.crowds.update(&crowd, crowdConfig.CrowdedCount, now)
+			pe.crowd.Store(&crowd)
+			pe.sessions.update(detections, width, height, pe.Instance(), now)
+			
+			// Notify output integrations; a panicking hook loses this batch only
+			start := time.Now()
+			pe.runRecovered(SubsystemBroadcast, func() {
+				pe.hooksMutex.RLock()
+				defer pe.hooksMutex.RUnlock()
+				for _, hook := range pe.detectionHooks {
+					hook(detections)
+				}
+			})
+			pe.publishDetections(detections)
+			broadcast := time.Now()
+			pe.recordStage(StageBroadcast, broadcast.Sub(start))
+			trace.add(StageBroadcast, start, broadcast)
+			pe.finishTrace(trace)
+			
+			// fast_approach goes out ahead of zone changes from the same batch
+			for _, event := range pe.approach.update(detections, pe.TrackingConfig().FastApproach, now) {
+				pe.emitEvent(event)
+			}
+			for _, event := range pe.zones.update(detections, now) {
+				pe.sessions.observe(event)
+				pe.emitEvent(event)
+			}
+			for _, event := range crowdEvents {
+				pe.emitEvent(event)
+			}
+			for _, event := range pe.rules.update(detections, pe.Instance(), now) {
+				pe.emitEvent(event)
+			}
+			for _, event := range scriptEvents {
+				pe.publishEvent(event)
+			}
+		
+		case event := <-pe.eventChan:
+			pe.emitEvent(event)
+		
+		case now := <-ticker.C:
+			for _, event := range pe.zones.expire(now) {
+				pe.emitEvent(event)
+			}
+		}
+	}
+}
+
+// emitEvent passes an event through the pipeline script to the event hooks
+func (pe *ProximityEngine) emitEvent(event ProximityEvent) {
+	event.FriendAdjacent = pe.friendAdjacent()
+	pe.applyPlayerRule(&event)
+	event, keep, custom := pe.scriptEvent(event)
+	if keep {
+		pe.publishEvent(event)
+	}
+	for _, extra := range custom {
+		pe.publishEvent(extra)
+	}
+}
+
+// publishEvent decides whether the event alerts and passes it to the event
+// hooks and the bus
+func (pe *ProximityEngine) publishEvent(event ProximityEvent) {
+	event.Muted = !pe.alertAllowed(event, time.Now())
+	if clips := pe.clips.Load(); clips != nil && !event.Muted && !pe.PrivacyMode() && clips.clips(event) && pe.InstanceAllows(BehaviorRecord) {
+		event.Clip = clips.trigger(event, time.Now())
+	}
+	pe.hooksMutex.RLock()
+	for _, hook := range pe.eventHooks {
+		hook(event)
+	}
+	pe.hooksMutex.RUnlock()
+	pe.publishToBus(event)
+}
+
+// OnEvent registers a callback invoked for every proximity event on the
+// detection goroutine; outputs that may be slow should Subscribe instead
+func (pe *ProximityEngine) OnEvent(hook func(ProximityEvent)) {
+	pe.hooksMutex.Lock()
+	pe.eventHooks = append(pe.eventHooks, hook)
+	pe.hooksMutex.Unlock()
+}
+
+// OnDetections registers a callback invoked for every processed detection
+// batch on the detection goroutine; outputs that may be slow should
+// Subscribe instead
+func (pe *ProximityEngine) OnDetections(hook func([]Detection)) {
+	pe.hooksMutex.Lock()
+	pe.detectionHooks = append(pe.detectionHooks, hook)
+	pe.hooksMutex.Unlock()
+}
+
+// OnStageTiming registers a callback invoked with the duration of each pipeline stage
+func (pe *ProximityEngine) OnStageTiming(hook func(stage string, duration time.Duration)) {
+	pe.hooksMutex.Lock()
+	pe.stageHooks = append(pe.stageHooks, hook)
+	pe.hooksMutex.Unlock()
+}
+
+// recordStage adds a stage duration to its histogram and the timing hooks
+func (pe *ProximityEngine) recordStage(stage string, duration time.Duration) {
+	if h, ok := pe.latencies[stage]; ok {
+		h.record(duration)
+	}
+	
+	pe.hooksMutex.RLock()
+	for _, hook := range pe.stageHooks {
+		hook(stage, duration)
+	}
+	pe.hooksMutex.RUnlock()
+}
+
+// StageLatencies returns latency quantiles for each pipeline stage
+func (pe *ProximityEngine) StageLatencies() map[string]LatencySummary {
+	result := make(map[string]LatencySummary, len(pe.latencies))
+	for stage, h := range pe.latencies {
+		result[stage] = h.summary()
+	}
+	return result
+}
+
+// FPS returns the frames processed per second over the last few seconds,
+// summed over every source
+func (pe *ProximityEngine) FPS() float64 {
+	return pe.frameRate.rate(time.Now())
+}
+
+// DetectionRate returns the detections per second over the last few seconds
+func (pe *ProximityEngine) DetectionRate() float64 {
+	return pe.detectionRate.rate(time.Now())
+}
+
+// DropRate returns the detection batches dropped per second over the last
+// few seconds because processing fell behind capture
+func (pe *ProximityEngine) DropRate() float64 {
+	return pe.dropRate.rate(time.Now())
+}
+
+// monitorInterval is how often monitorPerformance samples the process
+const monitorInterval = 5 * time.Second
+
+// monitorPerformance samples the process's CPU and memory use and enforces
+// the resource budget
+func (pe *ProximityEngine) monitorPerformance(ctx context.Context) {
+	ticker := time.NewTicker(monitorInterval)
+	defer ticker.Stop()
+	
+	self, err := process.NewProcess(int32(os.Getpid()))
+	if err != nil {
+		engineLog.Warn("Performance monitoring unavailable", "error", err)
+		return
+	}
+	var budget budgetEnforcer
+	defer pe.restoreBudget(&budget)
+	
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			// CPU use since the previous sample
+			if cpu, err := self.Percent(0); err == nil {
+				pe.cpuUsage.Store(int64(cpu))
+			}
+			if mem, err := self.MemoryInfo(); err == nil {
+				pe.memoryUsage.Store(int64(mem.RSS))
+			}
+			pe.enforceBudget(&budget, now)
+		}
+	}
+}
+
+// GetCurrentDetections returns current detection buffer
+func (pe *ProximityEngine) GetCurrentDetections() []Detection {
+	pe.bufferMutex.RLock()
+	defer pe.bufferMutex.RUnlock()
+	
+	// Return copy to prevent race conditions
+	result := make([]Detection, len(pe.detectionBuffer))
+	copy(result, pe.detectionBuffer)
+	return result
+}
+
+// SetTargetFPS sets the target frames per second
+func (pe *ProximityEngine) SetTargetFPS(fps int) {
+	pe.targetFPS.Store(int32(fps))
+	captureLog.Info("Target FPS set", "fps", fps)
+}
+
+// TargetFPS returns the target frames per second
+func (pe *ProximityEngine) TargetFPS() int {
+	return int(pe.targetFPS.Load())
+}
+
+// SetSensitivity sets motion sensitivity from 1 (least) to 100 (most)
+func (pe *ProximityEngine) SetSensitivity(sensitivity int) {
+	pe.sensitivity.Store(int32(sensitivity))
+	detectLog.Info("Sensitivity set", "sensitivity", sensitivity)
+}
+
+// Sensitivity returns the motion sensitivity
+func (pe *ProximityEngine) Sensitivity() int {
+	return int(pe.sensitivity.Load())
+}
+
+// SetPreviewConfig replaces the preview stream configuration; call before Start
+func (pe *ProximityEngine) SetPreviewConfig(config PreviewConfig) {
+	pe.preview = NewPreviewStream(config)
+	pe.preview.setPrivacy(pe.PrivacyConfig())
+}
+
+// Preview returns the MJPEG preview stream
+func (pe *ProximityEngine) Preview() *PreviewStream {
+	return pe.preview
+}
+
+// FrameSize returns the dimensions of the last captured frame
+func (pe *ProximityEngine) FrameSize() (width, height int) {
+	return int(pe.frameWidth.Load()), int(pe.frameHeight.Load())
+}
+
+// BufferStats reports frame buffer reuse for sources that keep a ring, such as the screen source
+func (pe *ProximityEngine) BufferStats() (capture.BufferStats, bool) {
+	if source, ok := pe.source.(interface{ BufferStats() capture.BufferStats }); ok {
+		return source.BufferStats(), true
+	}
+	return capture.BufferStats{}, false
+}
+
+// Status returns a snapshot of engine state and counters
+func (pe *ProximityEngine) Status() Status {
+	pe.bufferMutex.RLock()
+	currentDetections := len(pe.detectionBuffer)
+	pe.bufferMutex.RUnlock()
+	
+	return Status{
+		Running:           pe.running.Load(),
+		Paused:            pe.paused.Load(),
+		PausedReason:      pe.PausedReason(),
+		FramesProcessed:   pe.frameCount.Load(),
+		FramesSkipped:     pe.skippedFrames.Load(),
+		FramesDropped:     pe.droppedFrames.Load(),
+		TotalDetections:   pe.detectionsCount.Load(),
+		CurrentDetections: currentDetections,
+		AvgProcessTimeMS:  float64(pe.processTime.Load()) / 1000.0,
+		TargetFPS:         int(pe.targetFPS.Load()),
+		Sensitivity:       int(pe.sensitivity.Load()),
+		FrameWidth:        int(pe.frameWidth.Load()),
+		FrameHeight:       int(pe.frameHeight.Load()),
+		CPUUsage:          pe.cpuUsage.Load(),
+		MemoryUsageMB:     float64(pe.memoryUsage.Load()) / 1024 / 1024,
+		DegradedLevel:     pe.DegradedLevel(),
+	}
+}
+
+// GetStats returns engine statistics
+func (pe *ProximityEngine) GetStats() map[string]interface{} {
+	return map[string]interface{}{
+		"running":          pe.running.Load(),
+		"paused":           pe.paused.Load(),
+		"paused_reason":    pe.PausedReason(),
+		"frames_processed": pe.frameCount.Load(),
+		"total_detections": pe.detectionsCount.Load(),
+		"avg_process_time": float64(pe.processTime.Load()) / 1000.0,
+		"cpu_usage":        pe.cpuUsage.Load(),
+		"memory_usage_mb":  float64(pe.memoryUsage.Load()) / 1024 / 1024,
+		"target_fps":       pe.targetFPS.Load(),
+	}
+}
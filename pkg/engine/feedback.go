@@ -0,0 +1,417 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// feedbackRecall is how long a track can still be labeled after it was last
+// seen, so feedback on someone who just left view still counts
+const feedbackRecall = 2 * time.Minute
+
+// feedbackRing is how many of the latest detections can be labeled by ID
+const feedbackRing = 4096
+
+// ErrUnknownTrack is returned for feedback on a track that wasn't seen recently
+var ErrUnknownTrack = errors.New("track not seen recently")
+
+// ErrUnknownDetection is returned for feedback on a detection that is too
+// old or never existed
+var ErrUnknownDetection = errors.New("detection not seen recently")
+
+// FeedbackConfig configures learning from labeled detections
+type FeedbackConfig struct {
+	Path        string `json:"path" yaml:"path"`                 // JSON file the labeled examples are kept in; empty keeps them for this run only
+	MaxExamples int    `json:"max_examples" yaml:"max_examples"` // Oldest examples are dropped beyond this many
+
+	// With AutoTune, once there are MinExamples labels, area below that of
+	// all but 1-Recall of the real people is filtered out, and so is raw
+	// confidence below theirs of the same detection type
+	AutoTune    bool    `json:"auto_tune" yaml:"auto_tune"`
+	MinExamples int     `json:"min_examples" yaml:"min_examples"`
+	Recall      float32 `json:"recall" yaml:"recall"` // Share of real people the tuned thresholds must keep
+
+	// The frame is split into Grid by Grid cells; a cell with SuppressAfter
+	// false positives and few real people, such as an animated skybox, is
+	// ignored from then on
+	Grid          int `json:"grid" yaml:"grid"`
+	SuppressAfter int `json:"suppress_after" yaml:"suppress_after"` // 0 never ignores a region
+}
+
+// DefaultFeedbackConfig keeps examples in the working directory, tunes
+// thresholds after 20 labels to keep 95% of real people, and ignores a
+// sixteenth-of-the-frame cell after five false positives
+func DefaultFeedbackConfig() FeedbackConfig {
+	return FeedbackConfig{
+		Path:          "proximity_feedback.json",
+		MaxExamples:   5000,
+		AutoTune:      true,
+		MinExamples:   20,
+		Recall:        0.95,
+		Grid:          16,
+		SuppressAfter: 5,
+	}
+}
+
+// Validate checks the feedback ranges
+func (c FeedbackConfig) Validate() error {
+	if c.MaxExamples < 1 {
+		return fmt.Errorf("max_examples must be at least 1")
+	}
+	if c.MinExamples < 1 {
+		return fmt.Errorf("min_examples must be at least 1")
+	}
+	if c.Recall <= 0 || c.Recall > 1 {
+		return fmt.Errorf("recall must be above 0 and at most 1")
+	}
+	if c.Grid < 1 || c.Grid > 100 {
+		return fmt.Errorf("grid must be between 1 and 100")
+	}
+	if c.SuppressAfter < 0 {
+		return fmt.Errorf("suppress_after must not be negative")
+	}
+	return nil
+}
+
+// FeedbackExample is a labeled detection. Positions and area are fractions
+// of its source's frame, so they survive resolution changes.
+type FeedbackExample struct {
+	Time          time.Time `json:"time"`
+	TrackID       int64     `json:"track_id,omitempty"`
+	DetectionID   int64     `json:"detection_id,omitempty"`
+	SourceID      string    `json:"source_id,omitempty"`
+	Type          string    `json:"type"`
+	Confidence    float32   `json:"confidence"`               // As filtered, calibrated if calibration was on
+	RawConfidence float32   `json:"raw_confidence,omitempty"` // Detector's own, when calibrated
+	X             float64   `json:"x"`
+	Y             float64   `json:"y"`
+	Width         float64   `json:"width"`
+	Height        float64   `json:"height"`
+	Area          float64   `json:"area"`
+	Correct       bool      `json:"correct"` // false for a false positive
+}
+
+// SuppressedRegion is a part of a source's frame ignored for its false positives
+type SuppressedRegion struct {
+	SourceID string `json:"source_id,omitempty"`
+	Mask
+	False int `json:"false"`
+	True  int `json:"true"`
+}
+
+// FeedbackTuning is what the labeled examples taught
+type FeedbackTuning struct {
+	Examples      int                `json:"examples"`
+	True          int                `json:"true"`
+	False         int                `json:"false"`
+	MinConfidence map[string]float32 `json:"min_confidence"` // Raw confidence per detection type, applied on top of detection.min_confidence; empty until tuned
+	MinArea       float64            `json:"min_area"`       // Fraction of the frame; 0 until tuned
+	Regions       []SuppressedRegion `json:"regions"`
+}
+
+// feedbackStore holds the labeled examples, what they taught, and the
+// latest detections they can refer to
+type feedbackStore struct {
+	config atomic.Pointer[FeedbackConfig]
+	tuning atomic.Pointer[FeedbackTuning]
+	nextID atomic.Int64
+
+	mu         sync.Mutex
+	examples   []FeedbackExample
+	tracks     map[int64]rememberedTrack
+	detections []Detection // The latest feedbackRing, by ID modulo the ring size
+}
+
+// rememberedTrack is the last detection of a track
+type rememberedTrack struct {
+	detection Detection
+	seen      time.Time
+}
+
+// SetFeedbackConfig replaces the feedback settings and retunes; safe to call while running
+func (pe *ProximityEngine) SetFeedbackConfig(config FeedbackConfig) {
+	pe.feedback.config.Store(&config)
+	pe.feedback.mu.Lock()
+	pe.retune()
+	pe.feedback.mu.Unlock()
+	detectLog.Info("Feedback learning set", "path", config.Path, "auto_tune", config.AutoTune, "suppress_after", config.SuppressAfter)
+}
+
+// FeedbackConfig returns the feedback settings
+func (pe *ProximityEngine) FeedbackConfig() FeedbackConfig {
+	if config := pe.feedback.config.Load(); config != nil {
+		return *config
+	}
+	return DefaultFeedbackConfig()
+}
+
+// FeedbackTuning returns the thresholds and regions learned from feedback
+func (pe *ProximityEngine) FeedbackTuning() FeedbackTuning {
+	if tuning := pe.feedback.tuning.Load(); tuning != nil {
+		return *tuning
+	}
+	return FeedbackTuning{MinConfidence: map[string]float32{}, Regions: []SuppressedRegion{}}
+}
+
+// rememberDetections numbers each detection and keeps the latest ones, and
+// the last of each track, for Feedback to refer to
+func (pe *ProximityEngine) rememberDetections(detections []Detection, now time.Time) {
+	f := &pe.feedback
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.tracks == nil {
+		f.tracks, f.detections = make(map[int64]rememberedTrack), make([]Detection, feedbackRing)
+	}
+	for i := range detections {
+		d := &detections[i]
+		d.ID = f.nextID.Add(1)
+		f.detections[d.ID%feedbackRing] = *d
+		if d.TrackID != 0 {
+			f.tracks[d.TrackID] = rememberedTrack{detection: *d, seen: now}
+		}
+	}
+	for id, track := range f.tracks {
+		if now.Sub(track.seen) > feedbackRecall {
+			delete(f.tracks, id)
+		}
+	}
+}
+
+// Feedback labels a recent track, or a single detection by ID when trackID
+// is 0, as a real person or a false positive. The label moves the confidence
+// calibration, is kept as an example, and retunes the thresholds and
+// ignored regions.
+func (pe *ProximityEngine) Feedback(trackID, detectionID int64, correct bool) (FeedbackExample, error) {
+	f := &pe.feedback
+	f.mu.Lock()
+	var d Detection
+	switch {
+	case trackID != 0:
+		track, ok := f.tracks[trackID]
+		if !ok {
+			f.mu.Unlock()
+			return FeedbackExample{}, ErrUnknownTrack
+		}
+		d = track.detection
+	case detectionID > 0 && len(f.detections) > 0 && f.detections[detectionID%feedbackRing].ID == detectionID:
+		d = f.detections[detectionID%feedbackRing]
+	default:
+		f.mu.Unlock()
+		return FeedbackExample{}, ErrUnknownDetection
+	}
+
+	example := pe.feedbackExample(d, correct)
+	config := pe.FeedbackConfig()
+	f.examples = append(f.examples, example)
+	if over := len(f.examples) - config.MaxExamples; over > 0 {
+		f.examples = append(f.examples[:0], f.examples[over:]...)
+	}
+	pe.retune()
+	f.mu.Unlock()
+
+	detectLog.Info("Detection feedback", "track", d.TrackID, "detection", d.ID, "type", d.Type, "confidence", d.Confidence, "correct", correct)
+	err := pe.learn(d, correct)
+	if config.Path != "" {
+		err = errors.Join(err, pe.SaveFeedback(config.Path))
+	}
+	return example, err
+}
+
+// feedbackExample describes d relative to its source's frame, undoing the
+// offset of the merged frame
+func (pe *ProximityEngine) feedbackExample(d Detection, correct bool) FeedbackExample {
+	offset, width, height := 0, 0, 0
+	for _, source := range pe.Sources() {
+		if source.ID == d.SourceID {
+			offset, width, height = source.OffsetX, source.Width, source.Height
+			break
+		}
+	}
+	width, height = max(width, 1), max(height, 1)
+	return FeedbackExample{
+		Time:          time.Now().UTC(),
+		TrackID:       d.TrackID,
+		DetectionID:   d.ID,
+		SourceID:      d.SourceID,
+		Type:          d.Type,
+		Confidence:    d.Confidence,
+		RawConfidence: d.RawConfidence,
+		X:             float64(int(d.BBox.X)-offset) / float64(width),
+		Y:             float64(d.BBox.Y) / float64(height),
+		Width:         float64(d.BBox.Width) / float64(width),
+		Height:        float64(d.BBox.Height) / float64(height),
+		Area:          float64(d.Area) / float64(width*height),
+		Correct:       correct,
+	}
+}
+
+// ClearFeedback forgets every labeled example and what they taught; the
+// confidence calibration is kept
+func (pe *ProximityEngine) ClearFeedback() error {
+	f := &pe.feedback
+	f.mu.Lock()
+	f.examples = nil
+	pe.retune()
+	f.mu.Unlock()
+	detectLog.Info("Feedback cleared")
+	if path := pe.FeedbackConfig().Path; path != "" {
+		return pe.SaveFeedback(path)
+	}
+	return nil
+}
+
+// retune recomputes the learned thresholds and regions from the examples;
+// the caller holds feedback.mu
+func (pe *ProximityEngine) retune() {
+	config := pe.FeedbackConfig()
+	examples := pe.feedback.examples
+	tuning := FeedbackTuning{Examples: len(examples), MinConfidence: map[string]float32{}, Regions: []SuppressedRegion{}}
+
+	// Calibration moves the calibrated confidence as labels come in, so the
+	// thresholds are learned on the detector's own, per type like the curves
+	confidences := make(map[string][]float64)
+	var areas []float64
+	for _, e := range examples {
+		if e.Correct {
+			tuning.True++
+			confidences[e.Type] = append(confidences[e.Type], float64(rawConfidence(e.Confidence, e.RawConfidence)))
+			areas = append(areas, e.Area)
+		} else {
+			tuning.False++
+		}
+	}
+	if config.AutoTune && len(examples) >= config.MinExamples && tuning.True > 0 && tuning.False > 0 {
+		for detectionType, values := range confidences {
+			tuning.MinConfidence[detectionType] = float32(recallThreshold(values, config.Recall))
+		}
+		tuning.MinArea = recallThreshold(areas, config.Recall)
+	}
+
+	if config.SuppressAfter > 0 {
+		type cellKey struct {
+			source string
+			x, y   int
+		}
+		cells := make(map[cellKey]*SuppressedRegion)
+		var keys []cellKey
+		for _, e := range examples {
+			x := min(max(int((e.X+e.Width/2)*float64(config.Grid)), 0), config.Grid-1)
+			y := min(max(int((e.Y+e.Height/2)*float64(config.Grid)), 0), config.Grid-1)
+			key := cellKey{e.SourceID, x, y}
+			cell := cells[key]
+			if cell == nil {
+				size := 1 / float64(config.Grid)
+				cell = &SuppressedRegion{SourceID: e.SourceID, Mask: Mask{X: float64(x) * size, Y: float64(y) * size, Width: size, Height: size}}
+				cells[key] = cell
+				keys = append(keys, key)
+			}
+			if e.Correct {
+				cell.True++
+			} else {
+				cell.False++
+			}
+		}
+		// Mostly false positives; a real person now and then doesn't save the region
+		for _, key := range keys {
+			if cell := cells[key]; cell.False >= config.SuppressAfter && cell.False >= 4*cell.True {
+				tuning.Regions = append(tuning.Regions, *cell)
+			}
+		}
+	}
+
+	previous := pe.FeedbackTuning()
+	pe.feedback.tuning.Store(&tuning)
+	if len(tuning.Regions) != len(previous.Regions) || !maps.Equal(tuning.MinConfidence, previous.MinConfidence) || tuning.MinArea != previous.MinArea {
+		detectLog.Info("Feedback tuning updated", "examples", tuning.Examples,
+			"min_confidence", tuning.MinConfidence, "min_area", tuning.MinArea, "regions", len(tuning.Regions))
+	}
+}
+
+// recallThreshold returns the largest threshold that keeps recall of values
+func recallThreshold(values []float64, recall float32) float64 {
+	sort.Float64s(values)
+	return values[int(math.Floor(float64(1-recall)*float64(len(values))))]
+}
+
+// applyFeedback drops detections in ignored regions of the source and
+// below the tuned thresholds
+func (pe *ProximityEngine) applyFeedback(sourceID string, detections []Detection, frameWidth, frameHeight int32) []Detection {
+	tuning := pe.feedback.tuning.Load()
+	if tuning == nil || frameWidth <= 0 || frameHeight <= 0 {
+		return detections
+	}
+	if len(tuning.MinConfidence) == 0 && tuning.MinArea == 0 && len(tuning.Regions) == 0 {
+		return detections
+	}
+
+	frameArea := float64(frameWidth) * float64(frameHeight)
+	kept := detections[:0]
+	for _, d := range detections {
+		if rawConfidence(d.Confidence, d.RawConfidence) < tuning.MinConfidence[d.Type] || float64(d.Area)/frameArea < tuning.MinArea {
+			continue
+		}
+		x := (float64(d.BBox.X) + float64(d.BBox.Width)/2) / float64(frameWidth)
+		y := (float64(d.BBox.Y) + float64(d.BBox.Height)/2) / float64(frameHeight)
+		suppressed := false
+		for _, region := range tuning.Regions {
+			if region.SourceID == sourceID && region.contains(x, y) {
+				suppressed = true
+				break
+			}
+		}
+		if !suppressed {
+			kept = append(kept, d)
+		}
+	}
+	return kept
+}
+
+// feedbackFile is the saved form of the examples
+type feedbackFile struct {
+	Examples []FeedbackExample `json:"examples"`
+}
+
+// LoadFeedback restores the examples saved at path and retunes; a missing
+// file starts from none
+func (pe *ProximityEngine) LoadFeedback(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read feedback: %w", err)
+	}
+	var file feedbackFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("parse feedback %s: %w", path, err)
+	}
+	pe.feedback.mu.Lock()
+	pe.feedback.examples = file.Examples
+	pe.retune()
+	pe.feedback.mu.Unlock()
+	detectLog.Info("Feedback loaded", "path", path, "examples", len(file.Examples))
+	return nil
+}
+
+// SaveFeedback writes the examples to path
+func (pe *ProximityEngine) SaveFeedback(path string) error {
+	pe.feedback.mu.Lock()
+	data, err := json.MarshalIndent(feedbackFile{Examples: pe.feedback.examples}, "", "  ")
+	pe.feedback.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return fmt.Errorf("save feedback: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DetectionConfig merges and filters converted detections before they reach the hooks
+type DetectionConfig struct {
+	MergeIoU      float32 `json:"merge_iou" yaml:"merge_iou"`           // Merge boxes overlapping at least this much; 0 disables
+	MinConfidence float32 `json:"min_confidence" yaml:"min_confidence"` // 0-1
+	MinArea       float32 `json:"min_area" yaml:"min_area"`             // Pixels
+	MaxDetections int     `json:"max_detections" yaml:"max_detections"` // Keep only the nearest; 0 keeps all
+}
+
+// DefaultDetectionConfig merges overlapping boxes and keeps every result
+func DefaultDetectionConfig() DetectionConfig {
+	return DetectionConfig{MergeIoU: 0.3}
+}
+
+// Validate checks the filter ranges
+func (c DetectionConfig) Validate() error {
+	if c.MergeIoU < 0 || c.MergeIoU > 1 {
+		return fmt.Errorf("merge_iou must be between 0 and 1")
+	}
+	if c.MinConfidence < 0 || c.MinConfidence > 1 {
+		return fmt.Errorf("min_confidence must be between 0 and 1")
+	}
+	if c.MinArea < 0 {
+		return fmt.Errorf("min_area must not be negative")
+	}
+	if c.MaxDetections < 0 {
+		return fmt.Errorf("max_detections must not be negative")
+	}
+	return nil
+}
+
+// SetDetectionConfig replaces the detection filter; safe to call while running
+func (pe *ProximityEngine) SetDetectionConfig(config DetectionConfig) {
+	pe.detectionConfig.Store(&config)
+	detectLog.Info("Detection filter set", "merge_iou", config.MergeIoU,
+		"min_confidence", config.MinConfidence, "min_area", config.MinArea, "max_detections", config.MaxDetections)
+}
+
+// DetectionConfig returns the detection filter
+func (pe *ProximityEngine) DetectionConfig() DetectionConfig {
+	if config := pe.detectionConfig.Load(); config != nil {
+		return *config
+	}
+	return DefaultDetectionConfig()
+}
+
+// filterDetections drops detections below the confidence and area minimums
+// and keeps at most MaxDetections, nearest first
+func (pe *ProximityEngine) filterDetections(detections []Detection) []Detection {
+	config := pe.DetectionConfig()
+
+	kept := detections[:0]
+	for _, d := range detections {
+		if d.Confidence >= config.MinConfidence && d.Area >= config.MinArea {
+			kept = append(kept, d)
+		}
+	}
+
+	if config.MaxDetections > 0 && len(kept) > config.MaxDetections {
+		sort.SliceStable(kept, func(i, j int) bool { return kept[i].Distance < kept[j].Distance })
+		kept = kept[:config.MaxDetections]
+	}
+	return kept
+}
+
+// mergeDetections replaces each group of boxes overlapping by at least
+// MergeIoU with their union, since motion detection often reports several
+// boxes for one avatar. Merged boxes get a fresh distance estimate.
+func (pe *ProximityEngine) mergeDetections(detections []Detection, frameWidth, frameHeight int32) []Detection {
+	threshold := pe.DetectionConfig().MergeIoU
+	if threshold <= 0 || len(detections) < 2 {
+		return detections
+	}
+
+	// Strongest first so its type survives the merge
+	sort.SliceStable(detections, func(i, j int) bool { return detections[i].Confidence > detections[j].Confidence })
+
+	// A union can grow into boxes it skipped earlier, so repeat until stable
+	for merged := true; merged; {
+		merged = false
+		result := detections[:0]
+		for _, d := range detections {
+			absorbed := false
+			for i := range result {
+				if boxIoU(result[i].BBox, d.BBox) >= threshold {
+					result[i] = mergePair(result[i], d)
+					absorbed, merged = true, true
+					break
+				}
+			}
+			if !absorbed {
+				result = append(result, d)
+			}
+		}
+		detections = result
+	}
+
+	for i := range detections {
+		detections[i].Distance, detections[i].Category = pe.estimateDistance(detections[i], frameWidth, frameHeight)
+	}
+	return detections
+}
+
+// mergePair combines b into a: union box, highest confidence, summed motion area
+func mergePair(a, b Detection) Detection {
+	x0, y0 := min(a.BBox.X, b.BBox.X), min(a.BBox.Y, b.BBox.Y)
+	x1 := max(a.BBox.X+a.BBox.Width, b.BBox.X+b.BBox.Width)
+	y1 := max(a.BBox.Y+a.BBox.Height, b.BBox.Y+b.BBox.Height)
+
+	a.BBox = BoundingBox{X: x0, Y: y0, Width: x1 - x0, Height: y1 - y0}
+	a.Confidence = max(a.Confidence, b.Confidence)
+	a.Area += b.Area
+	return a
+}
+
+// boxIoU returns the intersection over union of two boxes
+func boxIoU(a, b BoundingBox) float32 {
+	ix := min(a.X+a.Width, b.X+b.Width) - max(a.X, b.X)
+	iy := min(a.Y+a.Height, b.Y+b.Height) - max(a.Y, b.Y)
+	if ix <= 0 || iy <= 0 {
+		return 0
+	}
+	intersection := float32(ix) * float32(iy)
+	union := float32(a.Width)*float32(a.Height) + float32(b.Width)*float32(b.Height) - intersection
+	if union <= 0 {
+		return 0
+	}
+	return intersection / union
+}
@@ -0,0 +1,137 @@
+package engine
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+
+	"vrchat-proximity/pkg/capture"
+)
+
+// FollowConfig ties capture to a game process
+type FollowConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Process  string        `yaml:"process"`  // Executable name, matched case-insensitively
+	Interval time.Duration `yaml:"interval"` // How often the process list is scanned
+}
+
+// DefaultFollowConfig follows VRChat.exe, checking every two seconds
+func DefaultFollowConfig() FollowConfig {
+	return FollowConfig{
+		Process:  "VRChat.exe",
+		Interval: 2 * time.Second,
+	}
+}
+
+// FocusConfig ties capture to the game window having focus
+type FocusConfig struct {
+	Enabled     bool          `yaml:"enabled"`
+	WindowTitle string        `yaml:"window_title"` // Foreground window title that counts as focused
+	Interval    time.Duration `yaml:"interval"`
+}
+
+// DefaultFocusConfig watches for the VRChat window twice a second
+func DefaultFocusConfig() FocusConfig {
+	return FocusConfig{
+		WindowTitle: "VRChat",
+		Interval:    500 * time.Millisecond,
+	}
+}
+
+// FollowProcess pauses capture while the configured process is not running
+// and resumes it when the process appears, until ctx is cancelled. Other
+// pause reasons, such as a manual pause, are left in place. A launch starts
+// a new session and an exit ends the current one.
+func (pe *ProximityEngine) FollowProcess(ctx context.Context, config FollowConfig) {
+	if config.Interval <= 0 {
+		config.Interval = DefaultFollowConfig().Interval
+	}
+	captureLog.Info("Following process", "process", config.Process)
+
+	ticker := time.NewTicker(config.Interval)
+	defer ticker.Stop()
+
+	known, running := false, false
+	for {
+		found, err := processRunning(ctx, config.Process)
+		if err != nil {
+			captureLog.Warn("Process scan failed", "error", err)
+		} else if !known || found != running {
+			launched := known && found
+			known, running = true, found
+			if launched {
+				pe.sessions.begin(SessionLaunch, pe.Instance(), time.Now())
+			}
+			if found {
+				captureLog.Info("Game process started, resuming capture", "process", config.Process)
+				pe.ResumeFrom(PauseNoProcess)
+			} else {
+				captureLog.Info("Game process not running, pausing capture", "process", config.Process)
+				pe.sessions.end(time.Now())
+				pe.PauseFor(PauseNoProcess)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// processRunning reports whether a process with the given executable name exists
+func processRunning(ctx context.Context, name string) (bool, error) {
+	processes, err := process.ProcessesWithContext(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, p := range processes {
+		if n, err := p.NameWithContext(ctx); err == nil && strings.EqualFold(n, name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// FollowFocus pauses capture while another window is in the foreground, so
+// alt-tabbing to a browser or IDE doesn't produce detections, until ctx is
+// cancelled. It returns immediately where focus can't be detected.
+func (pe *ProximityEngine) FollowFocus(ctx context.Context, config FocusConfig) {
+	if config.Interval <= 0 {
+		config.Interval = DefaultFocusConfig().Interval
+	}
+	if _, err := capture.ForegroundWindowTitle(); err != nil {
+		captureLog.Warn("Focus-only capture disabled", "error", err)
+		return
+	}
+	captureLog.Info("Capturing only while focused", "window", config.WindowTitle)
+
+	ticker := time.NewTicker(config.Interval)
+	defer ticker.Stop()
+
+	known, focused := false, false
+	for {
+		title, err := capture.ForegroundWindowTitle()
+		if err == nil {
+			if now := strings.EqualFold(title, config.WindowTitle); !known || now != focused {
+				known, focused = true, now
+				if focused {
+					captureLog.Debug("Game window focused, resuming capture")
+					pe.ResumeFrom(PauseUnfocused)
+				} else {
+					captureLog.Debug("Game window lost focus, pausing capture", "foreground", title)
+					pe.PauseFor(PauseUnfocused)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
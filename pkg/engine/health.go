@@ -0,0 +1,70 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"vrchat-proximity/pkg/capture"
+)
+
+// Readiness checks reported by Ready
+const (
+	ReadyEngine  = "engine"
+	ReadyCapture = "capture"
+	ReadyZig     = "zig"
+)
+
+// readyFrameAge is how recent the last frame must be for the engine to be
+// ready, stretched to two frame intervals at very low FPS
+const readyFrameAge = time.Second
+
+// Ready runs the readiness checks and returns the reason each failed one
+// failed; an empty map means the engine is running and capturing frames.
+// Capture and the Zig library aren't checked when capture is disabled.
+func (pe *ProximityEngine) Ready() map[string]string {
+	failures := make(map[string]string)
+	switch state := pe.State(); state {
+	case StateRunning:
+	case StatePaused:
+		failures[ReadyEngine] = "paused: " + pe.PausedReason()
+	default:
+		failures[ReadyEngine] = "engine " + state
+	}
+	if !pe.captureEnabled {
+		return failures
+	}
+
+	if err := capture.Probe(); err != nil {
+		failures[ReadyZig] = err.Error()
+	}
+	if _, ok := failures[ReadyEngine]; ok {
+		return failures
+	}
+	if reason := pe.captureStale(time.Now()); reason != "" {
+		failures[ReadyCapture] = reason
+	}
+	return failures
+}
+
+// captureStale explains why no frame arrived recently, or returns ""
+func (pe *ProximityEngine) captureStale(now time.Time) string {
+	maxAge := readyFrameAge
+	if fps := pe.TargetFPS(); fps > 0 {
+		maxAge = max(maxAge, 2*time.Second/time.Duration(fps))
+	}
+	last := pe.lastFrameAt.Load()
+	if last != 0 && now.Sub(time.Unix(0, last)) <= maxAge {
+		return ""
+	}
+
+	switch {
+	case pe.sourceEnded.Load():
+		return "frame source ended"
+	case pe.lastNoFrame.Load() != 0 && now.Sub(time.Unix(0, pe.lastNoFrame.Load())) <= maxAge:
+		return "no frame available, VRChat window closed or minimized"
+	case last == 0:
+		return "no frame captured yet"
+	default:
+		return fmt.Sprintf("no frame captured for %s", now.Sub(time.Unix(0, last)).Round(100*time.Millisecond))
+	}
+}
@@ -0,0 +1,139 @@
+package engine
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Instance access types, from the tags in a VRChat instance ID
+const (
+	AccessPublic      = "public"
+	AccessFriendsPlus = "friends_plus" // ~hidden
+	AccessFriends     = "friends"
+	AccessInvitePlus  = "invite_plus" // ~private with ~canRequestInvite
+	AccessInvite      = "invite"
+	AccessGroup       = "group"
+)
+
+// AccessTypes lists the instance access types
+var AccessTypes = []string{AccessPublic, AccessFriendsPlus, AccessFriends, AccessInvitePlus, AccessInvite, AccessGroup}
+
+// Instance describes the VRChat instance the player is in, as reported by
+// an integration such as the VRChat API
+type Instance struct {
+	Known     bool      `json:"known"` // False until an integration has reported, or when the location is private
+	Location  string    `json:"location,omitempty"`
+	World     string    `json:"world,omitempty"`
+	Users     int       `json:"users"`
+	Friends   []string  `json:"friends"` // Display names of friends in the same instance
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Access returns the instance's access type, or "" while it is unknown
+func (i Instance) Access() string {
+	switch {
+	case !i.Known:
+		return ""
+	case strings.Contains(i.Location, "~group("):
+		return AccessGroup
+	case strings.Contains(i.Location, "~private(") && strings.Contains(i.Location, "~canRequestInvite"):
+		return AccessInvitePlus
+	case strings.Contains(i.Location, "~private("):
+		return AccessInvite
+	case strings.Contains(i.Location, "~friends("):
+		return AccessFriends
+	case strings.Contains(i.Location, "~hidden("):
+		return AccessFriendsPlus
+	default:
+		return AccessPublic
+	}
+}
+
+// instanceState holds the reported instance and the alert gate
+type instanceState struct {
+	mu             sync.RWMutex
+	instance       Instance
+	requireFriends bool
+}
+
+// SetInstance records the current instance, starting a new session when
+// the location changes; safe to call from any goroutine
+func (pe *ProximityEngine) SetInstance(instance Instance) {
+	instance.Friends = append([]string(nil), instance.Friends...)
+	pe.instance.mu.Lock()
+	pe.instance.instance = instance
+	pe.instance.mu.Unlock()
+	pe.sessions.located(instance, time.Now())
+}
+
+// SetInstanceLocation records a location seen without the rest of the
+// instance details, as from the VRChat log; friends and the user count are
+// kept while the location is unchanged
+func (pe *ProximityEngine) SetInstanceLocation(location, world string) {
+	pe.instance.mu.Lock()
+	instance := pe.instance.instance
+	if instance.Location != location {
+		instance.Users, instance.Friends, instance.World = 0, nil, ""
+	}
+	instance.Known, instance.Location, instance.UpdatedAt = true, location, time.Now()
+	if world != "" {
+		instance.World = world
+	}
+	pe.instance.instance = instance
+	pe.instance.mu.Unlock()
+	pe.sessions.located(instance, time.Now())
+}
+
+// Instance returns the last reported instance
+func (pe *ProximityEngine) Instance() Instance {
+	pe.instance.mu.RLock()
+	defer pe.instance.mu.RUnlock()
+	instance := pe.instance.instance
+	instance.Friends = append([]string(nil), instance.Friends...)
+	return instance
+}
+
+// SetAlertsRequireFriends mutes alert hooks while the known instance has no friends in it
+func (pe *ProximityEngine) SetAlertsRequireFriends(require bool) {
+	pe.instance.mu.Lock()
+	pe.instance.requireFriends = require
+	pe.instance.mu.Unlock()
+}
+
+// AlertsMuted reports whether alert hooks are currently muted, by the
+// friends requirement or by the rules for the instance type
+func (pe *ProximityEngine) AlertsMuted() bool {
+	pe.instance.mu.RLock()
+	friendless := pe.instance.requireFriends && pe.instance.instance.Known && len(pe.instance.instance.Friends) == 0
+	pe.instance.mu.RUnlock()
+	return friendless || !pe.InstanceAllows(BehaviorAlerts)
+}
+
+// friendAdjacent reports whether friends share the current instance
+func (pe *ProximityEngine) friendAdjacent() bool {
+	pe.instance.mu.RLock()
+	defer pe.instance.mu.RUnlock()
+	return len(pe.instance.instance.Friends) > 0
+}
+
+// OnAlert registers an event hook for outputs that interrupt the player,
+// such as notifications. It skips events marked muted by player rules,
+// quiet hours, cooldowns, or AlertsMuted.
+func (pe *ProximityEngine) OnAlert(hook func(ProximityEvent)) {
+	pe.OnEvent(func(event ProximityEvent) {
+		if !event.Muted {
+			hook(event)
+		}
+	})
+}
+
+// OnAlertDetections registers a detection hook for outputs that interrupt
+// the player, such as haptics. It sees the batch without never-alert
+// players, and only always-alert players while AlertsMuted; an empty batch
+// lets outputs that hold a level settle.
+func (pe *ProximityEngine) OnAlertDetections(hook func([]Detection)) {
+	pe.OnDetections(func(detections []Detection) {
+		hook(pe.alertDetections(detections))
+	})
+}
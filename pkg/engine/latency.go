@@ -0,0 +1,109 @@
+package engine
+
+import (
+	"math"
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// Histogram resolution: each power-of-two range of microseconds is split
+// into 16 linear sub-buckets, keeping quantile error under about 6%
+const (
+	histogramSubBits    = 4
+	histogramSubBuckets = 1 << histogramSubBits
+	histogramBuckets    = (64 - histogramSubBits + 1) * histogramSubBuckets
+)
+
+// LatencySummary reports quantiles of one pipeline stage in milliseconds
+type LatencySummary struct {
+	Count  uint64  `json:"count"`
+	MeanMS float64 `json:"mean_ms"`
+	P50MS  float64 `json:"p50_ms"`
+	P95MS  float64 `json:"p95_ms"`
+	P99MS  float64 `json:"p99_ms"`
+	MaxMS  float64 `json:"max_ms"`
+}
+
+// latencyHistogram is a lock-free log-linear histogram of durations
+type latencyHistogram struct {
+	buckets [histogramBuckets]atomic.Uint64
+	count   atomic.Uint64
+	sum     atomic.Uint64 // microseconds
+	max     atomic.Uint64 // microseconds
+}
+
+// record adds one duration
+func (h *latencyHistogram) record(d time.Duration) {
+	us := uint64(0)
+	if d > 0 {
+		us = uint64(d.Microseconds())
+	}
+
+	h.buckets[histogramIndex(us)].Add(1)
+	h.count.Add(1)
+	h.sum.Add(us)
+	for {
+		current := h.max.Load()
+		if us <= current || h.max.CompareAndSwap(current, us) {
+			break
+		}
+	}
+}
+
+// quantile returns the upper bound in microseconds of the bucket holding quantile q
+func (h *latencyHistogram) quantile(q float64) uint64 {
+	total := h.count.Load()
+	if total == 0 {
+		return 0
+	}
+	target := uint64(math.Ceil(q * float64(total)))
+	if target == 0 {
+		target = 1
+	}
+
+	var seen uint64
+	for i := range h.buckets {
+		seen += h.buckets[i].Load()
+		if seen >= target {
+			return min(histogramUpperBound(i), h.max.Load())
+		}
+	}
+	return h.max.Load()
+}
+
+// summary snapshots the histogram
+func (h *latencyHistogram) summary() LatencySummary {
+	count := h.count.Load()
+	s := LatencySummary{
+		Count: count,
+		P50MS: float64(h.quantile(0.50)) / 1000,
+		P95MS: float64(h.quantile(0.95)) / 1000,
+		P99MS: float64(h.quantile(0.99)) / 1000,
+		MaxMS: float64(h.max.Load()) / 1000,
+	}
+	if count > 0 {
+		s.MeanMS = float64(h.sum.Load()) / float64(count) / 1000
+	}
+	return s
+}
+
+// histogramIndex maps microseconds to a bucket
+func histogramIndex(us uint64) int {
+	if us < histogramSubBuckets {
+		return int(us)
+	}
+	shift := bits.Len64(us) - histogramSubBits - 1
+	mantissa := us >> uint(shift) // in [16, 32)
+	return (shift+1)*histogramSubBuckets + int(mantissa-histogramSubBuckets)
+}
+
+// histogramUpperBound is the largest value stored in bucket i
+func histogramUpperBound(i int) uint64 {
+	if i < histogramSubBuckets {
+		return uint64(i)
+	}
+	shift := uint(i/histogramSubBuckets - 1)
+	mantissa := uint64(histogramSubBuckets + i%histogramSubBuckets)
+	return (mantissa+1)<<shift - 1
+}
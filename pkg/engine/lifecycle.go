@@ -0,0 +1,140 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Engine states reported by State
+const (
+	StateStopped  = "stopped"
+	StateStarting = "starting"
+	StateRunning  = "running"
+	StatePaused   = "paused"
+	StateStopping = "stopping"
+)
+
+// Reasons capture can be paused for, reported by PausedReason
+const (
+	PauseManual    = "manual"
+	PauseNoProcess = "process_not_running"
+	PauseUnfocused = "window_unfocused"
+)
+
+// State returns whether the engine is stopped, starting, running, paused,
+// or stopping
+func (pe *ProximityEngine) State() string {
+	if transition, _ := pe.transition.Load().(string); transition != "" {
+		return transition
+	}
+	switch {
+	case !pe.running.Load():
+		return StateStopped
+	case pe.paused.Load():
+		return StatePaused
+	default:
+		return StateRunning
+	}
+}
+
+// PausedReason returns why capture is paused, comma-separated when several
+// reasons apply, or "" while capturing
+func (pe *ProximityEngine) PausedReason() string {
+	reason, _ := pe.pausedReason.Load().(string)
+	return reason
+}
+
+// Pause stops capturing frames while keeping hooks, zones, and injected
+// detections working. Start or Resume picks capture up again.
+func (pe *ProximityEngine) Pause() error {
+	return pe.PauseFor(PauseManual)
+}
+
+// PauseFor pauses capture for a reason; capture resumes once every reason
+// has been cleared with ResumeFrom, or unconditionally with Resume
+func (pe *ProximityEngine) PauseFor(reason string) error {
+	pe.lifecycleMutex.Lock()
+	defer pe.lifecycleMutex.Unlock()
+
+	if !pe.running.Load() {
+		return fmt.Errorf("engine not running")
+	}
+	if pe.pauseReasons[reason] {
+		return nil
+	}
+	pe.pauseReasons[reason] = true
+	pe.storePausedReason()
+	if pe.paused.Swap(true) {
+		return nil
+	}
+
+	pe.loopMutex.Lock()
+	if pe.cancelLoop != nil {
+		pe.cancelLoop()
+		pe.cancelLoop = nil
+	}
+	pe.loopMutex.Unlock()
+
+	engineLog.Info("Proximity Engine paused", "reason", reason)
+	return nil
+}
+
+// Resume restarts capture on a paused engine, whatever paused it
+func (pe *ProximityEngine) Resume() error {
+	pe.lifecycleMutex.Lock()
+	defer pe.lifecycleMutex.Unlock()
+
+	if !pe.running.Load() {
+		return fmt.Errorf("engine not running")
+	}
+	if pe.paused.Load() {
+		pe.resume()
+	}
+	return nil
+}
+
+// ResumeFrom clears one pause reason, resuming capture if it was the last
+func (pe *ProximityEngine) ResumeFrom(reason string) error {
+	pe.lifecycleMutex.Lock()
+	defer pe.lifecycleMutex.Unlock()
+
+	if !pe.running.Load() {
+		return fmt.Errorf("engine not running")
+	}
+	if !pe.pauseReasons[reason] {
+		return nil
+	}
+	delete(pe.pauseReasons, reason)
+	pe.storePausedReason()
+	if len(pe.pauseReasons) == 0 {
+		pe.resume()
+	}
+	return nil
+}
+
+// resume clears every pause reason and relaunches capture; lifecycleMutex must be held
+func (pe *ProximityEngine) resume() {
+	pe.clearPause()
+	if pe.captureEnabled {
+		pe.startCaptureLoop()
+	}
+	engineLog.Info("Proximity Engine resumed")
+}
+
+// clearPause forgets all pause reasons; lifecycleMutex must be held
+func (pe *ProximityEngine) clearPause() {
+	pe.pauseReasons = make(map[string]bool)
+	pe.storePausedReason()
+	pe.paused.Store(false)
+}
+
+// storePausedReason publishes the sorted pause reasons for PausedReason
+func (pe *ProximityEngine) storePausedReason() {
+	reasons := make([]string, 0, len(pe.pauseReasons))
+	for reason := range pe.pauseReasons {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	pe.pausedReason.Store(strings.Join(reasons, ","))
+}
@@ -0,0 +1,144 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CumulativeStats are counters over one run of the app or, with Runs and
+// Since set, over every run
+type CumulativeStats struct {
+	Frames        int64      `json:"frames"`
+	Detections    int64      `json:"detections"`
+	Sessions      int64      `json:"sessions"`
+	UptimeSeconds float64    `json:"uptime_seconds"`
+	Runs          int64      `json:"runs,omitempty"`
+	Since         *time.Time `json:"since,omitempty"` // Start of the first run
+}
+
+// StatsConfig configures where lifetime statistics are kept
+type StatsConfig struct {
+	Path         string        `json:"path" yaml:"path"`                   // JSON file; empty keeps statistics for this run only
+	SaveInterval time.Duration `json:"save_interval" yaml:"save_interval"` // Also save this often, so a crash loses little; 0 saves on exit only
+}
+
+// DefaultStatsConfig keeps lifetime statistics in the working directory
+func DefaultStatsConfig() StatsConfig {
+	return StatsConfig{Path: "proximity_stats.json", SaveInterval: 5 * time.Minute}
+}
+
+// Validate checks the save interval
+func (c StatsConfig) Validate() error {
+	if c.SaveInterval < 0 {
+		return fmt.Errorf("save_interval must not be negative")
+	}
+	return nil
+}
+
+// RunStats returns the counters since the engine was created
+func (pe *ProximityEngine) RunStats() CumulativeStats {
+	pe.sessions.mu.Lock()
+	sessions := int64(pe.sessions.nextID)
+	pe.sessions.mu.Unlock()
+	return CumulativeStats{
+		Frames:        pe.frameCount.Load(),
+		Detections:    pe.detectionsCount.Load(),
+		Sessions:      sessions,
+		UptimeSeconds: time.Since(pe.createdAt).Seconds(),
+	}
+}
+
+// LifetimeStats returns the counters of earlier runs, as loaded by
+// LoadLifetimeStats, plus this one
+func (pe *ProximityEngine) LifetimeStats() CumulativeStats {
+	stats := pe.RunStats()
+	stats.Runs = 1
+	since := pe.createdAt
+	stats.Since = &since
+	if previous := pe.lifetime.Load(); previous != nil {
+		stats.Frames += previous.Frames
+		stats.Detections += previous.Detections
+		stats.Sessions += previous.Sessions
+		stats.UptimeSeconds += previous.UptimeSeconds
+		stats.Runs += previous.Runs
+		if previous.Since != nil {
+			stats.Since = previous.Since
+		}
+	}
+	return stats
+}
+
+// LoadLifetimeStats adds the counters saved at path by earlier runs to
+// LifetimeStats; a missing file starts from zero
+func (pe *ProximityEngine) LoadLifetimeStats(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read lifetime stats: %w", err)
+	}
+	var stats CumulativeStats
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return fmt.Errorf("parse lifetime stats %s: %w", path, err)
+	}
+	pe.lifetime.Store(&stats)
+	engineLog.Info("Lifetime statistics loaded", "path", path, "runs", stats.Runs, "frames", stats.Frames)
+	return nil
+}
+
+// SaveLifetimeStats writes LifetimeStats to path
+func (pe *ProximityEngine) SaveLifetimeStats(path string) error {
+	data, err := json.MarshalIndent(pe.LifetimeStats(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return fmt.Errorf("save lifetime stats: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic replaces path with data only once data is fully written,
+// so a crash mid-save leaves the old file intact
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// KeepLifetimeStats saves LifetimeStats to path every interval until ctx is
+// cancelled; the final save on exit is up to the caller
+func (pe *ProximityEngine) KeepLifetimeStats(ctx context.Context, path string, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := pe.SaveLifetimeStats(path); err != nil {
+				engineLog.Warn("Saving lifetime statistics failed", "error", err)
+			}
+		}
+	}
+}
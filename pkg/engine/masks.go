@@ -0,0 +1,56 @@
+package engine
+
+// Mask is a screen region whose detections are ignored, such as a HUD, a
+// mirror, or a video player. Coordinates are fractions of the frame size.
+type Mask struct {
+	X      float64 `json:"x" yaml:"x"`
+	Y      float64 `json:"y" yaml:"y"`
+	Width  float64 `json:"width" yaml:"width"`
+	Height float64 `json:"height" yaml:"height"`
+}
+
+// contains reports whether a normalized point lies inside the mask
+func (m Mask) contains(x, y float64) bool {
+	return x >= m.X && x < m.X+m.Width && y >= m.Y && y < m.Y+m.Height
+}
+
+// SetMasks replaces the masked regions; safe to call while running
+func (pe *ProximityEngine) SetMasks(masks []Mask) {
+	masks = append([]Mask(nil), masks...)
+	pe.masks.Store(&masks)
+	detectLog.Info("Masks set", "count", len(masks))
+}
+
+// Masks returns the masked regions
+func (pe *ProximityEngine) Masks() []Mask {
+	if masks := pe.masks.Load(); masks != nil {
+		return append([]Mask(nil), *masks...)
+	}
+	return nil
+}
+
+// applyMasks drops detections whose center falls inside a mask
+func (pe *ProximityEngine) applyMasks(detections []Detection, frameWidth, frameHeight int32) []Detection {
+	masks := pe.masks.Load()
+	if masks == nil || len(*masks) == 0 || frameWidth <= 0 || frameHeight <= 0 {
+		return detections
+	}
+
+	kept := detections[:0]
+	for _, d := range detections {
+		x := (float64(d.BBox.X) + float64(d.BBox.Width)/2) / float64(frameWidth)
+		y := (float64(d.BBox.Y) + float64(d.BBox.Height)/2) / float64(frameHeight)
+
+		masked := false
+		for _, m := range *masks {
+			if m.contains(x, y) {
+				masked = true
+				break
+			}
+		}
+		if !masked {
+			kept = append(kept, d)
+		}
+	}
+	return kept
+}
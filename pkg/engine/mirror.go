@@ -0,0 +1,263 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"vrchat-proximity/pkg/capture"
+)
+
+// EventBehindYou is emitted when someone first shows up in a mirror you are
+// facing, meaning they are actually behind you
+const EventBehindYou = "behind_you"
+
+// DirectionRear is the direction of behind_you events
+const DirectionRear = "rear"
+
+// mirrorSamples bounds the pixels compared per side of a region when
+// scoring its symmetry
+const mirrorSamples = 64
+
+// MirrorConfig configures the mirror heuristic. Facing a VRChat mirror, your
+// own reflection is a large, left-right symmetric motion region near the
+// middle of the view that keeps moving with you; anyone else inside the
+// mirror around it is a reflection of someone behind you.
+type MirrorConfig struct {
+	Enabled         bool          `json:"enabled" yaml:"enabled"`
+	MinArea         float32       `json:"min_area" yaml:"min_area"`                 // Smallest reflection of yourself, as a fraction of the frame area
+	CenterTolerance float32       `json:"center_tolerance" yaml:"center_tolerance"` // How far its center may be from the middle, as a fraction of the frame width
+	MinSymmetry     float32       `json:"min_symmetry" yaml:"min_symmetry"`         // How closely it must match its own mirror image, 0 (anything) to 1 (exactly)
+	MinFrames       int           `json:"min_frames" yaml:"min_frames"`             // Frames in a row it must be seen before you count as facing a mirror
+	Hold            time.Duration `json:"hold" yaml:"hold"`                         // Keep facing the mirror this long after the reflection is last seen, e.g. while standing still
+	RegionScale     float32       `json:"region_scale" yaml:"region_scale"`         // The mirror is taken to be this many times the size of your reflection
+	IgnoreSelf      bool          `json:"ignore_self" yaml:"ignore_self"`           // Drop your own reflection instead of reporting it as someone very close
+}
+
+// DefaultMirrorConfig leaves the heuristic off; once enabled, a centered,
+// mostly symmetric region of a tenth of the frame seen for ten frames is a
+// mirror. Your reflection is still reported, in case it is someone close.
+func DefaultMirrorConfig() MirrorConfig {
+	return MirrorConfig{
+		MinArea:         0.1,
+		CenterTolerance: 0.1,
+		MinSymmetry:     0.5,
+		MinFrames:       10,
+		Hold:            10 * time.Second,
+		RegionScale:     2.5,
+	}
+}
+
+// Validate checks the mirror ranges
+func (c MirrorConfig) Validate() error {
+	if c.MinArea <= 0 || c.MinArea > 1 {
+		return fmt.Errorf("min_area must be above 0 and at most 1")
+	}
+	if c.CenterTolerance < 0 || c.CenterTolerance > 0.5 {
+		return fmt.Errorf("center_tolerance must be between 0 and 0.5")
+	}
+	if c.MinSymmetry < 0 || c.MinSymmetry > 1 {
+		return fmt.Errorf("min_symmetry must be between 0 and 1")
+	}
+	if c.MinFrames < 1 {
+		return fmt.Errorf("min_frames must be at least 1")
+	}
+	if c.Hold < 0 {
+		return fmt.Errorf("hold must not be negative")
+	}
+	if c.RegionScale < 1 {
+		return fmt.Errorf("region_scale must be at least 1")
+	}
+	return nil
+}
+
+// SetMirrorConfig replaces the mirror settings; safe to call while running
+func (pe *ProximityEngine) SetMirrorConfig(config MirrorConfig) {
+	pe.mirrorConfig.Store(&config)
+	detectLog.Info("Mirror settings set", "enabled", config.Enabled, "min_area", config.MinArea, "hold", config.Hold)
+}
+
+// MirrorConfig returns the mirror settings
+func (pe *ProximityEngine) MirrorConfig() MirrorConfig {
+	if config := pe.mirrorConfig.Load(); config != nil {
+		return *config
+	}
+	return DefaultMirrorConfig()
+}
+
+// FacingMirror reports whether any source currently looks into a mirror
+func (pe *ProximityEngine) FacingMirror() bool {
+	for _, p := range pe.pipelines() {
+		if p.mirror.facing.Load() {
+			return true
+		}
+	}
+	return false
+}
+
+// mirrorDetector follows one source's view for a mirror. Only facing is
+// read from other goroutines; the rest belongs to the capture loop.
+type mirrorDetector struct {
+	facing    atomic.Bool
+	self      BoundingBox // Last reflection of yourself
+	streak    int         // Frames in a row with a reflection like self
+	lastSeen  time.Time
+	announced map[int64]bool // Tracks behind you that behind_you went out for
+}
+
+// update looks for your reflection in detections and marks everyone inside
+// the mirror around it as behind you, turning their bearing around. It
+// returns the detections to keep and those newly behind you.
+func (m *mirrorDetector) update(detections []Detection, config MirrorConfig, frame capture.Frame, now time.Time) ([]Detection, []Detection) {
+	if !config.Enabled || !frame.Valid() {
+		m.reset()
+		return detections, nil
+	}
+	frameWidth, frameHeight := int32(frame.Width), int32(frame.Height)
+
+	self := -1
+	minArea := config.MinArea * float32(frameWidth) * float32(frameHeight)
+	for i, d := range detections {
+		if d.Area < minArea {
+			continue
+		}
+		center := (float32(d.BBox.X) + float32(d.BBox.Width)/2) / float32(frameWidth)
+		if center < 0.5-config.CenterTolerance || center > 0.5+config.CenterTolerance {
+			continue
+		}
+		if self >= 0 && d.Area <= detections[self].Area {
+			continue
+		}
+		if mirrorSymmetry(frame, d.BBox) < config.MinSymmetry {
+			continue
+		}
+		self = i
+	}
+
+	if self >= 0 {
+		if m.streak > 0 && boxIoU(m.self, detections[self].BBox) < 0.3 {
+			m.streak = 0
+		}
+		m.streak++
+		m.self = detections[self].BBox
+		m.lastSeen = now
+	} else if now.Sub(m.lastSeen) > config.Hold {
+		m.reset()
+		return detections, nil
+	}
+
+	if !m.facing.Load() {
+		if m.streak < config.MinFrames {
+			return detections, nil
+		}
+		m.facing.Store(true)
+		detectLog.Info("Facing a mirror", "x", m.self.X, "y", m.self.Y, "width", m.self.Width, "height", m.self.Height)
+	}
+
+	region := scaleBox(m.self, config.RegionScale, frameWidth, frameHeight)
+	kept := detections[:0]
+	var behind []Detection
+	seen := make(map[int64]bool)
+	for i, d := range detections {
+		if i == self {
+			if !config.IgnoreSelf {
+				kept = append(kept, d)
+			}
+			continue
+		}
+		if boxContainsCenter(region, d.BBox) {
+			d.Behind = true
+			d.Bearing = rearBearing(d.Bearing)
+			if d.TrackID != 0 {
+				seen[d.TrackID] = true
+				if !m.announced[d.TrackID] {
+					behind = append(behind, d)
+				}
+			}
+		}
+		kept = append(kept, d)
+	}
+	m.announced = seen
+	return kept, behind
+}
+
+// reset forgets the mirror
+func (m *mirrorDetector) reset() {
+	if m.facing.Swap(false) {
+		detectLog.Info("No longer facing a mirror")
+	}
+	m.streak = 0
+	m.announced = nil
+}
+
+// mirrorSymmetry scores how closely the pixels in box match their own
+// left-right mirror image, from 1 for an exact match to 0 for halves no
+// more alike than random ones. Your reflection faces you and is nearly
+// symmetric; someone walking past at an angle mostly isn't.
+func mirrorSymmetry(frame capture.Frame, box BoundingBox) float32 {
+	x0, y0 := max(int(box.X), 0), max(int(box.Y), 0)
+	x1, y1 := min(int(box.X+box.Width), frame.Width), min(int(box.Y+box.Height), frame.Height)
+	if x1-x0 < 2 || y1 <= y0 {
+		return 0
+	}
+	step := max((x1-x0)/mirrorSamples, (y1-y0)/mirrorSamples, 1)
+	luma := func(x, y int) float64 {
+		p := frame.Data[(y*frame.Width+x)*3:]
+		return float64(int(p[0])+2*int(p[1])+int(p[2])) / 4
+	}
+
+	var sum, count float64
+	for y := y0; y < y1; y += step {
+		for x := x0; x < x1; x += step {
+			sum += luma(x, y)
+			count++
+		}
+	}
+	mean := sum / count
+	var deviation, difference float64
+	for y := y0; y < y1; y += step {
+		for x := x0; x < x1; x += step {
+			value := luma(x, y)
+			deviation += math.Abs(value - mean)
+			difference += math.Abs(value - luma(x0+x1-1-x, y))
+		}
+	}
+	if deviation == 0 {
+		// A flat region has no shape to be symmetric
+		return 0
+	}
+	// Unrelated pixels differ by about sqrt(2) times their deviation
+	return float32(max(1-difference/(math.Sqrt2*deviation), 0))
+}
+
+// rearBearing turns the bearing of a reflection into that of the person
+// behind you: straight ahead in the mirror is straight behind, and the mirror
+// keeps left and right
+func rearBearing(bearing float32) float32 {
+	if bearing < 0 {
+		return -180 - bearing
+	}
+	return 180 - bearing
+}
+
+// scaleBox grows box about its center by scale, clipped to the frame
+func scaleBox(box BoundingBox, scale float32, frameWidth, frameHeight int32) BoundingBox {
+	width := int32(float32(box.Width) * scale)
+	height := int32(float32(box.Height) * scale)
+	x := max(box.X+box.Width/2-width/2, 0)
+	y := max(box.Y+box.Height/2-height/2, 0)
+	return BoundingBox{
+		X:      x,
+		Y:      y,
+		Width:  min(x+width, frameWidth) - x,
+		Height: min(y+height, frameHeight) - y,
+	}
+}
+
+// boxContainsCenter reports whether the center of inner lies within outer
+func boxContainsCenter(outer, inner BoundingBox) bool {
+	x := inner.X + inner.Width/2
+	y := inner.Y + inner.Height/2
+	return x >= outer.X && x < outer.X+outer.Width && y >= outer.Y && y < outer.Y+outer.Height
+}
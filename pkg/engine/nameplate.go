@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"image"
+	"time"
+
+	"vrchat-proximity/pkg/capture"
+)
+
+// nameplateForget is how long a track keeps its last nameplate distance
+// while no nameplate is measured, e.g. while the letters blur in motion
+const nameplateForget = 5 * time.Second
+
+// nameplateEdge is the brightness step between neighboring pixels that
+// counts as the edge of a letter
+const nameplateEdge = 48
+
+// nameplateDensity is the fraction of a row that must be letter edges for
+// the row to be part of the text band
+const nameplateDensity = 0.12
+
+// nameplateMinRows is the shortest text band measured; smaller ones are too
+// coarse to give a distance
+const nameplateMinRows = 4
+
+// measureNameplates replaces height-based distances with ones measured
+// from the nameplate above each detection where one is found. It runs
+// before the tracker, which smooths and debounces them and holds a track's
+// nameplate distance while none is measured.
+func (pe *ProximityEngine) measureNameplates(detections []Detection, frame capture.Frame) {
+	camera := pe.CameraConfig()
+	if !camera.Nameplates || !frame.Valid() {
+		return
+	}
+	width, height := int32(frame.Width), int32(frame.Height)
+	for i := range detections {
+		d := &detections[i]
+		rows := nameplateRows(frame, d.BBox)
+		if rows == 0 {
+			continue
+		}
+		distance := camera.distanceFor(float64(rows), camera.NameplateHeight, width, height)
+		d.Distance, d.Category, d.Nameplate = distance, categoryForDistance(distance), true
+		d.AvatarHeight = camera.heightAt(float64(d.BBox.Height), distance, width, height)
+	}
+}
+
+// nameplateRows returns the height in pixels of the nameplate text above
+// box, or 0 when none is found. The text is the tallest band of rows dense
+// with sharp brightness steps straddling the top of the box; it must be a
+// bar, much wider than it is tall. The band searched reaches at least half
+// the box width up, so tiny avatars' nameplates still fit.
+func nameplateRows(frame capture.Frame, box BoundingBox) int {
+	x, y, w, h := int(box.X), int(box.Y), int(box.Width), int(box.Height)
+	above := max(h*3/10, w/2)
+	img := capture.Crop(frame, image.Rect(x+w/4, y-above, x+w*3/4, y+h*3/20), 1)
+	width, rows := img.Rect.Dx(), img.Rect.Dy()
+	if width < 8 {
+		return 0
+	}
+
+	best, run, gap := 0, 0, 0
+	for row := 0; row < rows; row++ {
+		pix := img.Pix[row*img.Stride : row*img.Stride+width]
+		edges := 0
+		for i := 1; i < width; i++ {
+			if step := int(pix[i]) - int(pix[i-1]); step >= nameplateEdge || step <= -nameplateEdge {
+				edges++
+			}
+		}
+		switch {
+		case float32(edges) >= nameplateDensity*float32(width):
+			run += gap + 1
+			gap = 0
+		case run > 0 && gap == 0:
+			// Bridge a single sparse row, such as the gap inside a letter
+			gap = 1
+		default:
+			run, gap = 0, 0
+		}
+		best = max(best, run)
+	}
+	if best < nameplateMinRows || best*2 > width {
+		return 0
+	}
+	return best
+}
@@ -0,0 +1,176 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"os/exec"
+	"sync"
+	"time"
+
+	"vrchat-proximity/pkg/capture"
+)
+
+// ocrQueue is how many nameplate crops may wait for the OCR worker
+const ocrQueue = 4
+
+// ocrTimeout bounds one tesseract run
+const ocrTimeout = 5 * time.Second
+
+// OCRConfig configures reading nameplates above detections
+type OCRConfig struct {
+	Enabled   bool          `json:"enabled" yaml:"enabled"`
+	Tesseract string        `json:"tesseract" yaml:"tesseract"`   // tesseract executable
+	Interval  time.Duration `json:"interval" yaml:"interval"`     // Minimum time between reads of the same track
+	MinHeight int           `json:"min_height" yaml:"min_height"` // Skip boxes shorter than this many pixels; their text is unreadable
+	Forget    time.Duration `json:"forget" yaml:"forget"`         // Drop a track's name this long after it was last read
+}
+
+// DefaultOCRConfig reads each track's nameplate at most every 2s with tesseract from PATH
+func DefaultOCRConfig() OCRConfig {
+	return OCRConfig{Tesseract: "tesseract", Interval: 2 * time.Second, MinHeight: 80, Forget: 10 * time.Second}
+}
+
+// Validate checks the OCR ranges
+func (c OCRConfig) Validate() error {
+	if c.Interval <= 0 || c.Forget <= 0 {
+		return fmt.Errorf("interval and forget must be positive")
+	}
+	if c.MinHeight < 0 {
+		return fmt.Errorf("min_height must not be negative")
+	}
+	return nil
+}
+
+// ocrJob is a nameplate crop waiting to be read
+type ocrJob struct {
+	track int64
+	img   *image.Gray
+}
+
+// ocrResult is the last name read for a track
+type ocrResult struct {
+	name string
+	read time.Time
+}
+
+// nameplateReader attaches best-effort player names to tracked detections.
+// Crops are read on a background worker so tesseract never stalls capture.
+type nameplateReader struct {
+	config    OCRConfig
+	ocr       capture.Tesseract
+	jobs      chan ocrJob
+	startOnce sync.Once
+
+	mu        sync.Mutex
+	names     map[int64]ocrResult
+	attempted map[int64]time.Time
+	missing   bool // tesseract isn't installed; stop trying until reconfigured
+}
+
+// newNameplateReader creates a disabled reader
+func newNameplateReader() *nameplateReader {
+	return &nameplateReader{
+		config:    DefaultOCRConfig(),
+		jobs:      make(chan ocrJob, ocrQueue),
+		names:     make(map[int64]ocrResult),
+		attempted: make(map[int64]time.Time),
+	}
+}
+
+// SetOCRConfig enables or reconfigures nameplate reading; safe to call while running
+func (pe *ProximityEngine) SetOCRConfig(config OCRConfig) {
+	r := pe.nameplates
+	r.mu.Lock()
+	r.config = config
+	r.ocr = capture.Tesseract{Binary: config.Tesseract}
+	r.missing = false
+	r.mu.Unlock()
+	if config.Enabled {
+		r.startOnce.Do(func() { go r.work() })
+	}
+	detectLog.Info("Nameplate OCR set", "enabled", config.Enabled, "tesseract", config.Tesseract)
+}
+
+// OCRConfig returns the nameplate reading settings
+func (pe *ProximityEngine) OCRConfig() OCRConfig {
+	pe.nameplates.mu.Lock()
+	defer pe.nameplates.mu.Unlock()
+	return pe.nameplates.config
+}
+
+// tag sets Player on tracked detections from earlier reads and queues
+// crops for tracks that are due another read
+func (r *nameplateReader) tag(detections []Detection, frame capture.Frame, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.config.Enabled || r.missing || !frame.Valid() {
+		return
+	}
+
+	for i := range detections {
+		d := &detections[i]
+		if d.TrackID == 0 {
+			continue
+		}
+		if result, ok := r.names[d.TrackID]; ok {
+			d.Player = result.name
+		}
+		if int(d.BBox.Height) < r.config.MinHeight || now.Sub(r.attempted[d.TrackID]) < r.config.Interval {
+			continue
+		}
+		select {
+		case r.jobs <- ocrJob{track: d.TrackID, img: capture.Crop(frame, nameplateRegion(d.BBox), 2)}:
+			r.attempted[d.TrackID] = now
+		default:
+			// Worker is behind; try again next frame
+		}
+	}
+
+	for id, result := range r.names {
+		if now.Sub(result.read) > r.config.Forget {
+			delete(r.names, id)
+		}
+	}
+	for id, at := range r.attempted {
+		if now.Sub(at) > r.config.Forget {
+			delete(r.attempted, id)
+		}
+	}
+}
+
+// nameplateRegion is where VRChat draws the nameplate relative to an
+// avatar's box: a strip straddling the top edge, wider than the avatar
+func nameplateRegion(box BoundingBox) image.Rectangle {
+	x, y, w, h := int(box.X), int(box.Y), int(box.Width), int(box.Height)
+	return image.Rect(x-w/4, y-h*3/10, x+w+w/4, y+h*3/20)
+}
+
+// work reads queued crops for the life of the process
+func (r *nameplateReader) work() {
+	for job := range r.jobs {
+		r.mu.Lock()
+		ocr, enabled := r.ocr, r.config.Enabled && !r.missing
+		r.mu.Unlock()
+		if !enabled {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), ocrTimeout)
+		name, err := ocr.ReadLine(ctx, job.img)
+		cancel()
+
+		r.mu.Lock()
+		switch {
+		case errors.Is(err, exec.ErrNotFound):
+			detectLog.Warn("Nameplate OCR disabled, tesseract not found", "tesseract", ocr.Binary)
+			r.missing = true
+		case err != nil:
+			detectLog.Debug("Nameplate OCR failed", "track", job.track, "error", err)
+		case len([]rune(name)) >= 2:
+			r.names[job.track] = ocrResult{name: name, read: time.Now()}
+		}
+		r.mu.Unlock()
+	}
+}
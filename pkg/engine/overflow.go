@@ -0,0 +1,128 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// What to do with a detection batch when processing has fallen behind and
+// the detection channel is full
+const (
+	OverflowDropNewest     = "drop_newest"     // Drop the new batch, keeping the queue in order
+	OverflowDropOldest     = "drop_oldest"     // Drop the oldest queued batch to make room
+	OverflowCoalesceLatest = "coalesce_latest" // Drop everything queued; only the latest state matters
+	OverflowBlock          = "block"           // Wait up to Timeout for room, then drop the new batch
+)
+
+// OverflowStrategies lists the valid OverflowConfig strategies
+var OverflowStrategies = []string{OverflowDropNewest, OverflowDropOldest, OverflowCoalesceLatest, OverflowBlock}
+
+// OverflowConfig picks how capture hands batches to a busy detection stage.
+// Haptics and OSC outputs only care about the current state, so
+// coalesce_latest suits them better than keeping every batch.
+type OverflowConfig struct {
+	Strategy string        `json:"strategy" yaml:"strategy"` // One of OverflowStrategies
+	Timeout  time.Duration `json:"timeout" yaml:"timeout"`   // Longest a capture loop waits with block
+}
+
+// DefaultOverflowConfig drops new batches, as the engine always has
+func DefaultOverflowConfig() OverflowConfig {
+	return OverflowConfig{Strategy: OverflowDropNewest, Timeout: 20 * time.Millisecond}
+}
+
+// Validate checks the strategy is known
+func (c OverflowConfig) Validate() error {
+	if !slices.Contains(OverflowStrategies, c.Strategy) {
+		return fmt.Errorf("strategy must be one of %v", OverflowStrategies)
+	}
+	if c.Strategy == OverflowBlock && (c.Timeout <= 0 || c.Timeout > time.Second) {
+		return fmt.Errorf("timeout must be between 0 and 1s with %s", OverflowBlock)
+	}
+	return nil
+}
+
+// SetOverflowConfig replaces the overflow strategy; safe to call while running
+func (pe *ProximityEngine) SetOverflowConfig(config OverflowConfig) {
+	pe.overflowConfig.Store(&config)
+	detectLog.Info("Detection overflow strategy set", "strategy", config.Strategy, "timeout", config.Timeout)
+}
+
+// OverflowConfig returns the overflow strategy
+func (pe *ProximityEngine) OverflowConfig() OverflowConfig {
+	if config := pe.overflowConfig.Load(); config != nil {
+		return *config
+	}
+	return DefaultOverflowConfig()
+}
+
+// queueDetections hands a batch to processDetections without letting a
+// busy detection stage stall capture for long. The frame's trace, if any,
+// ends here unless the batch is queued.
+func (pe *ProximityEngine) queueDetections(detections []Detection, trace *FrameTrace) {
+	if trace != nil {
+		trace.Detections = len(detections)
+		trace.queued = time.Now()
+	}
+	if len(detections) == 0 {
+		pe.finishTrace(trace)
+		return
+	}
+	batch := detectionBatch{detections: detections, trace: trace}
+	select {
+	case pe.detectionChan <- batch:
+		return
+	default:
+	}
+
+	config := pe.OverflowConfig()
+	switch config.Strategy {
+	case OverflowDropOldest:
+		pe.discardQueued(1)
+	case OverflowCoalesceLatest:
+		pe.discardQueued(cap(pe.detectionChan))
+	case OverflowBlock:
+		timer := time.NewTimer(config.Timeout)
+		defer timer.Stop()
+		select {
+		case pe.detectionChan <- batch:
+			return
+		case <-timer.C:
+		}
+	}
+
+	// Another capture loop may have refilled the channel meanwhile
+	select {
+	case pe.detectionChan <- batch:
+	default:
+		pe.dropFrame(trace)
+		if config.Strategy == OverflowDropNewest || config.Strategy == OverflowBlock {
+			detectLog.Warn("Detection channel full, dropping frame", "strategy", config.Strategy)
+		}
+	}
+}
+
+// discardQueued drops up to n queued batches, oldest first
+func (pe *ProximityEngine) discardQueued(n int) {
+	for ; n > 0; n-- {
+		select {
+		case batch := <-pe.detectionChan:
+			pe.dropFrame(batch.trace)
+		default:
+			return
+		}
+	}
+}
+
+// dropFrame counts a batch that never reached processDetections and ends
+// its trace
+func (pe *ProximityEngine) dropFrame(trace *FrameTrace) {
+	now := time.Now()
+	pe.droppedFrames.Add(1)
+	pe.dropRate.add(now, 1)
+	if trace != nil {
+		trace.Dropped = true
+		trace.add(StageQueue, trace.queued, now)
+		pe.finishTrace(trace)
+	}
+}
@@ -0,0 +1,178 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Player rule actions
+const (
+	PlayerAlways = "always" // Alert for this player even while alerts are muted
+	PlayerNever  = "never"  // Never alert for this player
+	PlayerHaptic = "haptic" // Alert with the rule's haptic pattern
+)
+
+// HapticPattern is a custom buzz played for a player instead of the distance curve
+type HapticPattern struct {
+	Intensity int `json:"intensity" yaml:"intensity"` // 0-100
+	Pulses    int `json:"pulses" yaml:"pulses"`
+	GapMS     int `json:"gap_ms" yaml:"gap_ms"` // Pause between pulses
+}
+
+// Validate checks the pattern ranges and plays one pulse when none is set
+func (p *HapticPattern) Validate() error {
+	if p.Intensity < 0 || p.Intensity > 100 || p.Pulses < 0 || p.GapMS < 0 {
+		return fmt.Errorf("haptic intensity must be 0-100 and pulses and gap_ms not negative")
+	}
+	if p.Pulses == 0 {
+		p.Pulses = 1
+	}
+	return nil
+}
+
+// PlayerRule decides how alerts treat one player, identified by the name
+// read from their nameplate or a color profile label
+type PlayerRule struct {
+	Player string         `json:"player" yaml:"player"` // Matched case-insensitively
+	Action string         `json:"action" yaml:"action"` // always, never, or haptic
+	Haptic *HapticPattern `json:"haptic,omitempty" yaml:"haptic"`
+}
+
+// Validate checks the rule and fills in pattern defaults
+func (r *PlayerRule) Validate() error {
+	if strings.TrimSpace(r.Player) == "" {
+		return fmt.Errorf("player is required")
+	}
+	switch r.Action {
+	case PlayerAlways, PlayerNever:
+	case PlayerHaptic:
+		if r.Haptic == nil {
+			return fmt.Errorf("player %q: haptic action needs a haptic pattern", r.Player)
+		}
+		if err := r.Haptic.Validate(); err != nil {
+			return fmt.Errorf("player %q: %w", r.Player, err)
+		}
+	default:
+		return fmt.Errorf("player %q: action must be %s, %s, or %s", r.Player, PlayerAlways, PlayerNever, PlayerHaptic)
+	}
+	return nil
+}
+
+// playerRules is the rules store, keyed by normalized player name
+type playerRules struct {
+	mu    sync.RWMutex
+	rules []PlayerRule
+	index map[string]PlayerRule
+}
+
+// playerKey normalizes a player name for lookups
+func playerKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// SetPlayerRules replaces the per-player rules; safe to call while running
+func (pe *ProximityEngine) SetPlayerRules(rules []PlayerRule) error {
+	index := make(map[string]PlayerRule, len(rules))
+	copied := make([]PlayerRule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.Haptic != nil {
+			pattern := *rule.Haptic
+			rule.Haptic = &pattern
+		}
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+		index[playerKey(rule.Player)] = rule
+		copied = append(copied, rule)
+	}
+
+	pe.players.mu.Lock()
+	pe.players.rules, pe.players.index = copied, index
+	pe.players.mu.Unlock()
+	detectLog.Info("Player rules set", "count", len(copied))
+	return nil
+}
+
+// PlayerRules returns the per-player rules
+func (pe *ProximityEngine) PlayerRules() []PlayerRule {
+	pe.players.mu.RLock()
+	defer pe.players.mu.RUnlock()
+	return append([]PlayerRule(nil), pe.players.rules...)
+}
+
+// playerRule looks up the rule for a detection's player or label
+func (pe *ProximityEngine) playerRule(d *Detection) (PlayerRule, bool) {
+	if d == nil {
+		return PlayerRule{}, false
+	}
+	pe.players.mu.RLock()
+	defer pe.players.mu.RUnlock()
+	for _, name := range []string{d.Player, d.Label} {
+		if name == "" {
+			continue
+		}
+		if rule, ok := pe.players.index[playerKey(name)]; ok {
+			return rule, true
+		}
+	}
+	return PlayerRule{}, false
+}
+
+// applyPlayerRule attaches the haptic pattern of the event's player, unless
+// the event already carries one
+func (pe *ProximityEngine) applyPlayerRule(event *ProximityEvent) {
+	if event.Haptic != nil {
+		return
+	}
+	if rule, ok := pe.playerRule(event.Detection); ok && rule.Action == PlayerHaptic {
+		event.Haptic = rule.Haptic
+	}
+}
+
+// alertAllowed reports whether alert hooks should see an event. Never-alert
+// players and quiet hours always win; always-alert players skip the
+// cooldown and the instance mute. It is called once per event.
+func (pe *ProximityEngine) alertAllowed(event ProximityEvent, now time.Time) bool {
+	config := pe.AlertConfig()
+	rule, ok := pe.playerRule(event.Detection)
+	switch {
+	case ok && rule.Action == PlayerNever, config.QuietHours.Active(now):
+		return false
+	case ok && rule.Action == PlayerAlways:
+		return true
+	case pe.AlertsMuted():
+		return false
+	}
+	return !pe.cooldowns.cooling(event, config.Cooldown, now)
+}
+
+// alertDetections drops detections of never-alert players and, while
+// alerts are muted, keeps only always-alert players. Quiet hours drop all.
+func (pe *ProximityEngine) alertDetections(detections []Detection) []Detection {
+	if pe.QuietHoursActive() {
+		return nil
+	}
+	muted := pe.AlertsMuted()
+	pe.players.mu.RLock()
+	noRules := len(pe.players.index) == 0
+	pe.players.mu.RUnlock()
+	if noRules {
+		if muted {
+			return nil
+		}
+		return detections
+	}
+
+	var kept []Detection
+	for i := range detections {
+		rule, ok := pe.playerRule(&detections[i])
+		switch {
+		case ok && rule.Action == PlayerNever:
+		case ok && rule.Action == PlayerAlways, !muted:
+			kept = append(kept, detections[i])
+		}
+	}
+	return kept
+}
@@ -0,0 +1,377 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// PreviewConfig configures the MJPEG preview stream
+type PreviewConfig struct {
+	Enabled  bool `yaml:"enabled"`
+	MaxWidth int  `yaml:"max_width"` // Frames are downscaled to at most this width
+	FPS      int  `yaml:"fps"`
+	Quality  int  `yaml:"quality"` // JPEG quality 1-100
+}
+
+// DefaultPreviewConfig returns a light preview suitable for debugging
+func DefaultPreviewConfig() PreviewConfig {
+	return PreviewConfig{
+		Enabled:  true,
+		MaxWidth: 640,
+		FPS:      5,
+		Quality:  70,
+	}
+}
+
+// Validate checks the preview ranges
+func (c PreviewConfig) Validate() error {
+	if c.FPS < 1 {
+		return fmt.Errorf("fps must be at least 1")
+	}
+	if c.MaxWidth <= 0 {
+		return fmt.Errorf("max_width must be positive")
+	}
+	if c.Quality < 1 || c.Quality > 100 {
+		return fmt.Errorf("quality must be between 1 and 100")
+	}
+	return nil
+}
+
+// previewFrame is a downscaled frame waiting to be annotated and encoded
+type previewFrame struct {
+	img        *image.RGBA
+	scale      float64 // Preview pixels per captured pixel
+	detections []Detection
+}
+
+// previewSubscriber receives encoded JPEG frames
+type previewSubscriber struct {
+	overlay bool
+	frames  chan []byte
+}
+
+// PreviewStream renders captured frames with detection overlays as MJPEG
+type PreviewStream struct {
+	config      PreviewConfig
+	enabled     atomic.Bool
+	lastFrame   time.Time
+	pending     chan previewFrame
+	subscribers sync.Map // *previewSubscriber -> bool
+	count       atomic.Int32
+	privacy     atomic.Pointer[PrivacyConfig] // Obscures frames while enabled
+}
+
+// NewPreviewStream creates a preview stream with the given config
+func NewPreviewStream(config PreviewConfig) *PreviewStream {
+	p := &PreviewStream{
+		config:  config,
+		pending: make(chan previewFrame, 1),
+	}
+	p.enabled.Store(config.Enabled)
+	return p
+}
+
+// SetEnabled toggles the preview stream
+func (p *PreviewStream) SetEnabled(enabled bool) {
+	p.enabled.Store(enabled)
+}
+
+// setPrivacy sets how frames are obscured in privacy mode
+func (p *PreviewStream) setPrivacy(config PrivacyConfig) {
+	p.privacy.Store(&config)
+}
+
+// Enabled reports whether the preview stream is on
+func (p *PreviewStream) Enabled() bool {
+	return p.enabled.Load()
+}
+
+// wants reports whether the capture loop should hand over the next frame
+func (p *PreviewStream) wants() bool {
+	if !p.enabled.Load() || p.count.Load() == 0 {
+		return false
+	}
+	return time.Since(p.lastFrame) >= time.Second/time.Duration(p.config.FPS)
+}
+
+// offer downscales a packed 24-bit BGR frame and queues it for rendering.
+// It must be called from the capture goroutine while the frame is valid.
+func (p *PreviewStream) offer(frame []byte, width, height int, detections []Detection) {
+	if width <= 0 || height <= 0 || len(frame) < width*height*3 {
+		return
+	}
+	p.lastFrame = time.Now()
+	img, scale := downscaleBGR(frame, width, height, p.config.MaxWidth)
+	p.offerImage(img, scale, detections)
+}
+
+// downscaleBGR copies a packed 24-bit BGR frame into an RGBA image at most
+// maxWidth wide, returning it with its pixels per captured pixel
+func downscaleBGR(frame []byte, width, height, maxWidth int) (*image.RGBA, float64) {
+	scale := 1.0
+	if width > maxWidth {
+		scale = float64(maxWidth) / float64(width)
+	}
+	outW := int(float64(width) * scale)
+	outH := int(float64(height) * scale)
+
+	// Nearest-neighbour downscale straight out of the capture buffer
+	img := image.NewRGBA(image.Rect(0, 0, outW, outH))
+	for y := 0; y < outH; y++ {
+		srcRow := int(float64(y)/scale) * width * 3
+		dst := img.Pix[y*img.Stride:]
+		for x := 0; x < outW; x++ {
+			src := srcRow + int(float64(x)/scale)*3
+			dst[x*4] = frame[src+2]
+			dst[x*4+1] = frame[src+1]
+			dst[x*4+2] = frame[src]
+			dst[x*4+3] = 0xff
+		}
+	}
+	return img, scale
+}
+
+// offerImage queues an already downscaled frame for rendering
+func (p *PreviewStream) offerImage(img *image.RGBA, scale float64, detections []Detection) {
+	// Replace an unrendered frame rather than queueing behind it
+	select {
+	case <-p.pending:
+	default:
+	}
+	select {
+	case p.pending <- previewFrame{img: img, scale: scale, detections: detections}:
+	default:
+	}
+}
+
+// Subscribe registers a consumer of encoded frames and returns its channel
+// along with a function that removes the subscription
+func (p *PreviewStream) Subscribe(overlay bool) (<-chan []byte, func()) {
+	sub := &previewSubscriber{
+		overlay: overlay,
+		frames:  make(chan []byte, 1),
+	}
+	p.subscribers.Store(sub, true)
+	p.count.Add(1)
+
+	return sub.frames, func() {
+		p.subscribers.Delete(sub)
+		p.count.Add(-1)
+	}
+}
+
+// run renders queued frames until ctx is cancelled
+func (p *PreviewStream) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case frame := <-p.pending:
+			p.render(frame)
+		}
+	}
+}
+
+// render encodes a frame for raw and annotated subscribers. In privacy
+// mode the picture is obscured first and only the boxes stay readable.
+func (p *PreviewStream) render(frame previewFrame) {
+	if privacy := p.privacy.Load(); privacy != nil && privacy.Enabled {
+		obscureImage(frame.img, privacy.Preview)
+	}
+
+	var raw, annotated []byte
+	wantRaw, wantAnnotated := false, false
+	p.subscribers.Range(func(key, _ interface{}) bool {
+		if key.(*previewSubscriber).overlay {
+			wantAnnotated = true
+		} else {
+			wantRaw = true
+		}
+		return true
+	})
+
+	if wantRaw {
+		raw = p.encode(frame.img)
+	}
+	if wantAnnotated {
+		drawDetections(frame.img, frame.detections, frame.scale)
+		annotated = p.encode(frame.img)
+	}
+
+	p.subscribers.Range(func(key, _ interface{}) bool {
+		sub := key.(*previewSubscriber)
+		data := raw
+		if sub.overlay {
+			data = annotated
+		}
+		select {
+		case sub.frames <- data:
+		default:
+			// Subscriber is still writing the previous frame
+		}
+		return true
+	})
+}
+
+// encode compresses an image as JPEG
+func (p *PreviewStream) encode(img image.Image) []byte {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: p.config.Quality}); err != nil {
+		return nil
+	}
+	return buf.Bytes()
+}
+
+// ServeHTTP streams multipart/x-mixed-replace JPEG frames.
+// Pass ?overlay=0 to receive frames without detection boxes.
+func (p *PreviewStream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !p.enabled.Load() {
+		http.Error(w, "preview disabled", http.StatusServiceUnavailable)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	frames, unsubscribe := p.Subscribe(r.URL.Query().Get("overlay") != "0")
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary=frame")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case data := <-frames:
+			if !p.enabled.Load() {
+				return
+			}
+			if len(data) == 0 {
+				continue
+			}
+			fmt.Fprintf(w, "--frame\r\nContent-Type: image/jpeg\r\nContent-Length: %d\r\n\r\n", len(data))
+			if _, err := w.Write(data); err != nil {
+				return
+			}
+			w.Write([]byte("\r\n"))
+			flusher.Flush()
+		}
+	}
+}
+
+// HandleConfig reads or toggles the preview
+func (p *PreviewStream) HandleConfig(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var body struct {
+			Enabled *bool `json:"enabled"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Enabled == nil {
+			http.Error(w, `expected {"enabled": true|false}`, http.StatusBadRequest)
+			return
+		}
+		p.SetEnabled(*body.Enabled)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"enabled":   p.enabled.Load(),
+		"fps":       p.config.FPS,
+		"max_width": p.config.MaxWidth,
+		"viewers":   p.count.Load(),
+	})
+}
+
+// drawDetections draws bounding boxes and distance labels onto img
+func drawDetections(img *image.RGBA, detections []Detection, scale float64) {
+	for _, d := range detections {
+		c, ok := CategoryColor(d.Category)
+		if !ok {
+			c = color.RGBA{0xff, 0xff, 0xff, 0xff}
+		}
+		x0 := int(float64(d.BBox.X) * scale)
+		y0 := int(float64(d.BBox.Y) * scale)
+		x1 := int(float64(d.BBox.X+d.BBox.Width) * scale)
+		y1 := int(float64(d.BBox.Y+d.BBox.Height) * scale)
+
+		drawRect(img, x0, y0, x1, y1, 2, c)
+		drawText(img, x0+2, y0+2, fmt.Sprintf("%.1fm", d.Distance), 2, c)
+	}
+}
+
+// drawRect draws a rectangle outline of the given thickness
+func drawRect(img *image.RGBA, x0, y0, x1, y1, thickness int, c color.RGBA) {
+	for t := 0; t < thickness; t++ {
+		for x := x0; x <= x1; x++ {
+			img.SetRGBA(x, y0+t, c)
+			img.SetRGBA(x, y1-t, c)
+		}
+		for y := y0; y <= y1; y++ {
+			img.SetRGBA(x0+t, y, c)
+			img.SetRGBA(x1-t, y, c)
+		}
+	}
+}
+
+// previewGlyphs is a 3x5 bitmap font covering distance labels
+var previewGlyphs = map[rune][5]uint8{
+	'0': {0b111, 0b101, 0b101, 0b101, 0b111},
+	'1': {0b010, 0b110, 0b010, 0b010, 0b111},
+	'2': {0b111, 0b001, 0b111, 0b100, 0b111},
+	'3': {0b111, 0b001, 0b111, 0b001, 0b111},
+	'4': {0b101, 0b101, 0b111, 0b001, 0b001},
+	'5': {0b111, 0b100, 0b111, 0b001, 0b111},
+	'6': {0b111, 0b100, 0b111, 0b101, 0b111},
+	'7': {0b111, 0b001, 0b001, 0b001, 0b001},
+	'8': {0b111, 0b101, 0b111, 0b101, 0b111},
+	'9': {0b111, 0b101, 0b111, 0b001, 0b111},
+	'.': {0b000, 0b000, 0b000, 0b000, 0b010},
+	'm': {0b000, 0b000, 0b111, 0b111, 0b101},
+}
+
+// drawText renders text with previewGlyphs on a dark background
+func drawText(img *image.RGBA, x, y int, text string, size int, c color.RGBA) {
+	width := len(text) * 4 * size
+	background := color.RGBA{0, 0, 0, 0xff}
+	for py := y; py < y+6*size; py++ {
+		for px := x; px < x+width; px++ {
+			img.SetRGBA(px, py, background)
+		}
+	}
+
+	for i, r := range text {
+		glyph, ok := previewGlyphs[r]
+		if !ok {
+			continue
+		}
+		gx := x + size/2 + i*4*size
+		for row, bits := range glyph {
+			for col := 0; col < 3; col++ {
+				if bits&(1<<(2-col)) == 0 {
+					continue
+				}
+				for dy := 0; dy < size; dy++ {
+					for dx := 0; dx < size; dx++ {
+						img.SetRGBA(gx+col*size+dx, y+size/2+row*size+dy, c)
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package engine
+
+import (
+	"errors"
+	"image"
+)
+
+// Privacy preview treatments
+const (
+	PrivacyBlur  = "blur"
+	PrivacyBlack = "black"
+)
+
+// PrivacyConfig configures privacy mode, which keeps frames and player
+// names out of anything written to disk and obscures the preview
+type PrivacyConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Preview string `yaml:"preview"` // blur or black; bounding boxes stay visible either way
+}
+
+// DefaultPrivacyConfig leaves privacy mode off, blurring the preview when on
+func DefaultPrivacyConfig() PrivacyConfig {
+	return PrivacyConfig{Preview: PrivacyBlur}
+}
+
+// Validate checks the preview treatment
+func (c PrivacyConfig) Validate() error {
+	if c.Preview != PrivacyBlur && c.Preview != PrivacyBlack {
+		return errors.New("preview must be blur or black")
+	}
+	return nil
+}
+
+// SetPrivacyConfig turns privacy mode on or off; safe to call while running.
+// While on, clips and recording keyframes are not saved, stored detections
+// lose their OCR'd player names, and preview frames are obscured.
+func (pe *ProximityEngine) SetPrivacyConfig(config PrivacyConfig) {
+	pe.privacyConfig.Store(&config)
+	pe.preview.setPrivacy(config)
+	engineLog.Info("Privacy mode set", "enabled", config.Enabled, "preview", config.Preview)
+}
+
+// PrivacyConfig returns the privacy settings
+func (pe *ProximityEngine) PrivacyConfig() PrivacyConfig {
+	if config := pe.privacyConfig.Load(); config != nil {
+		return *config
+	}
+	return DefaultPrivacyConfig()
+}
+
+// PrivacyMode reports whether privacy mode is on
+func (pe *ProximityEngine) PrivacyMode() bool {
+	return pe.PrivacyConfig().Enabled
+}
+
+// StoredDetections returns detections as they may be written to disk:
+// unchanged normally, or copied without player names in privacy mode
+func (pe *ProximityEngine) StoredDetections(detections []Detection) []Detection {
+	if !pe.PrivacyMode() {
+		return detections
+	}
+	stripped := append([]Detection(nil), detections...)
+	for i := range stripped {
+		stripped[i].Player = ""
+	}
+	return stripped
+}
+
+// obscureImage blacks out or heavily blurs img in place
+func obscureImage(img *image.RGBA, mode string) {
+	if mode == PrivacyBlack {
+		for i := 0; i < len(img.Pix); i += 4 {
+			img.Pix[i], img.Pix[i+1], img.Pix[i+2] = 0, 0, 0
+		}
+		return
+	}
+	// Three box blur passes approximate a Gaussian wide enough to make
+	// nameplates and faces unreadable at preview sizes
+	radius := max(4, img.Bounds().Dx()/40)
+	for pass := 0; pass < 3; pass++ {
+		boxBlur(img, radius, true)
+		boxBlur(img, radius, false)
+	}
+}
+
+// boxBlur averages each pixel with its neighbors within radius along one axis
+func boxBlur(img *image.RGBA, radius int, horizontal bool) {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	lines, length, step, stride := h, w, 4, img.Stride
+	if !horizontal {
+		lines, length, step, stride = w, h, img.Stride, 4
+	}
+	line := make([]uint8, length*3)
+	for l := 0; l < lines; l++ {
+		base := l * stride
+		for i := 0; i < length; i++ {
+			copy(line[i*3:i*3+3], img.Pix[base+i*step:])
+		}
+		var sum [3]int
+		for i := -radius; i <= radius; i++ {
+			j := min(max(i, 0), length-1)
+			for c := 0; c < 3; c++ {
+				sum[c] += int(line[j*3+c])
+			}
+		}
+		n := 2*radius + 1
+		for i := 0; i < length; i++ {
+			p := base + i*step
+			for c := 0; c < 3; c++ {
+				img.Pix[p+c] = uint8(sum[c] / n)
+			}
+			out, in := min(max(i-radius, 0), length-1), min(i+radius+1, length-1)
+			for c := 0; c < 3; c++ {
+				sum[c] += int(line[in*3+c]) - int(line[out*3+c])
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package engine
+
+import (
+	"fmt"
+
+	"vrchat-proximity/pkg/capture"
+)
+
+// ProcessingConfig sets the resolution motion detection runs at. Larger
+// frames are box-filtered down to fit before detection and the boxes are
+// scaled back up, so detections keep full-frame coordinates.
+type ProcessingConfig struct {
+	Width  int `json:"width" yaml:"width"`   // Maximum detection width; 0 is unconstrained
+	Height int `json:"height" yaml:"height"` // Maximum detection height; 0 is unconstrained
+}
+
+// DefaultProcessingConfig detects at the captured resolution
+func DefaultProcessingConfig() ProcessingConfig {
+	return ProcessingConfig{}
+}
+
+// Validate checks the processing resolution
+func (c ProcessingConfig) Validate() error {
+	if c.Width < 0 || c.Height < 0 {
+		return fmt.Errorf("width and height must not be negative")
+	}
+	if (c.Width > 0 && c.Width < 64) || (c.Height > 0 && c.Height < 36) {
+		return fmt.Errorf("resolution must be at least 64x36")
+	}
+	return nil
+}
+
+// SetProcessingConfig changes the detection resolution; safe to call while running
+func (pe *ProximityEngine) SetProcessingConfig(config ProcessingConfig) {
+	pe.processingConfig.Store(&config)
+	if config.Width > 0 || config.Height > 0 {
+		pe.downscaler.Store(capture.NewDownscaler(config.Width, config.Height))
+	} else {
+		pe.downscaler.Store(nil)
+	}
+	detectLog.Info("Processing resolution set", "width", config.Width, "height", config.Height)
+}
+
+// ProcessingConfig returns the detection resolution settings
+func (pe *ProximityEngine) ProcessingConfig() ProcessingConfig {
+	if config := pe.processingConfig.Load(); config != nil {
+		return *config
+	}
+	return DefaultProcessingConfig()
+}
+
+// downscale reduces frame to the processing resolution, or returns it unchanged
+func (pe *ProximityEngine) downscale(frame capture.Frame) capture.Frame {
+	if scaler := pe.downscaler.Load(); scaler != nil {
+		return scaler.Scale(frame)
+	}
+	return frame
+}
@@ -0,0 +1,64 @@
+package engine
+
+import (
+	"sync"
+	"time"
+)
+
+// Rates are averaged over the last rateWindow, from a ring of the most
+// recent rateSlots samples
+const (
+	rateWindow = 5 * time.Second
+	rateSlots  = 2048
+)
+
+// rateCounter measures how often something happens over a sliding window.
+// Each sample is a timestamp and a count, e.g. a frame and its detections.
+type rateCounter struct {
+	mu     sync.Mutex
+	times  [rateSlots]int64 // unix nanos
+	counts [rateSlots]int64
+	next   int
+	size   int
+	start  int64 // unix nanos of the last reset; rates are averaged over less than the window until it has passed
+}
+
+// reset forgets every sample, starting a new measurement at now
+func (r *rateCounter) reset(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.next, r.size, r.start = 0, 0, now.UnixNano()
+}
+
+// add records count occurrences at now
+func (r *rateCounter) add(now time.Time, count int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.times[r.next], r.counts[r.next] = now.UnixNano(), count
+	r.next = (r.next + 1) % rateSlots
+	r.size = min(r.size+1, rateSlots)
+}
+
+// rate returns occurrences per second over the window. When the ring
+// overflows within the window, the rate covers the samples it still holds.
+func (r *rateCounter) rate(now time.Time) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	from := max(now.Add(-rateWindow).UnixNano(), r.start)
+	var total int64
+	for i := 1; i <= r.size; i++ {
+		slot := (r.next - i + rateSlots) % rateSlots
+		if r.times[slot] <= from {
+			break
+		}
+		total += r.counts[slot]
+		if i == rateSlots {
+			from = r.times[slot]
+		}
+	}
+	span := float64(now.UnixNano()-from) / float64(time.Second)
+	if span <= 0 {
+		return 0
+	}
+	return float64(total) / span
+}
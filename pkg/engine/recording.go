@@ -0,0 +1,400 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"io"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	"vrchat-proximity/pkg/logging"
+)
+
+// recordLog is the "recording" subsystem logger
+var recordLog = logging.For("recording")
+
+// Recording file format: a gzip stream containing the header
+// "VRPX" | version byte | start time (unix nanos, big endian int64)
+// followed by records of
+// kind byte | uvarint nanoseconds since previous record | body
+const (
+	recordingMagic   = "VRPX"
+	recordingVersion = 1
+
+	recordDetections = 1
+	recordKeyframe   = 2
+)
+
+// RecorderConfig configures what a Recorder writes
+type RecorderConfig struct {
+	Keyframes        bool          // Also store downscaled preview frames
+	KeyframeInterval time.Duration // Minimum time between keyframes
+}
+
+// DefaultRecorderConfig records detections only
+func DefaultRecorderConfig() RecorderConfig {
+	return RecorderConfig{KeyframeInterval: time.Second}
+}
+
+// Recorder writes timestamped detection streams to a file
+type Recorder struct {
+	config RecorderConfig
+	file   *os.File
+	gz     *gzip.Writer
+	w      *bufio.Writer
+
+	last   time.Time
+	frames int64
+	mu     sync.Mutex
+
+	unsubscribe func()
+	done        chan struct{}
+}
+
+// NewRecorder creates a recording file at path
+func NewRecorder(path string, config RecorderConfig) (*Recorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("create recording: %w", err)
+	}
+
+	gz := gzip.NewWriter(file)
+	r := &Recorder{
+		config: config,
+		file:   file,
+		gz:     gz,
+		w:      bufio.NewWriter(gz),
+		last:   time.Now(),
+		done:   make(chan struct{}),
+	}
+
+	r.w.WriteString(recordingMagic)
+	r.w.WriteByte(recordingVersion)
+	binary.Write(r.w, binary.BigEndian, r.last.UnixNano())
+
+	return r, nil
+}
+
+// Attach hooks the recorder into an engine's detection and preview pipeline
+func (r *Recorder) Attach(pe *ProximityEngine) {
+	pe.OnDetections(func(detections []Detection) {
+		if pe.InstanceAllows(BehaviorRecord) {
+			r.WriteDetections(detections, int(pe.frameWidth.Load()), int(pe.frameHeight.Load()))
+		}
+	})
+
+	if r.config.Keyframes {
+		frames, unsubscribe := pe.preview.Subscribe(false)
+		r.unsubscribe = unsubscribe
+		go r.keyframeLoop(pe, frames)
+	}
+}
+
+// keyframeLoop stores preview frames at the configured interval, and none
+// in privacy mode or where the rules pause recording
+func (r *Recorder) keyframeLoop(pe *ProximityEngine, frames <-chan []byte) {
+	var lastKeyframe time.Time
+	for {
+		select {
+		case <-r.done:
+			return
+		case data := <-frames:
+			if len(data) == 0 || time.Since(lastKeyframe) < r.config.KeyframeInterval || pe.PrivacyMode() || !pe.InstanceAllows(BehaviorRecord) {
+				continue
+			}
+			lastKeyframe = time.Now()
+			r.WriteKeyframe(data, int(pe.frameWidth.Load()), int(pe.frameHeight.Load()))
+		}
+	}
+}
+
+// WriteDetections appends a detection batch
+func (r *Recorder) WriteDetections(detections []Detection, frameWidth, frameHeight int) {
+	var body bytes.Buffer
+	writeUvarint(&body, uint64(frameWidth))
+	writeUvarint(&body, uint64(frameHeight))
+	writeUvarint(&body, uint64(len(detections)))
+	for _, d := range detections {
+		writeVarint(&body, int64(d.BBox.X))
+		writeVarint(&body, int64(d.BBox.Y))
+		writeVarint(&body, int64(d.BBox.Width))
+		writeVarint(&body, int64(d.BBox.Height))
+		writeFloat32(&body, d.Confidence)
+		writeString(&body, d.Type)
+		writeFloat32(&body, d.Area)
+		writeFloat32(&body, d.Distance)
+		writeString(&body, d.Category)
+	}
+
+	r.writeRecord(recordDetections, body.Bytes())
+	r.mu.Lock()
+	r.frames++
+	r.mu.Unlock()
+}
+
+// WriteKeyframe appends a JPEG keyframe captured at the original frame size
+func (r *Recorder) WriteKeyframe(jpegData []byte, frameWidth, frameHeight int) {
+	var body bytes.Buffer
+	writeUvarint(&body, uint64(frameWidth))
+	writeUvarint(&body, uint64(frameHeight))
+	writeUvarint(&body, uint64(len(jpegData)))
+	body.Write(jpegData)
+
+	r.writeRecord(recordKeyframe, body.Bytes())
+}
+
+// writeRecord writes the record header and body
+func (r *Recorder) writeRecord(kind byte, body []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.w.WriteByte(kind)
+	writeUvarint(r.w, uint64(now.Sub(r.last).Nanoseconds()))
+	r.w.Write(body)
+	r.last = now
+}
+
+// Close flushes and closes the recording
+func (r *Recorder) Close() error {
+	close(r.done)
+	if r.unsubscribe != nil {
+		r.unsubscribe()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	recordLog.Info("Recording closed", "detection_batches", r.frames)
+	if err := r.w.Flush(); err != nil {
+		return err
+	}
+	if err := r.gz.Close(); err != nil {
+		return err
+	}
+	return r.file.Close()
+}
+
+// Player replays a recording into an engine
+type Player struct {
+	path  string
+	Speed float64 // 1 = original speed, 2 = twice as fast
+	Loop  bool
+}
+
+// NewPlayer creates a player for the recording at path
+func NewPlayer(path string) *Player {
+	return &Player{path: path, Speed: 1}
+}
+
+// Play feeds the recording into the engine until it ends or ctx is cancelled
+func (p *Player) Play(ctx context.Context, pe *ProximityEngine) error {
+	for {
+		if err := p.playOnce(ctx, pe); err != nil {
+			return err
+		}
+		if !p.Loop || ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// playOnce replays the recording a single time
+func (p *Player) playOnce(ctx context.Context, pe *ProximityEngine) error {
+	file, err := os.Open(p.path)
+	if err != nil {
+		return fmt.Errorf("open recording: %w", err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("read recording: %w", err)
+	}
+	r := bufio.NewReader(gz)
+
+	header := make([]byte, len(recordingMagic)+1+8)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return fmt.Errorf("read recording header: %w", err)
+	}
+	if string(header[:4]) != recordingMagic || header[4] != recordingVersion {
+		return fmt.Errorf("not a version %d recording", recordingVersion)
+	}
+
+	speed := p.Speed
+	if speed <= 0 {
+		speed = 1
+	}
+
+	for {
+		kind, err := r.ReadByte()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		delta, err := binary.ReadUvarint(r)
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(float64(delta) / speed)):
+		}
+
+		switch kind {
+		case recordDetections:
+			detections, width, height, err := readDetections(r)
+			if err != nil {
+				return err
+			}
+			pe.frameWidth.Store(int32(width))
+			pe.frameHeight.Store(int32(height))
+			pe.InjectDetections(detections)
+		case recordKeyframe:
+			if err := replayKeyframe(r, pe); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unknown record kind %d", kind)
+		}
+	}
+}
+
+// readDetections decodes a detections record body
+func readDetections(r *bufio.Reader) ([]Detection, int, int, error) {
+	width, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	height, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	count, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	dr := recordReader{r: r}
+	detections := make([]Detection, count)
+	for i := range detections {
+		d := &detections[i]
+		d.BBox.X = int32(dr.varint())
+		d.BBox.Y = int32(dr.varint())
+		d.BBox.Width = int32(dr.varint())
+		d.BBox.Height = int32(dr.varint())
+		d.Confidence = dr.float32()
+		d.Type = dr.string()
+		d.Area = dr.float32()
+		d.Distance = dr.float32()
+		d.Category = dr.string()
+	}
+	return detections, int(width), int(height), dr.err
+}
+
+// replayKeyframe decodes a keyframe and pushes it to preview viewers
+func replayKeyframe(r *bufio.Reader, pe *ProximityEngine) error {
+	dr := recordReader{r: r}
+	frameWidth := int(dr.uvarint())
+	dr.uvarint() // Frame height is implied by the aspect ratio
+	data := make([]byte, dr.uvarint())
+	if dr.err != nil {
+		return dr.err
+	}
+	if _, err := io.ReadFull(r, data); err != nil {
+		return err
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil || frameWidth == 0 {
+		return nil // Skip unreadable keyframes
+	}
+	rgba := image.NewRGBA(img.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+
+	pe.preview.offerImage(rgba, float64(rgba.Bounds().Dx())/float64(frameWidth), pe.GetCurrentDetections())
+	return nil
+}
+
+// recordReader accumulates the first decode error
+type recordReader struct {
+	r   *bufio.Reader
+	err error
+}
+
+func (d *recordReader) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	var v uint64
+	v, d.err = binary.ReadUvarint(d.r)
+	return v
+}
+
+func (d *recordReader) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	var v int64
+	v, d.err = binary.ReadVarint(d.r)
+	return v
+}
+
+func (d *recordReader) float32() float32 {
+	if d.err != nil {
+		return 0
+	}
+	var buf [4]byte
+	_, d.err = io.ReadFull(d.r, buf[:])
+	return math.Float32frombits(binary.BigEndian.Uint32(buf[:]))
+}
+
+func (d *recordReader) string() string {
+	n := d.uvarint()
+	if d.err != nil {
+		return ""
+	}
+	buf := make([]byte, n)
+	_, d.err = io.ReadFull(d.r, buf)
+	return string(buf)
+}
+
+// Record encoding helpers
+func writeUvarint(w io.ByteWriter, v uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	for _, b := range buf[:n] {
+		w.WriteByte(b)
+	}
+}
+
+func writeVarint(w io.ByteWriter, v int64) {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(buf[:], v)
+	for _, b := range buf[:n] {
+		w.WriteByte(b)
+	}
+}
+
+func writeFloat32(w *bytes.Buffer, v float32) {
+	binary.Write(w, binary.BigEndian, math.Float32bits(v))
+}
+
+func writeString(w *bytes.Buffer, s string) {
+	writeUvarint(w, uint64(len(s)))
+	w.WriteString(s)
+}
@@ -0,0 +1,351 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventRule is emitted when an automation rule's condition has held long enough
+const EventRule = "rule"
+
+// RuleCondition is what a rule waits for. Every field that is set must
+// match the current detection batch; For is how long they must keep matching.
+type RuleCondition struct {
+	MinCategory string        `json:"min_category,omitempty" yaml:"min_category"` // Nearest detection is at least this close
+	MinCount    int           `json:"min_count,omitempty" yaml:"min_count"`       // At least this many detections in view
+	Player      string        `json:"player,omitempty" yaml:"player"`             // A detection shows this player name or label
+	World       string        `json:"world,omitempty" yaml:"world"`               // Instance access type, one of AccessTypes
+	For         time.Duration `json:"for,omitempty" yaml:"for"`
+}
+
+// MarshalJSON writes for as a duration string such as "3s"
+func (c RuleCondition) MarshalJSON() ([]byte, error) {
+	type plain RuleCondition
+	aux := struct {
+		plain
+		For string `json:"for,omitempty"`
+	}{plain: plain(c)}
+	if c.For > 0 {
+		aux.For = c.For.String()
+	}
+	return json.Marshal(aux)
+}
+
+// UnmarshalJSON accepts for as a duration string such as "3s"
+func (c *RuleCondition) UnmarshalJSON(data []byte) error {
+	type plain RuleCondition
+	aux := struct {
+		*plain
+		For string `json:"for"`
+	}{plain: (*plain)(c)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if aux.For != "" {
+		d, err := time.ParseDuration(aux.For)
+		if err != nil {
+			return fmt.Errorf("rule for: %w", err)
+		}
+		c.For = d
+	}
+	return nil
+}
+
+// RuleAction is something a rule does when it fires. Outputs act on the
+// rule event: the named webhook posts it and haptics play the pattern.
+// Alerts and Record are standing actions instead: they hold for as long as
+// the current instance has the rule's world type. Once any rule sets one
+// to true, that behavior is limited to instances with a matching rule.
+type RuleAction struct {
+	Webhook string         `json:"webhook,omitempty" yaml:"webhook"` // Name of a webhook target
+	Haptic  *HapticPattern `json:"haptic,omitempty" yaml:"haptic"`
+	Alerts  *bool          `json:"alerts,omitempty" yaml:"alerts"` // Allow or mute alerts in this instance type
+	Record  *bool          `json:"record,omitempty" yaml:"record"` // Allow or pause recording and clips in this instance type
+}
+
+// Instance behaviors set by standing rule actions
+const (
+	BehaviorAlerts = "alerts"
+	BehaviorRecord = "record"
+)
+
+// standing returns the action's setting for a behavior, or nil
+func (a RuleAction) standing(behavior string) *bool {
+	switch behavior {
+	case BehaviorAlerts:
+		return a.Alerts
+	case BehaviorRecord:
+		return a.Record
+	}
+	return nil
+}
+
+// standing reports whether the rule sets instance behavior rather than firing
+func (r Rule) standing() bool {
+	for _, action := range r.Actions {
+		if action.Alerts != nil || action.Record != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Rule fires its actions once each time its condition starts holding
+type Rule struct {
+	Name     string        `json:"name" yaml:"name"`
+	When     RuleCondition `json:"when" yaml:"when"`
+	Actions  []RuleAction  `json:"actions" yaml:"actions"`
+	Cooldown time.Duration `json:"cooldown,omitempty" yaml:"cooldown"` // Minimum time between firings, even if the condition re-arms
+}
+
+// MarshalJSON writes cooldown as a duration string such as "5m0s"
+func (r Rule) MarshalJSON() ([]byte, error) {
+	type plain Rule
+	aux := struct {
+		plain
+		Cooldown string `json:"cooldown,omitempty"`
+	}{plain: plain(r)}
+	if r.Cooldown > 0 {
+		aux.Cooldown = r.Cooldown.String()
+	}
+	return json.Marshal(aux)
+}
+
+// UnmarshalJSON accepts cooldown as a duration string such as "5m"
+func (r *Rule) UnmarshalJSON(data []byte) error {
+	type plain Rule
+	aux := struct {
+		*plain
+		Cooldown string `json:"cooldown"`
+	}{plain: (*plain)(r)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if aux.Cooldown != "" {
+		d, err := time.ParseDuration(aux.Cooldown)
+		if err != nil {
+			return fmt.Errorf("rule cooldown: %w", err)
+		}
+		r.Cooldown = d
+	}
+	return nil
+}
+
+// Validate checks the rule against the current distance categories and
+// fills in haptic pattern defaults
+func (r *Rule) Validate() error {
+	return r.ValidateFor(DistanceCategories())
+}
+
+// ValidateFor is Validate against the given distance categories
+func (r *Rule) ValidateFor(categories []string) error {
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	when := r.When
+	if when.MinCategory == "" && when.MinCount == 0 && when.Player == "" && when.World == "" {
+		return fmt.Errorf("rule %q: when needs at least one condition", r.Name)
+	}
+	if when.MinCategory != "" && !slices.Contains(categories, when.MinCategory) {
+		return fmt.Errorf("rule %q: min_category must be one of %s", r.Name, strings.Join(categories, ", "))
+	}
+	if when.World != "" && !slices.Contains(AccessTypes, when.World) {
+		return fmt.Errorf("rule %q: world must be one of %s", r.Name, strings.Join(AccessTypes, ", "))
+	}
+	if when.MinCount < 0 || when.For < 0 || r.Cooldown < 0 {
+		return fmt.Errorf("rule %q: min_count, for, and cooldown must not be negative", r.Name)
+	}
+	if len(r.Actions) == 0 {
+		return fmt.Errorf("rule %q: actions are required", r.Name)
+	}
+	if r.standing() {
+		if when.World == "" || when.MinCategory != "" || when.MinCount != 0 || when.Player != "" || when.For != 0 || r.Cooldown != 0 {
+			return fmt.Errorf("rule %q: alerts and record actions need a condition of only world", r.Name)
+		}
+		for i, action := range r.Actions {
+			if action.Webhook != "" || action.Haptic != nil {
+				return fmt.Errorf("rule %q: actions[%d]: alerts and record can't be mixed with webhook or haptic actions", r.Name, i)
+			}
+		}
+		return nil
+	}
+	for i := range r.Actions {
+		action := &r.Actions[i]
+		if action.Webhook == "" && action.Haptic == nil {
+			return fmt.Errorf("rule %q: actions[%d] needs a webhook, haptic, alerts, or record", r.Name, i)
+		}
+		if action.Haptic != nil {
+			if err := action.Haptic.Validate(); err != nil {
+				return fmt.Errorf("rule %q: actions[%d]: %w", r.Name, i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// matches reports whether the rule's condition holds for a batch
+func (c RuleCondition) matches(detections []Detection, instance Instance) bool {
+	if c.World != "" && instance.Access() != c.World {
+		return false
+	}
+	if len(detections) < max(c.MinCount, 1) {
+		return false
+	}
+	if c.MinCategory != "" {
+		nearest, _ := NearestDetection(detections)
+		if CategoryRank(nearest.Category) > CategoryRank(c.MinCategory) {
+			return false
+		}
+	}
+	if c.Player != "" {
+		key := playerKey(c.Player)
+		found := false
+		for _, d := range detections {
+			if playerKey(d.Player) == key || playerKey(d.Label) == key {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// ruleState tracks how long a rule's condition has held
+type ruleState struct {
+	since     time.Time // Zero while the condition does not hold
+	fired     bool
+	lastFired time.Time // Kept when the condition re-arms, for the cooldown
+}
+
+// ruleSet holds the automation rules and their state
+type ruleSet struct {
+	mu     sync.Mutex
+	rules  []Rule
+	states []ruleState
+}
+
+// SetRules replaces the automation rules; safe to call while running
+func (pe *ProximityEngine) SetRules(rules []Rule) error {
+	copied := make([]Rule, len(rules))
+	names := make(map[string]bool, len(rules))
+	for i, rule := range rules {
+		rule.Actions = append([]RuleAction(nil), rule.Actions...)
+		for j, action := range rule.Actions {
+			if action.Haptic != nil {
+				pattern := *action.Haptic
+				rule.Actions[j].Haptic = &pattern
+			}
+			if action.Alerts != nil {
+				alerts := *action.Alerts
+				rule.Actions[j].Alerts = &alerts
+			}
+			if action.Record != nil {
+				record := *action.Record
+				rule.Actions[j].Record = &record
+			}
+		}
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("duplicate rule name %q", rule.Name)
+		}
+		names[rule.Name] = true
+		copied[i] = rule
+	}
+
+	pe.rules.mu.Lock()
+	pe.rules.rules, pe.rules.states = copied, make([]ruleState, len(copied))
+	pe.rules.mu.Unlock()
+	detectLog.Info("Rules set", "count", len(copied))
+	return nil
+}
+
+// Rules returns the automation rules
+func (pe *ProximityEngine) Rules() []Rule {
+	pe.rules.mu.Lock()
+	defer pe.rules.mu.Unlock()
+	return append([]Rule(nil), pe.rules.rules...)
+}
+
+// update evaluates the rules against a batch and returns the rule events
+// for conditions that have now held for their full duration
+func (s *ruleSet) update(detections []Detection, instance Instance, now time.Time) []ProximityEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var events []ProximityEvent
+	for i, rule := range s.rules {
+		state := &s.states[i]
+		if rule.standing() {
+			continue
+		}
+		if !rule.When.matches(detections, instance) {
+			state.since, state.fired = time.Time{}, false
+			continue
+		}
+		if state.since.IsZero() {
+			state.since = now
+		}
+		if state.fired || now.Sub(state.since) < rule.When.For {
+			continue
+		}
+		// In cooldown the rule stays armed and fires once it ends
+		if !state.lastFired.IsZero() && now.Sub(state.lastFired) < rule.Cooldown {
+			continue
+		}
+		state.fired, state.lastFired = true, now
+
+		event := ProximityEvent{
+			Type:      EventRule,
+			Timestamp: now.Unix(),
+			Rule:      rule.Name,
+			Actions:   rule.Actions,
+			Count:     len(detections),
+		}
+		if nearest, ok := NearestDetection(detections); ok {
+			event.Category, event.Distance, event.Detection = nearest.Category, nearest.Distance, &nearest
+		}
+		for _, action := range rule.Actions {
+			if action.Haptic != nil {
+				event.Haptic = action.Haptic
+				break
+			}
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// InstanceAllows reports whether the standing rule actions permit a
+// behavior in the current instance. Everything is allowed while the
+// instance type is unknown.
+func (pe *ProximityEngine) InstanceAllows(behavior string) bool {
+	access := pe.Instance().Access()
+	if access == "" {
+		return true
+	}
+	pe.rules.mu.Lock()
+	defer pe.rules.mu.Unlock()
+	allowList := false
+	for _, rule := range pe.rules.rules {
+		for _, action := range rule.Actions {
+			setting := action.standing(behavior)
+			if setting == nil {
+				continue
+			}
+			if rule.When.World == access {
+				return *setting
+			}
+			allowList = allowList || *setting
+		}
+	}
+	return !allowList
+}
@@ -0,0 +1,108 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"vrchat-proximity/pkg/capture"
+)
+
+// EventSceneChange is emitted when most of the frame changes at once, such as
+// a world load or a menu opening
+const EventSceneChange = "scene_change"
+
+// sceneSampleStep is the pixel stride of the whole-frame comparison
+const sceneSampleStep = 8
+
+// scenePixelDelta is how far a channel must move for a sampled pixel to count as changed
+const scenePixelDelta = 40
+
+// SceneConfig configures scene-change detection
+type SceneConfig struct {
+	Threshold float32       `json:"threshold" yaml:"threshold"` // Fraction of the frame that must change at once; 0 disables
+	Settle    time.Duration `json:"settle" yaml:"settle"`       // Detections are suppressed this long after a scene change
+}
+
+// DefaultSceneConfig treats 60% of the frame changing as a new scene and settles for 2s
+func DefaultSceneConfig() SceneConfig {
+	return SceneConfig{Threshold: 0.6, Settle: 2 * time.Second}
+}
+
+// Validate checks the scene-change ranges
+func (c SceneConfig) Validate() error {
+	if c.Threshold < 0 || c.Threshold > 1 {
+		return fmt.Errorf("threshold must be between 0 and 1")
+	}
+	if c.Settle < 0 {
+		return fmt.Errorf("settle must not be negative")
+	}
+	return nil
+}
+
+// SetSceneConfig replaces the scene-change settings; safe to call while running
+func (pe *ProximityEngine) SetSceneConfig(config SceneConfig) {
+	pe.sceneConfig.Store(&config)
+	detectLog.Info("Scene change settings set", "threshold", config.Threshold, "settle", config.Settle)
+}
+
+// SceneConfig returns the scene-change settings
+func (pe *ProximityEngine) SceneConfig() SceneConfig {
+	if config := pe.sceneConfig.Load(); config != nil {
+		return *config
+	}
+	return DefaultSceneConfig()
+}
+
+// settling checks frame for a scene change against previous and reports
+// whether detections should be suppressed. The first change of a settling
+// period raises scene_change and forgets tracked objects; further changes
+// only extend the period.
+func (pe *ProximityEngine) settling(frame, previous capture.Frame, now time.Time) bool {
+	config := pe.SceneConfig()
+	settling := now.UnixNano() < pe.settleUntil.Load()
+	if config.Threshold == 0 {
+		return settling
+	}
+
+	changed := changedFraction(frame, previous)
+	if changed < config.Threshold {
+		return settling
+	}
+	pe.settleUntil.Store(now.Add(config.Settle).UnixNano())
+	if settling {
+		return true
+	}
+
+	pe.resetTracks()
+	detectLog.Info("Scene change, settling", "changed", changed, "settle", config.Settle)
+	select {
+	case pe.eventChan <- ProximityEvent{Type: EventSceneChange, Timestamp: now.Unix(), Changed: changed}:
+	default:
+	}
+	return true
+}
+
+// changedFraction samples two same-sized frames and returns the fraction of
+// pixels that differ noticeably
+func changedFraction(current, previous capture.Frame) float32 {
+	if !current.Valid() || !previous.Valid() || current.Width != previous.Width || current.Height != previous.Height {
+		return 0
+	}
+
+	var changed, total int
+	stride := current.Width * 3
+	for y := 0; y < current.Height; y += sceneSampleStep {
+		for x := 0; x < current.Width; x += sceneSampleStep {
+			i := y*stride + x*3
+			total++
+			for c := 0; c < 3; c++ {
+				delta := int(current.Data[i+c]) - int(previous.Data[i+c])
+				if delta > scenePixelDelta || delta < -scenePixelDelta {
+					changed++
+					break
+				}
+			}
+		}
+	}
+	return float32(changed) / float32(total)
+}
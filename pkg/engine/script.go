@@ -0,0 +1,38 @@
+package engine
+
+// PipelineScript lets user code rewrite the pipeline. Both methods run on
+// the detection processing goroutine, in order, before any output hook.
+type PipelineScript interface {
+	// HandleDetections returns the batch to publish and any custom events
+	HandleDetections(detections []Detection) ([]Detection, []ProximityEvent)
+	// HandleEvent returns the event to publish, false to suppress it, and
+	// any custom events to publish after it
+	HandleEvent(event ProximityEvent) (ProximityEvent, bool, []ProximityEvent)
+}
+
+// SetScript installs a pipeline script, or removes it when nil
+func (pe *ProximityEngine) SetScript(script PipelineScript) {
+	if script == nil {
+		pe.script.Store(nil)
+		return
+	}
+	pe.script.Store(&script)
+}
+
+// scriptDetections runs the script's detection hook over a batch
+func (pe *ProximityEngine) scriptDetections(detections []Detection) ([]Detection, []ProximityEvent) {
+	script := pe.script.Load()
+	if script == nil {
+		return detections, nil
+	}
+	return (*script).HandleDetections(detections)
+}
+
+// scriptEvent runs the script's event hook over an event
+func (pe *ProximityEngine) scriptEvent(event ProximityEvent) (ProximityEvent, bool, []ProximityEvent) {
+	script := pe.script.Load()
+	if script == nil {
+		return event, true, nil
+	}
+	return (*script).HandleEvent(event)
+}
@@ -0,0 +1,263 @@
+package engine
+
+import (
+	"sync"
+	"time"
+)
+
+// Reasons a session began
+const (
+	SessionStart     = "start"      // First detections after the app started
+	SessionLaunch    = "launch"     // The followed game process started
+	SessionWorldJoin = "world_join" // The reported instance location changed
+)
+
+// Session statistics tuning
+const (
+	maxSessions       = 50              // Finished sessions kept in memory
+	sessionMaxGap     = 2 * time.Second // Longer gaps between batches, such as pauses, are not counted
+	sessionBusyWindow = 5 * time.Minute // Length of the busiest period
+	heatmapColumns    = 64              // Heatmap grid over the frame, 16:9 like most captures
+	heatmapRows       = 36
+)
+
+// BusyPeriod is the window of a session with the most detections in view;
+// sessions with nobody in view have none
+type BusyPeriod struct {
+	Start        time.Time `json:"start"`
+	End          time.Time `json:"end"`
+	AverageCount float64   `json:"average_count"` // Detections per batch
+}
+
+// Session accumulates statistics between VRChat launches and world joins
+type Session struct {
+	ID              int                `json:"id"`
+	Reason          string             `json:"reason"` // start, launch, or world_join
+	World           string             `json:"world,omitempty"`
+	Location        string             `json:"location,omitempty"`
+	StartedAt       time.Time          `json:"started_at"`
+	EndedAt         *time.Time         `json:"ended_at,omitempty"` // Unset for the current session
+	DurationSeconds float64            `json:"duration_seconds"`
+	CategorySeconds map[string]float64 `json:"category_seconds"` // Time with the nearest detection in each category
+	CloseApproaches int                `json:"close_approaches"` // zone_enter into Close or nearer
+	PeakCount       int                `json:"peak_count"`       // Most detections in one batch
+	Busiest         *BusyPeriod        `json:"busiest,omitempty"`
+}
+
+// Heatmap counts detection centers per cell of a grid laid over the frame
+type Heatmap struct {
+	Columns int      `json:"columns"`
+	Rows    int      `json:"rows"`
+	Cells   []uint32 `json:"cells"` // Row-major, top left first
+}
+
+// Max returns the highest cell count
+func (h Heatmap) Max() uint32 {
+	var m uint32
+	for _, c := range h.Cells {
+		m = max(m, c)
+	}
+	return m
+}
+
+// sessionTracker holds the current session and the finished ones
+type sessionTracker struct {
+	mu       sync.Mutex
+	current  *Session
+	finished []Session
+	nextID   int
+	heatmaps map[int]*[heatmapColumns * heatmapRows]uint32 // By session ID, for the sessions kept
+
+	lastAt       time.Time
+	lastCategory string // Nearest category of the last batch, "" when empty
+	window       BusyPeriod
+	windowSum    int
+	windowN      int
+}
+
+// begin ends the current session and starts a new one
+func (t *sessionTracker) begin(reason string, instance Instance, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.endLocked(now)
+	t.nextID++
+	if t.heatmaps == nil {
+		t.heatmaps = make(map[int]*[heatmapColumns * heatmapRows]uint32)
+	}
+	t.heatmaps[t.nextID] = new([heatmapColumns * heatmapRows]uint32)
+	t.current = &Session{
+		ID:              t.nextID,
+		Reason:          reason,
+		StartedAt:       now,
+		CategorySeconds: make(map[string]float64),
+	}
+	if instance.Known {
+		t.current.World, t.current.Location = instance.World, instance.Location
+	}
+	t.lastAt, t.lastCategory = time.Time{}, ""
+	t.window, t.windowSum, t.windowN = BusyPeriod{Start: now}, 0, 0
+	engineLog.Info("Session started", "id", t.nextID, "reason", reason, "world", t.current.World)
+}
+
+// end finishes the current session, if any
+func (t *sessionTracker) end(now time.Time) {
+	t.mu.Lock()
+	t.endLocked(now)
+	t.mu.Unlock()
+}
+
+// endLocked moves the current session to the finished list
+func (t *sessionTracker) endLocked(now time.Time) {
+	if t.current == nil {
+		return
+	}
+	t.closeWindow()
+	session := t.snapshot(now)
+	session.EndedAt = &now
+	t.finished = append(t.finished, session)
+	if len(t.finished) > maxSessions {
+		for _, evicted := range t.finished[:len(t.finished)-maxSessions] {
+			delete(t.heatmaps, evicted.ID)
+		}
+		t.finished = t.finished[len(t.finished)-maxSessions:]
+	}
+	t.current = nil
+	engineLog.Info("Session ended", "id", session.ID, "duration", time.Duration(session.DurationSeconds*float64(time.Second)).Round(time.Second))
+}
+
+// located attaches the first reported instance to a session that began
+// without one, or starts a world_join session when the location changes
+func (t *sessionTracker) located(instance Instance, now time.Time) {
+	if !instance.Known || instance.Location == "" {
+		return
+	}
+	t.mu.Lock()
+	if t.current != nil && t.current.Location == "" {
+		t.current.World, t.current.Location = instance.World, instance.Location
+	}
+	changed := t.current == nil || t.current.Location != instance.Location
+	t.mu.Unlock()
+	if changed {
+		t.begin(SessionWorldJoin, instance, now)
+	}
+}
+
+// update adds a detection batch, starting a session if none is open.
+// Centers are only added to the heatmap once the frame size is known.
+func (t *sessionTracker) update(detections []Detection, frameWidth, frameHeight int, instance Instance, now time.Time) {
+	t.mu.Lock()
+	open := t.current != nil
+	t.mu.Unlock()
+	if !open {
+		t.begin(SessionStart, instance, now)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := t.current
+	if gap := now.Sub(t.lastAt); !t.lastAt.IsZero() && gap <= sessionMaxGap && t.lastCategory != "" {
+		s.CategorySeconds[t.lastCategory] += gap.Seconds()
+	}
+	t.lastAt, t.lastCategory = now, ""
+	if nearest, ok := NearestDetection(detections); ok {
+		t.lastCategory = nearest.Category
+	}
+	s.PeakCount = max(s.PeakCount, len(detections))
+	if heat := t.heatmaps[s.ID]; frameWidth > 0 && frameHeight > 0 {
+		for _, d := range detections {
+			col := int((float64(d.BBox.X) + float64(d.BBox.Width)/2) / float64(frameWidth) * heatmapColumns)
+			row := int((float64(d.BBox.Y) + float64(d.BBox.Height)/2) / float64(frameHeight) * heatmapRows)
+			if col >= 0 && col < heatmapColumns && row >= 0 && row < heatmapRows {
+				heat[row*heatmapColumns+col]++
+			}
+		}
+	}
+
+	if now.Sub(t.window.Start) >= sessionBusyWindow {
+		t.closeWindow()
+		t.window, t.windowSum, t.windowN = BusyPeriod{Start: now}, 0, 0
+	}
+	t.window.End = now
+	t.windowSum += len(detections)
+	t.windowN++
+}
+
+// observe counts close approaches, entries into the two nearest categories, from zone events
+func (t *sessionTracker) observe(event ProximityEvent) {
+	if event.Type != EventZoneEnter || CategoryRank(event.Category) > 1 {
+		return
+	}
+	if event.Previous != "" && CategoryRank(event.Previous) <= 1 {
+		return // Very Close to Close is the same approach
+	}
+	t.mu.Lock()
+	if t.current != nil {
+		t.current.CloseApproaches++
+	}
+	t.mu.Unlock()
+}
+
+// closeWindow keeps the current window if it is the busiest so far
+func (t *sessionTracker) closeWindow() {
+	if t.windowN == 0 {
+		return
+	}
+	t.window.AverageCount = float64(t.windowSum) / float64(t.windowN)
+	if busiest := t.current.Busiest; t.window.AverageCount > 0 && (busiest == nil || t.window.AverageCount > busiest.AverageCount) {
+		window := t.window
+		t.current.Busiest = &window
+	}
+}
+
+// snapshot copies the current session, including the open busy window
+func (t *sessionTracker) snapshot(now time.Time) Session {
+	session := *t.current
+	session.DurationSeconds = now.Sub(session.StartedAt).Seconds()
+	session.CategorySeconds = make(map[string]float64, len(t.current.CategorySeconds))
+	for category, seconds := range t.current.CategorySeconds {
+		session.CategorySeconds[category] = seconds
+	}
+	if t.windowN > 0 {
+		window := t.window
+		window.AverageCount = float64(t.windowSum) / float64(t.windowN)
+		if window.AverageCount > 0 && (session.Busiest == nil || window.AverageCount > session.Busiest.AverageCount) {
+			session.Busiest = &window
+		}
+	}
+	return session
+}
+
+// CurrentSession returns the open session, if any
+func (pe *ProximityEngine) CurrentSession() (Session, bool) {
+	pe.sessions.mu.Lock()
+	defer pe.sessions.mu.Unlock()
+	if pe.sessions.current == nil {
+		return Session{}, false
+	}
+	return pe.sessions.snapshot(time.Now()), true
+}
+
+// SessionHeatmap returns where detections appeared during a kept session,
+// or during the current one for id 0
+func (pe *ProximityEngine) SessionHeatmap(id int) (Heatmap, bool) {
+	pe.sessions.mu.Lock()
+	defer pe.sessions.mu.Unlock()
+	if id == 0 {
+		if pe.sessions.current == nil {
+			return Heatmap{}, false
+		}
+		id = pe.sessions.current.ID
+	}
+	heat, ok := pe.sessions.heatmaps[id]
+	if !ok {
+		return Heatmap{}, false
+	}
+	return Heatmap{Columns: heatmapColumns, Rows: heatmapRows, Cells: append([]uint32(nil), heat[:]...)}, true
+}
+
+// Sessions returns the finished sessions kept in memory, oldest first
+func (pe *ProximityEngine) Sessions() []Session {
+	pe.sessions.mu.Lock()
+	defer pe.sessions.mu.Unlock()
+	return append([]Session(nil), pe.sessions.finished...)
+}
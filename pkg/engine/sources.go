@@ -0,0 +1,244 @@
+package engine
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"vrchat-proximity/pkg/capture"
+)
+
+// sourceStale drops a source's detections from merged batches when it has
+// produced no frame for this long, e.g. after its monitor went to sleep
+const sourceStale = 500 * time.Millisecond
+
+// sourceTrackIDs separates the track IDs of each source's tracker
+const sourceTrackIDs = 1 << 40
+
+// NamedSource is a frame source and the SourceID its detections carry
+type NamedSource struct {
+	ID     string
+	Source capture.FrameSource
+}
+
+// SourceInfo is where a source's detections sit in the merged frame:
+// sources are laid side by side in order, so BBox.X of a detection from a
+// later source is offset by the widths of those before it
+type SourceInfo struct {
+	ID      string `json:"id"`
+	OffsetX int    `json:"offset_x"`
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+	Frames  int64  `json:"frames"` // Frames processed from this source
+}
+
+// sourcePipeline is the per-source state of one capture loop. The first
+// pipeline uses the engine's own source, tracker, and deduper, and is the
+// only one feeding the preview and clips.
+type sourcePipeline struct {
+	index   int
+	id      string
+	source  capture.FrameSource
+	tracker *tracker
+	dedupe  *frameDeduper
+	mirror  mirrorDetector
+	ended   atomic.Bool
+	width   atomic.Int32
+	height  atomic.Int32
+	frames  atomic.Int64
+
+	// Private copies of stateful processing, rebuilt when the engine's change;
+	// only touched by the pipeline's own capture loop
+	motion       *motionDetector
+	detector     capture.Detector
+	sharedScaler *capture.Downscaler
+	scaler       *capture.Downscaler
+
+	// Merge state, guarded by sourceSet.mu
+	latest   []Detection
+	latestAt time.Time
+	fresh    bool // latest hasn't been merged yet
+
+	// Untracked detections of the right eye for the left to pair with,
+	// guarded by sourceSet.mu
+	unpaired   []Detection
+	unpairedAt time.Time
+}
+
+// sourceSet holds the capture pipelines
+type sourceSet struct {
+	mu        sync.Mutex // Guards the merge and stereo state of every pipeline
+	pipelines []*sourcePipeline
+}
+
+// SetFrameSources captures from several sources concurrently, e.g. each eye
+// of a VR mirror or two monitors, and merges their detections into one
+// batch; call before Start. The first source is the primary one.
+func (pe *ProximityEngine) SetFrameSources(sources []NamedSource) {
+	if len(sources) == 0 {
+		return
+	}
+	pe.source = sources[0].Source
+	pipelines := make([]*sourcePipeline, len(sources))
+	for i, named := range sources {
+		p := &sourcePipeline{index: i, id: named.ID, source: named.Source, tracker: pe.tracker, dedupe: pe.dedupe}
+		if i > 0 {
+			p.tracker = newTracker(pe.TrackingConfig())
+			p.tracker.nextID = int64(i) * sourceTrackIDs
+			p.dedupe = newFrameDeduper()
+		}
+		pipelines[i] = p
+	}
+	pe.sources.pipelines = pipelines
+	if len(sources) > 1 {
+		captureLog.Info("Capturing from several sources", "count", len(sources))
+	}
+}
+
+// FrameSource returns the primary frame source
+func (pe *ProximityEngine) FrameSource() capture.FrameSource {
+	return pe.source
+}
+
+// Sources returns the layout of the sources in the merged frame
+func (pe *ProximityEngine) Sources() []SourceInfo {
+	var infos []SourceInfo
+	offset := 0
+	for _, p := range pe.pipelines() {
+		width := int(p.width.Load())
+		infos = append(infos, SourceInfo{ID: p.id, OffsetX: offset, Width: width, Height: int(p.height.Load()), Frames: p.frames.Load()})
+		offset += width
+	}
+	return infos
+}
+
+// pipelines returns the capture pipelines, a single one for the engine's
+// source unless SetFrameSources was called
+func (pe *ProximityEngine) pipelines() []*sourcePipeline {
+	pe.sources.mu.Lock()
+	defer pe.sources.mu.Unlock()
+	if len(pe.sources.pipelines) == 0 {
+		pe.sources.pipelines = []*sourcePipeline{{source: pe.source, tracker: pe.tracker, dedupe: pe.dedupe}}
+	}
+	return pe.sources.pipelines
+}
+
+// resetSources forgets every source's tracks and previous frame hash
+func (pe *ProximityEngine) resetSources() {
+	for _, p := range pe.pipelines() {
+		p.tracker.reset()
+		p.dedupe.reset()
+	}
+}
+
+// resetTracks forgets the tracks of every source
+func (pe *ProximityEngine) resetTracks() {
+	for _, p := range pe.pipelines() {
+		p.tracker.reset()
+	}
+}
+
+// setFrameSize records a source's frame size and updates the merged size
+func (pe *ProximityEngine) setFrameSize(p *sourcePipeline, width, height int) {
+	p.width.Store(int32(width))
+	p.height.Store(int32(height))
+	var total, tallest int32
+	for _, q := range pe.pipelines() {
+		total += q.width.Load()
+		tallest = max(tallest, q.height.Load())
+	}
+	pe.frameWidth.Store(total)
+	pe.frameHeight.Store(tallest)
+}
+
+// sourceEnd marks a source as ended; the engine's source has ended once all have
+func (pe *ProximityEngine) sourceEnd(p *sourcePipeline) {
+	p.ended.Store(true)
+	for _, q := range pe.pipelines() {
+		if !q.ended.Load() {
+			return
+		}
+	}
+	pe.sourceEnded.Store(true)
+}
+
+// downscale reduces a frame to the processing resolution. Downscalers reuse
+// their output buffers, so other sources scale with a copy of the engine's.
+func (p *sourcePipeline) downscale(pe *ProximityEngine, frame capture.Frame) capture.Frame {
+	shared := pe.downscaler.Load()
+	if p.index == 0 || shared == nil {
+		return pe.downscale(frame)
+	}
+	if p.sharedScaler != shared {
+		config := pe.ProcessingConfig()
+		p.sharedScaler, p.scaler = shared, capture.NewDownscaler(config.Width, config.Height)
+	}
+	return p.scaler.Scale(frame)
+}
+
+// detectorFor returns the source's instance of the selected stateful
+// motion algorithm, or nil for frame differencing
+func (p *sourcePipeline) detectorFor(pe *ProximityEngine) capture.Detector {
+	motion := pe.motion.Load()
+	if motion == nil || motion.algorithm == capture.AlgorithmFrameDiff {
+		return nil
+	}
+	if p.index == 0 {
+		return motion.detector
+	}
+	if p.motion != motion {
+		detector, err := capture.NewDetector(motion.algorithm)
+		if err != nil {
+			return nil
+		}
+		p.motion, p.detector = motion, detector
+	}
+	return p.detector
+}
+
+// merge tags a source's detections and, with several sources, collects
+// rounds of them: once every source still producing frames has delivered
+// one since the last round, their newest detections, less the right-eye
+// partners of stereo detections, are combined in merged-frame coordinates
+// and returned with true. Each source frame lands in at most one merged
+// batch, and each merged batch is one frame of the engine.
+func (pe *ProximityEngine) merge(p *sourcePipeline, detections []Detection, now time.Time) ([]Detection, bool) {
+	for i := range detections {
+		detections[i].SourceID = p.id
+	}
+	pipelines := pe.pipelines()
+	if len(pipelines) == 1 {
+		return detections, true
+	}
+
+	stereo := pe.StereoConfig()
+	pe.sources.mu.Lock()
+	defer pe.sources.mu.Unlock()
+	p.latest, p.latestAt, p.fresh = detections, now, true
+	for _, q := range pipelines {
+		if !q.fresh && now.Sub(q.latestAt) <= sourceStale {
+			return nil, false
+		}
+	}
+	batches := make([][]Detection, len(pipelines))
+	for i, q := range pipelines {
+		if q.fresh && now.Sub(q.latestAt) <= sourceStale {
+			batches[i] = append([]Detection(nil), q.latest...)
+		}
+		q.fresh = false
+	}
+	if stereo.Enabled {
+		dropStereoPartners(stereo, pipelines, batches)
+	}
+
+	var merged []Detection
+	offset := int32(0)
+	for i, q := range pipelines {
+		for _, d := range batches[i] {
+			d.BBox.X += offset
+			merged = append(merged, d)
+		}
+		offset += q.width.Load()
+	}
+	return merged, true
+}
@@ -0,0 +1,189 @@
+package engine
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// StereoConfig configures distance from parallax when both VR eyes are
+// captured as separate sources
+type StereoConfig struct {
+	Enabled     bool    `json:"enabled" yaml:"enabled"`
+	Left        string  `json:"left" yaml:"left"`                   // Source ID of the left eye
+	Right       string  `json:"right" yaml:"right"`                 // Source ID of the right eye
+	Baseline    float64 `json:"baseline" yaml:"baseline"`           // Distance between the eye cameras in meters; scales with avatar size
+	FOV         float64 `json:"fov" yaml:"fov"`                     // Horizontal field of view of each eye image in degrees
+	MaxRowDelta float64 `json:"max_row_delta" yaml:"max_row_delta"` // Largest vertical offset of a pair, in box heights
+}
+
+// DefaultStereoConfig pairs the "left" and "right" sources with a typical
+// IPD and a 90 degree mirror view
+func DefaultStereoConfig() StereoConfig {
+	return StereoConfig{
+		Left:        "left",
+		Right:       "right",
+		Baseline:    0.063,
+		FOV:         90,
+		MaxRowDelta: 0.25,
+	}
+}
+
+// Validate checks the stereo geometry
+func (c StereoConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Left == "" || c.Right == "" || c.Left == c.Right {
+		return errors.New("left and right must name two different sources")
+	}
+	if c.Baseline <= 0 {
+		return errors.New("baseline must be positive")
+	}
+	if c.FOV <= 0 || c.FOV >= 180 {
+		return errors.New("fov must be between 0 and 180 degrees")
+	}
+	if c.MaxRowDelta <= 0 {
+		return errors.New("max_row_delta must be positive")
+	}
+	return nil
+}
+
+// SetStereoConfig replaces the stereo settings; safe to call while running
+func (pe *ProximityEngine) SetStereoConfig(config StereoConfig) {
+	pe.stereoConfig.Store(&config)
+	detectLog.Info("Stereo distance set", "enabled", config.Enabled, "left", config.Left, "right", config.Right, "baseline", config.Baseline)
+}
+
+// StereoConfig returns the stereo settings
+func (pe *ProximityEngine) StereoConfig() StereoConfig {
+	if config := pe.stereoConfig.Load(); config != nil {
+		return *config
+	}
+	return DefaultStereoConfig()
+}
+
+// stereoPair is a left-right pairing
+type stereoPair struct {
+	left, right int
+	disparity   float64 // Left-eye pixels
+	cost        float64
+}
+
+// stereoPairs pairs detections of the left and right eye, each in its own
+// eye's coordinates, and returns the best pairing of each
+func stereoPairs(config StereoConfig, left, right []Detection, leftWidth, rightWidth float64) []stereoPair {
+	// The same object sits on the same rows at a similar size in both eyes,
+	// further right in the left eye the closer it is
+	var candidates []stereoPair
+	for i, l := range left {
+		lx, ly, lh := float64(l.BBox.X)+float64(l.BBox.Width)/2, float64(l.BBox.Y)+float64(l.BBox.Height)/2, float64(l.BBox.Height)
+		for j, r := range right {
+			rx := (float64(r.BBox.X) + float64(r.BBox.Width)/2) * leftWidth / rightWidth
+			ry, rh := float64(r.BBox.Y)+float64(r.BBox.Height)/2, float64(r.BBox.Height)
+			tallest := math.Max(math.Max(lh, rh), 1)
+			rows, size := math.Abs(ly-ry)/tallest, math.Abs(lh-rh)/tallest
+			if rows > config.MaxRowDelta || size > 0.3 || lx-rx < 0.5 {
+				continue
+			}
+			candidates = append(candidates, stereoPair{left: i, right: j, disparity: lx - rx, cost: rows + size})
+		}
+	}
+	sort.Slice(candidates, func(a, b int) bool { return candidates[a].cost < candidates[b].cost })
+
+	var pairs []stereoPair
+	usedLeft, usedRight := make([]bool, len(left)), make([]bool, len(right))
+	for _, pair := range candidates {
+		if usedLeft[pair.left] || usedRight[pair.right] {
+			continue
+		}
+		usedLeft[pair.left], usedRight[pair.right] = true, true
+		pairs = append(pairs, pair)
+	}
+	return pairs
+}
+
+// stereoEyes returns the pipelines of the left and right eye, nil when a
+// source is missing
+func stereoEyes(config StereoConfig, pipelines []*sourcePipeline) (left, right *sourcePipeline) {
+	for _, p := range pipelines {
+		switch p.id {
+		case config.Left:
+			left = p
+		case config.Right:
+			right = p
+		}
+	}
+	if left == nil || right == nil {
+		return nil, nil
+	}
+	return left, right
+}
+
+// stereoDistances runs before a source's tracker. The right eye keeps its
+// detections for pairing; left-eye detections with a partner among the
+// right eye's latest get the distance from their disparity, which their
+// tracker then smooths and debounces like any other.
+func (pe *ProximityEngine) stereoDistances(p *sourcePipeline, detections []Detection, now time.Time) {
+	config := pe.StereoConfig()
+	if !config.Enabled {
+		return
+	}
+	left, right := stereoEyes(config, pe.pipelines())
+	if left == nil {
+		return
+	}
+
+	pe.sources.mu.Lock()
+	defer pe.sources.mu.Unlock()
+	switch p {
+	case right:
+		p.unpaired, p.unpairedAt = append(p.unpaired[:0], detections...), now
+	case left:
+		leftWidth, rightWidth := float64(left.width.Load()), float64(right.width.Load())
+		if now.Sub(right.unpairedAt) > sourceStale || leftWidth <= 0 || rightWidth <= 0 {
+			return
+		}
+		focal := leftWidth / 2 / math.Tan(config.FOV/2*math.Pi/180)
+		for _, pair := range stereoPairs(config, detections, right.unpaired, leftWidth, rightWidth) {
+			d := &detections[pair.left]
+			distance := float32(math.Min(focal*config.Baseline/pair.disparity, MaxEstimatedDistance))
+			d.Distance, d.Category, d.Stereo = distance, categoryForDistance(distance), true
+		}
+	}
+}
+
+// dropStereoPartners removes the right-eye partners of stereo left-eye
+// detections from a merged round, so each object is reported once. batches
+// holds each pipeline's detections in its own coordinates.
+func dropStereoPartners(config StereoConfig, pipelines []*sourcePipeline, batches [][]Detection) {
+	li, ri := -1, -1
+	for i, p := range pipelines {
+		switch p.id {
+		case config.Left:
+			li = i
+		case config.Right:
+			ri = i
+		}
+	}
+	if li < 0 || ri < 0 || len(batches[li]) == 0 || len(batches[ri]) == 0 {
+		return
+	}
+	leftWidth, rightWidth := float64(pipelines[li].width.Load()), float64(pipelines[ri].width.Load())
+	if leftWidth <= 0 || rightWidth <= 0 {
+		return
+	}
+	left, right := batches[li], batches[ri]
+	partner := make([]bool, len(right))
+	for _, pair := range stereoPairs(config, left, right, leftWidth, rightWidth) {
+		partner[pair.right] = left[pair.left].Stereo
+	}
+	kept := right[:0]
+	for j, r := range right {
+		if !partner[j] {
+			kept = append(kept, r)
+		}
+	}
+	batches[ri] = kept
+}
@@ -0,0 +1,110 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Supervised subsystems reported by Restarts
+const (
+	SubsystemCapture   = "capture"
+	SubsystemDetection = "detection"
+	SubsystemBroadcast = "broadcast"
+)
+
+// Restart backoff: the delay doubles with each panic up to supervisorMaxBackoff
+// and starts over once a run has lasted supervisorHealthy
+const (
+	supervisorMinBackoff = time.Second
+	supervisorMaxBackoff = 30 * time.Second
+	supervisorHealthy    = time.Minute
+)
+
+// supervisor counts the panics each subsystem recovered from
+type supervisor struct {
+	mu        sync.Mutex
+	restarts  map[string]int64
+	crashFile string // Set before Start; empty only logs crashes
+}
+
+// SetCrashFile sets the file panic stack traces are appended to; empty
+// only logs them. Call before Start.
+func (pe *ProximityEngine) SetCrashFile(path string) {
+	pe.supervisor.crashFile = path
+}
+
+// Restarts returns how often each subsystem was restarted after a panic
+func (pe *ProximityEngine) Restarts() map[string]int64 {
+	s := &pe.supervisor
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	restarts := map[string]int64{SubsystemCapture: 0, SubsystemDetection: 0, SubsystemBroadcast: 0}
+	for subsystem, count := range s.restarts {
+		restarts[subsystem] = count
+	}
+	return restarts
+}
+
+// Supervise runs run until it returns, restarting it with backoff whenever
+// it panics. The panic and its stack trace are logged and appended to the
+// crash file. Closing done abandons a pending restart.
+func (pe *ProximityEngine) Supervise(done <-chan struct{}, subsystem string, run func()) {
+	backoff := supervisorMinBackoff
+	for {
+		start := time.Now()
+		if !pe.runRecovered(subsystem, run) {
+			return
+		}
+		if time.Since(start) >= supervisorHealthy {
+			backoff = supervisorMinBackoff
+		}
+		engineLog.Warn("Restarting after panic", "component", subsystem, "in", backoff)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		backoff = min(2*backoff, supervisorMaxBackoff)
+	}
+}
+
+// runRecovered calls run and reports whether it panicked
+func (pe *ProximityEngine) runRecovered(subsystem string, run func()) (panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicked = true
+			pe.recordCrash(subsystem, r, debug.Stack())
+		}
+	}()
+	run()
+	return false
+}
+
+// recordCrash counts a panic and writes it to the log and the crash file
+func (pe *ProximityEngine) recordCrash(subsystem string, value interface{}, stack []byte) {
+	s := &pe.supervisor
+	s.mu.Lock()
+	if s.restarts == nil {
+		s.restarts = make(map[string]int64)
+	}
+	s.restarts[subsystem]++
+	s.mu.Unlock()
+
+	engineLog.Error("Panic recovered", "component", subsystem, "panic", fmt.Sprint(value), "crash_file", s.crashFile)
+	if s.crashFile == "" {
+		return
+	}
+	f, err := os.OpenFile(s.crashFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		engineLog.Error("Crash file not written", "path", s.crashFile, "error", err)
+		return
+	}
+	defer f.Close()
+	fmt.Fprintf(f, "%s %s panic: %v\n%s\n", time.Now().Format(time.RFC3339), subsystem, value, stack)
+}
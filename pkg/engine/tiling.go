@@ -0,0 +1,63 @@
+package engine
+
+import (
+	"fmt"
+
+	"vrchat-proximity/pkg/capture"
+)
+
+// TilingConfig configures tile-parallel motion detection
+type TilingConfig struct {
+	Tiles   int `json:"tiles" yaml:"tiles"`     // Grid cells per frame; 0 or 1 detects on the whole frame
+	Workers int `json:"workers" yaml:"workers"` // Tiles processed at once; 0 uses every CPU
+	Overlap int `json:"overlap" yaml:"overlap"` // Pixels each tile extends into its neighbors
+}
+
+// DefaultTilingConfig detects on the whole frame
+func DefaultTilingConfig() TilingConfig {
+	return TilingConfig{Overlap: 32}
+}
+
+// Validate checks the tiling ranges
+func (c TilingConfig) Validate() error {
+	if c.Tiles < 0 || c.Tiles > 64 {
+		return fmt.Errorf("tiles must be between 0 and 64")
+	}
+	if c.Workers < 0 || c.Overlap < 0 {
+		return fmt.Errorf("workers and overlap must not be negative")
+	}
+	return nil
+}
+
+// SetTilingConfig switches between whole-frame and tiled detection; safe to call while running
+func (pe *ProximityEngine) SetTilingConfig(config TilingConfig) {
+	pe.tilingConfig.Store(&config)
+	if config.Tiles > 1 {
+		pe.tiler.Store(capture.NewTileDetector(config.Tiles, config.Workers, config.Overlap))
+	} else {
+		pe.tiler.Store(nil)
+	}
+	detectLog.Info("Tiling set", "tiles", config.Tiles, "workers", config.Workers, "overlap", config.Overlap)
+}
+
+// TilingConfig returns the tiling settings
+func (pe *ProximityEngine) TilingConfig() TilingConfig {
+	if config := pe.tilingConfig.Load(); config != nil {
+		return *config
+	}
+	return DefaultTilingConfig()
+}
+
+// detectMotion runs the selected motion algorithm on a source's frame.
+// Frame differencing runs on the whole frame or tile by tile; the other
+// algorithms keep per-pixel state and always see the whole frame.
+func (pe *ProximityEngine) detectMotion(p *sourcePipeline, frame, previousFrame capture.Frame) []capture.RawDetection {
+	threshold := uint8(pe.motionThreshold())
+	if detector := p.detectorFor(pe); detector != nil {
+		return detector.Detect(frame, previousFrame, threshold)
+	}
+	if tiler := pe.tiler.Load(); tiler != nil {
+		return tiler.Detect(frame, previousFrame, threshold)
+	}
+	return capture.DetectMotion(frame, previousFrame, threshold)
+}
@@ -0,0 +1,86 @@
+package engine
+
+import "time"
+
+// StageQueue is the wait between convert and broadcast while a batch sits in
+// the detection channel; it appears in frame traces only
+const StageQueue = "queue"
+
+// StageSpan is when one pipeline stage ran for a frame
+type StageSpan struct {
+	Stage string
+	Start time.Time
+	End   time.Time
+}
+
+// FrameTrace is one frame's path through the pipeline, stage by stage.
+// Frames without detections end after convert; Dropped frames never reached
+// broadcast because processing fell behind.
+type FrameTrace struct {
+	SourceID   string
+	Detections int
+	Dropped    bool
+	Stages     []StageSpan
+
+	queued time.Time
+}
+
+// Start returns when the first stage began
+func (t *FrameTrace) Start() time.Time {
+	if len(t.Stages) == 0 {
+		return time.Time{}
+	}
+	return t.Stages[0].Start
+}
+
+// End returns when the last stage finished
+func (t *FrameTrace) End() time.Time {
+	if len(t.Stages) == 0 {
+		return time.Time{}
+	}
+	return t.Stages[len(t.Stages)-1].End
+}
+
+// add appends a stage; a nil trace ignores it
+func (t *FrameTrace) add(stage string, start, end time.Time) {
+	if t != nil {
+		t.Stages = append(t.Stages, StageSpan{Stage: stage, Start: start, End: end})
+	}
+}
+
+// detectionBatch is a batch on its way to processDetections
+type detectionBatch struct {
+	detections []Detection
+	trace      *FrameTrace // nil unless frame traces are wanted
+}
+
+// OnFrameTrace registers a callback invoked with each captured frame's trace
+// once it leaves the pipeline, on the capture or detection goroutine. Frames
+// are only traced while a callback is registered.
+func (pe *ProximityEngine) OnFrameTrace(hook func(FrameTrace)) {
+	pe.hooksMutex.Lock()
+	pe.traceHooks = append(pe.traceHooks, hook)
+	pe.hooksMutex.Unlock()
+	pe.tracing.Store(true)
+}
+
+// newFrameTrace starts a trace for a frame from p, or returns nil when
+// nothing wants traces
+func (pe *ProximityEngine) newFrameTrace(p *sourcePipeline) *FrameTrace {
+	if !pe.tracing.Load() {
+		return nil
+	}
+	return &FrameTrace{SourceID: p.id}
+}
+
+// finishTrace hands a completed trace to the trace hooks
+func (pe *ProximityEngine) finishTrace(trace *FrameTrace) {
+	if trace == nil {
+		return
+	}
+	pe.hooksMutex.RLock()
+	defer pe.hooksMutex.RUnlock()
+	for _, hook := range pe.traceHooks {
+		hook(*trace)
+	}
+}
@@ -0,0 +1,309 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// TrackingConfig configures how detections are followed across frames and
+// how much their distance and category are smoothed
+type TrackingConfig struct {
+	Smoothing      float32 `json:"smoothing" yaml:"smoothing"`             // Weight of the previous distance, 0 (off) to below 1
+	CategoryFrames int     `json:"category_frames" yaml:"category_frames"` // Frames a new category must hold before it's reported
+	MatchDistance  float32 `json:"match_distance" yaml:"match_distance"`   // Max center movement per frame, as a fraction of the frame diagonal
+	MaxMissed      int     `json:"max_missed" yaml:"max_missed"`           // Frames a track survives without a matching detection
+	FastApproach   float32 `json:"fast_approach" yaml:"fast_approach"`     // Approach rate in m/s that raises fast_approach; 0 disables
+	TrailLength    int     `json:"trail_length" yaml:"trail_length"`       // Recent positions kept per track for Trails; 0 disables
+}
+
+// DefaultTrackingConfig smooths moderately and requires three frames for a category change
+func DefaultTrackingConfig() TrackingConfig {
+	return TrackingConfig{
+		Smoothing:      0.6,
+		CategoryFrames: 3,
+		MatchDistance:  0.15,
+		MaxMissed:      5,
+		FastApproach:   18,
+		TrailLength:    20,
+	}
+}
+
+// Validate checks the tracking ranges
+func (c TrackingConfig) Validate() error {
+	if c.Smoothing < 0 || c.Smoothing >= 1 {
+		return fmt.Errorf("smoothing must be at least 0 and below 1")
+	}
+	if c.CategoryFrames < 0 || c.MaxMissed < 0 || c.TrailLength < 0 {
+		return fmt.Errorf("category_frames, max_missed, and trail_length must not be negative")
+	}
+	if c.FastApproach < 0 {
+		return fmt.Errorf("fast_approach must not be negative")
+	}
+	if c.MatchDistance <= 0 {
+		return fmt.Errorf("match_distance must be positive")
+	}
+	return nil
+}
+
+// track is one object followed across frames
+type track struct {
+	id           int64
+	cx, cy       float64 // Center as a fraction of the frame size
+	distance     float32 // Smoothed
+	category     string  // Reported
+	pending      string  // Category waiting to be confirmed
+	pendingCount int
+	missed       int
+	lastSeen     time.Time
+	vx, vy       float64 // Smoothed center velocity in frame fractions per second
+	approach     float32 // Closing speed over approachWindow in meters per second
+	history      []distanceSample
+	trail        []TrailPoint // Oldest first, at most TrailLength
+	nameplateAt  time.Time    // Last nameplate measurement
+	avatarHeight float32      // From the last nameplate measurement
+}
+
+// approachWindow is how far back the approach rate looks, long enough to
+// span several steps of the coarse distance estimate
+const approachWindow = time.Second
+
+// distanceSample is a smoothed track distance at a point in time
+type distanceSample struct {
+	at       time.Time
+	distance float32
+}
+
+// tracker assigns track IDs to detections and smooths them per track
+type tracker struct {
+	mu     sync.Mutex
+	config TrackingConfig
+	tracks []*track
+	nextID int64
+}
+
+// newTracker creates a tracker with no tracks
+func newTracker(config TrackingConfig) *tracker {
+	return &tracker{config: config}
+}
+
+// setConfig replaces the tracking config, keeping existing tracks
+func (t *tracker) setConfig(config TrackingConfig) {
+	t.mu.Lock()
+	t.config = config
+	t.mu.Unlock()
+}
+
+// reset forgets every track
+func (t *tracker) reset() {
+	t.mu.Lock()
+	t.tracks = nil
+	t.mu.Unlock()
+}
+
+// candidate is a possible track-detection pairing
+type candidate struct {
+	track, detection int
+	distance         float64
+}
+
+// update matches a frame's detections to tracks by nearest center, then
+// replaces their Distance and Category with the smoothed track values and
+// fills in velocity and approach rate. A track measured by its nameplate
+// keeps that distance for nameplateForget while none is found.
+func (t *tracker) update(detections []Detection, frameWidth, frameHeight int32, now time.Time) []Detection {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if frameWidth <= 0 || frameHeight <= 0 {
+		return detections
+	}
+	w, h := float64(frameWidth), float64(frameHeight)
+	diagonal := math.Hypot(w, h)
+
+	centers := make([][2]float64, len(detections))
+	for i, d := range detections {
+		centers[i] = [2]float64{
+			(float64(d.BBox.X) + float64(d.BBox.Width)/2) / w,
+			(float64(d.BBox.Y) + float64(d.BBox.Height)/2) / h,
+		}
+	}
+
+	// Greedily pair the closest track and detection until none are in range
+	var candidates []candidate
+	for ti, tr := range t.tracks {
+		for di, c := range centers {
+			dist := math.Hypot((c[0]-tr.cx)*w, (c[1]-tr.cy)*h) / diagonal
+			if dist <= float64(t.config.MatchDistance) {
+				candidates = append(candidates, candidate{ti, di, dist})
+			}
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].distance < candidates[j].distance })
+
+	trackUsed := make([]bool, len(t.tracks))
+	matched := make([]*track, len(detections))
+	for _, c := range candidates {
+		if trackUsed[c.track] || matched[c.detection] != nil {
+			continue
+		}
+		trackUsed[c.track] = true
+		matched[c.detection] = t.tracks[c.track]
+	}
+
+	// Age out tracks that went unmatched for too long
+	kept := t.tracks[:0]
+	for i, tr := range t.tracks {
+		if !trackUsed[i] {
+			tr.missed++
+			if tr.missed > t.config.MaxMissed {
+				continue
+			}
+		}
+		kept = append(kept, tr)
+	}
+	t.tracks = kept
+
+	for i := range detections {
+		d := &detections[i]
+		tr := matched[i]
+		measured := d.Nameplate
+		if tr != nil && !measured && !d.Stereo && now.Sub(tr.nameplateAt) <= nameplateForget {
+			// Letters blur in motion; keep the track's nameplate distance a while
+			d.Distance, d.Category, d.Nameplate, d.AvatarHeight = tr.distance, tr.category, true, tr.avatarHeight
+		}
+		if tr == nil {
+			t.nextID++
+			tr = &track{id: t.nextID, distance: d.Distance, category: d.Category}
+			tr.history = append(tr.history, distanceSample{now, tr.distance})
+			t.tracks = append(t.tracks, tr)
+		} else {
+			t.smooth(tr, d, centers[i], now)
+		}
+		tr.cx, tr.cy = centers[i][0], centers[i][1]
+		tr.missed = 0
+		tr.lastSeen = now
+		if measured {
+			tr.nameplateAt, tr.avatarHeight = now, d.AvatarHeight
+		}
+
+		d.TrackID = tr.id
+		d.Distance = tr.distance
+		d.Category = tr.category
+		d.VelocityX = float32(tr.vx)
+		d.VelocityY = float32(tr.vy)
+		d.ApproachRate = tr.approach
+		tr.addTrailPoint(d, now, t.config.TrailLength)
+	}
+	return detections
+}
+
+// smooth folds a matched detection into its track
+func (t *tracker) smooth(tr *track, d *Detection, center [2]float64, now time.Time) {
+	s := t.config.Smoothing
+	tr.distance = s*tr.distance + (1-s)*d.Distance
+
+	if dt := now.Sub(tr.lastSeen).Seconds(); dt > 0 {
+		sv := float64(s)
+		tr.vx = sv*tr.vx + (1-sv)*(center[0]-tr.cx)/dt
+		tr.vy = sv*tr.vy + (1-sv)*(center[1]-tr.cy)/dt
+	}
+
+	// Approach rate compares against the oldest sample still in the window
+	tr.history = append(tr.history, distanceSample{now, tr.distance})
+	drop := 0
+	for drop < len(tr.history)-1 && now.Sub(tr.history[drop+1].at) >= approachWindow {
+		drop++
+	}
+	tr.history = tr.history[drop:]
+	if oldest := tr.history[0]; now.Sub(oldest.at) >= approachWindow/2 {
+		tr.approach = (oldest.distance - tr.distance) / float32(now.Sub(oldest.at).Seconds())
+	} else {
+		tr.approach = 0
+	}
+
+	switch {
+	case d.Category == tr.category:
+		tr.pending, tr.pendingCount = "", 0
+	case d.Category == tr.pending:
+		tr.pendingCount++
+	default:
+		tr.pending, tr.pendingCount = d.Category, 1
+	}
+	if tr.pending != "" && tr.pendingCount >= t.config.CategoryFrames {
+		tr.category = tr.pending
+		tr.pending, tr.pendingCount = "", 0
+	}
+}
+
+// SetTrackingConfig replaces the tracking and smoothing settings; safe to call while running
+func (pe *ProximityEngine) SetTrackingConfig(config TrackingConfig) {
+	for _, p := range pe.pipelines() {
+		p.tracker.setConfig(config)
+	}
+	detectLog.Info("Tracking set", "smoothing", config.Smoothing, "category_frames", config.CategoryFrames)
+}
+
+// TrackingConfig returns the tracking and smoothing settings
+func (pe *ProximityEngine) TrackingConfig() TrackingConfig {
+	pe.tracker.mu.Lock()
+	defer pe.tracker.mu.Unlock()
+	return pe.tracker.config
+}
+
+// approachForget drops an alerted track after it has been out of view this long
+const approachForget = 5 * time.Second
+
+// approachMonitor raises fast_approach once per track each time its approach
+// rate crosses the threshold. It re-arms after the rate falls below half.
+type approachMonitor struct {
+	alerted map[int64]time.Time // Track ID to when it was last seen
+}
+
+// newApproachMonitor creates a monitor with no alerted tracks
+func newApproachMonitor() *approachMonitor {
+	return &approachMonitor{alerted: make(map[int64]time.Time)}
+}
+
+// update processes a detection batch and returns any fast_approach events
+func (a *approachMonitor) update(detections []Detection, threshold float32, now time.Time) []ProximityEvent {
+	if threshold <= 0 {
+		return nil
+	}
+
+	var events []ProximityEvent
+	for i := range detections {
+		d := detections[i]
+		if d.TrackID == 0 {
+			continue
+		}
+		_, alerted := a.alerted[d.TrackID]
+		if alerted {
+			a.alerted[d.TrackID] = now
+		}
+
+		switch {
+		case d.ApproachRate >= threshold && !alerted:
+			a.alerted[d.TrackID] = now
+			events = append(events, ProximityEvent{
+				Type:         EventFastApproach,
+				Timestamp:    now.Unix(),
+				Priority:     PriorityHigh,
+				Category:     d.Category,
+				Distance:     d.Distance,
+				ApproachRate: d.ApproachRate,
+				Detection:    &d,
+			})
+		case d.ApproachRate < threshold/2:
+			delete(a.alerted, d.TrackID)
+		}
+	}
+	for id, seen := range a.alerted {
+		if now.Sub(seen) > approachForget {
+			delete(a.alerted, id)
+		}
+	}
+	return events
+}
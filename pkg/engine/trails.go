@@ -0,0 +1,66 @@
+package engine
+
+import "time"
+
+// TrailPoint is where a track was in one frame, in merged-frame pixels
+type TrailPoint struct {
+	X           int32   `json:"x"` // Center of the box
+	Y           int32   `json:"y"`
+	Distance    float32 `json:"distance"`
+	Category    string  `json:"category"`
+	TimestampMS int64   `json:"timestamp_ms"`
+}
+
+// addTrailPoint appends a detection's position to the track's trail,
+// dropping the oldest points beyond length
+func (tr *track) addTrailPoint(d *Detection, now time.Time, length int) {
+	if length <= 0 {
+		tr.trail = nil
+		return
+	}
+	if len(tr.trail) >= length {
+		tr.trail = append(tr.trail[:0], tr.trail[len(tr.trail)-length+1:]...)
+	}
+	tr.trail = append(tr.trail, TrailPoint{
+		X:           d.BBox.X + d.BBox.Width/2,
+		Y:           d.BBox.Y + d.BBox.Height/2,
+		Distance:    d.Distance,
+		Category:    d.Category,
+		TimestampMS: now.UnixMilli(),
+	})
+}
+
+// Trails returns the recent positions of each detection's track, oldest
+// first and keyed by TrackID, for drawing motion trails. Detections
+// without a track or a trail are left out.
+func (pe *ProximityEngine) Trails(detections []Detection) map[int64][]TrailPoint {
+	wanted := make(map[int64]bool, len(detections))
+	for _, d := range detections {
+		if d.TrackID != 0 {
+			wanted[d.TrackID] = true
+		}
+	}
+	trails := make(map[int64][]TrailPoint, len(wanted))
+	if len(wanted) == 0 {
+		return trails
+	}
+
+	// Sources sit side by side in the merged frame, as in merge
+	offset := int32(0)
+	for _, p := range pe.pipelines() {
+		p.tracker.mu.Lock()
+		for _, tr := range p.tracker.tracks {
+			if !wanted[tr.id] || len(tr.trail) == 0 {
+				continue
+			}
+			trail := append([]TrailPoint(nil), tr.trail...)
+			for i := range trail {
+				trail[i].X += offset
+			}
+			trails[tr.id] = trail
+		}
+		p.tracker.mu.Unlock()
+		offset += p.width.Load()
+	}
+	return trails
+}
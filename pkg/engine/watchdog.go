@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"context"
+	"time"
+)
+
+// startCaptureLoop launches a capture loop per source, cancelling any previous ones
+func (pe *ProximityEngine) startCaptureLoop() {
+	pe.loopMutex.Lock()
+	ctx, cancel := context.WithCancel(pe.screenCaptureCtx)
+	if pe.cancelLoop != nil {
+		pe.cancelLoop()
+	}
+	pe.cancelLoop = cancel
+	pe.loopMutex.Unlock()
+
+	for _, p := range pe.pipelines() {
+		p := p
+		go pe.Supervise(ctx.Done(), SubsystemCapture, func() { pe.captureAndDetectLoop(ctx, p) })
+	}
+}
+
+// SetWatchdogTimeout sets how long frameCount may stall before the capture
+// loop is restarted; 0 disables the watchdog. Call before Start.
+func (pe *ProximityEngine) SetWatchdogTimeout(timeout time.Duration) {
+	pe.watchdogTimeout = timeout
+}
+
+// watchdog restarts the capture loop when frames stop arriving, e.g. after a
+// hung Zig call or a GPU driver reset. A source that reports ErrNoFrame (the
+// VRChat window is closed) or has ended is idle, not stalled, and so is a
+// paused engine.
+func (pe *ProximityEngine) watchdog(ctx context.Context) {
+	timeout := pe.watchdogTimeout
+	if timeout <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	lastCount := pe.frameCount.Load()
+	lastAdvance := time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if count := pe.frameCount.Load(); count != lastCount || pe.paused.Load() {
+				lastCount = count
+				lastAdvance = now
+				continue
+			}
+			if pe.sourceEnded.Load() || now.Sub(lastAdvance) < timeout {
+				continue
+			}
+			if idle := pe.lastNoFrame.Load(); idle != 0 && now.Sub(time.Unix(0, idle)) < timeout {
+				continue
+			}
+
+			// Re-check under the lifecycle lock so a concurrent Pause or Stop wins
+			pe.lifecycleMutex.Lock()
+			if pe.paused.Load() || ctx.Err() != nil {
+				pe.lifecycleMutex.Unlock()
+				continue
+			}
+			gap := now.Sub(lastAdvance)
+			captureLog.Warn("Capture stalled, restarting capture loop", "gap", gap.Round(time.Second))
+			pe.startCaptureLoop()
+			pe.lifecycleMutex.Unlock()
+			lastAdvance = now
+
+			select {
+			case pe.eventChan <- ProximityEvent{Type: EventCaptureRestarted, Timestamp: now.Unix(), GapMS: gap.Milliseconds()}:
+			default:
+			}
+		}
+	}
+}
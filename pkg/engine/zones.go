@@ -0,0 +1,113 @@
+package engine
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Event types emitted by the engine
+const (
+	EventZoneEnter        = "zone_enter"
+	EventZoneExit         = "zone_exit"
+	EventCaptureRestarted = "capture_restarted"
+	EventFastApproach     = "fast_approach"
+	EventCrowded          = "crowded"
+	EventCrowdCleared     = "crowd_cleared"
+	EventDegraded         = "degraded"
+)
+
+// PriorityHigh marks events consumers should surface immediately
+const PriorityHigh = "high"
+
+// ProximityEvent describes a change in the proximity situation
+type ProximityEvent struct {
+	Type           string         `json:"type"`
+	Timestamp      int64          `json:"timestamp"`
+	Priority       string         `json:"priority,omitempty"`
+	Category       string         `json:"category,omitempty"`
+	Previous       string         `json:"previous,omitempty"`
+	Distance       float32        `json:"distance,omitempty"`
+	ApproachRate   float32        `json:"approach_rate,omitempty"` // Closing speed in m/s for fast_approach
+	Detection      *Detection     `json:"detection,omitempty"`
+	GapMS          int64          `json:"gap_ms,omitempty"`          // Capture outage length for capture_restarted
+	Count          int            `json:"count,omitempty"`           // Detections in view for crowded and crowd_cleared
+	Changed        float32        `json:"changed,omitempty"`         // Fraction of the frame that changed for scene_change
+	FriendAdjacent bool           `json:"friend_adjacent,omitempty"` // Friends are in the same instance
+	Haptic         *HapticPattern `json:"haptic,omitempty"`          // Custom pattern from a player rule or rule action
+	Rule           string         `json:"rule,omitempty"`            // Name of the rule for rule events
+	Actions        []RuleAction   `json:"actions,omitempty"`         // Actions of the rule for rule events
+	Muted          bool           `json:"muted,omitempty"`           // Withheld from notifications and haptics
+	Clip           string         `json:"clip,omitempty"`            // URL of the GIF saved around the event, written a few seconds later
+	Level          int            `json:"level,omitempty"`           // Degradation steps applied for degraded; 0 once recovered
+	Reason         string         `json:"reason,omitempty"`          // Exceeded budget for degraded: memory, cpu, or recovered
+	Direction      string         `json:"direction,omitempty"`       // Where the detection really is for behind_you: rear
+}
+
+// zoneTracker turns detection batches into zone_enter/zone_exit events.
+// The current zone is the category of the nearest detection.
+type zoneTracker struct {
+	current  string
+	lastSeen time.Time
+	timeout  atomic.Int64 // nanoseconds; set from other goroutines on config reload
+}
+
+// newZoneTracker creates a tracker that exits the zone after timeout without detections
+func newZoneTracker(timeout time.Duration) *zoneTracker {
+	z := &zoneTracker{}
+	z.timeout.Store(int64(timeout))
+	return z
+}
+
+// SetZoneExitTimeout sets how long the zone is held without detections
+// before zone_exit is emitted; safe to call while running
+func (pe *ProximityEngine) SetZoneExitTimeout(timeout time.Duration) {
+	pe.zones.timeout.Store(int64(timeout))
+}
+
+// update processes a detection batch and returns the resulting events
+func (z *zoneTracker) update(detections []Detection, now time.Time) []ProximityEvent {
+	nearest, ok := NearestDetection(detections)
+	if !ok {
+		return nil
+	}
+	z.lastSeen = now
+
+	if nearest.Category == z.current {
+		return nil
+	}
+
+	var events []ProximityEvent
+	if z.current != "" {
+		events = append(events, ProximityEvent{
+			Type:      EventZoneExit,
+			Timestamp: now.Unix(),
+			Category:  z.current,
+		})
+	}
+	events = append(events, ProximityEvent{
+		Type:      EventZoneEnter,
+		Timestamp: now.Unix(),
+		Priority:  categoryPriority(nearest.Category),
+		Category:  nearest.Category,
+		Previous:  z.current,
+		Distance:  nearest.Distance,
+		Detection: &nearest,
+	})
+	z.current = nearest.Category
+	return events
+}
+
+// expire emits zone_exit once no detections have arrived for the timeout
+func (z *zoneTracker) expire(now time.Time) []ProximityEvent {
+	if z.current == "" || now.Sub(z.lastSeen) < time.Duration(z.timeout.Load()) {
+		return nil
+	}
+
+	event := ProximityEvent{
+		Type:      EventZoneExit,
+		Timestamp: now.Unix(),
+		Category:  z.current,
+	}
+	z.current = ""
+	return []ProximityEvent{event}
+}
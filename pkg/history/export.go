@@ -0,0 +1,297 @@
+package history
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"vrchat-proximity/pkg/engine"
+)
+
+// Export formats served by /export
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+// exportColumns is the CSV header of /export
+var exportColumns = []string{"session_id", "timestamp_ms", "time", "x", "y", "width", "height", "confidence", "type", "area", "distance", "category"}
+
+// ExportedDetection is one line of /export?format=jsonl and the export file
+type ExportedDetection struct {
+	SessionID   int64 `json:"session_id,omitempty"`
+	TimestampMS int64 `json:"timestamp_ms"`
+	engine.Detection
+}
+
+// handleExport streams every stored detection in a time range as CSV (the
+// default) or JSONL, for analysis in external tools
+func (h *HistoryStore) handleExport(w http.ResponseWriter, r *http.Request) {
+	q, err := parseHistoryQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.URL.Query().Get("limit") == "" {
+		q.limit = -1 // SQLite reads a negative LIMIT as none
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = FormatCSV
+	}
+	if format != FormatCSV && format != FormatJSONL {
+		http.Error(w, fmt.Sprintf("format must be %s or %s", FormatCSV, FormatJSONL), http.StatusBadRequest)
+		return
+	}
+
+	rows, err := h.db.Query(`SELECT session_id, ts, x, y, width, height, confidence, type, area, distance, category
+		FROM detections WHERE ts BETWEEN ? AND ? AND (? = '' OR category = ?)
+		ORDER BY ts LIMIT ?`, q.from, q.to, q.category, q.category, q.limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	name := "detections." + format
+	if q.from > 0 {
+		name = "detections-" + time.UnixMilli(q.from).UTC().Format("20060102T150405") + "." + format
+	}
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	var write func(ExportedDetection) error
+	var flush func() error
+	if format == FormatCSV {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		out := csv.NewWriter(w)
+		out.Write(exportColumns)
+		write = func(d ExportedDetection) error {
+			return out.Write([]string{
+				strconv.FormatInt(d.SessionID, 10),
+				strconv.FormatInt(d.TimestampMS, 10),
+				time.UnixMilli(d.TimestampMS).UTC().Format(time.RFC3339Nano),
+				strconv.Itoa(int(d.BBox.X)), strconv.Itoa(int(d.BBox.Y)),
+				strconv.Itoa(int(d.BBox.Width)), strconv.Itoa(int(d.BBox.Height)),
+				strconv.FormatFloat(float64(d.Confidence), 'f', -1, 32),
+				d.Type,
+				strconv.FormatFloat(float64(d.Area), 'f', -1, 32),
+				strconv.FormatFloat(float64(d.Distance), 'f', -1, 32),
+				d.Category,
+			})
+		}
+		flush = func() error { out.Flush(); return out.Error() }
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		out := bufio.NewWriter(w)
+		encoder := json.NewEncoder(out)
+		write = func(d ExportedDetection) error { return encoder.Encode(d) }
+		flush = out.Flush
+	}
+
+	// Headers are sent by now, so later failures can only cut the body short
+	for rows.Next() {
+		var d ExportedDetection
+		if err := rows.Scan(&d.SessionID, &d.TimestampMS, &d.BBox.X, &d.BBox.Y, &d.BBox.Width, &d.BBox.Height,
+			&d.Confidence, &d.Type, &d.Area, &d.Distance, &d.Category); err != nil {
+			historyLog.Warn("Export failed", "error", err)
+			break
+		}
+		if err := write(d); err != nil {
+			historyLog.Debug("Export client went away", "error", err)
+			return
+		}
+	}
+	if err := rows.Err(); err != nil {
+		historyLog.Warn("Export failed", "error", err)
+	}
+	flush()
+}
+
+// ExportConfig configures the continuous JSONL export file
+type ExportConfig struct {
+	Enabled   bool   `yaml:"enabled"`
+	Path      string `yaml:"path"`
+	MaxSizeMB int    `yaml:"max_size_mb"` // Rotate once the file reaches this size
+	MaxFiles  int    `yaml:"max_files"`   // Rotated files kept as path.1 (newest) to path.N
+}
+
+// DefaultExportConfig rotates at 100 MB and keeps five old files
+func DefaultExportConfig() ExportConfig {
+	return ExportConfig{
+		Path:      "proximity_detections.jsonl",
+		MaxSizeMB: 100,
+		MaxFiles:  5,
+	}
+}
+
+// Validate checks the export settings
+func (c ExportConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Path == "" {
+		return errors.New("path is required")
+	}
+	if c.MaxSizeMB < 1 {
+		return errors.New("max_size_mb must be at least 1")
+	}
+	if c.MaxFiles < 0 {
+		return errors.New("max_files must not be negative")
+	}
+	return nil
+}
+
+// exportFlushInterval bounds how long lines wait in the write buffer
+const exportFlushInterval = time.Second
+
+// Exporter appends every detection to a JSONL file, rotating it by size
+type Exporter struct {
+	config ExportConfig
+	file   *os.File
+	out    *bufio.Writer
+	size   int64
+
+	lines   chan ExportedDetection
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// OpenExporter opens the export file for appending
+func OpenExporter(config ExportConfig) (*Exporter, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("export: %w", err)
+	}
+	e := &Exporter{
+		config:  config,
+		lines:   make(chan ExportedDetection, 4096),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	if err := e.open(); err != nil {
+		return nil, err
+	}
+	go e.writeLoop()
+
+	historyLog.Info("Exporting detections", "path", config.Path, "max_size_mb", config.MaxSizeMB)
+	return e, nil
+}
+
+// Attach hooks the exporter into an engine's detection pipeline; player
+// names are left out in privacy mode
+func (e *Exporter) Attach(pe *engine.ProximityEngine) {
+	pe.OnDetections(func(detections []engine.Detection) {
+		e.RecordDetections(pe.StoredDetections(detections))
+	})
+}
+
+// RecordDetections queues a detection batch without blocking the pipeline
+func (e *Exporter) RecordDetections(detections []engine.Detection) {
+	ts := time.Now().UnixMilli()
+	for _, d := range detections {
+		select {
+		case e.lines <- ExportedDetection{TimestampMS: ts, Detection: d}:
+		default:
+			historyLog.Warn("Export queue full, dropping detection")
+			return
+		}
+	}
+}
+
+// Close writes what is queued and closes the file
+func (e *Exporter) Close() error {
+	close(e.done)
+	<-e.stopped
+	e.out.Flush()
+	return e.file.Close()
+}
+
+// open opens the export file and records its current size
+func (e *Exporter) open() error {
+	file, err := os.OpenFile(e.config.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open export file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("open export file: %w", err)
+	}
+	e.file, e.out, e.size = file, bufio.NewWriter(file), info.Size()
+	return nil
+}
+
+// writeLoop writes queued lines and flushes them once a second
+func (e *Exporter) writeLoop() {
+	defer close(e.stopped)
+	flush := time.NewTicker(exportFlushInterval)
+	defer flush.Stop()
+
+	for {
+		select {
+		case line := <-e.lines:
+			e.write(line)
+		case <-flush.C:
+			if err := e.out.Flush(); err != nil {
+				historyLog.Error("Export write failed", "error", err)
+			}
+		case <-e.done:
+			for {
+				select {
+				case line := <-e.lines:
+					e.write(line)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// write appends one line, rotating first if the file is full
+func (e *Exporter) write(line ExportedDetection) {
+	data, err := json.Marshal(line)
+	if err != nil {
+		historyLog.Error("Export encode failed", "error", err)
+		return
+	}
+	if e.size > 0 && e.size+int64(len(data))+1 > int64(e.config.MaxSizeMB)<<20 {
+		if err := e.rotate(); err != nil {
+			historyLog.Error("Export rotation failed", "error", err)
+		}
+	}
+	e.out.Write(data)
+	e.out.WriteByte('\n')
+	e.size += int64(len(data)) + 1
+}
+
+// rotate shifts path.N-1 to path.N, the current file to path.1, and starts
+// an empty file; with max_files 0 the full file is simply discarded
+func (e *Exporter) rotate() error {
+	e.out.Flush()
+	e.file.Close()
+
+	path := e.config.Path
+	os.Remove(path + "." + strconv.Itoa(e.config.MaxFiles))
+	for n := e.config.MaxFiles - 1; n >= 1; n-- {
+		os.Rename(path+"."+strconv.Itoa(n), path+"."+strconv.Itoa(n+1))
+	}
+	var err error
+	if e.config.MaxFiles > 0 {
+		err = os.Rename(path, path+".1")
+	} else {
+		err = os.Remove(path)
+	}
+	// Reopen even after a failed rename so detections keep being written
+	if openErr := e.open(); openErr != nil {
+		return openErr
+	}
+	if err == nil {
+		historyLog.Info("Export file rotated", "path", path)
+	}
+	return err
+}
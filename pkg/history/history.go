@@ -0,0 +1,460 @@
+// Package history stores detections and zone events in SQLite and serves queries over HTTP.
+package history
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"vrchat-proximity/pkg/engine"
+	"vrchat-proximity/pkg/logging"
+)
+
+// historyLog is the "history" subsystem logger
+var historyLog = logging.For("history")
+
+// HistoryConfig configures the SQLite detection history
+type HistoryConfig struct {
+	Enabled       bool          `yaml:"enabled"`
+	Path          string        `yaml:"path"`
+	Retention     time.Duration `yaml:"retention"` // Rows older than this are deleted; 0 keeps everything
+	FlushInterval time.Duration `yaml:"flush_interval"`
+}
+
+// DefaultHistoryConfig keeps a week of history
+func DefaultHistoryConfig() HistoryConfig {
+	return HistoryConfig{
+		Path:          "proximity_history.db",
+		Retention:     7 * 24 * time.Hour,
+		FlushInterval: time.Second,
+	}
+}
+
+const historySchema = `
+CREATE TABLE IF NOT EXISTS sessions (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	started_at INTEGER NOT NULL,
+	ended_at   INTEGER
+);
+CREATE TABLE IF NOT EXISTS detections (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	session_id INTEGER NOT NULL,
+	ts         INTEGER NOT NULL,
+	x          INTEGER, y INTEGER, width INTEGER, height INTEGER,
+	confidence REAL,
+	type       TEXT,
+	area       REAL,
+	distance   REAL,
+	category   TEXT
+);
+CREATE INDEX IF NOT EXISTS detections_ts ON detections (ts);
+CREATE INDEX IF NOT EXISTS detections_session ON detections (session_id);
+CREATE TABLE IF NOT EXISTS events (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	session_id INTEGER NOT NULL,
+	ts         INTEGER NOT NULL,
+	type       TEXT NOT NULL,
+	category   TEXT,
+	previous   TEXT,
+	distance   REAL
+);
+CREATE INDEX IF NOT EXISTS events_ts ON events (ts);
+`
+
+// historyRow is a pending insert; exactly one of the fields is set
+type historyRow struct {
+	ts        int64 // Unix milliseconds
+	detection *engine.Detection
+	event     *engine.ProximityEvent
+}
+
+// HistoryStore persists detections and zone events in SQLite
+type HistoryStore struct {
+	config    HistoryConfig
+	db        *sql.DB
+	sessionID int64
+
+	rows    chan historyRow
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// OpenHistoryStore opens the database and starts a new session
+func OpenHistoryStore(config HistoryConfig) (*HistoryStore, error) {
+	db, err := sql.Open("sqlite3", config.Path+"?_journal_mode=WAL&_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("open history: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(historySchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create history schema: %w", err)
+	}
+
+	result, err := db.Exec("INSERT INTO sessions (started_at) VALUES (?)", time.Now().UnixMilli())
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("start history session: %w", err)
+	}
+	sessionID, _ := result.LastInsertId()
+
+	h := &HistoryStore{
+		config:    config,
+		db:        db,
+		sessionID: sessionID,
+		rows:      make(chan historyRow, 4096),
+		done:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+	go h.writeLoop()
+
+	historyLog.Info("History session recording", "session", sessionID, "path", config.Path)
+	return h, nil
+}
+
+// Attach hooks the store into an engine's detection and event pipeline
+func (h *HistoryStore) Attach(pe *engine.ProximityEngine) {
+	pe.OnDetections(h.RecordDetections)
+	pe.OnEvent(h.RecordEvent)
+}
+
+// RecordDetections queues a detection batch for insertion
+func (h *HistoryStore) RecordDetections(detections []engine.Detection) {
+	ts := time.Now().UnixMilli()
+	for i := range detections {
+		h.queue(historyRow{ts: ts, detection: &detections[i]})
+	}
+}
+
+// RecordEvent queues an event for insertion
+func (h *HistoryStore) RecordEvent(event engine.ProximityEvent) {
+	h.queue(historyRow{ts: time.Now().UnixMilli(), event: &event})
+}
+
+// queue adds a row without blocking the detection pipeline
+func (h *HistoryStore) queue(row historyRow) {
+	select {
+	case h.rows <- row:
+	default:
+		historyLog.Warn("History queue full, dropping row")
+	}
+}
+
+// writeLoop batches queued rows into transactions and applies retention
+func (h *HistoryStore) writeLoop() {
+	defer close(h.stopped)
+	flush := time.NewTicker(h.config.FlushInterval)
+	defer flush.Stop()
+	retention := time.NewTicker(time.Hour)
+	defer retention.Stop()
+
+	h.applyRetention()
+
+	var batch []historyRow
+	for {
+		select {
+		case row := <-h.rows:
+			batch = append(batch, row)
+		case <-flush.C:
+			h.insert(batch)
+			batch = batch[:0]
+		case <-retention.C:
+			h.applyRetention()
+		case <-h.done:
+			// Drain what is already queued before closing
+			for {
+				select {
+				case row := <-h.rows:
+					batch = append(batch, row)
+				default:
+					h.insert(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+// insert writes a batch in a single transaction
+func (h *HistoryStore) insert(batch []historyRow) {
+	if len(batch) == 0 {
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		historyLog.Error("History insert failed", "error", err)
+		return
+	}
+	detStmt, err := tx.Prepare(`INSERT INTO detections
+		(session_id, ts, x, y, width, height, confidence, type, area, distance, category)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		tx.Rollback()
+		historyLog.Error("History insert failed", "error", err)
+		return
+	}
+	defer detStmt.Close()
+	eventStmt, err := tx.Prepare(`INSERT INTO events
+		(session_id, ts, type, category, previous, distance) VALUES (?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		tx.Rollback()
+		historyLog.Error("History insert failed", "error", err)
+		return
+	}
+	defer eventStmt.Close()
+
+	for _, row := range batch {
+		if d := row.detection; d != nil {
+			_, err = detStmt.Exec(h.sessionID, row.ts, d.BBox.X, d.BBox.Y, d.BBox.Width, d.BBox.Height,
+				d.Confidence, d.Type, d.Area, d.Distance, d.Category)
+		} else if e := row.event; e != nil {
+			_, err = eventStmt.Exec(h.sessionID, row.ts, e.Type, e.Category, e.Previous, e.Distance)
+		}
+		if err != nil {
+			tx.Rollback()
+			historyLog.Error("History insert failed", "error", err)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		historyLog.Error("History commit failed", "error", err)
+	}
+}
+
+// applyRetention deletes rows older than the retention window
+func (h *HistoryStore) applyRetention() {
+	if h.config.Retention <= 0 {
+		return
+	}
+	cutoff := time.Now().Add(-h.config.Retention).UnixMilli()
+	for _, table := range []string{"detections", "events"} {
+		if _, err := h.db.Exec("DELETE FROM "+table+" WHERE ts < ?", cutoff); err != nil {
+			historyLog.Error("History retention failed", "error", err)
+		}
+	}
+	h.db.Exec("DELETE FROM sessions WHERE ended_at IS NOT NULL AND ended_at < ?", cutoff)
+}
+
+// Close ends the session, flushes pending rows, and closes the database
+func (h *HistoryStore) Close() error {
+	close(h.done)
+	<-h.stopped
+
+	h.db.Exec("UPDATE sessions SET ended_at = ? WHERE id = ?", time.Now().UnixMilli(), h.sessionID)
+	return h.db.Close()
+}
+
+// RegisterHandlers mounts the history query and export endpoints
+func (h *HistoryStore) RegisterHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/history", h.handleHistory)
+	mux.HandleFunc("/history/per-minute", h.handlePerMinute)
+	mux.HandleFunc("/history/closest", h.handleClosest)
+	mux.HandleFunc("/export", h.handleExport)
+}
+
+// historyQuery holds the common from/to/category filter
+type historyQuery struct {
+	from, to int64 // Unix milliseconds
+	category string
+	limit    int
+}
+
+// parseHistoryQuery reads from, to (unix seconds or RFC 3339), category, and limit
+func parseHistoryQuery(r *http.Request) (historyQuery, error) {
+	q := historyQuery{to: time.Now().UnixMilli(), limit: 1000}
+	values := r.URL.Query()
+
+	var err error
+	if v := values.Get("from"); v != "" {
+		if q.from, err = parseHistoryTime(v); err != nil {
+			return q, fmt.Errorf("invalid from: %w", err)
+		}
+	}
+	if v := values.Get("to"); v != "" {
+		if q.to, err = parseHistoryTime(v); err != nil {
+			return q, fmt.Errorf("invalid to: %w", err)
+		}
+	}
+	if v := values.Get("limit"); v != "" {
+		if q.limit, err = strconv.Atoi(v); err != nil || q.limit <= 0 {
+			return q, fmt.Errorf("invalid limit")
+		}
+	}
+	q.category = values.Get("category")
+	return q, nil
+}
+
+// parseHistoryTime converts unix seconds or RFC 3339 to unix milliseconds
+func parseHistoryTime(v string) (int64, error) {
+	if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return secs * 1000, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return 0, err
+	}
+	return t.UnixMilli(), nil
+}
+
+// handleHistory returns stored detections and events in a time range
+func (h *HistoryStore) handleHistory(w http.ResponseWriter, r *http.Request) {
+	q, err := parseHistoryQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rows, err := h.db.Query(`SELECT session_id, ts, x, y, width, height, confidence, type, area, distance, category
+		FROM detections WHERE ts BETWEEN ? AND ? AND (? = '' OR category = ?)
+		ORDER BY ts LIMIT ?`, q.from, q.to, q.category, q.category, q.limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	type storedDetection struct {
+		engine.Detection
+		SessionID   int64 `json:"session_id"`
+		TimestampMS int64 `json:"timestamp_ms"`
+	}
+	detections := []storedDetection{}
+	for rows.Next() {
+		var d storedDetection
+		if err := rows.Scan(&d.SessionID, &d.TimestampMS, &d.BBox.X, &d.BBox.Y, &d.BBox.Width, &d.BBox.Height,
+			&d.Confidence, &d.Type, &d.Area, &d.Distance, &d.Category); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		detections = append(detections, d)
+	}
+
+	eventRows, err := h.db.Query(`SELECT session_id, ts, type, category, previous, distance
+		FROM events WHERE ts BETWEEN ? AND ? AND (? = '' OR category = ?)
+		ORDER BY ts LIMIT ?`, q.from, q.to, q.category, q.category, q.limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer eventRows.Close()
+
+	type storedEvent struct {
+		engine.ProximityEvent
+		SessionID   int64 `json:"session_id"`
+		TimestampMS int64 `json:"timestamp_ms"`
+	}
+	events := []storedEvent{}
+	for eventRows.Next() {
+		var e storedEvent
+		var category, previous sql.NullString
+		var distance sql.NullFloat64
+		if err := eventRows.Scan(&e.SessionID, &e.TimestampMS, &e.Type, &category, &previous, &distance); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		e.Timestamp = e.TimestampMS / 1000
+		e.Category, e.Previous, e.Distance = category.String, previous.String, float32(distance.Float64)
+		events = append(events, e)
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"from_ms":    q.from,
+		"to_ms":      q.to,
+		"detections": detections,
+		"events":     events,
+	})
+}
+
+// handlePerMinute returns detection counts bucketed by minute
+func (h *HistoryStore) handlePerMinute(w http.ResponseWriter, r *http.Request) {
+	q, err := parseHistoryQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rows, err := h.db.Query(`SELECT (ts / 60000) * 60 AS minute, COUNT(*), MIN(distance)
+		FROM detections WHERE ts BETWEEN ? AND ? AND (? = '' OR category = ?)
+		GROUP BY minute ORDER BY minute`, q.from, q.to, q.category, q.category)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	type bucket struct {
+		Minute      int64   `json:"minute"` // Unix seconds at the start of the minute
+		Count       int64   `json:"count"`
+		MinDistance float64 `json:"min_distance"`
+	}
+	buckets := []bucket{}
+	for rows.Next() {
+		var b bucket
+		if err := rows.Scan(&b.Minute, &b.Count, &b.MinDistance); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		buckets = append(buckets, b)
+	}
+
+	writeJSON(w, buckets)
+}
+
+// handleClosest returns the closest approach recorded in each session
+func (h *HistoryStore) handleClosest(w http.ResponseWriter, r *http.Request) {
+	q, err := parseHistoryQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rows, err := h.db.Query(`SELECT s.id, s.started_at, s.ended_at, d.distance, d.category, d.ts
+		FROM sessions s
+		JOIN detections d ON d.id = (
+			SELECT id FROM detections WHERE session_id = s.id AND ts BETWEEN ? AND ?
+			ORDER BY distance, ts LIMIT 1)
+		ORDER BY s.started_at DESC LIMIT ?`, q.from, q.to, q.limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	type approach struct {
+		SessionID   int64   `json:"session_id"`
+		StartedAtMS int64   `json:"started_at_ms"`
+		EndedAtMS   *int64  `json:"ended_at_ms"`
+		Distance    float64 `json:"distance"`
+		Category    string  `json:"category"`
+		TimestampMS int64   `json:"timestamp_ms"`
+	}
+	approaches := []approach{}
+	for rows.Next() {
+		var a approach
+		var ended sql.NullInt64
+		if err := rows.Scan(&a.SessionID, &a.StartedAtMS, &ended, &a.Distance, &a.Category, &a.TimestampMS); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if ended.Valid {
+			a.EndedAtMS = &ended.Int64
+		}
+		approaches = append(approaches, a)
+	}
+
+	writeJSON(w, approaches)
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
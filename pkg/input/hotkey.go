@@ -0,0 +1,106 @@
+// Package input handles global keyboard hotkeys that control the engine
+// while another application, such as VRChat, has focus.
+package input
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Modifier keys, using the Windows RegisterHotKey values
+const (
+	ModAlt   = 0x1
+	ModCtrl  = 0x2
+	ModShift = 0x4
+	ModWin   = 0x8
+)
+
+// Hotkey is a key combined with modifiers
+type Hotkey struct {
+	Modifiers uint32
+	Key       uint32 // Windows virtual-key code
+	name      string
+}
+
+// String returns the hotkey as it was written, e.g. "Ctrl+Alt+P"
+func (h Hotkey) String() string {
+	return h.name
+}
+
+// modifierNames maps the accepted modifier spellings
+var modifierNames = map[string]uint32{
+	"ctrl":    ModCtrl,
+	"control": ModCtrl,
+	"alt":     ModAlt,
+	"shift":   ModShift,
+	"win":     ModWin,
+	"super":   ModWin,
+}
+
+// keyNames maps named keys to virtual-key codes; letters, digits, and
+// F1-F24 are handled separately
+var keyNames = map[string]uint32{
+	"up":       0x26,
+	"down":     0x28,
+	"left":     0x25,
+	"right":    0x27,
+	"space":    0x20,
+	"enter":    0x0d,
+	"escape":   0x1b,
+	"tab":      0x09,
+	"home":     0x24,
+	"end":      0x23,
+	"pageup":   0x21,
+	"pagedown": 0x22,
+	"insert":   0x2d,
+	"delete":   0x2e,
+	"pause":    0x13,
+	"plus":     0xbb,
+	"minus":    0xbd,
+}
+
+// ParseHotkey parses a combination such as "Ctrl+Alt+P" or "Ctrl+Shift+F9".
+// At least one modifier is required so ordinary typing isn't swallowed.
+func ParseHotkey(value string) (Hotkey, error) {
+	hotkey := Hotkey{name: value}
+	parts := strings.Split(value, "+")
+	for i, part := range parts {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if i < len(parts)-1 {
+			mod, ok := modifierNames[part]
+			if !ok {
+				return Hotkey{}, fmt.Errorf("hotkey %q: unknown modifier %q", value, part)
+			}
+			hotkey.Modifiers |= mod
+			continue
+		}
+
+		key, ok := keyCode(part)
+		if !ok {
+			return Hotkey{}, fmt.Errorf("hotkey %q: unknown key %q", value, part)
+		}
+		hotkey.Key = key
+	}
+	if hotkey.Modifiers == 0 {
+		return Hotkey{}, fmt.Errorf("hotkey %q needs at least one modifier", value)
+	}
+	return hotkey, nil
+}
+
+// keyCode returns the virtual-key code of a lower-case key name
+func keyCode(name string) (uint32, bool) {
+	if len(name) == 1 {
+		switch c := name[0]; {
+		case c >= 'a' && c <= 'z':
+			return uint32(c-'a') + 'A', true
+		case c >= '0' && c <= '9':
+			return uint32(c), true
+		}
+	}
+	var n int
+	if _, err := fmt.Sscanf(name, "f%d", &n); err == nil && n >= 1 && n <= 24 && name == fmt.Sprintf("f%d", n) {
+		return 0x70 + uint32(n-1), true
+	}
+	code, ok := keyNames[name]
+	return code, ok
+}
@@ -0,0 +1,131 @@
+package input
+
+import (
+	"fmt"
+
+	"vrchat-proximity/pkg/engine"
+	"vrchat-proximity/pkg/logging"
+)
+
+// inputLog is the "input" subsystem logger
+var inputLog = logging.For("input")
+
+// HotkeyConfig binds global hotkeys to engine controls; an empty binding is skipped
+type HotkeyConfig struct {
+	Enabled         bool   `yaml:"enabled"`
+	Pause           string `yaml:"pause"` // Toggles capture
+	SensitivityUp   string `yaml:"sensitivity_up"`
+	SensitivityDown string `yaml:"sensitivity_down"`
+	SensitivityStep int    `yaml:"sensitivity_step"`
+	Overlay         string `yaml:"overlay"` // Shows or hides the headset radar
+}
+
+// DefaultHotkeyConfig uses Ctrl+Alt+P, Ctrl+Alt+Up/Down, and Ctrl+Alt+R
+func DefaultHotkeyConfig() HotkeyConfig {
+	return HotkeyConfig{
+		Pause:           "Ctrl+Alt+P",
+		SensitivityUp:   "Ctrl+Alt+Up",
+		SensitivityDown: "Ctrl+Alt+Down",
+		SensitivityStep: 10,
+		Overlay:         "Ctrl+Alt+R",
+	}
+}
+
+// Hotkeys runs engine actions when their global hotkeys are pressed
+type Hotkeys struct {
+	engine  *engine.ProximityEngine
+	config  HotkeyConfig
+	keys    []Hotkey
+	actions []func()
+	stop    func()
+}
+
+// NewHotkeys parses the configured bindings
+func NewHotkeys(pe *engine.ProximityEngine, config HotkeyConfig) (*Hotkeys, error) {
+	h := &Hotkeys{engine: pe, config: config}
+
+	bindings := []struct {
+		value  string
+		action func()
+	}{
+		{config.Pause, h.togglePause},
+		{config.SensitivityUp, func() { h.adjustSensitivity(config.SensitivityStep) }},
+		{config.SensitivityDown, func() { h.adjustSensitivity(-config.SensitivityStep) }},
+	}
+	for _, binding := range bindings {
+		if binding.value == "" {
+			continue
+		}
+		key, err := ParseHotkey(binding.value)
+		if err != nil {
+			return nil, err
+		}
+		h.keys = append(h.keys, key)
+		h.actions = append(h.actions, binding.action)
+	}
+	if config.Overlay != "" {
+		if _, err := ParseHotkey(config.Overlay); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// Start registers the hotkeys with the operating system
+func (h *Hotkeys) Start() error {
+	if len(h.keys) == 0 {
+		return nil
+	}
+	stop, err := listen(h.keys, func(i int) {
+		if i >= 0 && i < len(h.actions) {
+			h.actions[i]()
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("register hotkeys: %w", err)
+	}
+	h.stop = stop
+
+	names := make([]string, len(h.keys))
+	for i, key := range h.keys {
+		names[i] = key.String()
+	}
+	inputLog.Info("Hotkeys registered", "hotkeys", names)
+	return nil
+}
+
+// Stop unregisters the hotkeys
+func (h *Hotkeys) Stop() {
+	if h.stop != nil {
+		h.stop()
+		h.stop = nil
+	}
+}
+
+// SetOverlayToggle binds the overlay hotkey to fn; call before Start. The
+// key stays free when there's no overlay to toggle.
+func (h *Hotkeys) SetOverlayToggle(fn func()) {
+	if key, err := ParseHotkey(h.config.Overlay); err == nil {
+		h.keys = append(h.keys, key)
+		h.actions = append(h.actions, fn)
+	}
+}
+
+// togglePause pauses a running engine or resumes a paused one
+func (h *Hotkeys) togglePause() {
+	var err error
+	if h.engine.State() == engine.StatePaused {
+		err = h.engine.Resume()
+	} else {
+		err = h.engine.Pause()
+	}
+	if err != nil {
+		inputLog.Warn("Pause hotkey failed", "error", err)
+	}
+}
+
+// adjustSensitivity moves sensitivity by delta within 1-100
+func (h *Hotkeys) adjustSensitivity(delta int) {
+	sensitivity := min(max(h.engine.Sensitivity()+delta, 1), 100)
+	h.engine.SetSensitivity(sensitivity)
+}
@@ -0,0 +1,12 @@
+//go:build !windows
+
+package input
+
+import (
+	"errors"
+)
+
+// listen is only implemented on Windows
+func listen(keys []Hotkey, fire func(int)) (func(), error) {
+	return nil, errors.New("global hotkeys are only supported on Windows")
+}
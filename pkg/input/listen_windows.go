@@ -0,0 +1,84 @@
+//go:build windows
+
+package input
+
+import (
+	"fmt"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	user32   = syscall.NewLazyDLL("user32.dll")
+	kernel32 = syscall.NewLazyDLL("kernel32.dll")
+
+	procRegisterHotKey     = user32.NewProc("RegisterHotKey")
+	procUnregisterHotKey   = user32.NewProc("UnregisterHotKey")
+	procGetMessageW        = user32.NewProc("GetMessageW")
+	procPostThreadMessageW = user32.NewProc("PostThreadMessageW")
+	procGetCurrentThreadID = kernel32.NewProc("GetCurrentThreadId")
+)
+
+const (
+	wmQuit      = 0x0012
+	wmHotkey    = 0x0312
+	modNoRepeat = 0x4000
+)
+
+// message mirrors the Win32 MSG structure
+type message struct {
+	hwnd    uintptr
+	message uint32
+	wParam  uintptr
+	lParam  uintptr
+	time    uint32
+	pt      struct{ x, y int32 }
+	private uint32
+}
+
+// listen registers keys on a dedicated thread, whose message queue receives
+// WM_HOTKEY, and calls fire with the index of each pressed hotkey
+func listen(keys []Hotkey, fire func(int)) (func(), error) {
+	errc := make(chan error, 1)
+	var threadID uintptr
+
+	go func() {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		threadID, _, _ = procGetCurrentThreadID.Call()
+
+		registered := 0
+		defer func() {
+			for id := 1; id <= registered; id++ {
+				procUnregisterHotKey.Call(0, uintptr(id))
+			}
+		}()
+		for i, key := range keys {
+			if ok, _, err := procRegisterHotKey.Call(0, uintptr(i+1), uintptr(key.Modifiers|modNoRepeat), uintptr(key.Key)); ok == 0 {
+				errc <- fmt.Errorf("%s: %w", key, err)
+				return
+			}
+			registered++
+		}
+		errc <- nil
+
+		var msg message
+		for {
+			result, _, _ := procGetMessageW.Call(uintptr(unsafe.Pointer(&msg)), 0, 0, 0)
+			if int32(result) <= 0 {
+				return
+			}
+			if msg.message == wmHotkey {
+				fire(int(msg.wParam) - 1)
+			}
+		}
+	}()
+
+	if err := <-errc; err != nil {
+		return nil, err
+	}
+	return func() {
+		procPostThreadMessageW.Call(threadID, wmQuit, 0, 0)
+	}, nil
+}
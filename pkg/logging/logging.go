@@ -0,0 +1,165 @@
+// Package logging configures structured logging with per-subsystem loggers
+// and keeps recent entries in memory for the /logs endpoint.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+)
+
+// Config selects the log level, output format, and in-memory history size
+type Config struct {
+	Level     string `yaml:"level"`      // debug, info, warn, or error
+	Format    string `yaml:"format"`     // text or json
+	RingSize  int    `yaml:"ring_size"`  // Entries kept for GET /logs
+	CrashFile string `yaml:"crash_file"` // Stack traces of recovered panics are appended here; empty only logs them
+}
+
+// DefaultConfig logs info and above as text, keeps 500 entries, and
+// writes crashes to crash.log
+func DefaultConfig() Config {
+	return Config{Level: "info", Format: "text", RingSize: 500, CrashFile: "crash.log"}
+}
+
+// root is the handler shared by every subsystem logger
+var root atomic.Pointer[slog.Handler]
+
+// level is the minimum level of the root handler, adjustable at runtime
+var level slog.LevelVar
+
+// Setup installs the root handler writing to w and returns the ring buffer
+// of recent entries. Loggers from For pick up the new handler immediately.
+func Setup(config Config, w io.Writer) (*Ring, error) {
+	if err := SetLevel(config.Level); err != nil {
+		return nil, err
+	}
+
+	options := &slog.HandlerOptions{Level: &level}
+	var output slog.Handler
+	switch strings.ToLower(config.Format) {
+	case "", "text":
+		output = slog.NewTextHandler(w, options)
+	case "json":
+		output = slog.NewJSONHandler(w, options)
+	default:
+		return nil, fmt.Errorf("invalid log format %q", config.Format)
+	}
+
+	ring := NewRing(config.RingSize)
+	var handler slog.Handler = &teeHandler{next: output, ring: ring}
+	root.Store(&handler)
+	slog.SetDefault(slog.New(handler))
+	return ring, nil
+}
+
+// SetLevel changes the minimum level of every logger
+func SetLevel(name string) error {
+	var parsed slog.Level
+	if err := parsed.UnmarshalText([]byte(name)); err != nil {
+		return fmt.Errorf("invalid log level %q", name)
+	}
+	level.Set(parsed)
+	return nil
+}
+
+// For returns the logger of a subsystem such as "capture" or "osc"
+func For(subsystem string) *slog.Logger {
+	return slog.New(&lazyHandler{}).With("subsystem", subsystem)
+}
+
+// current returns the installed root handler, or slog's default before Setup
+func current() slog.Handler {
+	if h := root.Load(); h != nil {
+		return *h
+	}
+	return slog.Default().Handler()
+}
+
+// lazyHandler resolves the root handler at log time so package-level
+// loggers created before Setup still follow its configuration
+type lazyHandler struct {
+	ops []func(slog.Handler) slog.Handler
+}
+
+func (h *lazyHandler) resolve() slog.Handler {
+	handler := current()
+	for _, op := range h.ops {
+		handler = op(handler)
+	}
+	return handler
+}
+
+func (h *lazyHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return current().Enabled(ctx, level)
+}
+
+func (h *lazyHandler) Handle(ctx context.Context, record slog.Record) error {
+	return h.resolve().Handle(ctx, record)
+}
+
+func (h *lazyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(next slog.Handler) slog.Handler { return next.WithAttrs(attrs) })
+}
+
+func (h *lazyHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(next slog.Handler) slog.Handler { return next.WithGroup(name) })
+}
+
+func (h *lazyHandler) with(op func(slog.Handler) slog.Handler) *lazyHandler {
+	ops := make([]func(slog.Handler) slog.Handler, len(h.ops), len(h.ops)+1)
+	copy(ops, h.ops)
+	return &lazyHandler{ops: append(ops, op)}
+}
+
+// teeHandler writes records to the output handler and the ring buffer
+type teeHandler struct {
+	next   slog.Handler
+	ring   *Ring
+	attrs  []slog.Attr
+	prefix string // Group path for attributes added later
+}
+
+func (h *teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *teeHandler) Handle(ctx context.Context, record slog.Record) error {
+	entry := Entry{
+		Time:    record.Time,
+		Level:   record.Level.String(),
+		Message: record.Message,
+		Attrs:   make(map[string]interface{}),
+	}
+	for _, attr := range h.attrs {
+		entry.add(attr, "")
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		entry.add(attr, h.prefix)
+		return true
+	})
+	h.ring.add(entry)
+
+	return h.next.Handle(ctx, record)
+}
+
+func (h *teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.next = h.next.WithAttrs(attrs)
+	clone.attrs = append([]slog.Attr(nil), h.attrs...)
+	for _, attr := range attrs {
+		attr.Key = h.prefix + attr.Key
+		clone.attrs = append(clone.attrs, attr)
+	}
+	return &clone
+}
+
+func (h *teeHandler) WithGroup(name string) slog.Handler {
+	clone := *h
+	clone.next = h.next.WithGroup(name)
+	clone.prefix = h.prefix + name + "."
+	return &clone
+}
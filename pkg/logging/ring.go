@@ -0,0 +1,128 @@
+package logging
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is one log record kept in the ring buffer
+type Entry struct {
+	Time      time.Time              `json:"time"`
+	Level     string                 `json:"level"`
+	Subsystem string                 `json:"subsystem,omitempty"`
+	Message   string                 `json:"message"`
+	Attrs     map[string]interface{} `json:"attrs,omitempty"`
+}
+
+// add flattens an attribute into the entry, lifting out the subsystem
+func (e *Entry) add(attr slog.Attr, prefix string) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+	if attr.Value.Kind() == slog.KindGroup {
+		for _, a := range attr.Value.Group() {
+			e.add(a, prefix+attr.Key+".")
+		}
+		return
+	}
+
+	key := prefix + attr.Key
+	if key == "subsystem" {
+		e.Subsystem = attr.Value.String()
+		return
+	}
+	switch value := attr.Value.Any().(type) {
+	case error:
+		e.Attrs[key] = value.Error()
+	case time.Duration:
+		e.Attrs[key] = value.String()
+	default:
+		e.Attrs[key] = value
+	}
+}
+
+// Ring keeps the most recent log entries
+type Ring struct {
+	mu      sync.Mutex
+	entries []Entry
+	next    int
+	full    bool
+}
+
+// NewRing creates a buffer holding size entries
+func NewRing(size int) *Ring {
+	if size <= 0 {
+		size = 1
+	}
+	return &Ring{entries: make([]Entry, size)}
+}
+
+// add stores an entry, overwriting the oldest when full
+func (r *Ring) add(entry Entry) {
+	r.mu.Lock()
+	r.entries[r.next] = entry
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+	r.mu.Unlock()
+}
+
+// Entries returns the buffered entries from oldest to newest
+func (r *Ring) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.full {
+		return append([]Entry(nil), r.entries[:r.next]...)
+	}
+	result := make([]Entry, 0, len(r.entries))
+	result = append(result, r.entries[r.next:]...)
+	return append(result, r.entries[:r.next]...)
+}
+
+// ServeHTTP returns recent entries as JSON, oldest first.
+// Filter with ?level=warn (minimum level), ?subsystem=osc, and ?limit=100.
+func (r *Ring) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+
+	minLevel := slog.LevelDebug
+	if level := query.Get("level"); level != "" {
+		if err := minLevel.UnmarshalText([]byte(level)); err != nil {
+			http.Error(w, "invalid level", http.StatusBadRequest)
+			return
+		}
+	}
+	limit := 0
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	subsystem := query.Get("subsystem")
+
+	entries := []Entry{}
+	for _, entry := range r.Entries() {
+		var level slog.Level
+		level.UnmarshalText([]byte(entry.Level))
+		if level < minLevel || (subsystem != "" && !strings.EqualFold(entry.Subsystem, subsystem)) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
@@ -0,0 +1,222 @@
+package overlay
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"vrchat-proximity/pkg/engine"
+	"vrchat-proximity/pkg/transport"
+)
+
+// Controller sides, combined as a bit mask
+const (
+	handLeft  = 1
+	handRight = 2
+	handBoth  = handLeft | handRight
+)
+
+// ControllerHapticsConfig configures vibration of the VR controllers
+type ControllerHapticsConfig struct {
+	Enabled     bool                             `yaml:"enabled"`
+	Duration    time.Duration                    `yaml:"duration"`     // Length of each pulse, also the shortest time between two
+	Frequency   float32                          `yaml:"frequency"`    // Hz
+	CenterWidth float64                          `yaml:"center_width"` // Screen width fraction around the middle that pulses both controllers
+	Curves      map[string]transport.HapticCurve `yaml:"curves"`       // Amplitude (0-100) by distance category
+}
+
+// DefaultControllerHapticsConfig pulses for Close and Very Close detections
+func DefaultControllerHapticsConfig() ControllerHapticsConfig {
+	return ControllerHapticsConfig{
+		Duration:    150 * time.Millisecond,
+		Frequency:   160,
+		CenterWidth: 0.2,
+		Curves: map[string]transport.HapticCurve{
+			"Very Close": {MinIntensity: 60, MaxIntensity: 100, Exponent: 1},
+			"Close":      {MinIntensity: 20, MaxIntensity: 60, Exponent: 2},
+		},
+	}
+}
+
+// Validate checks the controller haptics settings
+func (c ControllerHapticsConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Duration <= 0 {
+		return errors.New("duration must be positive")
+	}
+	if c.Frequency <= 0 {
+		return errors.New("frequency must be positive")
+	}
+	if c.CenterWidth < 0 || c.CenterWidth > 1 {
+		return errors.New("center_width must be between 0 and 1")
+	}
+	return nil
+}
+
+// vrHaptics is a connection able to vibrate the controllers
+type vrHaptics interface {
+	Pulse(hands int, duration time.Duration, frequency, amplitude float32) error
+	Close()
+}
+
+// ControllerHaptics vibrates the VR controllers through the OpenVR input
+// API: stronger the closer the nearest detection is, on the controller on
+// its side of the screen, or both when it's straight ahead
+type ControllerHaptics struct {
+	engine *engine.ProximityEngine
+	config ControllerHapticsConfig
+
+	mu        sync.Mutex
+	haptics   vrHaptics // nil while SteamVR isn't running
+	lastPulse time.Time
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// NewControllerHaptics creates the output; call Start to connect to SteamVR
+func NewControllerHaptics(pe *engine.ProximityEngine, config ControllerHapticsConfig) (*ControllerHaptics, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &ControllerHaptics{engine: pe, config: config, stop: make(chan struct{}), done: make(chan struct{})}, nil
+}
+
+// Start connects to SteamVR, retrying while it isn't running
+func (c *ControllerHaptics) Start() {
+	go c.connectLoop()
+}
+
+// Stop disconnects from SteamVR
+func (c *ControllerHaptics) Stop() {
+	close(c.stop)
+	<-c.done
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.haptics != nil {
+		c.haptics.Close()
+		c.haptics = nil
+	}
+}
+
+// connectLoop opens the haptics connection whenever there isn't one
+func (c *ControllerHaptics) connectLoop() {
+	defer close(c.done)
+	ticker := time.NewTicker(reconnectInterval)
+	defer ticker.Stop()
+	for {
+		c.mu.Lock()
+		if c.haptics == nil {
+			haptics, err := openHaptics()
+			if err != nil {
+				overlayLog.Debug("SteamVR haptics unavailable", "error", err)
+			} else {
+				overlayLog.Info("Controller haptics connected")
+				c.haptics = haptics
+			}
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PublishDetections pulses for the nearest detection at most once per pulse
+func (c *ControllerHaptics) PublishDetections(detections []engine.Detection) {
+	nearest, ok := engine.NearestDetection(detections)
+	if !ok {
+		return
+	}
+	curve, ok := c.config.Curves[nearest.Category]
+	if !ok {
+		return
+	}
+	amplitude := curve.Intensity(nearest.Distance)
+	if amplitude == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.haptics == nil || time.Now().Before(c.lastPulse.Add(c.config.Duration)) {
+		return
+	}
+	c.lastPulse = time.Now()
+	c.pulse(c.side(nearest), amplitude)
+}
+
+// HandleEvent plays the custom pattern a player rule or a rule action
+// attached to an event on the detection's side, holding off distance
+// pulses until it ends
+func (c *ControllerHaptics) HandleEvent(event engine.ProximityEvent) {
+	pattern := event.Haptic
+	switch {
+	case pattern == nil:
+		return
+	case event.Type != engine.EventZoneEnter && event.Type != engine.EventFastApproach && event.Type != engine.EventRule:
+		return
+	}
+	gap := time.Duration(pattern.GapMS) * time.Millisecond
+	hands := handBoth
+	if event.Detection != nil {
+		hands = c.side(*event.Detection)
+	}
+
+	c.mu.Lock()
+	if c.haptics == nil {
+		c.mu.Unlock()
+		return
+	}
+	length := time.Duration(pattern.Pulses)*(c.config.Duration+gap) - gap
+	c.lastPulse = time.Now().Add(length)
+	c.mu.Unlock()
+
+	go func() {
+		for i := 0; i < pattern.Pulses; i++ {
+			if i > 0 {
+				select {
+				case <-c.stop:
+					return
+				case <-time.After(c.config.Duration + gap):
+				}
+			}
+			c.mu.Lock()
+			if c.haptics != nil {
+				c.pulse(hands, pattern.Intensity)
+			}
+			c.mu.Unlock()
+		}
+	}()
+}
+
+// side picks the controllers for a detection from its screen position
+func (c *ControllerHaptics) side(d engine.Detection) int {
+	width, _ := c.engine.FrameSize()
+	if width <= 0 {
+		return handBoth
+	}
+	offset := (float64(d.BBox.X)+float64(d.BBox.Width)/2)/float64(width) - 0.5
+	switch {
+	case offset < -c.config.CenterWidth/2:
+		return handLeft
+	case offset > c.config.CenterWidth/2:
+		return handRight
+	default:
+		return handBoth
+	}
+}
+
+// pulse vibrates the controllers at amplitude 0-100; the caller holds mu.
+// A failed pulse drops the connection so it is reopened.
+func (c *ControllerHaptics) pulse(hands, amplitude int) {
+	if err := c.haptics.Pulse(hands, c.config.Duration, c.config.Frequency, float32(amplitude)/100); err != nil {
+		overlayLog.Warn("Controller haptics lost", "error", err)
+		c.haptics.Close()
+		c.haptics = nil
+	}
+}
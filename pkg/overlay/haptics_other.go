@@ -0,0 +1,12 @@
+//go:build !windows
+
+package overlay
+
+import (
+	"errors"
+)
+
+// openHaptics is only implemented on Windows, where SteamVR input runs
+func openHaptics() (vrHaptics, error) {
+	return nil, errors.New("OpenVR controller haptics are only supported on Windows")
+}
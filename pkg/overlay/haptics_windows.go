@@ -0,0 +1,171 @@
+//go:build windows
+
+package overlay
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// inputInterface is the IVRInput function table version the slots below belong to
+const inputInterface = "FnTable:IVRInput_010"
+
+// Slots in the IVRInput_010 function table
+const (
+	methodSetActionManifestPath        = 0
+	methodGetActionSetHandle           = 1
+	methodGetActionHandle              = 2
+	methodGetInputSourceHandle         = 3
+	methodUpdateActionState            = 4
+	methodTriggerHapticVibrationAction = 21
+)
+
+// Action names in the generated manifest
+const (
+	actionSetProximity = "/actions/proximity"
+	actionPulse        = "/actions/proximity/out/pulse"
+	vrInputErrorNone   = 0
+)
+
+// hapticControllerTypes get a default binding of the pulse action to both hands
+var hapticControllerTypes = []string{"knuckles", "oculus_touch", "vive_controller", "vive_cosmos_controller", "holographic_controller", "hpmotioncontroller", "generic"}
+
+// vrActiveActionSet mirrors VRActiveActionSet_t
+type vrActiveActionSet struct {
+	ActionSet          uint64
+	RestrictedToDevice uint64
+	SecondaryActionSet uint64
+	Padding            uint32
+	Priority           int32
+}
+
+// openvrHaptics pulses controllers through a vibration action
+type openvrHaptics struct {
+	table     *[fnTableSize]uintptr
+	actionSet uint64
+	pulse     uint64
+	hands     [2]uint64 // Input source handles of /user/hand/left and right
+}
+
+// openHaptics registers the action manifest with SteamVR and looks up the
+// pulse action and both hands
+func openHaptics() (vrHaptics, error) {
+	manifest, err := writeActionManifest(filepath.Join(os.TempDir(), "vrchat-proximity-openvr"))
+	if err != nil {
+		return nil, err
+	}
+	if err := acquireVR(); err != nil {
+		return nil, err
+	}
+	table, err := vrFnTable(inputInterface)
+	if err != nil {
+		releaseVR()
+		return nil, err
+	}
+	h := &openvrHaptics{table: table}
+
+	calls := []struct {
+		name   string
+		method int
+		arg    string
+		out    *uint64
+	}{
+		{"SetActionManifestPath", methodSetActionManifestPath, manifest, nil},
+		{"GetActionSetHandle", methodGetActionSetHandle, actionSetProximity, &h.actionSet},
+		{"GetActionHandle", methodGetActionHandle, actionPulse, &h.pulse},
+		{"GetInputSourceHandle", methodGetInputSourceHandle, "/user/hand/left", &h.hands[0]},
+		{"GetInputSourceHandle", methodGetInputSourceHandle, "/user/hand/right", &h.hands[1]},
+	}
+	for _, call := range calls {
+		arg, _ := syscall.BytePtrFromString(call.arg)
+		args := []uintptr{uintptr(unsafe.Pointer(arg))}
+		if call.out != nil {
+			args = append(args, uintptr(unsafe.Pointer(call.out)))
+		}
+		if code := vrCall(table, call.method, args...); code != vrInputErrorNone {
+			releaseVR()
+			return nil, overlayError(call.name, code)
+		}
+	}
+	return h, nil
+}
+
+// Pulse activates the action set and triggers the vibration on each hand.
+// Float arguments are passed as their bits, as in SetOverlayWidthInMeters.
+func (h *openvrHaptics) Pulse(hands int, duration time.Duration, frequency, amplitude float32) error {
+	set := vrActiveActionSet{ActionSet: h.actionSet}
+	if code := vrCall(h.table, methodUpdateActionState, uintptr(unsafe.Pointer(&set)), unsafe.Sizeof(set), 1); code != vrInputErrorNone {
+		return overlayError("UpdateActionState", code)
+	}
+	for i, source := range h.hands {
+		if hands&(1<<i) == 0 {
+			continue
+		}
+		code := vrCall(h.table, methodTriggerHapticVibrationAction, uintptr(h.pulse),
+			uintptr(math.Float32bits(0)), uintptr(math.Float32bits(float32(duration.Seconds()))),
+			uintptr(math.Float32bits(frequency)), uintptr(math.Float32bits(amplitude)), uintptr(source))
+		if code != vrInputErrorNone {
+			return overlayError("TriggerHapticVibrationAction", code)
+		}
+	}
+	return nil
+}
+
+// Close releases the SteamVR connection
+func (h *openvrHaptics) Close() {
+	releaseVR()
+}
+
+// writeActionManifest writes an action manifest declaring the pulse action
+// with default bindings to both hands' haptics, and returns its path
+func writeActionManifest(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	binding := map[string]interface{}{
+		"bindings": map[string]interface{}{
+			actionSetProximity: map[string]interface{}{
+				"haptics": []map[string]string{
+					{"output": actionPulse, "path": "/user/hand/left/output/haptic"},
+					{"output": actionPulse, "path": "/user/hand/right/output/haptic"},
+				},
+			},
+		},
+	}
+	var defaults []map[string]string
+	for _, controller := range hapticControllerTypes {
+		binding["controller_type"] = controller
+		name := "bindings_" + controller + ".json"
+		if err := writeJSON(filepath.Join(dir, name), binding); err != nil {
+			return "", err
+		}
+		defaults = append(defaults, map[string]string{"controller_type": controller, "binding_url": name})
+	}
+
+	manifest := filepath.Join(dir, "actions.json")
+	err := writeJSON(manifest, map[string]interface{}{
+		"default_bindings": defaults,
+		"actions":          []map[string]string{{"name": actionPulse, "type": "vibration"}},
+		"action_sets":      []map[string]string{{"name": actionSetProximity, "usage": "leftright"}},
+		"localization": []map[string]string{{
+			"language_tag":     "en_US",
+			actionSetProximity: "VRChat Proximity",
+			actionPulse:        "Proximity pulse",
+		}},
+	})
+	return manifest, err
+}
+
+// writeJSON writes v as indented JSON
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,12 @@
+//go:build !windows
+
+package overlay
+
+import (
+	"errors"
+)
+
+// openOverlay is only implemented on Windows, where SteamVR overlays run
+func openOverlay(config OverlayConfig) (vrOverlay, error) {
+	return nil, errors.New("OpenVR overlays are only supported on Windows")
+}
@@ -0,0 +1,173 @@
+//go:build windows
+
+package overlay
+
+import (
+	"image"
+	"math"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	openvrAPI                 = syscall.NewLazyDLL("openvr_api.dll")
+	procVRInitInternal2       = openvrAPI.NewProc("VR_InitInternal2")
+	procVRShutdownInternal    = openvrAPI.NewProc("VR_ShutdownInternal")
+	procVRGetGenericInterface = openvrAPI.NewProc("VR_GetGenericInterface")
+)
+
+// overlayInterface is the IVROverlay function table version the slots below belong to
+const overlayInterface = "FnTable:IVROverlay_027"
+
+// Slots in the IVROverlay_027 function table
+const (
+	methodCreateOverlay                            = 1
+	methodDestroyOverlay                           = 2
+	methodSetOverlayWidthInMeters                  = 21
+	methodSetOverlayTransformTrackedDeviceRelative = 34
+	methodShowOverlay                              = 41
+	methodHideOverlay                              = 42
+	methodSetOverlayRaw                            = 60
+)
+
+// OpenVR constants used by the overlay
+const (
+	vrApplicationOverlay  = 2
+	trackedDeviceIndexHMD = 0
+	overlayKey            = "vrchat-proximity.radar"
+	overlayName           = "VRChat Proximity Radar"
+	overlayBytesPerPixel  = 4
+	fnTableSize           = 128
+	vrInitErrorNone       = 0
+	vrOverlayErrorNone    = 0
+)
+
+// openvrOverlay is an overlay created through openvr_api.dll
+type openvrOverlay struct {
+	table  *[fnTableSize]uintptr
+	handle uint64
+}
+
+// The radar and controller haptics share one SteamVR connection, since
+// VR_ShutdownInternal ends it for the whole process
+var (
+	vrMu   sync.Mutex
+	vrRefs int
+)
+
+// acquireVR connects to SteamVR as an overlay application, or adds a
+// reference to the existing connection
+func acquireVR() error {
+	vrMu.Lock()
+	defer vrMu.Unlock()
+	if vrRefs == 0 {
+		if err := openvrAPI.Load(); err != nil {
+			return err
+		}
+		var initErr int32
+		procVRInitInternal2.Call(uintptr(unsafe.Pointer(&initErr)), vrApplicationOverlay, 0)
+		if initErr != vrInitErrorNone {
+			return overlayError("VR_Init", initErr)
+		}
+	}
+	vrRefs++
+	return nil
+}
+
+// releaseVR drops a reference, disconnecting from SteamVR with the last one
+func releaseVR() {
+	vrMu.Lock()
+	defer vrMu.Unlock()
+	if vrRefs--; vrRefs == 0 {
+		procVRShutdownInternal.Call()
+	}
+}
+
+// vrFnTable returns the function table of an interface such as
+// "FnTable:IVROverlay_027"; the caller holds a reference from acquireVR
+func vrFnTable(version string) (*[fnTableSize]uintptr, error) {
+	name, _ := syscall.BytePtrFromString(version)
+	var ifaceErr int32
+	table, _, _ := procVRGetGenericInterface.Call(uintptr(unsafe.Pointer(name)), uintptr(unsafe.Pointer(&ifaceErr)))
+	if table == 0 || ifaceErr != vrInitErrorNone {
+		return nil, overlayError("VR_GetGenericInterface "+version, ifaceErr)
+	}
+	// The table lives in openvr_api.dll, outside the Go heap
+	return *(**[fnTableSize]uintptr)(unsafe.Pointer(&table)), nil
+}
+
+// vrCall invokes a function table entry and returns its error code
+func vrCall(table *[fnTableSize]uintptr, method int, args ...uintptr) int32 {
+	r, _, _ := syscall.SyscallN(table[method], args...)
+	return int32(r)
+}
+
+// openOverlay creates the radar overlay in front of the headset
+func openOverlay(config OverlayConfig) (vrOverlay, error) {
+	if err := acquireVR(); err != nil {
+		return nil, err
+	}
+	table, err := vrFnTable(overlayInterface)
+	if err != nil {
+		releaseVR()
+		return nil, err
+	}
+	o := &openvrOverlay{table: table}
+
+	key, _ := syscall.BytePtrFromString(overlayKey)
+	title, _ := syscall.BytePtrFromString(overlayName)
+	if code := o.call(methodCreateOverlay, uintptr(unsafe.Pointer(key)), uintptr(unsafe.Pointer(title)), uintptr(unsafe.Pointer(&o.handle))); code != vrOverlayErrorNone {
+		releaseVR()
+		return nil, overlayError("CreateOverlay", code)
+	}
+
+	// The width is a float; the Windows syscall path fills the XMM
+	// registers as well as the integer ones, so its bits are passed as is
+	if code := o.call(methodSetOverlayWidthInMeters, uintptr(o.handle), uintptr(math.Float32bits(config.Width))); code != vrOverlayErrorNone {
+		o.Close()
+		return nil, overlayError("SetOverlayWidthInMeters", code)
+	}
+	transform := [3][4]float32{
+		{1, 0, 0, config.Position[0]},
+		{0, 1, 0, config.Position[1]},
+		{0, 0, 1, config.Position[2]},
+	}
+	if code := o.call(methodSetOverlayTransformTrackedDeviceRelative, uintptr(o.handle), trackedDeviceIndexHMD, uintptr(unsafe.Pointer(&transform))); code != vrOverlayErrorNone {
+		o.Close()
+		return nil, overlayError("SetOverlayTransformTrackedDeviceRelative", code)
+	}
+	return o, nil
+}
+
+// call invokes an IVROverlay function and returns its EVROverlayError
+func (o *openvrOverlay) call(method int, args ...uintptr) int32 {
+	return vrCall(o.table, method, args...)
+}
+
+// SetImage uploads the radar as raw RGBA pixels
+func (o *openvrOverlay) SetImage(img *image.RGBA) error {
+	size := img.Bounds().Size()
+	if code := o.call(methodSetOverlayRaw, uintptr(o.handle), uintptr(unsafe.Pointer(&img.Pix[0])), uintptr(size.X), uintptr(size.Y), overlayBytesPerPixel); code != vrOverlayErrorNone {
+		return overlayError("SetOverlayRaw", code)
+	}
+	return nil
+}
+
+// SetVisible shows or hides the overlay
+func (o *openvrOverlay) SetVisible(visible bool) error {
+	method, call := methodHideOverlay, "HideOverlay"
+	if visible {
+		method, call = methodShowOverlay, "ShowOverlay"
+	}
+	if code := o.call(method, uintptr(o.handle)); code != vrOverlayErrorNone {
+		return overlayError(call, code)
+	}
+	return nil
+}
+
+// Close destroys the overlay and disconnects from SteamVR
+func (o *openvrOverlay) Close() {
+	o.call(methodDestroyOverlay, uintptr(o.handle))
+	releaseVR()
+}
@@ -0,0 +1,296 @@
+// Package overlay brings detections into the VR headset through OpenVR:
+// a radar overlay and controller haptics, so nothing needs to be watched
+// or worn outside VR.
+package overlay
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"vrchat-proximity/pkg/engine"
+	"vrchat-proximity/pkg/logging"
+)
+
+// overlayLog is the "overlay" subsystem logger
+var overlayLog = logging.For("overlay")
+
+// reconnectInterval is how often a lost or missing SteamVR is retried
+const reconnectInterval = 10 * time.Second
+
+// OverlayConfig configures the headset radar
+type OverlayConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Visible  bool          `yaml:"visible"`  // Shown at startup; toggle with OSC or the overlay hotkey
+	Size     int           `yaml:"size"`     // Texture size in pixels
+	Width    float32       `yaml:"width"`    // Overlay width in meters
+	Position [3]float32    `yaml:"position"` // Meters from the headset: right, up, forward is -z
+	FOV      float64       `yaml:"fov"`      // Horizontal field of view of the captured view, mapping screen position to bearing
+	Interval time.Duration `yaml:"interval"` // Between redraws
+}
+
+// DefaultOverlayConfig places a small radar low in the view, redrawn ten times a second
+func DefaultOverlayConfig() OverlayConfig {
+	return OverlayConfig{
+		Visible:  true,
+		Size:     256,
+		Width:    0.12,
+		Position: [3]float32{0, -0.18, -0.6},
+		FOV:      100,
+		Interval: 100 * time.Millisecond,
+	}
+}
+
+// Validate checks the overlay settings
+func (c OverlayConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Size < 32 || c.Size > 1024 {
+		return errors.New("size must be between 32 and 1024")
+	}
+	if c.Width <= 0 {
+		return errors.New("width must be positive")
+	}
+	if c.FOV <= 0 || c.FOV >= 360 {
+		return errors.New("fov must be between 0 and 360 degrees")
+	}
+	if c.Interval <= 0 {
+		return errors.New("interval must be positive")
+	}
+	return nil
+}
+
+// vrOverlay is a connected OpenVR overlay
+type vrOverlay interface {
+	SetImage(img *image.RGBA) error
+	SetVisible(visible bool) error
+	Close()
+}
+
+// Radar renders the latest detections as blips on a radar with forward at
+// the top. A blip's bearing comes from its horizontal screen position and
+// its ring from its distance category.
+type Radar struct {
+	engine *engine.ProximityEngine
+	config OverlayConfig
+
+	visible atomic.Bool
+	dirty   atomic.Bool // Visibility changed since the overlay was last updated
+
+	mu         sync.Mutex
+	detections []engine.Detection
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewRadar creates a radar; call Start to connect to SteamVR
+func NewRadar(pe *engine.ProximityEngine, config OverlayConfig) (*Radar, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	r := &Radar{engine: pe, config: config, stop: make(chan struct{}), done: make(chan struct{})}
+	r.visible.Store(config.Visible)
+	return r, nil
+}
+
+// Start connects to SteamVR, retrying while it isn't running, and redraws
+// at the configured interval
+func (r *Radar) Start() {
+	go r.run()
+}
+
+// Stop removes the overlay
+func (r *Radar) Stop() {
+	close(r.stop)
+	<-r.done
+}
+
+// PublishDetections stores the detections for the next redraw
+func (r *Radar) PublishDetections(detections []engine.Detection) {
+	r.mu.Lock()
+	r.detections = append(r.detections[:0], detections...)
+	r.mu.Unlock()
+}
+
+// SetVisible shows or hides the overlay
+func (r *Radar) SetVisible(visible bool) {
+	if r.visible.Swap(visible) != visible {
+		r.dirty.Store(true)
+		overlayLog.Info("Radar overlay toggled", "visible", visible)
+	}
+}
+
+// Toggle flips the overlay's visibility
+func (r *Radar) Toggle() {
+	r.SetVisible(!r.visible.Load())
+}
+
+// Visible reports whether the overlay is shown
+func (r *Radar) Visible() bool {
+	return r.visible.Load()
+}
+
+// run keeps the overlay connected and up to date
+func (r *Radar) run() {
+	defer close(r.done)
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	var overlay vrOverlay
+	var lastAttempt time.Time
+	defer func() {
+		if overlay != nil {
+			overlay.Close()
+		}
+	}()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+		}
+
+		if overlay == nil {
+			if time.Since(lastAttempt) < reconnectInterval {
+				continue
+			}
+			lastAttempt = time.Now()
+			var err error
+			if overlay, err = openOverlay(r.config); err != nil {
+				overlayLog.Debug("SteamVR overlay unavailable", "error", err)
+				continue
+			}
+			overlayLog.Info("Radar overlay connected")
+			r.dirty.Store(true)
+		}
+
+		if err := r.update(overlay); err != nil {
+			overlayLog.Warn("Radar overlay lost", "error", err)
+			overlay.Close()
+			overlay = nil
+		}
+	}
+}
+
+// update applies visibility changes and redraws a visible radar
+func (r *Radar) update(overlay vrOverlay) error {
+	visible := r.visible.Load()
+	if r.dirty.Swap(false) {
+		if err := overlay.SetVisible(visible); err != nil {
+			return err
+		}
+	}
+	if !visible {
+		return nil
+	}
+
+	r.mu.Lock()
+	detections := append([]engine.Detection(nil), r.detections...)
+	r.mu.Unlock()
+	width, _ := r.engine.FrameSize()
+	trails := r.engine.Trails(detections)
+	return overlay.SetImage(renderRadar(detections, trails, width, r.config.FOV, r.config.Size))
+}
+
+// radarColors are blip colors by distance category, nearest first
+var radarColors = []color.RGBA{
+	{255, 64, 64, 255},
+	{255, 150, 40, 255},
+	{255, 220, 60, 255},
+	{90, 210, 110, 255},
+	{90, 160, 255, 255},
+}
+
+// renderRadar draws the radar: a translucent disc with one ring per
+// distance category, the field of view as a wedge, and a blip per detection
+// behind a fading trail of where its track has been
+func renderRadar(detections []engine.Detection, trails map[int64][]engine.TrailPoint, frameWidth int, fov float64, size int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	center := float64(size) / 2
+	radius := center - 2
+	rings := len(engine.DistanceCategories())
+	halfFOV := fov / 2 * math.Pi / 180
+
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			dx, dy := float64(x)+0.5-center, float64(y)+0.5-center
+			dist := math.Hypot(dx, dy)
+			if dist > radius {
+				continue
+			}
+			c := color.RGBA{10, 14, 20, 150}
+			// Rings at the outer edge of each category band
+			ringSpacing := radius / float64(rings)
+			if math.Abs(dist-ringSpacing*math.Round(dist/ringSpacing)) < 0.8 && dist > 1 {
+				c = color.RGBA{120, 140, 160, 200}
+			}
+			// Field of view edges
+			angle := math.Atan2(dx, -dy)
+			if math.Abs(math.Abs(angle)-halfFOV) < 0.8/max(dist, 1) {
+				c = color.RGBA{80, 100, 120, 200}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+
+	if frameWidth <= 0 {
+		return img
+	}
+	// Position on the radar of a screen x and a category
+	place := func(x float64, category string) (float64, float64, int) {
+		rank := engine.CategoryRank(category)
+		if rank < 0 || rank >= rings {
+			rank = rings - 1
+		}
+		offset := x/float64(frameWidth) - 0.5
+		bearing := math.Max(-0.5, math.Min(0.5, offset)) * 2 * halfFOV
+		r := radius / float64(rings) * (float64(rank) + 0.5)
+		return center + r*math.Sin(bearing), center - r*math.Cos(bearing), rank
+	}
+
+	for _, trail := range trails {
+		for i, point := range trail {
+			x, y, rank := place(float64(point.X), point.Category)
+			// Older points fade out; image.RGBA colors are premultiplied
+			c := radarColors[rank%len(radarColors)]
+			alpha := uint32(40 + 120*(i+1)/len(trail))
+			c = color.RGBA{uint8(uint32(c.R) * alpha / 255), uint8(uint32(c.G) * alpha / 255), uint8(uint32(c.B) * alpha / 255), uint8(alpha)}
+			fillCircle(img, x, y, float64(size)/96, c)
+		}
+	}
+
+	nearest, hasNearest := engine.NearestDetection(detections)
+	for _, d := range detections {
+		x, y, rank := place(float64(d.BBox.X)+float64(d.BBox.Width)/2, d.Category)
+		blip := float64(size) / 32
+		if hasNearest && d == nearest {
+			blip *= 1.5
+		}
+		fillCircle(img, x, y, blip, radarColors[rank%len(radarColors)])
+	}
+	return img
+}
+
+// fillCircle draws a solid circle
+func fillCircle(img *image.RGBA, cx, cy, r float64, c color.RGBA) {
+	for y := int(cy - r); y <= int(cy+r); y++ {
+		for x := int(cx - r); x <= int(cx+r); x++ {
+			if math.Hypot(float64(x)+0.5-cx, float64(y)+0.5-cy) <= r {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+}
+
+// overlayError formats a failed OpenVR call
+func overlayError(call string, code int32) error {
+	return fmt.Errorf("openvr: %s failed with error %d", call, code)
+}
@@ -0,0 +1,291 @@
+// Package scripting runs user Lua scripts inside the event pipeline so they
+// can rewrite or drop detections and events and emit custom events.
+package scripting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+
+	"vrchat-proximity/pkg/engine"
+	"vrchat-proximity/pkg/logging"
+)
+
+// scriptLog is the "script" subsystem logger
+var scriptLog = logging.For("script")
+
+// ScriptConfig configures the pipeline script
+type ScriptConfig struct {
+	Path    string        `yaml:"path"`    // Lua file defining onDetection and/or onZoneEvent; empty disables
+	Timeout time.Duration `yaml:"timeout"` // Longest a single hook call may run
+}
+
+// DefaultScriptConfig runs no script and allows 20ms per hook call
+func DefaultScriptConfig() ScriptConfig {
+	return ScriptConfig{Timeout: 20 * time.Millisecond}
+}
+
+// LuaScript is an engine.PipelineScript backed by a Lua file. The hooks
+// receive detections and events as tables with the same keys as the JSON
+// API. A hook returning nil keeps its argument, false drops it, and a
+// table replaces it. emit(type, fields) publishes a custom event and
+// log(...) writes to the "script" logger.
+type LuaScript struct {
+	mu          sync.Mutex
+	state       *lua.LState
+	timeout     time.Duration
+	onDetection *lua.LFunction // nil when the script does not define it
+	onEvent     *lua.LFunction
+	emitted     []engine.ProximityEvent
+	lastError   string // Repeats of the same error are logged once
+}
+
+// LoadLuaScript runs the file at config.Path and looks up its hooks
+func LoadLuaScript(config ScriptConfig) (*LuaScript, error) {
+	s := &LuaScript{state: lua.NewState(), timeout: config.Timeout}
+	if s.timeout <= 0 {
+		s.timeout = DefaultScriptConfig().Timeout
+	}
+	s.state.SetGlobal("emit", s.state.NewFunction(s.luaEmit))
+	s.state.SetGlobal("log", s.state.NewFunction(luaLog))
+
+	if err := s.state.DoFile(config.Path); err != nil {
+		s.state.Close()
+		return nil, fmt.Errorf("load script %s: %w", config.Path, err)
+	}
+	s.onDetection, _ = s.state.GetGlobal("onDetection").(*lua.LFunction)
+	s.onEvent, _ = s.state.GetGlobal("onZoneEvent").(*lua.LFunction)
+	if s.onDetection == nil && s.onEvent == nil {
+		s.state.Close()
+		return nil, fmt.Errorf("load script %s: defines neither onDetection nor onZoneEvent", config.Path)
+	}
+	scriptLog.Info("Script loaded", "path", config.Path, "on_detection", s.onDetection != nil, "on_zone_event", s.onEvent != nil)
+	return s, nil
+}
+
+// Close releases the Lua state
+func (s *LuaScript) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.Close()
+}
+
+// HandleDetections passes each detection through onDetection
+func (s *LuaScript) HandleDetections(detections []engine.Detection) ([]engine.Detection, []engine.ProximityEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.onDetection == nil {
+		return detections, s.takeEmitted()
+	}
+
+	kept := make([]engine.Detection, 0, len(detections))
+	for _, d := range detections {
+		result, err := s.call(s.onDetection, d)
+		if err != nil {
+			s.warn("onDetection", err)
+			kept = append(kept, d)
+			continue
+		}
+		switch result := result.(type) {
+		case *lua.LNilType:
+			kept = append(kept, d)
+		case lua.LBool:
+			if result {
+				kept = append(kept, d)
+			}
+		case *lua.LTable:
+			var replaced engine.Detection
+			if err := fromTable(result, &replaced); err != nil {
+				s.warn("onDetection", err)
+				replaced = d
+			}
+			kept = append(kept, replaced)
+		default:
+			s.warn("onDetection", fmt.Errorf("returned a %s, want nil, false, or a table", result.Type()))
+			kept = append(kept, d)
+		}
+	}
+	return kept, s.takeEmitted()
+}
+
+// HandleEvent passes an event through onZoneEvent
+func (s *LuaScript) HandleEvent(event engine.ProximityEvent) (engine.ProximityEvent, bool, []engine.ProximityEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.onEvent == nil {
+		return event, true, nil
+	}
+
+	result, err := s.call(s.onEvent, event)
+	if err != nil {
+		s.warn("onZoneEvent", err)
+		return event, true, s.takeEmitted()
+	}
+	switch result := result.(type) {
+	case *lua.LNilType:
+	case lua.LBool:
+		if !result {
+			return event, false, s.takeEmitted()
+		}
+	case *lua.LTable:
+		var replaced engine.ProximityEvent
+		if err := fromTable(result, &replaced); err != nil {
+			s.warn("onZoneEvent", err)
+			return event, true, s.takeEmitted()
+		}
+		event = replaced
+	default:
+		s.warn("onZoneEvent", fmt.Errorf("returned a %s, want nil, false, or a table", result.Type()))
+	}
+	return event, true, s.takeEmitted()
+}
+
+// call runs a hook with arg as a table, bounded by the timeout
+func (s *LuaScript) call(fn *lua.LFunction, arg interface{}) (lua.LValue, error) {
+	table, err := toTable(s.state, arg)
+	if err != nil {
+		return lua.LNil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	s.state.SetContext(ctx)
+	defer s.state.RemoveContext()
+
+	if err := s.state.CallByParam(lua.P{Fn: fn, NRet: 1, Protect: true}, table); err != nil {
+		return lua.LNil, err
+	}
+	result := s.state.Get(-1)
+	s.state.Pop(1)
+	return result, nil
+}
+
+// warn logs a hook error unless it repeats the previous one
+func (s *LuaScript) warn(hook string, err error) {
+	if msg := hook + ": " + err.Error(); msg != s.lastError {
+		s.lastError = msg
+		scriptLog.Warn("Script hook failed, passing input through", "hook", hook, "error", err)
+	}
+}
+
+// takeEmitted returns and clears the events emitted during the last calls
+func (s *LuaScript) takeEmitted() []engine.ProximityEvent {
+	emitted := s.emitted
+	s.emitted = nil
+	return emitted
+}
+
+// luaEmit implements emit(type, fields)
+func (s *LuaScript) luaEmit(L *lua.LState) int {
+	event := engine.ProximityEvent{}
+	if fields, ok := L.Get(2).(*lua.LTable); ok {
+		if err := fromTable(fields, &event); err != nil {
+			L.RaiseError("emit: %v", err)
+			return 0
+		}
+	}
+	event.Type = L.CheckString(1)
+	if event.Timestamp == 0 {
+		event.Timestamp = time.Now().Unix()
+	}
+	s.emitted = append(s.emitted, event)
+	return 0
+}
+
+// luaLog implements log(...)
+func luaLog(L *lua.LState) int {
+	parts := make([]string, L.GetTop())
+	for i := range parts {
+		parts[i] = L.ToStringMeta(L.Get(i + 1)).String()
+	}
+	scriptLog.Info(strings.Join(parts, " "))
+	return 0
+}
+
+// toTable converts a JSON-encodable value to a Lua table
+func toTable(L *lua.LState, v interface{}) (*lua.LTable, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+	return toLua(L, decoded).(*lua.LTable), nil
+}
+
+// toLua converts a decoded JSON value to Lua
+func toLua(L *lua.LState, v interface{}) lua.LValue {
+	switch v := v.(type) {
+	case bool:
+		return lua.LBool(v)
+	case float64:
+		return lua.LNumber(v)
+	case string:
+		return lua.LString(v)
+	case []interface{}:
+		table := L.CreateTable(len(v), 0)
+		for _, item := range v {
+			table.Append(toLua(L, item))
+		}
+		return table
+	case map[string]interface{}:
+		table := L.CreateTable(0, len(v))
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			table.RawSetString(key, toLua(L, v[key]))
+		}
+		return table
+	default:
+		return lua.LNil
+	}
+}
+
+// fromTable decodes a Lua table into out through its JSON form
+func fromTable(table *lua.LTable, out interface{}) error {
+	data, err := json.Marshal(fromLua(table))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// fromLua converts a Lua value to its JSON equivalent. Tables with a
+// sequence part become arrays, others objects.
+func fromLua(v lua.LValue) interface{} {
+	switch v := v.(type) {
+	case lua.LBool:
+		return bool(v)
+	case lua.LNumber:
+		return float64(v)
+	case lua.LString:
+		return string(v)
+	case *lua.LTable:
+		if n := v.MaxN(); n > 0 {
+			items := make([]interface{}, n)
+			for i := range items {
+				items[i] = fromLua(v.RawGetInt(i + 1))
+			}
+			return items
+		}
+		fields := make(map[string]interface{})
+		v.ForEach(func(key, value lua.LValue) {
+			if key, ok := key.(lua.LString); ok {
+				fields[string(key)] = fromLua(value)
+			}
+		})
+		return fields
+	default:
+		return nil
+	}
+}
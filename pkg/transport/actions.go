@@ -0,0 +1,105 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"vrchat-proximity/pkg/engine"
+)
+
+// defaultSensitivityStep is the sensitivity change of one button press
+const defaultSensitivityStep = 10
+
+// actionState is the JSON body of the /actions endpoints
+type actionState struct {
+	State       string `json:"state"`
+	Haptics     bool   `json:"haptics"`
+	Sensitivity int    `json:"sensitivity"`
+}
+
+// actionInputError is an action failing on a malformed or out-of-range
+// parameter rather than on the engine's state
+type actionInputError string
+
+func (e actionInputError) Error() string {
+	return string(e)
+}
+
+// handleAction wraps a one-press action for button pads such as the Stream
+// Deck, whose HTTP plugins often only send GET. The reply is the new state
+// as plain text, or JSON with ?format=json or an Accept of application/json.
+// Bad parameters get 400 Bad Request and state conflicts 409 Conflict.
+func (s *Server) handleAction(action func(r *http.Request) (string, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		text, err := action(r)
+		var input actionInputError
+		switch {
+		case errors.As(err, &input):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(actionState{
+				State:       s.engine.State(),
+				Haptics:     s.engine.HapticsEnabled(),
+				Sensitivity: s.engine.Sensitivity(),
+			})
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, text)
+	}
+}
+
+// actionPause pauses a running engine or resumes a paused one
+func (s *Server) actionPause(r *http.Request) (string, error) {
+	var err error
+	if s.engine.State() == engine.StatePaused {
+		err = s.engine.Resume()
+	} else {
+		err = s.engine.Pause()
+	}
+	return s.engine.State(), err
+}
+
+// actionToggleHaptics switches every haptic output on or off
+func (s *Server) actionToggleHaptics(r *http.Request) (string, error) {
+	enabled := !s.engine.HapticsEnabled()
+	s.engine.SetHapticsEnabled(enabled)
+	if enabled {
+		return "haptics on", nil
+	}
+	return "haptics off", nil
+}
+
+// actionSensitivity returns an action moving sensitivity by ?step=, or
+// defaultSensitivityStep, in direction within 1-100
+func (s *Server) actionSensitivity(direction int) func(r *http.Request) (string, error) {
+	return func(r *http.Request) (string, error) {
+		step := defaultSensitivityStep
+		if v := r.URL.Query().Get("step"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				return "", actionInputError("step must be a positive integer")
+			}
+			step = n
+		}
+		sensitivity := min(max(s.engine.Sensitivity()+direction*step, 1), 100)
+		s.engine.SetSensitivity(sensitivity)
+		return "sensitivity " + strconv.Itoa(sensitivity), nil
+	}
+}
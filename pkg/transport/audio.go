@@ -0,0 +1,184 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"vrchat-proximity/pkg/engine"
+	"vrchat-proximity/pkg/logging"
+)
+
+// audioLog is the "audio" subsystem logger
+var audioLog = logging.For("audio")
+
+// audioSampleRate is the sample rate of generated cues
+const audioSampleRate = 44100
+
+// AudioCueConfig configures positional beeps for the nearest detection
+type AudioCueConfig struct {
+	Enabled     bool          `yaml:"enabled"`
+	Player      string        `yaml:"player"`       // Command that plays the WAV file appended as its last argument; empty picks one for this OS
+	MinCategory string        `yaml:"min_category"` // Only beep for detections at least this close
+	Interval    time.Duration `yaml:"interval"`     // Time between beeps while someone is in range
+	Frequency   float64       `yaml:"frequency"`    // Beep pitch in Hz
+	Length      time.Duration `yaml:"length"`
+	MinVolume   float64       `yaml:"min_volume"` // 0-1, at engine.MaxEstimatedDistance
+	MaxVolume   float64       `yaml:"max_volume"` // 0-1, at distance 0
+}
+
+// DefaultAudioCueConfig beeps twice a second for anyone Medium or closer
+func DefaultAudioCueConfig() AudioCueConfig {
+	return AudioCueConfig{
+		MinCategory: "Medium",
+		Interval:    500 * time.Millisecond,
+		Frequency:   880,
+		Length:      80 * time.Millisecond,
+		MinVolume:   0.1,
+		MaxVolume:   0.8,
+	}
+}
+
+// AudioCues plays a short beep panned toward the nearest detection's side
+// of the screen, louder the closer it is
+type AudioCues struct {
+	engine *engine.ProximityEngine
+	config AudioCueConfig
+	player func(path string) *exec.Cmd
+
+	mu       sync.Mutex
+	lastBeep time.Time
+	playing  bool
+	dir      string // Holds the temporary WAV files
+}
+
+// NewAudioCues checks that an audio player is available
+func NewAudioCues(pe *engine.ProximityEngine, config AudioCueConfig) (*AudioCues, error) {
+	command := strings.Fields(config.Player)
+	if len(command) == 0 {
+		switch runtime.GOOS {
+		case "windows":
+			command = []string{"powershell"}
+		case "darwin":
+			command = []string{"afplay"}
+		default:
+			command = []string{"paplay"}
+			if _, err := exec.LookPath("paplay"); err != nil {
+				command = []string{"aplay", "-q"}
+			}
+		}
+	}
+	if _, err := exec.LookPath(command[0]); err != nil {
+		return nil, fmt.Errorf("audio: player %s not found: %w", command[0], err)
+	}
+	dir, err := os.MkdirTemp("", "vrchat-proximity-audio")
+	if err != nil {
+		return nil, fmt.Errorf("audio: %w", err)
+	}
+
+	player := func(path string) *exec.Cmd {
+		return exec.Command(command[0], append(command[1:len(command):len(command)], path)...)
+	}
+	if config.Player == "" && runtime.GOOS == "windows" {
+		player = func(path string) *exec.Cmd {
+			script := "(New-Object Media.SoundPlayer '" + strings.ReplaceAll(path, "'", "''") + "').PlaySync()"
+			return exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+		}
+	}
+	return &AudioCues{engine: pe, config: config, player: player, dir: dir}, nil
+}
+
+// Stop removes the temporary files
+func (a *AudioCues) Stop() {
+	os.RemoveAll(a.dir)
+}
+
+// PublishDetections beeps for the nearest detection at most once per interval
+func (a *AudioCues) PublishDetections(detections []engine.Detection) {
+	nearest, ok := engine.NearestDetection(detections)
+	if !ok || engine.CategoryRank(nearest.Category) > engine.CategoryRank(a.config.MinCategory) {
+		return
+	}
+	width, _ := a.engine.FrameSize()
+	if width <= 0 {
+		return
+	}
+
+	a.mu.Lock()
+	now := time.Now()
+	if a.playing || now.Sub(a.lastBeep) < a.config.Interval {
+		a.mu.Unlock()
+		return
+	}
+	a.lastBeep, a.playing = now, true
+	a.mu.Unlock()
+
+	// Pan by bearing, so a detection 90 degrees off to one side is hard left or right
+	pan := 0.5 + math.Sin(float64(nearest.Bearing)*math.Pi/180)/2
+	closeness := 1 - math.Min(float64(nearest.Distance)/engine.MaxEstimatedDistance, 1)
+	volume := a.config.MinVolume + (a.config.MaxVolume-a.config.MinVolume)*closeness
+
+	go func() {
+		defer func() {
+			a.mu.Lock()
+			a.playing = false
+			a.mu.Unlock()
+		}()
+		if err := a.play(beepWAV(a.config.Frequency, a.config.Length, math.Max(0, math.Min(1, pan)), volume)); err != nil {
+			audioLog.Warn("Audio cue failed", "error", err)
+		}
+	}()
+}
+
+// play writes the WAV to a file and runs the player on it
+func (a *AudioCues) play(wav []byte) error {
+	path := filepath.Join(a.dir, "cue.wav")
+	if err := os.WriteFile(path, wav, 0o600); err != nil {
+		return err
+	}
+	if out, err := a.player(path).CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// beepWAV renders a stereo 16-bit sine beep with equal-power panning, where
+// pan 0 is hard left and 1 hard right. Short fades avoid clicks.
+func beepWAV(frequency float64, length time.Duration, pan, volume float64) []byte {
+	samples := int(length.Seconds() * audioSampleRate)
+	fade := min(audioSampleRate/200, samples/2) // 5ms
+	left, right := math.Cos(pan*math.Pi/2)*volume, math.Sin(pan*math.Pi/2)*volume
+
+	var buf bytes.Buffer
+	dataSize := samples * 4
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(36+dataSize))
+	buf.WriteString("WAVEfmt ")
+	binary.Write(&buf, binary.LittleEndian, [2]uint32{16, 1 | 2<<16}) // PCM, 2 channels
+	binary.Write(&buf, binary.LittleEndian, [2]uint32{audioSampleRate, audioSampleRate * 4})
+	binary.Write(&buf, binary.LittleEndian, [2]uint16{4, 16})
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(dataSize))
+
+	frame := make([]int16, 2)
+	for i := 0; i < samples; i++ {
+		envelope := 1.0
+		if i < fade {
+			envelope = float64(i) / float64(fade)
+		} else if i >= samples-fade {
+			envelope = float64(samples-1-i) / float64(fade)
+		}
+		v := math.Sin(2*math.Pi*frequency*float64(i)/audioSampleRate) * envelope * math.MaxInt16
+		frame[0], frame[1] = int16(v*left), int16(v*right)
+		binary.Write(&buf, binary.LittleEndian, frame)
+	}
+	return buf.Bytes()
+}
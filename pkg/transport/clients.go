@@ -0,0 +1,217 @@
+package transport
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Slow client policies for ServerConfig.SlowClientPolicy
+const (
+	SlowClientDisconnect = "disconnect" // Close the connection when its send buffer fills
+	SlowClientDrop       = "drop"       // Drop the message and keep the connection
+)
+
+// Coalesced stream message kinds; only the latest of each is kept per client
+const (
+	stateDetections = "detections"
+	stateNearest    = "nearest"
+)
+
+// clientStats is the per-client /metrics and /clients entry
+type clientStats struct {
+	ID          int64     `json:"id"`
+	RemoteAddr  string    `json:"remote_addr"`
+	UserAgent   string    `json:"user_agent,omitempty"`
+	ConnectedAt time.Time `json:"connected_at"`
+	NearestOnly bool      `json:"nearest_only"` // Receives only nearest summaries
+	Deltas      bool      `json:"deltas"`       // Receives detections_delta instead of full lists
+	Trails      bool      `json:"trails"`       // Receives each track's recent positions with detections
+	Sent        int64     `json:"sent"`
+	Received    int64     `json:"received"`
+	Coalesced   int64     `json:"coalesced"` // State messages replaced by a newer one before sending
+	Dropped     int64     `json:"dropped"`   // Messages dropped because the send buffer was full
+
+	Compressed   bool  `json:"compressed"`
+	PayloadBytes int64 `json:"payload_bytes"` // Sent message bytes before compression
+	WireBytes    int64 `json:"wire_bytes"`    // Bytes written to the socket
+}
+
+// stats returns the client's counters
+func (c *Client) stats() clientStats {
+	return clientStats{
+		ID:          c.id,
+		RemoteAddr:  c.remoteAddr,
+		UserAgent:   c.userAgent,
+		ConnectedAt: c.connectedAt,
+		NearestOnly: c.nearestOnly.Load(),
+		Deltas:      c.delta.Load() != nil,
+		Trails:      c.trails.Load(),
+		Sent:        c.sent.Load(),
+		Received:    c.received.Load(),
+		Coalesced:   c.coalesced.Load(),
+		Dropped:     c.dropped.Load(),
+
+		Compressed:   c.compressed,
+		PayloadBytes: c.payloadBytes.Load(),
+		WireBytes:    c.wireBytes.Load(),
+	}
+}
+
+// trySend queues a message that must not be skipped, such as an event. When
+// the buffer is full the slow client policy decides whether the message is
+// dropped or the client closed; it returns false once the client is closed.
+func (c *Client) trySend(data []byte) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return false
+	}
+	select {
+	case c.send <- data:
+		return true
+	default:
+		c.dropped.Add(1)
+		c.server.dropped.Add(1)
+		if c.server.config.SlowClientPolicy == SlowClientDrop {
+			return true
+		}
+		wsLog.Warn("Disconnecting slow WebSocket client", "client", c.id, "remote", c.remoteAddr)
+		c.server.slowDisconnects.Add(1)
+		c.closed = true
+		close(c.send)
+		return false
+	}
+}
+
+// offerState replaces the pending message of a kind with a newer one. The
+// write pump sends the latest of each kind at most ClientRate times a second.
+func (c *Client) offerState(kind string, data []byte) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return
+	}
+	if _, ok := c.pending[kind]; ok {
+		c.coalesced.Add(1)
+	}
+	c.pending[kind] = data
+	c.mu.Unlock()
+
+	select {
+	case c.stateReady <- struct{}{}:
+	default:
+	}
+}
+
+// takeState returns the pending state messages, oldest kind first
+func (c *Client) takeState() [][]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var messages [][]byte
+	for _, kind := range []string{stateDetections, stateNearest} {
+		if data, ok := c.pending[kind]; ok {
+			messages = append(messages, data)
+			delete(c.pending, kind)
+		}
+	}
+	return messages
+}
+
+// write sends one text message with the write deadline
+func (c *Client) write(data []byte) error {
+	c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+		return err
+	}
+	c.sent.Add(1)
+	c.payloadBytes.Add(int64(len(data)))
+	return nil
+}
+
+// stateInterval is the minimum time between state sends, 0 for unlimited
+func (c *Client) stateInterval() time.Duration {
+	if rate := c.server.config.ClientRate; rate > 0 {
+		return time.Duration(float64(time.Second) / rate)
+	}
+	return 0
+}
+
+// clientList returns the stats of every connected client, oldest first
+func (s *Server) clientList() []clientStats {
+	clients := []clientStats{}
+	s.clients.Range(func(key, _ interface{}) bool {
+		clients = append(clients, key.(*Client).stats())
+		return true
+	})
+	sort.Slice(clients, func(i, j int) bool { return clients[i].ID < clients[j].ID })
+	return clients
+}
+
+// kick closes the connection with a close frame; the read pump then
+// forgets the client
+func (c *Client) kick() {
+	message := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "disconnected by the engine owner")
+	c.conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(time.Second))
+	c.conn.Close()
+}
+
+// handleClients lists the connected WebSocket clients
+func (s *Server) handleClients(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.clientList())
+}
+
+// handleClient shows one client with GET /clients/{id} and disconnects it
+// with DELETE
+func (s *Server) handleClient(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/clients/"), 10, 64)
+	if err != nil {
+		http.Error(w, "client id must be a number", http.StatusBadRequest)
+		return
+	}
+	var client *Client
+	s.clients.Range(func(key, _ interface{}) bool {
+		if c := key.(*Client); c.id == id {
+			client = c
+			return false
+		}
+		return true
+	})
+	if client == nil {
+		http.Error(w, "no such client", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(client.stats())
+	case http.MethodDelete:
+		wsLog.Info("Disconnecting WebSocket client", "client", client.id, "remote", client.remoteAddr)
+		client.kick()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// clientMetrics summarizes WebSocket delivery for /metrics
+func (s *Server) clientMetrics() map[string]interface{} {
+	clients := s.clientList()
+	return map[string]interface{}{
+		"clients":          clients,
+		"dropped_total":    s.dropped.Load(),
+		"slow_disconnects": s.slowDisconnects.Load(),
+	}
+}
@@ -0,0 +1,53 @@
+package transport
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// countingConn counts the bytes written to the socket, after compression
+type countingConn struct {
+	net.Conn
+	written *atomic.Int64
+}
+
+// Write counts and forwards to the underlying connection
+func (c *countingConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.written.Add(int64(n))
+	return n, err
+}
+
+// countingResponseWriter hands the WebSocket upgrader a counting connection
+type countingResponseWriter struct {
+	http.ResponseWriter
+	written *atomic.Int64
+}
+
+// Hijack wraps the hijacked connection in a countingConn
+func (w countingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response does not implement http.Hijacker")
+	}
+	conn, brw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	return &countingConn{Conn: conn, written: w.written}, brw, nil
+}
+
+// offersDeflate reports whether the client offered permessage-deflate;
+// gorilla/websocket accepts it whenever compression is enabled
+func offersDeflate(r *http.Request) bool {
+	for _, header := range r.Header.Values("Sec-WebSocket-Extensions") {
+		if strings.Contains(header, "permessage-deflate") {
+			return true
+		}
+	}
+	return false
+}
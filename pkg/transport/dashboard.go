@@ -0,0 +1,19 @@
+package transport
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed dashboard
+var dashboardFiles embed.FS
+
+// dashboardHandler serves the embedded single-page dashboard
+func dashboardHandler() http.Handler {
+	root, err := fs.Sub(dashboardFiles, "dashboard")
+	if err != nil {
+		panic(err) // The directory is embedded at build time
+	}
+	return http.FileServer(http.FS(root))
+}
@@ -0,0 +1,402 @@
+"use strict";
+
+// Category colors, nearest first; replaced by the server's table from /config/categories
+let categoryColors = {
+  "Very Close": "#ff4d4d",
+  "Close": "#ff9f40",
+  "Medium": "#ffd84d",
+  "Far": "#4dc3ff",
+  "Very Far": "#8a8f99",
+};
+
+// An API token passed as ?token= is remembered for later visits
+const params = new URLSearchParams(location.search);
+if (params.has("token")) {
+  localStorage.setItem("token", params.get("token"));
+}
+const token = localStorage.getItem("token");
+
+function api(path) {
+  path = "/api/v1" + path;
+  if (!token) {
+    return path;
+  }
+  return path + (path.includes("?") ? "&" : "?") + "token=" + encodeURIComponent(token);
+}
+
+const overlay = document.getElementById("overlay");
+const ctx = overlay.getContext("2d");
+let frameWidth = 1280;
+let frameHeight = 720;
+let lastDetections = [];
+let lastTrails = {}; // Recent positions of each live track, by track ID
+let selected = null; // Detection picked for feedback by clicking its box
+
+// Detection centers of the last few seconds, drawn as fading trails
+const trailSeconds = 10;
+let trail = [];
+
+function addTrail(timestamp, detections) {
+  trail.push({ timestamp, detections });
+  const cutoff = Date.now() - trailSeconds * 1000;
+  while (trail.length && trail[0].timestamp < cutoff) {
+    trail.shift();
+  }
+}
+
+// Legend
+const legend = document.getElementById("legend");
+function showLegend() {
+  legend.innerHTML = "";
+  for (const [category, color] of Object.entries(categoryColors)) {
+    const span = document.createElement("span");
+    span.textContent = category;
+    span.style.setProperty("--color", color);
+    legend.appendChild(span);
+  }
+}
+showLegend();
+
+async function loadCategories() {
+  const bands = await (await fetch(api("/config/categories"))).json();
+  categoryColors = Object.fromEntries(bands.map((band) => [band.name, band.color || "#ffffff"]));
+  showLegend();
+}
+
+// Raw preview frames under the overlay; boxes are drawn client-side
+const preview = document.getElementById("preview");
+preview.onerror = () => setTimeout(() => (preview.src = api("/preview.mjpeg?overlay=0&t=" + Date.now())), 5000);
+preview.src = api("/preview.mjpeg?overlay=0");
+
+// Detection overlay
+function drawDetections() {
+  if (overlay.width !== frameWidth || overlay.height !== frameHeight) {
+    overlay.width = frameWidth;
+    overlay.height = frameHeight;
+  }
+  ctx.clearRect(0, 0, overlay.width, overlay.height);
+
+  const now = Date.now();
+  const dot = Math.max(2, frameWidth / 320);
+  for (const { timestamp, detections } of trail) {
+    ctx.globalAlpha = Math.max(0, 1 - (now - timestamp) / (trailSeconds * 1000)) * 0.6;
+    for (const d of detections) {
+      ctx.fillStyle = categoryColors[d.category] || "#ffffff";
+      ctx.beginPath();
+      ctx.arc(d.bbox.x + d.bbox.width / 2, d.bbox.y + d.bbox.height / 2, dot, 0, 2 * Math.PI);
+      ctx.fill();
+    }
+  }
+  ctx.globalAlpha = 1;
+
+  ctx.lineWidth = Math.max(2, frameWidth / 400);
+
+  // Motion trails leading up to each box
+  ctx.lineJoin = "round";
+  for (const d of lastDetections) {
+    const points = lastTrails[d.track_id];
+    if (!points || points.length < 2) {
+      continue;
+    }
+    ctx.strokeStyle = categoryColors[d.category] || "#ffffff";
+    ctx.globalAlpha = 0.7;
+    ctx.beginPath();
+    ctx.moveTo(points[0].x, points[0].y);
+    for (const p of points.slice(1)) {
+      ctx.lineTo(p.x, p.y);
+    }
+    ctx.stroke();
+  }
+  ctx.globalAlpha = 1;
+
+  ctx.font = `${Math.max(14, frameWidth / 80)}px system-ui`;
+
+  for (const d of lastDetections) {
+    const color = categoryColors[d.category] || "#ffffff";
+    ctx.strokeStyle = color;
+    ctx.strokeRect(d.bbox.x, d.bbox.y, d.bbox.width, d.bbox.height);
+    if (selected && (selected.track_id ? d.track_id === selected.track_id : d.id === selected.id)) {
+      ctx.setLineDash([ctx.lineWidth * 3, ctx.lineWidth * 2]);
+      ctx.strokeStyle = "#ffffff";
+      ctx.strokeRect(d.bbox.x - ctx.lineWidth * 2, d.bbox.y - ctx.lineWidth * 2, d.bbox.width + ctx.lineWidth * 4, d.bbox.height + ctx.lineWidth * 4);
+      ctx.setLineDash([]);
+    }
+
+    const label = `${d.category} ${d.distance.toFixed(1)}m`;
+    const textWidth = ctx.measureText(label).width;
+    ctx.fillStyle = color;
+    ctx.fillRect(d.bbox.x, d.bbox.y - 20, textWidth + 8, 20);
+    ctx.fillStyle = "#000";
+    ctx.fillText(label, d.bbox.x + 4, d.bbox.y - 5);
+  }
+}
+
+// Seed the trail with what happened before the page loaded
+async function loadRecent() {
+  const recent = await (await fetch(api(`/history/recent?seconds=${trailSeconds}`))).json();
+  if (recent.frame_width > 0) {
+    frameWidth = recent.frame_width;
+    frameHeight = recent.frame_height;
+  }
+  trail = recent.batches.filter((b) => b.detections.length > 0).map((b) => ({ timestamp: b.timestamp_ms, detections: b.detections }));
+  drawDetections();
+}
+
+// Live WebSocket stream
+function connect() {
+  const scheme = location.protocol === "https:" ? "wss" : "ws";
+  const ws = new WebSocket(`${scheme}://${location.host}${api("/ws?trails=1")}`);
+  const badge = document.getElementById("connection");
+
+  ws.onopen = () => {
+    badge.textContent = "online";
+    badge.className = "badge online";
+  };
+  ws.onclose = () => {
+    badge.textContent = "offline";
+    badge.className = "badge offline";
+    setTimeout(connect, 2000);
+  };
+  ws.onmessage = (msg) => {
+    const data = JSON.parse(msg.data);
+    if (data.type === "detections") {
+      if (data.frame_width > 0) {
+        frameWidth = data.frame_width;
+        frameHeight = data.frame_height;
+      }
+      lastDetections = data.detections;
+      lastTrails = data.trails || {};
+      addTrail(Date.now(), data.detections);
+      drawDetections();
+    } else {
+      addEvent(data);
+    }
+  };
+}
+
+function addEvent(event) {
+  const list = document.getElementById("events");
+  const item = document.createElement("li");
+  const time = new Date(event.timestamp * 1000).toLocaleTimeString();
+  item.textContent = `${time} ${event.type}${event.category ? " " + event.category : ""}`;
+  list.prepend(item);
+  while (list.children.length > 50) {
+    list.lastChild.remove();
+  }
+}
+
+// Feedback: click a box, then label it to teach the confidence calibration
+const selection = document.getElementById("selection");
+const falsePositive = document.getElementById("false-positive");
+const truePositive = document.getElementById("true-positive");
+
+function select(detection, text) {
+  selected = detection;
+  selection.textContent = text;
+  falsePositive.disabled = truePositive.disabled = !detection;
+  drawDetections();
+}
+
+function describe(d) {
+  return d.track_id ? `Track ${d.track_id}` : `Detection ${d.id}`;
+}
+
+overlay.addEventListener("click", (e) => {
+  const rect = overlay.getBoundingClientRect();
+  const x = ((e.clientX - rect.left) * frameWidth) / rect.width;
+  const y = ((e.clientY - rect.top) * frameHeight) / rect.height;
+  // The smallest box under the pointer, so nested boxes stay reachable
+  let hit = null;
+  for (const d of lastDetections) {
+    const inside = x >= d.bbox.x && x < d.bbox.x + d.bbox.width && y >= d.bbox.y && y < d.bbox.y + d.bbox.height;
+    if (inside && (!hit || d.bbox.width * d.bbox.height < hit.bbox.width * hit.bbox.height)) {
+      hit = d;
+    }
+  }
+  if (hit) {
+    select(hit, `${describe(hit)}: ${hit.type}, ${(hit.confidence * 100).toFixed(0)}% confident`);
+  } else {
+    select(null, "Click a box to select it");
+  }
+});
+
+async function sendFeedback(correct) {
+  const response = await fetch(api("/feedback"), {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ track_id: selected.track_id, detection_id: selected.id, correct }),
+  });
+  if (response.ok) {
+    select(null, `${describe(selected)} labeled ${correct ? "correct" : "a false positive"}`);
+  } else {
+    selection.textContent = (await response.text()).trim();
+  }
+}
+
+falsePositive.addEventListener("click", () => sendFeedback(false).catch(() => {}));
+truePositive.addEventListener("click", () => sendFeedback(true).catch(() => {}));
+
+// Metrics charts
+class Sparkline {
+  constructor(canvas, color) {
+    this.canvas = canvas;
+    this.ctx = canvas.getContext("2d");
+    this.color = color;
+    this.values = [];
+  }
+
+  push(value) {
+    this.values.push(value);
+    if (this.values.length > 120) {
+      this.values.shift();
+    }
+    this.draw();
+  }
+
+  draw() {
+    const { ctx, canvas, values } = this;
+    ctx.clearRect(0, 0, canvas.width, canvas.height);
+    if (values.length < 2) {
+      return;
+    }
+    const max = Math.max(...values, 1);
+    const step = canvas.width / (values.length - 1);
+    ctx.strokeStyle = this.color;
+    ctx.lineWidth = 2;
+    ctx.beginPath();
+    values.forEach((v, i) => {
+      const y = canvas.height - (v / max) * (canvas.height - 4) - 2;
+      i === 0 ? ctx.moveTo(0, y) : ctx.lineTo(i * step, y);
+    });
+    ctx.stroke();
+  }
+}
+
+const fpsChart = new Sparkline(document.getElementById("fps-chart"), "#4dc3ff");
+const latencyChart = new Sparkline(document.getElementById("latency-chart"), "#ff9f40");
+
+async function pollMetrics() {
+  try {
+    const metrics = await (await fetch(api("/metrics"))).json();
+    const perf = metrics.performance;
+    fpsChart.push(perf.frames_per_sec);
+    latencyChart.push(perf.avg_process_time);
+    document.getElementById("fps-value").textContent = perf.frames_per_sec.toFixed(1);
+    document.getElementById("latency-value").textContent = perf.avg_process_time.toFixed(2);
+  } catch (err) {
+    // Engine unreachable; the WebSocket badge already shows offline
+  }
+}
+
+async function pollStatus() {
+  try {
+    const status = await (await fetch(api("/status"))).json();
+    const list = document.getElementById("status");
+    list.innerHTML = "";
+    for (const [key, value] of Object.entries(status)) {
+      const dt = document.createElement("dt");
+      dt.textContent = key.replace(/_/g, " ");
+      const dd = document.createElement("dd");
+      dd.textContent = typeof value === "number" && !Number.isInteger(value) ? value.toFixed(2) : String(value);
+      list.append(dt, dd);
+    }
+  } catch (err) {
+    // Ignore until the engine is reachable again
+  }
+}
+
+// Sessions
+function formatDuration(seconds) {
+  const m = Math.floor(seconds / 60);
+  return m >= 60 ? `${Math.floor(m / 60)}h ${m % 60}m` : `${m}m ${Math.floor(seconds % 60)}s`;
+}
+
+function sessionTitle(session) {
+  const start = new Date(session.started_at).toLocaleTimeString();
+  return `${start} ${session.world || session.reason.replace(/_/g, " ")}`;
+}
+
+async function pollSessions() {
+  try {
+    const { current, sessions } = await (await fetch(api("/sessions"))).json();
+    const list = document.getElementById("session");
+    list.innerHTML = "";
+    if (current) {
+      const rows = [
+        ["world", current.world || "-"],
+        ["duration", formatDuration(current.duration_seconds)],
+        ["close approaches", current.close_approaches],
+        ["peak count", current.peak_count],
+      ];
+      for (const category of Object.keys(categoryColors)) {
+        if (current.category_seconds[category]) {
+          rows.push([category.toLowerCase(), formatDuration(current.category_seconds[category])]);
+        }
+      }
+      if (current.busiest) {
+        rows.push(["busiest", `${new Date(current.busiest.start).toLocaleTimeString()} (${current.busiest.average_count.toFixed(1)})`]);
+      }
+      for (const [key, value] of rows) {
+        const dt = document.createElement("dt");
+        dt.textContent = key;
+        const dd = document.createElement("dd");
+        dd.textContent = String(value);
+        list.append(dt, dd);
+      }
+    }
+
+    const past = document.getElementById("sessions");
+    past.innerHTML = "";
+    for (const session of sessions.slice(0, 10)) {
+      const item = document.createElement("li");
+      item.textContent = `${sessionTitle(session)}: ${formatDuration(session.duration_seconds)}, ${session.close_approaches} close`;
+      past.appendChild(item);
+    }
+  } catch (err) {
+    // Ignore until the engine is reachable again
+  }
+}
+
+// Controls
+const fpsInput = document.getElementById("fps");
+const sensitivityInput = document.getElementById("sensitivity");
+
+function showSettings(settings) {
+  fpsInput.value = settings.target_fps;
+  sensitivityInput.value = settings.sensitivity;
+  document.getElementById("fps-out").textContent = settings.target_fps;
+  document.getElementById("sensitivity-out").textContent = settings.sensitivity;
+}
+
+async function loadSettings() {
+  showSettings(await (await fetch(api("/config/capture"))).json());
+}
+
+async function saveSettings() {
+  const response = await fetch(api("/config/capture"), {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({
+      target_fps: Number(fpsInput.value),
+      sensitivity: Number(sensitivityInput.value),
+    }),
+  });
+  if (response.ok) {
+    showSettings(await response.json());
+  }
+}
+
+fpsInput.addEventListener("change", saveSettings);
+sensitivityInput.addEventListener("change", saveSettings);
+fpsInput.addEventListener("input", () => (document.getElementById("fps-out").textContent = fpsInput.value));
+sensitivityInput.addEventListener("input", () => (document.getElementById("sensitivity-out").textContent = sensitivityInput.value));
+
+loadCategories().catch(() => {});
+loadRecent().catch(() => {}).finally(connect);
+loadSettings().catch(() => {});
+pollMetrics();
+pollStatus();
+pollSessions();
+setInterval(pollMetrics, 1000);
+setInterval(pollStatus, 2000);
+setInterval(pollSessions, 5000);
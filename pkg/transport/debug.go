@@ -0,0 +1,62 @@
+package transport
+
+import (
+	"net"
+	"net/http"
+	netpprof "net/http/pprof"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+)
+
+// debugPrefix covers pprof and the goroutine dump
+const debugPrefix = "/debug/"
+
+// registerDebug mounts pprof and the goroutine dump on mux
+func registerDebug(mux *http.ServeMux) {
+	mux.HandleFunc(debugPrefix+"goroutines", handleGoroutines)
+	mux.HandleFunc(debugPrefix+"pprof/", netpprof.Index)
+	mux.HandleFunc(debugPrefix+"pprof/cmdline", netpprof.Cmdline)
+	mux.HandleFunc(debugPrefix+"pprof/profile", netpprof.Profile)
+	mux.HandleFunc(debugPrefix+"pprof/symbol", netpprof.Symbol)
+	mux.HandleFunc(debugPrefix+"pprof/trace", netpprof.Trace)
+}
+
+// handleGoroutines writes the stacks of all goroutines as text.
+// ?debug=1 groups identical stacks with counts instead of listing each one.
+func handleGoroutines(w http.ResponseWriter, r *http.Request) {
+	debug := 2
+	if value := r.URL.Query().Get("debug"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 || n > 2 {
+			http.Error(w, "debug must be 0, 1, or 2", http.StatusBadRequest)
+			return
+		}
+		debug = n
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	pprof.Lookup("goroutine").WriteTo(w, debug)
+}
+
+// allowDebug reports whether r may use the debug endpoints: with API keys
+// configured any authenticated caller may, otherwise only loopback clients
+func (s *Security) allowDebug(r *http.Request) bool {
+	if !strings.HasPrefix(r.URL.Path, debugPrefix) {
+		return true
+	}
+	if len(s.config.APIKeys) > 0 {
+		return s.Authorized(r)
+	}
+	return isLoopback(r.RemoteAddr)
+}
+
+// isLoopback reports whether a host:port address is on this machine
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,92 @@
+package transport
+
+import (
+	"encoding/json"
+	"time"
+
+	"vrchat-proximity/pkg/engine"
+)
+
+// deltaMessage is a detections_delta message carrying changes keyed by track ID
+type deltaMessage struct {
+	Type      string             `json:"type"`
+	Seq       int64              `json:"seq"`
+	Timestamp int64              `json:"timestamp"`
+	Keyframe  bool               `json:"keyframe"`
+	Added     []engine.Detection `json:"added,omitempty"`
+	Updated   []engine.Detection `json:"updated,omitempty"`
+	Removed   []int64            `json:"removed,omitempty"`
+}
+
+// keyframeMessage is a detections_delta message carrying the full detection list
+type keyframeMessage struct {
+	Type       string             `json:"type"`
+	Seq        int64              `json:"seq"`
+	Timestamp  int64              `json:"timestamp"`
+	Keyframe   bool               `json:"keyframe"`
+	Detections []engine.Detection `json:"detections"`
+}
+
+// deltaState is what a delta client was last sent. Encoding only happens
+// from the detection hook, so it needs no locking.
+type deltaState struct {
+	tracks map[int64]engine.Detection
+	sent   int // Messages since the last keyframe
+}
+
+// encode returns the next message for the client, or nil when nothing
+// changed. nextSeq is only called for messages that are sent.
+func (d *deltaState) encode(detections []engine.Detection, keyframeEvery int, nextSeq func() int64) []byte {
+	keyframe := d.tracks == nil || d.sent >= keyframeEvery-1
+	for _, det := range detections {
+		if det.TrackID == 0 {
+			// Untracked detections, e.g. injected ones, can't be diffed
+			keyframe = true
+			break
+		}
+	}
+
+	tracks := make(map[int64]engine.Detection, len(detections))
+	for _, det := range detections {
+		tracks[det.TrackID] = det
+	}
+
+	var message interface{}
+	if keyframe {
+		if detections == nil {
+			detections = []engine.Detection{}
+		}
+		message = keyframeMessage{Type: "detections_delta", Seq: nextSeq(), Timestamp: time.Now().Unix(), Keyframe: true, Detections: detections}
+		d.sent = 0
+	} else {
+		delta := deltaMessage{Type: "detections_delta", Timestamp: time.Now().Unix()}
+		for _, det := range detections {
+			previous, ok := d.tracks[det.TrackID]
+			switch {
+			case !ok:
+				delta.Added = append(delta.Added, det)
+			case previous != det:
+				delta.Updated = append(delta.Updated, det)
+			}
+		}
+		for id := range d.tracks {
+			if _, ok := tracks[id]; !ok {
+				delta.Removed = append(delta.Removed, id)
+			}
+		}
+		if len(delta.Added) == 0 && len(delta.Updated) == 0 && len(delta.Removed) == 0 {
+			return nil
+		}
+		delta.Seq = nextSeq()
+		message = delta
+		d.sent++
+	}
+	d.tracks = tracks
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		wsLog.Error("JSON marshal failed", "error", err)
+		return nil
+	}
+	return data
+}
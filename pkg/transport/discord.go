@@ -0,0 +1,361 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"vrchat-proximity/pkg/engine"
+	"vrchat-proximity/pkg/logging"
+)
+
+// discordLog is the "discord" subsystem logger
+var discordLog = logging.For("discord")
+
+// discordPresenceInterval keeps activity updates inside Discord's limit of 5 per 20s
+const discordPresenceInterval = 15 * time.Second
+
+// Discord IPC opcodes
+const (
+	discordOpHandshake = 0
+	discordOpFrame     = 1
+	discordOpClose     = 2
+)
+
+// DiscordConfig configures Discord Rich Presence and session summaries
+type DiscordConfig struct {
+	RichPresence   bool          `yaml:"rich_presence"`
+	ClientID       string        `yaml:"client_id"`       // Discord application ID shown as the activity; required for Rich Presence
+	SummaryWebhook string        `yaml:"summary_webhook"` // Discord webhook URL for end-of-session summaries; empty disables
+	MinSession     time.Duration `yaml:"min_session"`     // Sessions shorter than this are not summarized
+}
+
+// DefaultDiscordConfig summarizes sessions of at least five minutes
+func DefaultDiscordConfig() DiscordConfig {
+	return DiscordConfig{MinSession: 5 * time.Minute}
+}
+
+// discordSession accumulates the stats of one session
+type discordSession struct {
+	start    time.Time
+	location string
+	world    string
+	peak     int
+	closest  float32 // Zero until something was seen
+	events   int     // zone_enter events
+}
+
+// Discord shows a live nearby count as Discord Rich Presence and posts a
+// summary to a Discord webhook when a session ends. A session ends when
+// the instance changes or the engine shuts down.
+type Discord struct {
+	engine *engine.ProximityEngine
+	config DiscordConfig
+	client *http.Client
+
+	mu      sync.Mutex
+	session discordSession
+	nearby  int
+
+	conn net.Conn // Discord IPC connection; nil while disconnected
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewDiscord creates the integration; call Start to begin
+func NewDiscord(pe *engine.ProximityEngine, config DiscordConfig) (*Discord, error) {
+	if config.RichPresence && config.ClientID == "" {
+		return nil, errors.New("discord: rich presence needs client_id, the ID of a Discord application")
+	}
+	d := &Discord{
+		engine: pe,
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	d.session = discordSession{start: time.Now()}
+	return d, nil
+}
+
+// Start begins presence updates and session tracking
+func (d *Discord) Start() {
+	go d.run()
+}
+
+// Stop posts the summary of the current session and clears the presence
+func (d *Discord) Stop() {
+	close(d.stop)
+	<-d.done
+
+	d.mu.Lock()
+	session := d.session
+	d.mu.Unlock()
+	d.summarize(session, time.Now())
+	if d.conn != nil {
+		d.conn.Close()
+	}
+}
+
+// PublishDetections tracks the nearby count, peak crowd, and closest approach
+func (d *Discord) PublishDetections(detections []engine.Detection) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.nearby = len(detections)
+	d.session.peak = max(d.session.peak, len(detections))
+	if nearest, ok := engine.NearestDetection(detections); ok && (d.session.closest == 0 || nearest.Distance < d.session.closest) {
+		d.session.closest = max(nearest.Distance, 0.1)
+	}
+}
+
+// HandleEvent counts zone entries for the summary
+func (d *Discord) HandleEvent(event engine.ProximityEvent) {
+	if event.Type == engine.EventZoneEnter {
+		d.mu.Lock()
+		d.session.events++
+		d.mu.Unlock()
+	}
+}
+
+// run updates the presence and watches for instance changes
+func (d *Discord) run() {
+	defer close(d.done)
+	ticker := time.NewTicker(discordPresenceInterval)
+	defer ticker.Stop()
+	for {
+		d.rollSession()
+		if d.config.RichPresence {
+			if err := d.updatePresence(); err != nil {
+				discordLog.Debug("Rich Presence update failed", "error", err)
+				if d.conn != nil {
+					d.conn.Close()
+					d.conn = nil
+				}
+			}
+		}
+
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// rollSession ends the session when the reported instance changes
+func (d *Discord) rollSession() {
+	instance := d.engine.Instance()
+	now := time.Now()
+
+	d.mu.Lock()
+	previous := d.session
+	if !instance.Known || instance.Location == previous.location {
+		d.mu.Unlock()
+		return
+	}
+	if previous.location == "" {
+		// The first report only names the session that was already running
+		d.session.location, d.session.world = instance.Location, instance.World
+		d.mu.Unlock()
+		return
+	}
+	d.session = discordSession{start: now, location: instance.Location, world: instance.World}
+	d.mu.Unlock()
+	d.summarize(previous, now)
+}
+
+// discordEmbed is a Discord webhook embed
+type discordEmbed struct {
+	Title  string              `json:"title"`
+	Color  int                 `json:"color"`
+	Fields []discordEmbedField `json:"fields"`
+}
+
+type discordEmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+// summarize posts a session summary to the webhook
+func (d *Discord) summarize(session discordSession, end time.Time) {
+	duration := end.Sub(session.start)
+	if d.config.SummaryWebhook == "" || duration < d.config.MinSession {
+		return
+	}
+
+	closest := "nobody came near"
+	if session.closest > 0 {
+		closest = fmt.Sprintf("~%.1fm", session.closest)
+	}
+	title := "VRChat session summary"
+	if session.world != "" {
+		title += ": " + session.world
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"username": "VRChat Proximity",
+		"embeds": []discordEmbed{{
+			Title: title,
+			Color: 0x5865f2,
+			Fields: []discordEmbedField{
+				{Name: "Duration", Value: duration.Round(time.Minute).String(), Inline: true},
+				{Name: "Peak crowd", Value: strconv.Itoa(session.peak), Inline: true},
+				{Name: "Closest approach", Value: closest, Inline: true},
+				{Name: "Zone entries", Value: strconv.Itoa(session.events), Inline: true},
+			},
+		}},
+	})
+	if err != nil {
+		discordLog.Error("Session summary encode failed", "error", err)
+		return
+	}
+
+	resp, err := d.client.Post(d.config.SummaryWebhook, "application/json", bytes.NewReader(body))
+	if err != nil {
+		discordLog.Warn("Session summary failed", "error", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		discordLog.Warn("Session summary rejected", "status", resp.Status)
+		return
+	}
+	discordLog.Info("Session summary posted", "duration", duration.Round(time.Second), "peak", session.peak)
+}
+
+// updatePresence sets the activity to the current nearby count
+func (d *Discord) updatePresence() error {
+	if d.conn == nil {
+		conn, err := dialDiscord(d.config.ClientID)
+		if err != nil {
+			return err
+		}
+		d.conn = conn
+	}
+
+	d.mu.Lock()
+	nearby, session := d.nearby, d.session
+	d.mu.Unlock()
+
+	state := "Nobody nearby"
+	switch {
+	case nearby == 1:
+		state = "1 player nearby"
+	case nearby > 1:
+		state = fmt.Sprintf("%d players nearby", nearby)
+	}
+	activity := map[string]interface{}{
+		"state":      state,
+		"timestamps": map[string]int64{"start": session.start.Unix()},
+	}
+	if session.world != "" {
+		activity["details"] = "In " + session.world
+	}
+	err := discordSend(d.conn, discordOpFrame, map[string]interface{}{
+		"cmd":   "SET_ACTIVITY",
+		"args":  map[string]interface{}{"pid": os.Getpid(), "activity": activity},
+		"nonce": strconv.FormatInt(time.Now().UnixNano(), 36),
+	})
+	if err != nil {
+		return err
+	}
+	// Read the reply so the connection's buffer never fills
+	op, payload, err := discordRead(d.conn)
+	if err == nil && op == discordOpClose {
+		err = fmt.Errorf("discord: connection closed: %s", payload)
+	}
+	return err
+}
+
+// dialDiscord connects to the local Discord client's IPC endpoint and
+// performs the handshake
+func dialDiscord(clientID string) (net.Conn, error) {
+	var lastErr error
+	for i := 0; i < 10; i++ {
+		conn, err := openDiscordIPC(i)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if err := discordSend(conn, discordOpHandshake, map[string]interface{}{"v": 1, "client_id": clientID}); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		op, payload, err := discordRead(conn)
+		if err != nil || op == discordOpClose {
+			conn.Close()
+			return nil, fmt.Errorf("discord: handshake rejected: %s %v", payload, err)
+		}
+		return conn, nil
+	}
+	return nil, fmt.Errorf("discord: client not running: %w", lastErr)
+}
+
+// openDiscordIPC opens discord-ipc-N: a named pipe on Windows and a Unix
+// socket in the runtime or temp directory elsewhere
+func openDiscordIPC(n int) (net.Conn, error) {
+	name := "discord-ipc-" + strconv.Itoa(n)
+	if runtime.GOOS == "windows" {
+		pipe, err := os.OpenFile(`\\.\pipe\`+name, os.O_RDWR, 0)
+		if err != nil {
+			return nil, err
+		}
+		return pipeConn{pipe}, nil
+	}
+	dir := os.TempDir()
+	for _, env := range []string{"XDG_RUNTIME_DIR", "TMPDIR", "TMP", "TEMP"} {
+		if v := os.Getenv(env); v != "" {
+			dir = v
+			break
+		}
+	}
+	return net.DialTimeout("unix", filepath.Join(dir, name), time.Second)
+}
+
+// pipeConn adapts a named pipe file to net.Conn for the IPC helpers
+type pipeConn struct {
+	*os.File
+}
+
+func (pipeConn) LocalAddr() net.Addr  { return nil }
+func (pipeConn) RemoteAddr() net.Addr { return nil }
+
+// discordSend writes one IPC frame
+func discordSend(conn net.Conn, op uint32, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	frame := make([]byte, 8+len(data))
+	binary.LittleEndian.PutUint32(frame[0:], op)
+	binary.LittleEndian.PutUint32(frame[4:], uint32(len(data)))
+	copy(frame[8:], data)
+	conn.SetWriteDeadline(time.Now().Add(2 * time.Second))
+	_, err = conn.Write(frame)
+	return err
+}
+
+// discordRead reads one IPC frame
+func discordRead(conn net.Conn) (uint32, []byte, error) {
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var header [8]byte
+	if _, err := io.ReadFull(conn, header[:]); err != nil {
+		return 0, nil, err
+	}
+	payload := make([]byte, binary.LittleEndian.Uint32(header[4:]))
+	if _, err := io.ReadFull(conn, payload); err != nil {
+		return 0, nil, err
+	}
+	return binary.LittleEndian.Uint32(header[0:]), payload, nil
+}
@@ -0,0 +1,81 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"vrchat-proximity/pkg/engine"
+)
+
+// feedbackRequest labels a recent track, or a single detection by ID
+type feedbackRequest struct {
+	TrackID     int64 `json:"track_id"`
+	DetectionID int64 `json:"detection_id"`
+	Correct     *bool `json:"correct"` // false for a false positive
+}
+
+// feedbackResponse is the stored example, the updated calibration curve of
+// its detection type, and what all the feedback has taught
+type feedbackResponse struct {
+	Example     engine.FeedbackExample  `json:"example"`
+	Calibration []engine.CalibrationBin `json:"calibration"`
+	Tuning      engine.FeedbackTuning   `json:"tuning"`
+}
+
+// handleFeedback labels a track or detection seen in the last couple of
+// minutes as a real person or a false positive (POST), reports what the
+// labels taught (GET), or forgets them (DELETE)
+func (s *Server) handleFeedback(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.engine.FeedbackTuning())
+		return
+	case http.MethodDelete:
+		if err := s.engine.ClearFeedback(); err != nil {
+			wsLog.Warn("Saving feedback failed", "error", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case http.MethodPost:
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req feedbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if (req.TrackID == 0 && req.DetectionID == 0) || req.Correct == nil {
+		http.Error(w, "track_id or detection_id, and correct, are required", http.StatusBadRequest)
+		return
+	}
+
+	example, err := s.engine.Feedback(req.TrackID, req.DetectionID, *req.Correct)
+	switch {
+	case errors.Is(err, engine.ErrUnknownTrack), errors.Is(err, engine.ErrUnknownDetection):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		// The label counts for this run even if it couldn't be saved
+		wsLog.Warn("Saving feedback failed", "error", err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(feedbackResponse{
+		Example:     example,
+		Calibration: s.engine.Calibration()[example.Type],
+		Tuning:      s.engine.FeedbackTuning(),
+	})
+}
+
+// handleCalibration serves the calibration settings and learned curves
+func (s *Server) handleCalibration(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"config": s.engine.CalibrationConfig(),
+		"types":  s.engine.Calibration(),
+	})
+}
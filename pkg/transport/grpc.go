@@ -0,0 +1,204 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"vrchat-proximity/pkg/engine"
+	"vrchat-proximity/pkg/logging"
+	proximityv1 "vrchat-proximity/proto/proximity/v1"
+)
+
+// grpcLog is the "grpc" subsystem logger
+var grpcLog = logging.For("grpc")
+
+//go:generate protoc -I ../../proto --go_out=../../proto --go_opt=paths=source_relative --go-grpc_out=../../proto --go-grpc_opt=paths=source_relative proximity/v1/proximity.proto
+
+// GRPCServer serves the ProximityService API on its own port
+type GRPCServer struct {
+	proximityv1.UnimplementedProximityServiceServer
+
+	engine  *engine.ProximityEngine
+	address string
+	server  *grpc.Server
+
+	streams sync.Map // *grpcStream -> bool
+}
+
+// grpcStream is the fan-out channel of one StreamDetections call
+type grpcStream struct {
+	events     bool
+	detections bool
+	send       chan *proximityv1.StreamDetectionsResponse
+}
+
+// NewGRPCServer creates a gRPC server for the engine
+func NewGRPCServer(pe *engine.ProximityEngine, address string) *GRPCServer {
+	s := &GRPCServer{
+		engine:  pe,
+		address: address,
+		server:  grpc.NewServer(),
+	}
+	proximityv1.RegisterProximityServiceServer(s.server, s)
+
+	pe.Subscribe("grpc", engine.BusBuffer, engine.Subscriber{Detections: s.publishDetections, Event: s.publishEvent})
+	return s
+}
+
+// Start listens and serves in the background
+func (s *GRPCServer) Start() error {
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return fmt.Errorf("listen gRPC: %w", err)
+	}
+
+	grpcLog.Info("gRPC server starting", "addr", listener.Addr().String())
+	go func() {
+		if err := s.server.Serve(listener); err != nil {
+			grpcLog.Error("gRPC server failed", "error", err)
+		}
+	}()
+	return nil
+}
+
+// Stop ends all streams and stops the server
+func (s *GRPCServer) Stop() {
+	s.server.GracefulStop()
+}
+
+// StreamDetections streams detection batches and events until the client disconnects
+func (s *GRPCServer) StreamDetections(req *proximityv1.StreamDetectionsRequest, stream proximityv1.ProximityService_StreamDetectionsServer) error {
+	sub := &grpcStream{
+		events:     req.IncludeEvents || req.EventsOnly,
+		detections: !req.EventsOnly,
+		send:       make(chan *proximityv1.StreamDetectionsResponse, 64),
+	}
+	s.streams.Store(sub, true)
+	defer s.streams.Delete(sub)
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case msg := <-sub.send:
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// GetStatus returns engine state and counters
+func (s *GRPCServer) GetStatus(ctx context.Context, req *proximityv1.GetStatusRequest) (*proximityv1.GetStatusResponse, error) {
+	st := s.engine.Status()
+	return &proximityv1.GetStatusResponse{
+		Running:           st.Running,
+		FramesProcessed:   st.FramesProcessed,
+		TotalDetections:   st.TotalDetections,
+		CurrentDetections: int32(st.CurrentDetections),
+		AvgProcessTimeMs:  st.AvgProcessTimeMS,
+		TargetFps:         int32(st.TargetFPS),
+		Sensitivity:       int32(st.Sensitivity),
+		FrameWidth:        int32(st.FrameWidth),
+		FrameHeight:       int32(st.FrameHeight),
+	}, nil
+}
+
+// Configure updates FPS and sensitivity
+func (s *GRPCServer) Configure(ctx context.Context, req *proximityv1.ConfigureRequest) (*proximityv1.ConfigureResponse, error) {
+	if req.TargetFps != nil {
+		if *req.TargetFps < 1 || *req.TargetFps > 120 {
+			return nil, status.Error(codes.InvalidArgument, "target_fps must be between 1 and 120")
+		}
+		s.engine.SetTargetFPS(int(*req.TargetFps))
+	}
+	if req.Sensitivity != nil {
+		if *req.Sensitivity < 1 || *req.Sensitivity > 100 {
+			return nil, status.Error(codes.InvalidArgument, "sensitivity must be between 1 and 100")
+		}
+		s.engine.SetSensitivity(int(*req.Sensitivity))
+	}
+
+	return &proximityv1.ConfigureResponse{
+		TargetFps:   int32(s.engine.TargetFPS()),
+		Sensitivity: int32(s.engine.Sensitivity()),
+	}, nil
+}
+
+// publishDetections fans a detection batch out to streaming clients
+func (s *GRPCServer) publishDetections(detections []engine.Detection) {
+	st := s.engine.Status()
+	batch := &proximityv1.DetectionBatch{
+		Timestamp:   time.Now().Unix(),
+		FrameCount:  st.FramesProcessed,
+		FrameWidth:  int32(st.FrameWidth),
+		FrameHeight: int32(st.FrameHeight),
+		Detections:  make([]*proximityv1.Detection, len(detections)),
+	}
+	for i := range detections {
+		batch.Detections[i] = detectionToProto(&detections[i])
+	}
+
+	msg := &proximityv1.StreamDetectionsResponse{
+		Payload: &proximityv1.StreamDetectionsResponse_Detections{Detections: batch},
+	}
+	s.fanOut(msg, func(sub *grpcStream) bool { return sub.detections })
+}
+
+// publishEvent fans an event out to streaming clients that asked for events
+func (s *GRPCServer) publishEvent(event engine.ProximityEvent) {
+	pb := &proximityv1.ProximityEvent{
+		Type:      event.Type,
+		Timestamp: event.Timestamp,
+		Category:  event.Category,
+		Previous:  event.Previous,
+		Distance:  event.Distance,
+	}
+	if event.Detection != nil {
+		pb.Detection = detectionToProto(event.Detection)
+	}
+
+	msg := &proximityv1.StreamDetectionsResponse{
+		Payload: &proximityv1.StreamDetectionsResponse_Event{Event: pb},
+	}
+	s.fanOut(msg, func(sub *grpcStream) bool { return sub.events })
+}
+
+// fanOut delivers msg to matching streams, skipping those that are behind
+func (s *GRPCServer) fanOut(msg *proximityv1.StreamDetectionsResponse, wants func(*grpcStream) bool) {
+	s.streams.Range(func(key, _ interface{}) bool {
+		sub := key.(*grpcStream)
+		if wants(sub) {
+			select {
+			case sub.send <- msg:
+			default:
+				// Slow stream; drop this message rather than block the pipeline
+			}
+		}
+		return true
+	})
+}
+
+// detectionToProto converts a Detection to its protobuf form
+func detectionToProto(d *engine.Detection) *proximityv1.Detection {
+	return &proximityv1.Detection{
+		Bbox: &proximityv1.BoundingBox{
+			X:      d.BBox.X,
+			Y:      d.BBox.Y,
+			Width:  d.BBox.Width,
+			Height: d.BBox.Height,
+		},
+		Confidence: d.Confidence,
+		Type:       d.Type,
+		Area:       d.Area,
+		Distance:   d.Distance,
+		Category:   d.Category,
+	}
+}
@@ -0,0 +1,270 @@
+package transport
+
+import (
+	"encoding/json"
+	"math"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"vrchat-proximity/pkg/engine"
+	"vrchat-proximity/pkg/logging"
+)
+
+// hapticsLog is the "haptics" subsystem logger
+var hapticsLog = logging.For("haptics")
+
+// HapticCurve maps distance within a category to a motor intensity
+type HapticCurve struct {
+	MinIntensity float64 `json:"min_intensity" yaml:"min_intensity"` // 0-100, at engine.MaxEstimatedDistance
+	MaxIntensity float64 `json:"max_intensity" yaml:"max_intensity"` // 0-100, at distance 0
+	Exponent     float64 `json:"exponent" yaml:"exponent"`           // >1 ramps up late, <1 ramps up early
+}
+
+// Intensity evaluates the curve for a distance in meters
+func (c HapticCurve) Intensity(distance float32) int {
+	closeness := 1 - math.Min(float64(distance)/engine.MaxEstimatedDistance, 1)
+	exp := c.Exponent
+	if exp <= 0 {
+		exp = 1
+	}
+	v := c.MinIntensity + (c.MaxIntensity-c.MinIntensity)*math.Pow(closeness, exp)
+	return int(math.Max(0, math.Min(100, v)))
+}
+
+// HapticsConfig configures the bHaptics output
+type HapticsConfig struct {
+	Enabled   bool                   `yaml:"enabled"`
+	PlayerURL string                 `yaml:"player_url"` // bHaptics Player WebSocket endpoint
+	AppID     string                 `yaml:"app_id"`
+	AppName   string                 `yaml:"app_name"`
+	Positions []string               `yaml:"positions"` // bHaptics device positions, e.g. VestFront, ForearmL
+	Duration  time.Duration          `yaml:"duration"`
+	Curves    map[string]HapticCurve `yaml:"curves"` // Keyed by distance category
+}
+
+// DefaultHapticsConfig returns a config that only buzzes for close detections
+func DefaultHapticsConfig() HapticsConfig {
+	return HapticsConfig{
+		PlayerURL: "ws://127.0.0.1:15881/v2/feedbacks",
+		AppID:     "vrchat-proximity",
+		AppName:   "VRChat Proximity",
+		Positions: []string{"VestFront", "ForearmL", "ForearmR"},
+		Duration:  200 * time.Millisecond,
+		Curves: map[string]HapticCurve{
+			"Very Close": {MinIntensity: 60, MaxIntensity: 100, Exponent: 1},
+			"Close":      {MinIntensity: 20, MaxIntensity: 60, Exponent: 2},
+		},
+	}
+}
+
+// hapticDotCounts is the number of motors per bHaptics position
+var hapticDotCounts = map[string]int{
+	"VestFront": 20,
+	"VestBack":  20,
+	"Head":      6,
+	"ForearmL":  3,
+	"ForearmR":  3,
+	"HandL":     3,
+	"HandR":     3,
+	"FootL":     3,
+	"FootR":     3,
+}
+
+// bHaptics Player v2 request structures
+type hapticDotPoint struct {
+	Index     int `json:"Index"`
+	Intensity int `json:"Intensity"`
+}
+
+type hapticFrame struct {
+	DurationMillis int              `json:"DurationMillis"`
+	Position       string           `json:"Position"`
+	DotPoints      []hapticDotPoint `json:"DotPoints"`
+	PathPoints     []interface{}    `json:"PathPoints"`
+}
+
+type hapticSubmit struct {
+	Type  string      `json:"Type"`
+	Key   string      `json:"Key"`
+	Frame hapticFrame `json:"Frame"`
+}
+
+type hapticRequest struct {
+	Submit []hapticSubmit `json:"Submit"`
+}
+
+// HapticsOutput drives bHaptics devices from detection batches
+type HapticsOutput struct {
+	config HapticsConfig
+
+	conn     *websocket.Conn
+	connMu   sync.Mutex
+	lastSend time.Time
+	stop     chan struct{}
+}
+
+// NewHapticsOutput creates a haptics output with the given config
+func NewHapticsOutput(config HapticsConfig) *HapticsOutput {
+	return &HapticsOutput{
+		config: config,
+		stop:   make(chan struct{}),
+	}
+}
+
+// Start connects to the bHaptics Player and keeps the connection alive
+func (h *HapticsOutput) Start() {
+	go h.connectLoop()
+}
+
+// Stop closes the bHaptics connection
+func (h *HapticsOutput) Stop() {
+	close(h.stop)
+	h.connMu.Lock()
+	if h.conn != nil {
+		h.conn.Close()
+		h.conn = nil
+	}
+	h.connMu.Unlock()
+}
+
+// connectLoop reconnects to the bHaptics Player whenever the connection drops
+func (h *HapticsOutput) connectLoop() {
+	query := url.Values{"app_id": {h.config.AppID}, "app_name": {h.config.AppName}}
+	playerURL := h.config.PlayerURL + "?" + query.Encode()
+	backoff := time.Second
+
+	for {
+		conn, _, err := websocket.DefaultDialer.Dial(playerURL, nil)
+		if err == nil {
+			hapticsLog.Info("Connected to bHaptics Player")
+			backoff = time.Second
+			h.connMu.Lock()
+			h.conn = conn
+			h.connMu.Unlock()
+
+			// Block until the player closes the connection
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					break
+				}
+			}
+
+			h.connMu.Lock()
+			h.conn = nil
+			h.connMu.Unlock()
+			hapticsLog.Warn("bHaptics Player disconnected")
+		}
+
+		select {
+		case <-h.stop:
+			return
+		case <-time.After(backoff):
+		}
+		if backoff < 30*time.Second {
+			backoff *= 2
+		}
+	}
+}
+
+// PublishDetections plays feedback for the nearest detection
+func (h *HapticsOutput) PublishDetections(detections []engine.Detection) {
+	nearest, ok := engine.NearestDetection(detections)
+	if !ok {
+		return
+	}
+	curve, ok := h.config.Curves[nearest.Category]
+	if !ok {
+		return
+	}
+	intensity := curve.Intensity(nearest.Distance)
+	if intensity == 0 {
+		return
+	}
+
+	h.connMu.Lock()
+	defer h.connMu.Unlock()
+	if h.conn == nil || time.Now().Before(h.lastSend.Add(h.config.Duration)) {
+		return
+	}
+	h.lastSend = time.Now()
+	h.send(intensity)
+}
+
+// HandleEvent plays the custom pattern a player rule attached to a
+// zone_enter or fast_approach event, or a rule event's haptic action,
+// holding off curve feedback until it ends
+func (h *HapticsOutput) HandleEvent(event engine.ProximityEvent) {
+	pattern := event.Haptic
+	switch {
+	case pattern == nil:
+		return
+	case event.Type != engine.EventZoneEnter && event.Type != engine.EventFastApproach && event.Type != engine.EventRule:
+		return
+	}
+	gap := time.Duration(pattern.GapMS) * time.Millisecond
+
+	h.connMu.Lock()
+	if h.conn == nil {
+		h.connMu.Unlock()
+		return
+	}
+	// lastSend in the future blocks PublishDetections for the whole pattern
+	length := time.Duration(pattern.Pulses)*(h.config.Duration+gap) - gap
+	h.lastSend = time.Now().Add(length)
+	h.connMu.Unlock()
+
+	go func() {
+		for i := 0; i < pattern.Pulses; i++ {
+			if i > 0 {
+				select {
+				case <-h.stop:
+					return
+				case <-time.After(h.config.Duration + gap):
+				}
+			}
+			h.connMu.Lock()
+			if h.conn != nil {
+				h.send(pattern.Intensity)
+			}
+			h.connMu.Unlock()
+		}
+	}()
+}
+
+// send writes one frame at intensity; the caller holds connMu
+func (h *HapticsOutput) send(intensity int) {
+	data, err := json.Marshal(h.buildRequest(intensity))
+	if err != nil {
+		hapticsLog.Error("Haptics marshal failed", "error", err)
+		return
+	}
+	h.conn.SetWriteDeadline(time.Now().Add(time.Second))
+	if err := h.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+		hapticsLog.Warn("Haptics send failed", "error", err)
+	}
+}
+
+// buildRequest creates a frame submission lighting every motor on each position
+func (h *HapticsOutput) buildRequest(intensity int) hapticRequest {
+	var req hapticRequest
+	for _, position := range h.config.Positions {
+		dots := make([]hapticDotPoint, hapticDotCounts[position])
+		for i := range dots {
+			dots[i] = hapticDotPoint{Index: i, Intensity: intensity}
+		}
+		req.Submit = append(req.Submit, hapticSubmit{
+			Type: "frame",
+			Key:  "proximity_" + position,
+			Frame: hapticFrame{
+				DurationMillis: int(h.config.Duration.Milliseconds()),
+				Position:       position,
+				DotPoints:      dots,
+				PathPoints:     []interface{}{},
+			},
+		})
+	}
+	return req
+}
@@ -0,0 +1,25 @@
+package transport
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// handleHealthz serves GET /healthz, answering whenever the process is alive
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "state": s.engine.State()})
+}
+
+// handleReadyz serves GET /readyz: 200 while the engine is running and
+// capturing frames, otherwise 503 with the reason for each failed check
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	failures := s.engine.Ready()
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if len(failures) > 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"ready": len(failures) == 0, "failures": failures})
+}
@@ -0,0 +1,143 @@
+package transport
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"net/http"
+	"strconv"
+
+	"vrchat-proximity/pkg/engine"
+)
+
+// Heatmap image limits
+const (
+	defaultHeatmapWidth = 640
+	maxHeatmapWidth     = 1920
+)
+
+// handleHeatmap renders where detections appeared in a session as a PNG:
+// ?session=<id> picks a finished session (the current one by default),
+// ?width= sets the image width, and ?transparent=1 leaves cold cells clear
+// for laying the image over a screenshot
+func (s *Server) handleHeatmap(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	id, width := 0, defaultHeatmapWidth
+	if v := query.Get("session"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "session must be a session id", http.StatusBadRequest)
+			return
+		}
+		id = n
+	}
+	if v := query.Get("width"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 16 || n > maxHeatmapWidth {
+			http.Error(w, "width must be between 16 and "+strconv.Itoa(maxHeatmapWidth), http.StatusBadRequest)
+			return
+		}
+		width = n
+	}
+
+	heat, ok := s.engine.SessionHeatmap(id)
+	if !ok {
+		http.Error(w, "no such session", http.StatusNotFound)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, renderHeatmap(heat, width, query.Get("transparent") == "1")); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(buf.Bytes())
+}
+
+// renderHeatmap smooths the grid, scales it up bilinearly, and colors it
+// from dark blue through red to yellow. Counts are compressed with a square
+// root so a few busy spots don't wash out the rest.
+func renderHeatmap(heat engine.Heatmap, width int, transparent bool) *image.RGBA {
+	smooth := blurHeatmap(heat)
+	peak := 0.0
+	for _, v := range smooth {
+		peak = math.Max(peak, v)
+	}
+
+	height := max(1, width*heat.Rows/heat.Columns)
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		gy := (float64(y)+0.5)/float64(height)*float64(heat.Rows) - 0.5
+		for x := 0; x < width; x++ {
+			gx := (float64(x)+0.5)/float64(width)*float64(heat.Columns) - 0.5
+			v := 0.0
+			if peak > 0 {
+				v = math.Sqrt(sampleHeatmap(smooth, heat.Columns, heat.Rows, gx, gy) / peak)
+			}
+			img.SetRGBA(x, y, heatColor(v, transparent))
+		}
+	}
+	return img
+}
+
+// blurHeatmap applies a 3x3 box blur so single hits read as spots
+func blurHeatmap(heat engine.Heatmap) []float64 {
+	out := make([]float64, len(heat.Cells))
+	for row := 0; row < heat.Rows; row++ {
+		for col := 0; col < heat.Columns; col++ {
+			sum, n := 0.0, 0
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					r, c := row+dy, col+dx
+					if r >= 0 && r < heat.Rows && c >= 0 && c < heat.Columns {
+						sum += float64(heat.Cells[r*heat.Columns+c])
+						n++
+					}
+				}
+			}
+			out[row*heat.Columns+col] = sum / float64(n)
+		}
+	}
+	return out
+}
+
+// sampleHeatmap interpolates the grid at fractional cell coordinates
+func sampleHeatmap(cells []float64, columns, rows int, x, y float64) float64 {
+	x = math.Max(0, math.Min(x, float64(columns-1)))
+	y = math.Max(0, math.Min(y, float64(rows-1)))
+	x0, y0 := int(x), int(y)
+	x1, y1 := min(x0+1, columns-1), min(y0+1, rows-1)
+	fx, fy := x-float64(x0), y-float64(y0)
+	top := cells[y0*columns+x0]*(1-fx) + cells[y0*columns+x1]*fx
+	bottom := cells[y1*columns+x0]*(1-fx) + cells[y1*columns+x1]*fx
+	return top*(1-fy) + bottom*fy
+}
+
+// heatStops is the color ramp from cold to hot
+var heatStops = []color.RGBA{
+	{10, 14, 40, 255},
+	{30, 60, 200, 255},
+	{220, 40, 40, 255},
+	{255, 230, 60, 255},
+}
+
+// heatColor maps 0-1 onto heatStops; transparent fades the cold end out
+func heatColor(v float64, transparent bool) color.RGBA {
+	v = math.Max(0, math.Min(v, 1))
+	pos := v * float64(len(heatStops)-1)
+	i := min(int(pos), len(heatStops)-2)
+	f := pos - float64(i)
+	a, b := heatStops[i], heatStops[i+1]
+	lerp := func(p, q uint8) uint8 { return uint8(float64(p)*(1-f) + float64(q)*f) }
+	c := color.RGBA{lerp(a.R, b.R), lerp(a.G, b.G), lerp(a.B, b.B), 255}
+	if transparent {
+		// Premultiplied alpha, as image.RGBA stores it
+		alpha := math.Min(1, v*1.5)
+		c = color.RGBA{uint8(float64(c.R) * alpha), uint8(float64(c.G) * alpha), uint8(float64(c.B) * alpha), uint8(255 * alpha)}
+	}
+	return c
+}
@@ -0,0 +1,406 @@
+package transport
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"vrchat-proximity/pkg/engine"
+	"vrchat-proximity/pkg/logging"
+)
+
+// intifaceLog is the "intiface" subsystem logger
+var intifaceLog = logging.For("intiface")
+
+// IntifaceConfig configures vibration of Buttplug devices through Intiface Central
+type IntifaceConfig struct {
+	Enabled  bool               `yaml:"enabled"`
+	URL      string             `yaml:"url"`      // Intiface server WebSocket address
+	Scan     bool               `yaml:"scan"`     // Scan for devices after connecting
+	Levels   map[string]float64 `yaml:"levels"`   // Vibration 0-1 by distance category of the nearest detection
+	Caps     map[string]float64 `yaml:"caps"`     // Highest vibration 0-1 by device name; others may reach 1
+	Interval time.Duration      `yaml:"interval"` // Shortest time between level changes
+}
+
+// DefaultIntifaceConfig vibrates from Medium inward against a local Intiface Central
+func DefaultIntifaceConfig() IntifaceConfig {
+	return IntifaceConfig{
+		URL:  "ws://127.0.0.1:12345",
+		Scan: true,
+		Levels: map[string]float64{
+			"Very Close": 0.8,
+			"Close":      0.5,
+			"Medium":     0.2,
+		},
+		Interval: 200 * time.Millisecond,
+	}
+}
+
+// Validate checks the levels and caps
+func (c IntifaceConfig) Validate() error {
+	for category, level := range c.Levels {
+		if level < 0 || level > 1 {
+			return fmt.Errorf("levels[%s] must be between 0 and 1", category)
+		}
+	}
+	for device, limit := range c.Caps {
+		if limit < 0 || limit > 1 {
+			return fmt.Errorf("caps[%s] must be between 0 and 1", device)
+		}
+	}
+	return nil
+}
+
+// intifaceDevice is a connected device with vibrating actuators
+type intifaceDevice struct {
+	Index     int     `json:"index"`
+	Name      string  `json:"name"`
+	Vibrators []int   `json:"-"` // ScalarCmd actuator indexes of type Vibrate
+	Cap       float64 `json:"cap"`
+}
+
+// intifaceStatus is the body of /intiface
+type intifaceStatus struct {
+	Connected bool             `json:"connected"`
+	Stopped   bool             `json:"stopped"` // Emergency stop is latched
+	Level     float64          `json:"level"`
+	Devices   []intifaceDevice `json:"devices"`
+}
+
+// Intiface maps the nearest detection's category to a vibration level on
+// every device connected to an Intiface (Buttplug) server. An emergency
+// stop halts all devices and keeps them still until resumed.
+type Intiface struct {
+	config IntifaceConfig
+	ids    atomic.Uint32 // Buttplug message IDs; 0 is reserved for server events
+
+	mu         sync.Mutex
+	conn       *websocket.Conn
+	writeMu    sync.Mutex
+	devices    map[int]*intifaceDevice
+	level      float64 // Last level sent
+	lastChange time.Time
+	stopped    bool
+	stop       chan struct{}
+}
+
+// NewIntiface creates the output; call Start to connect
+func NewIntiface(config IntifaceConfig) *Intiface {
+	return &Intiface{config: config, devices: make(map[int]*intifaceDevice), stop: make(chan struct{})}
+}
+
+// Start connects to the Intiface server and reconnects when it drops
+func (i *Intiface) Start() {
+	go i.connectLoop()
+}
+
+// Stop halts every device and closes the connection
+func (i *Intiface) Stop() {
+	close(i.stop)
+	i.mu.Lock()
+	conn := i.conn
+	i.conn = nil
+	i.mu.Unlock()
+	if conn != nil {
+		i.send(conn, "StopAllDevices", map[string]interface{}{})
+		conn.Close()
+	}
+}
+
+// RegisterHandlers mounts the status and emergency stop endpoints
+func (i *Intiface) RegisterHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/intiface", i.handleStatus)
+	mux.HandleFunc("/intiface/stop", i.handleControl(i.EmergencyStop))
+	mux.HandleFunc("/intiface/resume", i.handleControl(i.Resume))
+}
+
+// EmergencyStop halts every device and ignores detections until Resume
+func (i *Intiface) EmergencyStop() {
+	i.mu.Lock()
+	i.stopped, i.level = true, 0
+	conn := i.conn
+	i.mu.Unlock()
+	intifaceLog.Warn("Emergency stop")
+	if conn != nil {
+		i.send(conn, "StopAllDevices", map[string]interface{}{})
+	}
+}
+
+// Resume lifts an emergency stop
+func (i *Intiface) Resume() {
+	i.mu.Lock()
+	i.stopped = false
+	i.mu.Unlock()
+	intifaceLog.Info("Emergency stop lifted")
+}
+
+// connectLoop runs one connection at a time, backing off between attempts
+func (i *Intiface) connectLoop() {
+	backoff := time.Second
+	for {
+		conn, _, err := websocket.DefaultDialer.Dial(i.config.URL, nil)
+		if err == nil {
+			backoff = time.Second
+			i.session(conn)
+		} else {
+			intifaceLog.Debug("Intiface connect failed", "url", i.config.URL, "error", err)
+		}
+
+		select {
+		case <-i.stop:
+			return
+		case <-time.After(backoff):
+		}
+		if backoff < 30*time.Second {
+			backoff *= 2
+		}
+	}
+}
+
+// buttplugMessage is one message of a Buttplug v3 packet, keyed by type
+type buttplugMessage map[string]json.RawMessage
+
+// buttplugDevice is a device in DeviceList and DeviceAdded
+type buttplugDevice struct {
+	DeviceIndex    int    `json:"DeviceIndex"`
+	DeviceName     string `json:"DeviceName"`
+	DeviceMessages struct {
+		ScalarCmd []struct {
+			ActuatorType string `json:"ActuatorType"`
+		} `json:"ScalarCmd"`
+	} `json:"DeviceMessages"`
+}
+
+// session performs the handshake and reads server messages until the connection drops
+func (i *Intiface) session(conn *websocket.Conn) {
+	defer conn.Close()
+	i.send(conn, "RequestServerInfo", map[string]interface{}{"ClientName": "VRChat Proximity", "MessageVersion": 3})
+	var info struct {
+		ServerName  string `json:"ServerName"`
+		MaxPingTime int    `json:"MaxPingTime"` // Milliseconds; 0 disables the ping requirement
+	}
+	if err := i.expect(conn, "ServerInfo", &info); err != nil {
+		intifaceLog.Warn("Intiface handshake failed", "error", err)
+		return
+	}
+	intifaceLog.Info("Connected to Intiface", "server", info.ServerName)
+
+	i.mu.Lock()
+	i.conn, i.level = conn, 0
+	i.devices = make(map[int]*intifaceDevice)
+	i.mu.Unlock()
+	defer func() {
+		i.mu.Lock()
+		if i.conn == conn {
+			i.conn = nil
+		}
+		i.mu.Unlock()
+	}()
+
+	done := make(chan struct{})
+	defer close(done)
+	if info.MaxPingTime > 0 {
+		go i.ping(conn, time.Duration(info.MaxPingTime)*time.Millisecond/2, done)
+	}
+	if i.config.Scan {
+		i.send(conn, "StartScanning", map[string]interface{}{})
+	}
+	i.send(conn, "RequestDeviceList", map[string]interface{}{})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			select {
+			case <-i.stop:
+			default:
+				intifaceLog.Warn("Intiface disconnected", "error", err)
+			}
+			return
+		}
+		var packet []buttplugMessage
+		if err := json.Unmarshal(data, &packet); err != nil {
+			continue
+		}
+		for _, msg := range packet {
+			i.handleMessage(msg)
+		}
+	}
+}
+
+// expect reads one packet and decodes the message of the given type
+func (i *Intiface) expect(conn *websocket.Conn, kind string, out interface{}) error {
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	defer conn.SetReadDeadline(time.Time{})
+	var packet []buttplugMessage
+	if err := conn.ReadJSON(&packet); err != nil {
+		return err
+	}
+	for _, msg := range packet {
+		if body, ok := msg[kind]; ok {
+			return json.Unmarshal(body, out)
+		}
+		if body, ok := msg["Error"]; ok {
+			return fmt.Errorf("intiface: %s", body)
+		}
+	}
+	return fmt.Errorf("intiface: expected %s", kind)
+}
+
+// ping keeps the connection alive within the server's MaxPingTime
+func (i *Intiface) ping(conn *websocket.Conn, interval time.Duration, done chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			i.send(conn, "Ping", map[string]interface{}{})
+		}
+	}
+}
+
+// handleMessage tracks devices coming and going
+func (i *Intiface) handleMessage(msg buttplugMessage) {
+	switch {
+	case msg["DeviceList"] != nil:
+		var list struct {
+			Devices []buttplugDevice `json:"Devices"`
+		}
+		if json.Unmarshal(msg["DeviceList"], &list) == nil {
+			for _, d := range list.Devices {
+				i.addDevice(d)
+			}
+		}
+	case msg["DeviceAdded"] != nil:
+		var d buttplugDevice
+		if json.Unmarshal(msg["DeviceAdded"], &d) == nil {
+			i.addDevice(d)
+		}
+	case msg["DeviceRemoved"] != nil:
+		var d buttplugDevice
+		if json.Unmarshal(msg["DeviceRemoved"], &d) == nil {
+			i.mu.Lock()
+			delete(i.devices, d.DeviceIndex)
+			i.mu.Unlock()
+			intifaceLog.Info("Device removed", "index", d.DeviceIndex)
+		}
+	case msg["Error"] != nil:
+		intifaceLog.Warn("Intiface error", "error", string(msg["Error"]))
+	}
+}
+
+// addDevice records a device that has vibrators
+func (i *Intiface) addDevice(d buttplugDevice) {
+	device := &intifaceDevice{Index: d.DeviceIndex, Name: d.DeviceName, Cap: 1}
+	for index, actuator := range d.DeviceMessages.ScalarCmd {
+		if actuator.ActuatorType == "Vibrate" {
+			device.Vibrators = append(device.Vibrators, index)
+		}
+	}
+	if len(device.Vibrators) == 0 {
+		return
+	}
+	for name, limit := range i.config.Caps {
+		if strings.EqualFold(name, d.DeviceName) {
+			device.Cap = limit
+		}
+	}
+
+	i.mu.Lock()
+	i.devices[d.DeviceIndex] = device
+	level, conn := i.level, i.conn
+	i.mu.Unlock()
+	intifaceLog.Info("Device added", "index", d.DeviceIndex, "name", d.DeviceName, "vibrators", len(device.Vibrators), "cap", device.Cap)
+	if conn != nil && level > 0 {
+		i.vibrate(conn, device, level)
+	}
+}
+
+// PublishDetections sets every device to the level of the nearest detection's category
+func (i *Intiface) PublishDetections(detections []engine.Detection) {
+	level := 0.0
+	if nearest, ok := engine.NearestDetection(detections); ok {
+		level = i.config.Levels[nearest.Category]
+	}
+
+	i.mu.Lock()
+	now := time.Now()
+	if i.conn == nil || i.stopped || level == i.level || now.Sub(i.lastChange) < i.config.Interval {
+		i.mu.Unlock()
+		return
+	}
+	i.level, i.lastChange = level, now
+	conn := i.conn
+	devices := make([]*intifaceDevice, 0, len(i.devices))
+	for _, d := range i.devices {
+		devices = append(devices, d)
+	}
+	i.mu.Unlock()
+
+	for _, d := range devices {
+		i.vibrate(conn, d, level)
+	}
+}
+
+// vibrate sets a device's vibrators to level, limited by the device's cap
+func (i *Intiface) vibrate(conn *websocket.Conn, d *intifaceDevice, level float64) {
+	scalars := make([]map[string]interface{}, len(d.Vibrators))
+	for n, index := range d.Vibrators {
+		scalars[n] = map[string]interface{}{"Index": index, "Scalar": min(level, d.Cap), "ActuatorType": "Vibrate"}
+	}
+	i.send(conn, "ScalarCmd", map[string]interface{}{"DeviceIndex": d.Index, "Scalars": scalars})
+}
+
+// send writes one message with a fresh ID
+func (i *Intiface) send(conn *websocket.Conn, kind string, fields map[string]interface{}) {
+	fields["Id"] = i.ids.Add(1)
+	i.writeMu.Lock()
+	defer i.writeMu.Unlock()
+	conn.SetWriteDeadline(time.Now().Add(time.Second))
+	if err := conn.WriteJSON([]map[string]interface{}{{kind: fields}}); err != nil {
+		intifaceLog.Warn("Intiface send failed", "message", kind, "error", err)
+	}
+}
+
+// status reports the connection, stop latch, and devices
+func (i *Intiface) status() intifaceStatus {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	status := intifaceStatus{Connected: i.conn != nil, Stopped: i.stopped, Level: i.level, Devices: []intifaceDevice{}}
+	for _, d := range i.devices {
+		status.Devices = append(status.Devices, *d)
+	}
+	sort.Slice(status.Devices, func(a, b int) bool { return status.Devices[a].Index < status.Devices[b].Index })
+	return status
+}
+
+// handleStatus serves GET /intiface
+func (i *Intiface) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(i.status())
+}
+
+// handleControl serves POST /intiface/stop and /intiface/resume
+func (i *Intiface) handleControl(action func()) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		action()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(i.status())
+	}
+}
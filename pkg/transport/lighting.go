@@ -0,0 +1,274 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"vrchat-proximity/pkg/engine"
+	"vrchat-proximity/pkg/logging"
+)
+
+// lightingLog is the "lighting" subsystem logger
+var lightingLog = logging.For("lighting")
+
+// Lighting drivers
+const (
+	LightingWLED = "wled"
+	LightingHue  = "hue"
+)
+
+// LightingConfig configures ambient lighting driven by proximity
+type LightingConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Driver  string `yaml:"driver"` // wled or hue
+
+	WLEDURL   string   `yaml:"wled_url"`   // WLED device, e.g. http://wled.local
+	HueBridge string   `yaml:"hue_bridge"` // Hue bridge address
+	HueUser   string   `yaml:"hue_user"`   // Hue API username from pressing the link button
+	HueLights []string `yaml:"hue_lights"` // Light IDs; empty drives hue_group instead
+	HueGroup  string   `yaml:"hue_group"`  // Group (room) ID, 0 for all lights
+
+	Colors        map[string]string `yaml:"colors"`         // #rrggbb by distance category of the nearest detection
+	IdleColor     string            `yaml:"idle_color"`     // #rrggbb with nobody around
+	MinBrightness int               `yaml:"min_brightness"` // Percent, with one detection or none
+	MaxBrightness int               `yaml:"max_brightness"` // Percent, at the crowd's crowded_count
+	Interval      time.Duration     `yaml:"interval"`       // Between updates; Hue bridges allow about ten a second in total
+}
+
+// DefaultLightingConfig fades from blue to red as people come closer
+func DefaultLightingConfig() LightingConfig {
+	return LightingConfig{
+		Driver:   LightingWLED,
+		WLEDURL:  "http://wled.local",
+		HueGroup: "0",
+		Colors: map[string]string{
+			"Very Close": "#ff0000",
+			"Close":      "#ff6000",
+			"Medium":     "#ffc000",
+			"Far":        "#40ff40",
+			"Very Far":   "#0080ff",
+		},
+		IdleColor:     "#2040ff",
+		MinBrightness: 15,
+		MaxBrightness: 100,
+		Interval:      time.Second,
+	}
+}
+
+// lightState is a color and a brightness percentage
+type lightState struct {
+	color      [3]uint8
+	brightness int
+}
+
+// Lighting sets WLED or Hue lights to the nearest detection's category
+// color, brighter the more crowded the scene is
+type Lighting struct {
+	engine *engine.ProximityEngine
+	config LightingConfig
+	client *http.Client
+	colors map[string][3]uint8
+	idle   [3]uint8
+
+	mu     sync.Mutex
+	target lightState
+	sent   lightState
+	synced bool // sent has been applied to the lights
+	stop   chan struct{}
+}
+
+// NewLighting parses the colors and checks the driver settings
+func NewLighting(pe *engine.ProximityEngine, config LightingConfig) (*Lighting, error) {
+	switch config.Driver {
+	case LightingWLED:
+		if config.WLEDURL == "" {
+			return nil, fmt.Errorf("lighting: wled needs wled_url")
+		}
+	case LightingHue:
+		if config.HueBridge == "" || config.HueUser == "" {
+			return nil, fmt.Errorf("lighting: hue needs hue_bridge and hue_user")
+		}
+	default:
+		return nil, fmt.Errorf("lighting: unknown driver %q, want %s or %s", config.Driver, LightingWLED, LightingHue)
+	}
+
+	l := &Lighting{
+		engine: pe,
+		config: config,
+		client: &http.Client{Timeout: 3 * time.Second},
+		colors: make(map[string][3]uint8),
+		stop:   make(chan struct{}),
+	}
+	for category, hex := range config.Colors {
+		c, err := parseHexColor(hex)
+		if err != nil {
+			return nil, fmt.Errorf("lighting: colors[%s]: %w", category, err)
+		}
+		l.colors[category] = c
+	}
+	idle, err := parseHexColor(config.IdleColor)
+	if err != nil {
+		return nil, fmt.Errorf("lighting: idle_color: %w", err)
+	}
+	l.idle = idle
+	l.target = lightState{color: idle, brightness: config.MinBrightness}
+	return l, nil
+}
+
+// Start sends updates at the configured interval
+func (l *Lighting) Start() {
+	go l.run()
+}
+
+// Stop ends the updates, leaving the lights as they are
+func (l *Lighting) Stop() {
+	close(l.stop)
+}
+
+// PublishDetections sets the target color and brightness
+func (l *Lighting) PublishDetections(detections []engine.Detection) {
+	state := lightState{color: l.idle, brightness: l.config.MinBrightness}
+	if nearest, ok := engine.NearestDetection(detections); ok {
+		if c, ok := l.colors[nearest.Category]; ok {
+			state.color = c
+		}
+		crowded := l.engine.CrowdConfig().CrowdedCount
+		if crowded <= 1 {
+			crowded = engine.DefaultCrowdConfig().CrowdedCount
+		}
+		density := math.Min(float64(len(detections)-1)/float64(crowded-1), 1)
+		state.brightness = l.config.MinBrightness + int(math.Round(density*float64(l.config.MaxBrightness-l.config.MinBrightness)))
+	}
+
+	l.mu.Lock()
+	l.target = state
+	l.mu.Unlock()
+}
+
+// run applies the target whenever it differs from what the lights show
+func (l *Lighting) run() {
+	ticker := time.NewTicker(l.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+		}
+
+		l.mu.Lock()
+		target, changed := l.target, l.target != l.sent || !l.synced
+		l.mu.Unlock()
+		if !changed {
+			continue
+		}
+
+		err := l.apply(target)
+		l.mu.Lock()
+		l.sent, l.synced = target, err == nil
+		l.mu.Unlock()
+		if err != nil {
+			lightingLog.Warn("Lighting update failed", "driver", l.config.Driver, "error", err)
+		}
+	}
+}
+
+// apply sends a state to the lights, fading over the update interval
+func (l *Lighting) apply(state lightState) error {
+	transition := int(l.config.Interval / (100 * time.Millisecond)) // Both APIs count tenths of a second
+	switch l.config.Driver {
+	case LightingHue:
+		x, y := rgbToXY(state.color)
+		body := map[string]interface{}{
+			"on":             state.brightness > 0,
+			"bri":            max(1, state.brightness*254/100),
+			"xy":             [2]float64{x, y},
+			"transitiontime": transition,
+		}
+		base := "http://" + l.config.HueBridge + "/api/" + l.config.HueUser
+		if len(l.config.HueLights) == 0 {
+			return l.put(base+"/groups/"+l.config.HueGroup+"/action", body)
+		}
+		for _, id := range l.config.HueLights {
+			if err := l.put(base+"/lights/"+id+"/state", body); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		c := state.color
+		return l.send(http.MethodPost, strings.TrimRight(l.config.WLEDURL, "/")+"/json/state", map[string]interface{}{
+			"on":         state.brightness > 0,
+			"bri":        state.brightness * 255 / 100,
+			"transition": transition,
+			"seg":        []map[string]interface{}{{"col": [][3]uint8{c}}},
+		})
+	}
+}
+
+// put sends a Hue state change
+func (l *Lighting) put(url string, body interface{}) error {
+	return l.send(http.MethodPut, url, body)
+}
+
+// send writes body as JSON and checks the status
+func (l *Lighting) send(method, url string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: %s", method, url, resp.Status)
+	}
+	return nil
+}
+
+// parseHexColor parses #rrggbb
+func parseHexColor(hex string) ([3]uint8, error) {
+	hex = strings.TrimPrefix(hex, "#")
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 6 || err != nil {
+		return [3]uint8{}, fmt.Errorf("%q is not a #rrggbb color", hex)
+	}
+	return [3]uint8{uint8(v >> 16), uint8(v >> 8), uint8(v)}, nil
+}
+
+// rgbToXY converts an sRGB color to CIE xy chromaticity, which Hue uses
+func rgbToXY(c [3]uint8) (float64, float64) {
+	var linear [3]float64
+	for i, v := range c {
+		f := float64(v) / 255
+		if f > 0.04045 {
+			f = math.Pow((f+0.055)/1.055, 2.4)
+		} else {
+			f /= 12.92
+		}
+		linear[i] = f
+	}
+	r, g, b := linear[0], linear[1], linear[2]
+	X := r*0.4124 + g*0.3576 + b*0.1805
+	Y := r*0.2126 + g*0.7152 + b*0.0722
+	Z := r*0.0193 + g*0.1192 + b*0.9505
+	if sum := X + Y + Z; sum > 0 {
+		return X / sum, Y / sum
+	}
+	return 0.3127, 0.3290 // White point for black
+}
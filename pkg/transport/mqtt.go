@@ -0,0 +1,273 @@
+package transport
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"vrchat-proximity/pkg/engine"
+	"vrchat-proximity/pkg/logging"
+)
+
+// mqttLog is the "mqtt" subsystem logger
+var mqttLog = logging.For("mqtt")
+
+// MQTTConfig configures the MQTT publisher
+type MQTTConfig struct {
+	Enabled     bool          `yaml:"enabled"`
+	Broker      string        `yaml:"broker"` // e.g. tcp://127.0.0.1:1883
+	ClientID    string        `yaml:"client_id"`
+	Username    string        `yaml:"username"`
+	Password    string        `yaml:"password"`
+	TopicPrefix string        `yaml:"topic_prefix"`
+	QoS         byte          `yaml:"qos"`
+	Interval    time.Duration `yaml:"interval"`     // Summary publish interval
+	IdleTimeout time.Duration `yaml:"idle_timeout"` // Report zero detections after this long without any
+
+	// Home Assistant MQTT discovery
+	Discovery       bool   `yaml:"discovery"`        // Announce sensors so Home Assistant creates them
+	DiscoveryPrefix string `yaml:"discovery_prefix"` // Home Assistant's discovery topic prefix
+}
+
+// DefaultMQTTConfig returns settings for a local broker
+func DefaultMQTTConfig() MQTTConfig {
+	return MQTTConfig{
+		Broker:      "tcp://127.0.0.1:1883",
+		ClientID:    "vrchat-proximity",
+		TopicPrefix: "proximity",
+		Interval:    500 * time.Millisecond,
+		IdleTimeout: time.Second,
+
+		Discovery:       true,
+		DiscoveryPrefix: "homeassistant",
+	}
+}
+
+// mqttNearest is the payload of the nearest topic
+type mqttNearest struct {
+	Distance  float32 `json:"distance"`
+	Category  string  `json:"category"`
+	Timestamp int64   `json:"timestamp"`
+}
+
+// MQTTPublisher publishes detection summaries and events to an MQTT broker
+type MQTTPublisher struct {
+	config MQTTConfig
+	client mqtt.Client
+
+	nearest       *engine.Detection
+	count         int
+	lastDetection time.Time
+	lastPublished string
+	engineState   func() string // Published to the engine topic; nil skips it
+	mu            sync.Mutex
+
+	stop chan struct{}
+}
+
+// NewMQTTPublisher creates a publisher with the given config
+func NewMQTTPublisher(config MQTTConfig) *MQTTPublisher {
+	p := &MQTTPublisher{
+		config: config,
+		stop:   make(chan struct{}),
+	}
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(config.Broker).
+		SetClientID(config.ClientID).
+		SetUsername(config.Username).
+		SetPassword(config.Password).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetWill(p.topic("status"), "offline", 1, true).
+		SetOnConnectHandler(func(c mqtt.Client) {
+			mqttLog.Info("Connected to MQTT broker", "broker", config.Broker)
+			c.Publish(p.topic("status"), 1, true, "online")
+			if config.Discovery {
+				p.publishDiscovery(c)
+			}
+			p.mu.Lock()
+			p.lastPublished = "" // Republish retained state after reconnecting
+			p.mu.Unlock()
+		}).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			mqttLog.Warn("MQTT connection lost", "error", err)
+		})
+	p.client = mqtt.NewClient(opts)
+
+	return p
+}
+
+// topic joins the configured prefix and a topic name
+func (p *MQTTPublisher) topic(name string) string {
+	return p.config.TopicPrefix + "/" + name
+}
+
+// SetEngineState publishes state(), such as the engine's State, to the
+// engine topic whenever it changes; call before Start
+func (p *MQTTPublisher) SetEngineState(state func() string) {
+	p.engineState = state
+}
+
+// Start connects to the broker and begins publishing summaries
+func (p *MQTTPublisher) Start() error {
+	token := p.client.Connect()
+	if !token.WaitTimeout(5*time.Second) && !p.client.IsConnectionOpen() {
+		mqttLog.Warn("MQTT broker not reachable yet, retrying in background", "broker", p.config.Broker)
+	} else if err := token.Error(); err != nil {
+		return fmt.Errorf("connect MQTT: %w", err)
+	}
+
+	go p.publishLoop()
+	return nil
+}
+
+// Stop publishes the offline status and disconnects
+func (p *MQTTPublisher) Stop() {
+	close(p.stop)
+	if p.client.IsConnected() {
+		p.client.Publish(p.topic("status"), 1, true, "offline").WaitTimeout(time.Second)
+	}
+	p.client.Disconnect(250)
+}
+
+// PublishDetections records the latest detection summary
+func (p *MQTTPublisher) PublishDetections(detections []engine.Detection) {
+	nearest, ok := engine.NearestDetection(detections)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.count = len(detections)
+	p.lastDetection = time.Now()
+	if ok {
+		p.nearest = &nearest
+	} else {
+		p.nearest = nil
+	}
+}
+
+// HandleEvent publishes proximity events as they happen
+func (p *MQTTPublisher) HandleEvent(event engine.ProximityEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	p.client.Publish(p.topic("events"), p.config.QoS, false, data)
+}
+
+// publishLoop publishes the nearest and count topics when they change
+func (p *MQTTPublisher) publishLoop() {
+	ticker := time.NewTicker(p.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.publishSummary()
+		}
+	}
+}
+
+// publishSummary sends retained summary topics if the state has changed
+func (p *MQTTPublisher) publishSummary() {
+	p.mu.Lock()
+	if time.Since(p.lastDetection) > p.config.IdleTimeout {
+		p.count = 0
+		p.nearest = nil
+	}
+
+	nearest := mqttNearest{Category: "None", Timestamp: time.Now().Unix()}
+	if p.nearest != nil {
+		nearest.Distance = p.nearest.Distance
+		nearest.Category = p.nearest.Category
+	}
+	count := p.count
+	engineState := ""
+	if p.engineState != nil {
+		engineState = p.engineState()
+	}
+
+	// Compare without the timestamp so an unchanged scene isn't republished
+	state := fmt.Sprintf("%d|%s|%.1f|%s", count, nearest.Category, nearest.Distance, engineState)
+	if state == p.lastPublished || !p.client.IsConnectionOpen() {
+		p.mu.Unlock()
+		return
+	}
+	p.lastPublished = state
+	p.mu.Unlock()
+
+	data, _ := json.Marshal(nearest)
+	p.client.Publish(p.topic("nearest"), p.config.QoS, true, data)
+	p.client.Publish(p.topic("count"), p.config.QoS, true, strconv.Itoa(count))
+	if engineState != "" {
+		p.client.Publish(p.topic("engine"), p.config.QoS, true, engineState)
+	}
+}
+
+// haDiscoverySensor describes one sensor in a Home Assistant discovery message
+type haDiscoverySensor struct {
+	id, name, topic, template, unit, deviceClass, stateClass, icon string
+}
+
+// publishDiscovery announces the summary topics as Home Assistant sensors
+// of one device. The messages are retained so Home Assistant finds them
+// after it restarts.
+func (p *MQTTPublisher) publishDiscovery(c mqtt.Client) {
+	node := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, strings.ToLower(p.config.ClientID))
+
+	sensors := []haDiscoverySensor{
+		{id: "nearest_distance", name: "Nearest distance", topic: "nearest", template: "{{ value_json.distance }}", unit: "m", deviceClass: "distance", stateClass: "measurement"},
+		{id: "nearest_category", name: "Nearest category", topic: "nearest", template: "{{ value_json.category }}", icon: "mdi:account-arrow-left"},
+		{id: "crowd_count", name: "Crowd count", topic: "count", unit: "players", stateClass: "measurement", icon: "mdi:account-group"},
+	}
+	if p.engineState != nil {
+		sensors = append(sensors, haDiscoverySensor{id: "engine_state", name: "Engine state", topic: "engine", icon: "mdi:radar"})
+	}
+
+	device := map[string]interface{}{
+		"identifiers":  []string{node},
+		"name":         "VRChat Proximity",
+		"manufacturer": "VRChat Proximity",
+		"model":        "Proximity Engine",
+	}
+	for _, sensor := range sensors {
+		config := map[string]interface{}{
+			"name":                  sensor.name,
+			"unique_id":             node + "_" + sensor.id,
+			"state_topic":           p.topic(sensor.topic),
+			"availability_topic":    p.topic("status"),
+			"payload_available":     "online",
+			"payload_not_available": "offline",
+			"device":                device,
+		}
+		for key, value := range map[string]string{
+			"value_template":      sensor.template,
+			"unit_of_measurement": sensor.unit,
+			"device_class":        sensor.deviceClass,
+			"state_class":         sensor.stateClass,
+			"icon":                sensor.icon,
+		} {
+			if value != "" {
+				config[key] = value
+			}
+		}
+		data, err := json.Marshal(config)
+		if err != nil {
+			continue
+		}
+		c.Publish(p.config.DiscoveryPrefix+"/sensor/"+node+"/"+sensor.id+"/config", 1, true, data)
+	}
+	mqttLog.Info("Home Assistant discovery published", "sensors", len(sensors), "prefix", p.config.DiscoveryPrefix)
+}
@@ -0,0 +1,65 @@
+package transport
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"vrchat-proximity/pkg/engine"
+)
+
+// nearestMessage is the GET /nearest response and the "nearest" stream message
+type nearestMessage struct {
+	Type      string  `json:"type"`
+	Seq       int64   `json:"seq,omitempty"` // Stream messages only
+	Timestamp int64   `json:"timestamp"`
+	Present   bool    `json:"present"` // False when nothing is detected
+	Distance  float32 `json:"distance,omitempty"`
+	Category  string  `json:"category,omitempty"`
+	TrackID   int64   `json:"track_id,omitempty"`
+}
+
+// nearest summarizes the closest current detection
+func (s *Server) nearest() nearestMessage {
+	message := nearestMessage{Type: "nearest", Timestamp: time.Now().Unix()}
+	if d, ok := engine.NearestDetection(s.engine.GetCurrentDetections()); ok {
+		message.Present = true
+		message.Distance = d.Distance
+		message.Category = d.Category
+		message.TrackID = d.TrackID
+	}
+	return message
+}
+
+// handleNearest serves the closest current detection; the ETag only
+// changes with the detection, not the timestamp
+func (s *Server) handleNearest(w http.ResponseWriter, r *http.Request) {
+	s.servePolled(w, r, func() interface{} { return s.nearest() }, "timestamp")
+}
+
+// streamNearest sends a nearest message to WebSocket and SSE clients at
+// NearestRate until the server stops
+func (s *Server) streamNearest() {
+	if s.config.NearestRate <= 0 {
+		return
+	}
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / s.config.NearestRate))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			message := s.nearest()
+			message.Seq = s.nextSeq()
+			data, err := json.Marshal(message)
+			if err != nil {
+				wsLog.Error("JSON marshal failed", "error", err)
+				continue
+			}
+			s.broadcastState(stateNearest, data, func(*Client) bool { return true })
+			s.sse.publish("nearest", data)
+		}
+	}
+}
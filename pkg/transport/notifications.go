@@ -0,0 +1,241 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"vrchat-proximity/pkg/engine"
+	"vrchat-proximity/pkg/logging"
+)
+
+// notifyLog is the "notify" subsystem logger
+var notifyLog = logging.For("notify")
+
+// templateFuncs are available in notification and speech templates
+var templateFuncs = template.FuncMap{"lower": strings.ToLower, "upper": strings.ToUpper}
+
+// NotificationTemplate is a text/template pair rendered with a ProximityEvent
+type NotificationTemplate struct {
+	Title   string `json:"title" yaml:"title"`
+	Content string `json:"content" yaml:"content"`
+}
+
+// NotificationConfig configures in-VR notifications for zone_enter events
+type NotificationConfig struct {
+	XSOverlay     bool          `yaml:"xsoverlay"`
+	XSOverlayAddr string        `yaml:"xsoverlay_addr"` // XSOverlay UDP notification API
+	OVRToolkit    bool          `yaml:"ovrtoolkit"`
+	OVRToolkitURL string        `yaml:"ovrtoolkit_url"` // OVR Toolkit WebSocket API
+	Timeout       time.Duration `yaml:"timeout"`
+
+	// Throttling
+	MinInterval         time.Duration `yaml:"min_interval"`          // Between any two notifications
+	CategoryMinInterval time.Duration `yaml:"category_min_interval"` // Between notifications for the same category
+
+	// Templates keyed by category; categories without a template are not notified
+	Templates map[string]NotificationTemplate `yaml:"templates"`
+}
+
+// DefaultNotificationConfig returns templates for the two closest categories
+func DefaultNotificationConfig() NotificationConfig {
+	return NotificationConfig{
+		XSOverlayAddr:       "127.0.0.1:42069",
+		OVRToolkitURL:       "ws://127.0.0.1:11450/api",
+		Timeout:             3 * time.Second,
+		MinInterval:         2 * time.Second,
+		CategoryMinInterval: 10 * time.Second,
+		Templates: map[string]NotificationTemplate{
+			"Very Close": {Title: "Proximity alert", Content: "Someone is very close (~{{printf \"%.0f\" .Distance}}m)"},
+			"Close":      {Title: "Proximity", Content: "Someone is nearby (~{{printf \"%.0f\" .Distance}}m)"},
+		},
+	}
+}
+
+// xsOverlayMessage is the XSOverlay UDP notification payload
+type xsOverlayMessage struct {
+	MessageType   int     `json:"messageType"`
+	Index         int     `json:"index"`
+	Timeout       float64 `json:"timeout"`
+	Height        float64 `json:"height"`
+	Opacity       float64 `json:"opacity"`
+	Volume        float64 `json:"volume"`
+	AudioPath     string  `json:"audioPath"`
+	Title         string  `json:"title"`
+	Content       string  `json:"content"`
+	UseBase64Icon bool    `json:"useBase64Icon"`
+	Icon          string  `json:"icon"`
+	SourceApp     string  `json:"sourceApp"`
+}
+
+// ovrToolkitMessage is the OVR Toolkit WebSocket API envelope
+type ovrToolkitMessage struct {
+	MessageType string `json:"messageType"`
+	JSON        string `json:"json"`
+}
+
+// compiledTemplate holds parsed title/content templates
+type compiledTemplate struct {
+	title   *template.Template
+	content *template.Template
+}
+
+// Notifier pushes zone_enter events to VR overlay applications
+type Notifier struct {
+	config    NotificationConfig
+	templates map[string]compiledTemplate
+
+	udpConn *net.UDPConn
+	ovrConn *websocket.Conn
+
+	lastSent         time.Time
+	lastSentCategory map[string]time.Time
+	mu               sync.Mutex
+}
+
+// NewNotifier parses the templates and creates a notifier
+func NewNotifier(config NotificationConfig) (*Notifier, error) {
+	n := &Notifier{
+		config:           config,
+		templates:        make(map[string]compiledTemplate),
+		lastSentCategory: make(map[string]time.Time),
+	}
+
+	for category, t := range config.Templates {
+		title, err := template.New(category + " title").Funcs(templateFuncs).Parse(t.Title)
+		if err != nil {
+			return nil, fmt.Errorf("template %q title: %w", category, err)
+		}
+		content, err := template.New(category + " content").Funcs(templateFuncs).Parse(t.Content)
+		if err != nil {
+			return nil, fmt.Errorf("template %q content: %w", category, err)
+		}
+		n.templates[category] = compiledTemplate{title: title, content: content}
+	}
+
+	return n, nil
+}
+
+// Stop closes any open connections
+func (n *Notifier) Stop() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.udpConn != nil {
+		n.udpConn.Close()
+		n.udpConn = nil
+	}
+	if n.ovrConn != nil {
+		n.ovrConn.Close()
+		n.ovrConn = nil
+	}
+}
+
+// HandleEvent sends a notification for zone_enter events that pass throttling
+func (n *Notifier) HandleEvent(event engine.ProximityEvent) {
+	if event.Type != engine.EventZoneEnter {
+		return
+	}
+	tmpl, ok := n.templates[event.Category]
+	if !ok {
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(n.lastSent) < n.config.MinInterval || now.Sub(n.lastSentCategory[event.Category]) < n.config.CategoryMinInterval {
+		return
+	}
+
+	var title, content bytes.Buffer
+	if err := tmpl.title.Execute(&title, event); err != nil {
+		notifyLog.Error("Notification template failed", "error", err)
+		return
+	}
+	if err := tmpl.content.Execute(&content, event); err != nil {
+		notifyLog.Error("Notification template failed", "error", err)
+		return
+	}
+
+	n.lastSent = now
+	n.lastSentCategory[event.Category] = now
+
+	if n.config.XSOverlay {
+		if err := n.sendXSOverlay(title.String(), content.String()); err != nil {
+			notifyLog.Warn("XSOverlay notification failed", "error", err)
+		}
+	}
+	if n.config.OVRToolkit {
+		if err := n.sendOVRToolkit(title.String(), content.String()); err != nil {
+			notifyLog.Warn("OVR Toolkit notification failed", "error", err)
+		}
+	}
+}
+
+// sendXSOverlay sends a notification over XSOverlay's UDP API
+func (n *Notifier) sendXSOverlay(title, content string) error {
+	if n.udpConn == nil {
+		addr, err := net.ResolveUDPAddr("udp", n.config.XSOverlayAddr)
+		if err != nil {
+			return err
+		}
+		if n.udpConn, err = net.DialUDP("udp", nil, addr); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(xsOverlayMessage{
+		MessageType: 1,
+		Timeout:     n.config.Timeout.Seconds(),
+		Height:      175,
+		Opacity:     1,
+		Volume:      0.7,
+		AudioPath:   "default",
+		Title:       title,
+		Content:     content,
+		Icon:        "default",
+		SourceApp:   "VRChat Proximity",
+	})
+	if err != nil {
+		return err
+	}
+	_, err = n.udpConn.Write(data)
+	return err
+}
+
+// sendOVRToolkit sends a notification over OVR Toolkit's WebSocket API
+func (n *Notifier) sendOVRToolkit(title, content string) error {
+	if n.ovrConn == nil {
+		conn, _, err := websocket.DefaultDialer.Dial(n.config.OVRToolkitURL, nil)
+		if err != nil {
+			return err
+		}
+		n.ovrConn = conn
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"title": title, "body": content, "icon": nil})
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(ovrToolkitMessage{MessageType: "SendNotification", JSON: string(body)})
+	if err != nil {
+		return err
+	}
+
+	n.ovrConn.SetWriteDeadline(time.Now().Add(time.Second))
+	if err := n.ovrConn.WriteMessage(websocket.TextMessage, data); err != nil {
+		// Reconnect on the next notification
+		n.ovrConn.Close()
+		n.ovrConn = nil
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,383 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"vrchat-proximity/pkg/engine"
+	"vrchat-proximity/pkg/logging"
+)
+
+// oscLog is the "osc" subsystem logger
+var oscLog = logging.For("osc")
+
+// OSCConfig configures OSC output to VRChat and the local OSC input
+type OSCConfig struct {
+	Enabled         bool          `yaml:"enabled"`
+	SendAddr        string        `yaml:"send_addr"`   // VRChat OSC input, usually 127.0.0.1:9000
+	ListenAddr      string        `yaml:"listen_addr"` // Local OSC input for control messages
+	ParameterPrefix string        `yaml:"parameter_prefix"`
+	SendInterval    time.Duration `yaml:"send_interval"`
+
+	// OSCQuery advertisement
+	OSCQuery     bool   `yaml:"oscquery"`
+	OSCQueryAddr string `yaml:"oscquery_addr"` // HTTP address serving the OSCQuery tree
+	ServiceName  string `yaml:"service_name"`
+}
+
+// DefaultOSCConfig returns the standard VRChat OSC setup
+func DefaultOSCConfig() OSCConfig {
+	return OSCConfig{
+		Enabled:         true,
+		SendAddr:        "127.0.0.1:9000",
+		ListenAddr:      "127.0.0.1:9002",
+		ParameterPrefix: "/avatar/parameters/",
+		SendInterval:    100 * time.Millisecond,
+		OSCQuery:        true,
+		OSCQueryAddr:    "127.0.0.1:9080",
+		ServiceName:     "VRChat-Proximity",
+	}
+}
+
+// OSCParameter describes an OSC address exposed by the bridge
+type OSCParameter struct {
+	Address     string
+	Type        byte // OSC type tag: 'f', 'i', 'T' (bool)
+	Access      int  // 1 = read (we send it), 2 = write (we accept it)
+	Description string
+	Min, Max    float64
+}
+
+// OSC addresses relative to the parameter prefix
+const (
+	oscParamCloseness = "ProximityCloseness"
+	oscParamCount     = "ProximityCount"
+	oscParamVeryClose = "ProximityVeryClose"
+	oscAddrFPS        = "/proximity/fps"
+	oscAddrOverlay    = "/proximity/overlay"
+)
+
+// oscMessage is a decoded OSC message
+type oscMessage struct {
+	Address string
+	Args    []interface{}
+}
+
+// OSCBridge publishes detection summaries to VRChat and accepts control messages
+type OSCBridge struct {
+	config OSCConfig
+	engine *engine.ProximityEngine
+
+	sendConn   *net.UDPConn
+	listenConn *net.UDPConn
+	query      *OSCQueryServer
+
+	overlay func(visible bool) // Shows or hides the headset radar; nil without one
+
+	lastSend time.Time
+	values   map[string]interface{}
+	mu       sync.Mutex
+}
+
+// NewOSCBridge creates a bridge for the given engine
+func NewOSCBridge(pe *engine.ProximityEngine, config OSCConfig) *OSCBridge {
+	return &OSCBridge{
+		config: config,
+		engine: pe,
+		values: make(map[string]interface{}),
+	}
+}
+
+// Parameters returns the OSC address schema exposed by the bridge
+func (b *OSCBridge) Parameters() []OSCParameter {
+	prefix := b.config.ParameterPrefix
+	params := []OSCParameter{
+		{Address: prefix + oscParamCloseness, Type: 'f', Access: 1, Description: "Closeness of the nearest detection (0 = none, 1 = touching)", Min: 0, Max: 1},
+		{Address: prefix + oscParamCount, Type: 'i', Access: 1, Description: "Number of detections in the current frame", Min: 0, Max: 255},
+		{Address: prefix + oscParamVeryClose, Type: 'T', Access: 1, Description: "True while any detection is Very Close"},
+		{Address: oscAddrFPS, Type: 'i', Access: 2, Description: "Set the engine target FPS", Min: 1, Max: 120},
+	}
+	if b.overlay != nil {
+		params = append(params, OSCParameter{Address: oscAddrOverlay, Type: 'T', Access: 2, Description: "Show or hide the headset radar overlay"})
+	}
+	return params
+}
+
+// SetOverlayHandler routes /proximity/overlay messages to fn; call before Start
+func (b *OSCBridge) SetOverlayHandler(fn func(visible bool)) {
+	b.overlay = fn
+}
+
+// Start opens the OSC sockets and, if enabled, the OSCQuery advertisement
+func (b *OSCBridge) Start() error {
+	sendAddr, err := net.ResolveUDPAddr("udp", b.config.SendAddr)
+	if err != nil {
+		return fmt.Errorf("resolve OSC send address: %w", err)
+	}
+	if b.sendConn, err = net.DialUDP("udp", nil, sendAddr); err != nil {
+		return fmt.Errorf("dial OSC send address: %w", err)
+	}
+
+	listenAddr, err := net.ResolveUDPAddr("udp", b.config.ListenAddr)
+	if err != nil {
+		return fmt.Errorf("resolve OSC listen address: %w", err)
+	}
+	if b.listenConn, err = net.ListenUDP("udp", listenAddr); err != nil {
+		b.sendConn.Close()
+		return fmt.Errorf("listen OSC: %w", err)
+	}
+	go b.receiveLoop()
+
+	if b.config.OSCQuery {
+		b.query = NewOSCQueryServer(b.config, b.listenConn.LocalAddr().(*net.UDPAddr), b.Parameters)
+		b.query.valueFunc = b.value
+		if err := b.query.Start(); err != nil {
+			oscLog.Warn("OSCQuery disabled", "error", err)
+			b.query = nil
+		}
+	}
+
+	oscLog.Info("OSC bridge started", "send", b.config.SendAddr, "listen", b.listenConn.LocalAddr().String())
+	return nil
+}
+
+// Stop closes the OSC sockets
+func (b *OSCBridge) Stop() {
+	if b.query != nil {
+		b.query.Stop()
+	}
+	if b.listenConn != nil {
+		b.listenConn.Close()
+	}
+	if b.sendConn != nil {
+		b.sendConn.Close()
+	}
+}
+
+// PublishDetections sends the current detection summary as avatar parameters
+func (b *OSCBridge) PublishDetections(detections []engine.Detection) {
+	b.mu.Lock()
+	if time.Since(b.lastSend) < b.config.SendInterval {
+		b.mu.Unlock()
+		return
+	}
+	b.lastSend = time.Now()
+	b.mu.Unlock()
+
+	closeness := float32(0)
+	veryClose := false
+	for _, d := range detections {
+		c := 1 - d.Distance/engine.MaxEstimatedDistance
+		if c > closeness {
+			closeness = c
+		}
+		if engine.CategoryRank(d.Category) == 0 {
+			veryClose = true
+		}
+	}
+
+	count := len(detections)
+	if count > 255 {
+		count = 255
+	}
+
+	prefix := b.config.ParameterPrefix
+	b.send(prefix+oscParamCloseness, closeness)
+	b.send(prefix+oscParamCount, int32(count))
+	b.send(prefix+oscParamVeryClose, veryClose)
+}
+
+// send encodes and writes a single-argument OSC message
+func (b *OSCBridge) send(address string, value interface{}) {
+	b.mu.Lock()
+	b.values[address] = value
+	b.mu.Unlock()
+
+	if b.sendConn == nil {
+		return
+	}
+	packet, err := encodeOSCMessage(oscMessage{Address: address, Args: []interface{}{value}})
+	if err != nil {
+		oscLog.Error("OSC encode failed", "error", err)
+		return
+	}
+	if _, err := b.sendConn.Write(packet); err != nil {
+		oscLog.Warn("OSC send failed", "error", err)
+	}
+}
+
+// value returns the last value sent or received for an address
+func (b *OSCBridge) value(address string) (interface{}, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	v, ok := b.values[address]
+	return v, ok
+}
+
+// receiveLoop handles incoming OSC control messages
+func (b *OSCBridge) receiveLoop() {
+	buf := make([]byte, 65535)
+	for {
+		n, _, err := b.listenConn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		msg, err := decodeOSCMessage(buf[:n])
+		if err != nil {
+			continue
+		}
+		b.handleMessage(msg)
+	}
+}
+
+// handleMessage applies a received OSC message
+func (b *OSCBridge) handleMessage(msg oscMessage) {
+	if len(msg.Args) == 0 {
+		return
+	}
+
+	switch msg.Address {
+	case oscAddrFPS:
+		if fps, ok := oscInt(msg.Args[0]); ok && fps > 0 && fps <= 120 {
+			b.engine.SetTargetFPS(fps)
+			b.mu.Lock()
+			b.values[msg.Address] = int32(fps)
+			b.mu.Unlock()
+		}
+	case oscAddrOverlay:
+		if b.overlay == nil {
+			return
+		}
+		visible, ok := msg.Args[0].(bool)
+		if n, isInt := oscInt(msg.Args[0]); isInt {
+			visible, ok = n != 0, true
+		}
+		if ok {
+			b.overlay(visible)
+			b.mu.Lock()
+			b.values[msg.Address] = visible
+			b.mu.Unlock()
+		}
+	}
+}
+
+// oscInt converts a numeric OSC argument to int
+func oscInt(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int32:
+		return int(n), true
+	case float32:
+		return int(n), true
+	}
+	return 0, false
+}
+
+// encodeOSCMessage serializes a message using the OSC 1.0 binary format
+func encodeOSCMessage(msg oscMessage) ([]byte, error) {
+	var buf bytes.Buffer
+	writeOSCString(&buf, msg.Address)
+
+	tags := ","
+	var args bytes.Buffer
+	for _, arg := range msg.Args {
+		switch v := arg.(type) {
+		case int32:
+			tags += "i"
+			binary.Write(&args, binary.BigEndian, v)
+		case float32:
+			tags += "f"
+			binary.Write(&args, binary.BigEndian, math.Float32bits(v))
+		case string:
+			tags += "s"
+			writeOSCString(&args, v)
+		case bool:
+			if v {
+				tags += "T"
+			} else {
+				tags += "F"
+			}
+		default:
+			return nil, fmt.Errorf("unsupported OSC argument type %T", arg)
+		}
+	}
+
+	writeOSCString(&buf, tags)
+	buf.Write(args.Bytes())
+	return buf.Bytes(), nil
+}
+
+// decodeOSCMessage parses a single OSC message (bundles are ignored)
+func decodeOSCMessage(data []byte) (oscMessage, error) {
+	var msg oscMessage
+
+	address, rest, err := readOSCString(data)
+	if err != nil {
+		return msg, err
+	}
+	if !strings.HasPrefix(address, "/") {
+		return msg, fmt.Errorf("not an OSC message: %q", address)
+	}
+	msg.Address = address
+
+	tags, rest, err := readOSCString(rest)
+	if err != nil || !strings.HasPrefix(tags, ",") {
+		return msg, nil // Messages without a type tag string carry no arguments
+	}
+
+	for _, tag := range tags[1:] {
+		switch tag {
+		case 'i':
+			if len(rest) < 4 {
+				return msg, fmt.Errorf("truncated int argument")
+			}
+			msg.Args = append(msg.Args, int32(binary.BigEndian.Uint32(rest)))
+			rest = rest[4:]
+		case 'f':
+			if len(rest) < 4 {
+				return msg, fmt.Errorf("truncated float argument")
+			}
+			msg.Args = append(msg.Args, math.Float32frombits(binary.BigEndian.Uint32(rest)))
+			rest = rest[4:]
+		case 's':
+			var s string
+			if s, rest, err = readOSCString(rest); err != nil {
+				return msg, err
+			}
+			msg.Args = append(msg.Args, s)
+		case 'T':
+			msg.Args = append(msg.Args, true)
+		case 'F':
+			msg.Args = append(msg.Args, false)
+		default:
+			return msg, fmt.Errorf("unsupported OSC type tag %q", tag)
+		}
+	}
+
+	return msg, nil
+}
+
+// writeOSCString writes a null-terminated string padded to 4 bytes
+func writeOSCString(buf *bytes.Buffer, s string) {
+	buf.WriteString(s)
+	pad := 4 - len(s)%4
+	buf.Write(make([]byte, pad))
+}
+
+// readOSCString reads a padded OSC string and returns the remaining data
+func readOSCString(data []byte) (string, []byte, error) {
+	end := bytes.IndexByte(data, 0)
+	if end < 0 {
+		return "", nil, fmt.Errorf("unterminated OSC string")
+	}
+	size := (end/4 + 1) * 4
+	if size > len(data) {
+		return "", nil, fmt.Errorf("truncated OSC string")
+	}
+	return string(data[:end]), data[size:], nil
+}
@@ -0,0 +1,371 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// OSCQuery access values
+const (
+	oscQueryAccessNone  = 0
+	oscQueryAccessRead  = 1
+	oscQueryAccessWrite = 2
+)
+
+// mDNS constants
+const (
+	mdnsAddr         = "224.0.0.251:5353"
+	mdnsTTL          = 120
+	oscJSONService   = "_oscjson._tcp.local."
+	oscUDPService    = "_osc._udp.local."
+	mdnsUnicastClass = 1 << 15
+)
+
+// oscQueryNode is a node of the OSCQuery JSON tree
+type oscQueryNode struct {
+	FullPath    string                   `json:"FULL_PATH"`
+	Description string                   `json:"DESCRIPTION,omitempty"`
+	Access      int                      `json:"ACCESS"`
+	Contents    map[string]*oscQueryNode `json:"CONTENTS,omitempty"`
+	Type        string                   `json:"TYPE,omitempty"`
+	Value       []interface{}            `json:"VALUE,omitempty"`
+	Range       []map[string]float64     `json:"RANGE,omitempty"`
+}
+
+// OSCQueryServer serves the OSCQuery tree and advertises it over mDNS
+type OSCQueryServer struct {
+	config     OSCConfig
+	oscAddr    *net.UDPAddr
+	parameters func() []OSCParameter
+	valueFunc  func(address string) (interface{}, bool)
+
+	httpServer *http.Server
+	httpAddr   *net.TCPAddr
+	mdnsConn   *net.UDPConn
+	cancel     context.CancelFunc
+}
+
+// NewOSCQueryServer creates an OSCQuery server for the given OSC input address
+func NewOSCQueryServer(config OSCConfig, oscAddr *net.UDPAddr, parameters func() []OSCParameter) *OSCQueryServer {
+	return &OSCQueryServer{
+		config:     config,
+		oscAddr:    oscAddr,
+		parameters: parameters,
+	}
+}
+
+// Start begins serving HTTP and answering mDNS queries
+func (q *OSCQueryServer) Start() error {
+	listener, err := net.Listen("tcp", q.config.OSCQueryAddr)
+	if err != nil {
+		return fmt.Errorf("listen OSCQuery: %w", err)
+	}
+	q.httpAddr = listener.Addr().(*net.TCPAddr)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", q.handleQuery)
+	q.httpServer = &http.Server{Handler: mux}
+	go func() {
+		if err := q.httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+			oscLog.Error("OSCQuery server failed", "error", err)
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q.cancel = cancel
+
+	group, err := net.ResolveUDPAddr("udp4", mdnsAddr)
+	if err != nil {
+		return err
+	}
+	if q.mdnsConn, err = net.ListenMulticastUDP("udp4", nil, group); err != nil {
+		oscLog.Warn("mDNS advertisement disabled", "error", err)
+	} else {
+		go q.mdnsLoop()
+		go q.announce(ctx)
+	}
+
+	oscLog.Info("OSCQuery serving", "url", "http://"+q.httpAddr.String())
+	return nil
+}
+
+// Stop withdraws the mDNS records and shuts down the HTTP server
+func (q *OSCQueryServer) Stop() {
+	if q.cancel != nil {
+		q.cancel()
+	}
+	if q.mdnsConn != nil {
+		q.sendRecords(0) // Goodbye packet
+		q.mdnsConn.Close()
+	}
+	if q.httpServer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		q.httpServer.Shutdown(ctx)
+	}
+}
+
+// handleQuery serves HOST_INFO, whole nodes, or single node attributes
+func (q *OSCQueryServer) handleQuery(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if _, ok := r.URL.Query()["HOST_INFO"]; ok {
+		json.NewEncoder(w).Encode(q.hostInfo())
+		return
+	}
+
+	node := q.findNode(q.buildTree(), r.URL.Path)
+	if node == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Attribute queries such as /path?VALUE return only that attribute
+	if r.URL.RawQuery != "" {
+		attribute := strings.ToUpper(r.URL.RawQuery)
+		data, _ := json.Marshal(node)
+		var fields map[string]interface{}
+		json.Unmarshal(data, &fields)
+		value, ok := fields[attribute]
+		if !ok {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{attribute: value})
+		return
+	}
+
+	json.NewEncoder(w).Encode(node)
+}
+
+// hostInfo returns the OSCQuery HOST_INFO document
+func (q *OSCQueryServer) hostInfo() map[string]interface{} {
+	return map[string]interface{}{
+		"NAME": q.config.ServiceName,
+		"EXTENSIONS": map[string]bool{
+			"ACCESS":      true,
+			"VALUE":       true,
+			"RANGE":       true,
+			"DESCRIPTION": true,
+		},
+		"OSC_IP":        q.oscAddr.IP.String(),
+		"OSC_PORT":      q.oscAddr.Port,
+		"OSC_TRANSPORT": "UDP",
+	}
+}
+
+// buildTree creates the OSCQuery node tree from the parameter schema
+func (q *OSCQueryServer) buildTree() *oscQueryNode {
+	root := &oscQueryNode{FullPath: "/", Access: oscQueryAccessNone, Description: "root node"}
+
+	params := q.parameters()
+	sort.Slice(params, func(i, j int) bool { return params[i].Address < params[j].Address })
+
+	for _, p := range params {
+		node := root
+		parts := strings.Split(strings.Trim(p.Address, "/"), "/")
+		for i, part := range parts {
+			if node.Contents == nil {
+				node.Contents = make(map[string]*oscQueryNode)
+			}
+			child, ok := node.Contents[part]
+			if !ok {
+				child = &oscQueryNode{FullPath: "/" + strings.Join(parts[:i+1], "/"), Access: oscQueryAccessNone}
+				node.Contents[part] = child
+			}
+			node = child
+		}
+
+		node.Type = string(p.Type)
+		node.Access = p.Access
+		node.Description = p.Description
+		if p.Max > p.Min {
+			node.Range = []map[string]float64{{"MIN": p.Min, "MAX": p.Max}}
+		}
+		if q.valueFunc != nil {
+			if v, ok := q.valueFunc(p.Address); ok {
+				node.Value = []interface{}{v}
+			}
+		}
+	}
+
+	return root
+}
+
+// findNode walks the tree to the node at path
+func (q *OSCQueryServer) findNode(root *oscQueryNode, path string) *oscQueryNode {
+	node := root
+	for _, part := range strings.Split(strings.Trim(path, "/"), "/") {
+		if part == "" {
+			continue
+		}
+		child, ok := node.Contents[part]
+		if !ok {
+			return nil
+		}
+		node = child
+	}
+	return node
+}
+
+// announce sends unsolicited mDNS announcements after startup
+func (q *OSCQueryServer) announce(ctx context.Context) {
+	for i := 0; i < 3; i++ {
+		q.sendRecords(mdnsTTL)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(1<<i) * time.Second):
+		}
+	}
+}
+
+// mdnsLoop answers mDNS queries for our service types
+func (q *OSCQueryServer) mdnsLoop() {
+	buf := make([]byte, 9000)
+	for {
+		n, _, err := q.mdnsConn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+
+		var parser dnsmessage.Parser
+		header, err := parser.Start(buf[:n])
+		if err != nil || header.Response {
+			continue
+		}
+		questions, err := parser.AllQuestions()
+		if err != nil {
+			continue
+		}
+
+		for _, question := range questions {
+			if q.answers(question) {
+				q.sendRecords(mdnsTTL)
+				break
+			}
+		}
+	}
+}
+
+// answers reports whether a question is about one of our records
+func (q *OSCQueryServer) answers(question dnsmessage.Question) bool {
+	if question.Class&^mdnsUnicastClass != dnsmessage.ClassINET && question.Class != dnsmessage.ClassANY {
+		return false
+	}
+
+	name := strings.ToLower(question.Name.String())
+	for _, known := range []string{oscJSONService, oscUDPService, q.instanceName(oscJSONService), q.instanceName(oscUDPService), q.hostName()} {
+		if name == strings.ToLower(known) {
+			return true
+		}
+	}
+	return false
+}
+
+// instanceName returns the service instance name for a service type
+func (q *OSCQueryServer) instanceName(service string) string {
+	return q.config.ServiceName + "." + service
+}
+
+// hostName returns the advertised mDNS host name
+func (q *OSCQueryServer) hostName() string {
+	return strings.ToLower(q.config.ServiceName) + ".local."
+}
+
+// sendRecords multicasts our PTR/SRV/TXT/A records with the given TTL
+func (q *OSCQueryServer) sendRecords(ttl uint32) {
+	packet, err := q.buildRecords(ttl)
+	if err != nil {
+		oscLog.Error("mDNS build failed", "error", err)
+		return
+	}
+
+	group, _ := net.ResolveUDPAddr("udp4", mdnsAddr)
+	if _, err := q.mdnsConn.WriteToUDP(packet, group); err != nil {
+		oscLog.Warn("mDNS send failed", "error", err)
+	}
+}
+
+// buildRecords assembles the mDNS response advertising both services
+func (q *OSCQueryServer) buildRecords(ttl uint32) ([]byte, error) {
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{Response: true, Authoritative: true})
+	builder.EnableCompression()
+	if err := builder.StartAnswers(); err != nil {
+		return nil, err
+	}
+
+	host, err := dnsmessage.NewName(q.hostName())
+	if err != nil {
+		return nil, err
+	}
+
+	services := []struct {
+		service string
+		port    int
+	}{
+		{oscJSONService, q.httpAddr.Port},
+		{oscUDPService, q.oscAddr.Port},
+	}
+
+	for _, s := range services {
+		serviceName, err := dnsmessage.NewName(s.service)
+		if err != nil {
+			return nil, err
+		}
+		instance, err := dnsmessage.NewName(q.instanceName(s.service))
+		if err != nil {
+			return nil, err
+		}
+
+		if err := builder.PTRResource(
+			dnsmessage.ResourceHeader{Name: serviceName, Class: dnsmessage.ClassINET, TTL: ttl},
+			dnsmessage.PTRResource{PTR: instance},
+		); err != nil {
+			return nil, err
+		}
+		if err := builder.SRVResource(
+			dnsmessage.ResourceHeader{Name: instance, Class: dnsmessage.ClassINET, TTL: ttl},
+			dnsmessage.SRVResource{Port: uint16(s.port), Target: host},
+		); err != nil {
+			return nil, err
+		}
+		if err := builder.TXTResource(
+			dnsmessage.ResourceHeader{Name: instance, Class: dnsmessage.ClassINET, TTL: ttl},
+			dnsmessage.TXTResource{TXT: []string{"txtvers=1"}},
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := builder.StartAdditionals(); err != nil {
+		return nil, err
+	}
+
+	var a [4]byte
+	copy(a[:], advertisedIP(q.oscAddr.IP).To4())
+	if err := builder.AResource(
+		dnsmessage.ResourceHeader{Name: host, Class: dnsmessage.ClassINET, TTL: ttl},
+		dnsmessage.AResource{A: a},
+	); err != nil {
+		return nil, err
+	}
+
+	return builder.Finish()
+}
+
+// advertisedIP returns the IPv4 address to advertise for a listen address
+func advertisedIP(ip net.IP) net.IP {
+	if ip4 := ip.To4(); ip4 != nil && !ip4.IsUnspecified() {
+		return ip4
+	}
+	return net.IPv4(127, 0, 0, 1).To4()
+}
@@ -0,0 +1,155 @@
+package transport
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Polled responses are serialized at most once per pollCacheTTL for each
+// path and field selection; at most pollCacheSize selections are kept
+const (
+	pollCacheTTL  = 250 * time.Millisecond
+	pollCacheSize = 64
+)
+
+// pollEntry is a serialized response and its ETag
+type pollEntry struct {
+	body []byte
+	etag string
+	at   time.Time
+}
+
+// pollCache keeps the latest serialization of each polled response, so
+// dashboards polling /status or /nearest many times a second share one
+type pollCache struct {
+	mu      sync.Mutex
+	entries map[string]pollEntry
+}
+
+// servePolled writes the JSON from build, trimmed to the comma-separated
+// top-level keys in ?fields= when given. Responses carry an ETag and
+// Cache-Control: no-cache, and a matching If-None-Match gets 304 Not Modified.
+// The ETag is strong unless keys are named in unhashed, such as a timestamp:
+// those are left out of it, so they alone never change it, and it is weak.
+func (s *Server) servePolled(w http.ResponseWriter, r *http.Request, build func() interface{}, unhashed ...string) {
+	fields := pollFields(r.URL.Query().Get("fields"))
+	key := r.URL.Path + "?" + strings.Join(fields, ",")
+
+	entry, err := s.polls.get(key, time.Now(), unhashed, func() ([]byte, error) {
+		body, err := json.Marshal(build())
+		if err != nil || len(fields) == 0 {
+			return body, err
+		}
+		return selectFields(body, fields)
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", entry.etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), entry.etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(entry.body)
+}
+
+// get returns the entry for key, serializing it again once it is older
+// than pollCacheTTL
+func (c *pollCache) get(key string, now time.Time, unhashed []string, serialize func() ([]byte, error)) (pollEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.entries[key]; ok && now.Sub(entry.at) < pollCacheTTL {
+		return entry, nil
+	}
+
+	body, err := serialize()
+	if err != nil {
+		return pollEntry{}, err
+	}
+	body = append(body, '\n')
+	hashed, weak := body, ""
+	if len(unhashed) > 0 {
+		if hashed, err = dropFields(body, unhashed); err != nil {
+			return pollEntry{}, err
+		}
+		weak = "W/"
+	}
+	h := fnv.New64a()
+	h.Write(hashed)
+	entry := pollEntry{body: body, etag: fmt.Sprintf(`%s"%016x"`, weak, h.Sum64()), at: now}
+
+	if c.entries == nil || len(c.entries) >= pollCacheSize {
+		c.entries = make(map[string]pollEntry)
+	}
+	c.entries[key] = entry
+	return entry, nil
+}
+
+// pollFields parses a ?fields= list into sorted, distinct names
+func pollFields(value string) []string {
+	var fields []string
+	for _, field := range strings.Split(value, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	distinct := fields[:0]
+	for i, field := range fields {
+		if i == 0 || field != fields[i-1] {
+			distinct = append(distinct, field)
+		}
+	}
+	return distinct
+}
+
+// selectFields keeps only the named top-level keys of a JSON object;
+// unknown names are ignored
+func selectFields(body []byte, fields []string) ([]byte, error) {
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(body, &all); err != nil {
+		return nil, err
+	}
+	selected := make(map[string]json.RawMessage, len(fields))
+	for _, field := range fields {
+		if value, ok := all[field]; ok {
+			selected[field] = value
+		}
+	}
+	return json.Marshal(selected)
+}
+
+// dropFields removes the named top-level keys of a JSON object
+func dropFields(body []byte, fields []string) ([]byte, error) {
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(body, &all); err != nil {
+		return nil, err
+	}
+	for _, field := range fields {
+		delete(all, field)
+	}
+	return json.Marshal(all)
+}
+
+// etagMatches reports whether an If-None-Match header lists etag or "*",
+// comparing weakly
+func etagMatches(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package transport
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// ProtocolVersion is the WebSocket protocol version this server speaks.
+// Clients that never send hello get the version 1 defaults.
+const ProtocolVersion = 1
+
+// WebSocket features a client can ask for in its hello. Every message is
+// JSON text; binary framing isn't offered yet.
+const (
+	FeatureDeltas      = "deltas"       // detections_delta instead of full detection lists
+	FeatureNearestOnly = "nearest_only" // Only "nearest" summaries
+	FeatureTrails      = "trails"       // Recent positions of each track with detections
+	FeatureReplay      = "replay"       // ?since=<seq> resume; advertised only
+	FeatureCompression = "compression"  // permessage-deflate; advertised only
+	FeaturePreview     = "preview"      // MJPEG preview at /preview.mjpeg; advertised only
+)
+
+// helloMessage is sent by the server on connect and by clients to negotiate
+type helloMessage struct {
+	Type     string   `json:"type"`
+	Protocol int      `json:"protocol"`
+	Server   string   `json:"server,omitempty"`
+	Features []string `json:"features"`
+}
+
+// helloAck confirms what the server enabled for a client
+type helloAck struct {
+	Type     string   `json:"type"`
+	Protocol int      `json:"protocol"`
+	Features []string `json:"features"` // Accepted subset of the requested features
+	Error    string   `json:"error,omitempty"`
+}
+
+// features lists what this server can offer right now
+func (s *Server) features() []string {
+	features := []string{FeatureDeltas, FeatureNearestOnly, FeatureTrails, FeatureReplay}
+	if s.config.Compression {
+		features = append(features, FeatureCompression)
+	}
+	if s.engine.Preview().Enabled() {
+		features = append(features, FeaturePreview)
+	}
+	return features
+}
+
+// hello is the greeting queued for every new client
+func (s *Server) hello() []byte {
+	data, _ := json.Marshal(helloMessage{
+		Type:     "hello",
+		Protocol: ProtocolVersion,
+		Server:   "vrchat-proximity",
+		Features: s.features(),
+	})
+	return data
+}
+
+// handleMessage processes a message from the client; only hello is understood
+func (c *Client) handleMessage(data []byte) {
+	var hello helloMessage
+	if err := json.Unmarshal(data, &hello); err != nil || hello.Type != "hello" {
+		return
+	}
+
+	ack := helloAck{Type: "hello_ack", Protocol: min(hello.Protocol, ProtocolVersion), Features: []string{}}
+	if hello.Protocol < 1 {
+		ack.Protocol = 0
+		ack.Error = "unsupported protocol version"
+	} else {
+		offered := make(map[string]bool)
+		for _, f := range c.server.features() {
+			offered[f] = true
+		}
+		for _, f := range hello.Features {
+			if !offered[f] {
+				continue
+			}
+			switch f {
+			case FeatureDeltas:
+				if c.delta.Load() == nil {
+					c.delta.Store(&deltaState{})
+				}
+			case FeatureNearestOnly:
+				c.nearestOnly.Store(true)
+			case FeatureTrails:
+				c.trails.Store(true)
+			case FeatureCompression:
+				// Only the upgrade request can negotiate permessage-deflate
+				if !c.compressed {
+					continue
+				}
+			}
+			ack.Features = append(ack.Features, f)
+		}
+		sort.Strings(ack.Features)
+	}
+
+	data, _ = json.Marshal(ack)
+	c.trySend(data)
+}
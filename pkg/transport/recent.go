@@ -0,0 +1,91 @@
+package transport
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"vrchat-proximity/pkg/engine"
+)
+
+// recentBatch is one detection batch in /history/recent
+type recentBatch struct {
+	Timestamp  int64              `json:"timestamp_ms"` // Unix milliseconds
+	Detections []engine.Detection `json:"detections"`
+}
+
+// recentResponse is the response of /history/recent
+type recentResponse struct {
+	Seconds     float64       `json:"seconds"`
+	FrameWidth  int           `json:"frame_width"`
+	FrameHeight int           `json:"frame_height"`
+	Batches     []recentBatch `json:"batches"` // Oldest first, including empty batches
+}
+
+// recentBuffer keeps the detection batches of the last window in memory,
+// so late clients can draw what just happened without the history database
+type recentBuffer struct {
+	mu      sync.Mutex
+	window  time.Duration
+	batches []recentBatch
+}
+
+// newRecentBuffer creates a buffer holding window worth of batches
+func newRecentBuffer(window time.Duration) *recentBuffer {
+	return &recentBuffer{window: window}
+}
+
+// add records a batch and evicts those older than the window
+func (b *recentBuffer) add(detections []engine.Detection, now time.Time) {
+	if b.window <= 0 {
+		return
+	}
+	batch := recentBatch{Timestamp: now.UnixMilli(), Detections: append([]engine.Detection{}, detections...)}
+	cutoff := now.Add(-b.window).UnixMilli()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	drop := 0
+	for drop < len(b.batches) && b.batches[drop].Timestamp < cutoff {
+		drop++
+	}
+	b.batches = append(b.batches[drop:], batch)
+}
+
+// since returns the batches at or after from, oldest first
+func (b *recentBuffer) since(from time.Time) []recentBatch {
+	cutoff := from.UnixMilli()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, batch := range b.batches {
+		if batch.Timestamp >= cutoff {
+			return append([]recentBatch(nil), b.batches[i:]...)
+		}
+	}
+	return []recentBatch{}
+}
+
+// handleRecent serves the batches of the last ?seconds= (default and at
+// most the configured window)
+func (s *Server) handleRecent(w http.ResponseWriter, r *http.Request) {
+	window := s.recent.window
+	if v := r.URL.Query().Get("seconds"); v != "" {
+		seconds, err := strconv.ParseFloat(v, 64)
+		if err != nil || seconds <= 0 {
+			http.Error(w, "seconds must be a positive number", http.StatusBadRequest)
+			return
+		}
+		window = min(window, time.Duration(seconds*float64(time.Second)))
+	}
+
+	width, height := s.engine.FrameSize()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(recentResponse{
+		Seconds:     window.Seconds(),
+		FrameWidth:  width,
+		FrameHeight: height,
+		Batches:     s.recent.since(time.Now().Add(-window)),
+	})
+}
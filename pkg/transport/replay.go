@@ -0,0 +1,88 @@
+package transport
+
+import (
+	"encoding/json"
+	"sync"
+
+	"vrchat-proximity/pkg/engine"
+)
+
+// sequencedEvent is a proximity event as broadcast, with its sequence number
+type sequencedEvent struct {
+	Seq int64 `json:"seq"`
+	engine.ProximityEvent
+}
+
+// replayTruncated tells a resuming client that events older than the buffer were lost
+type replayTruncated struct {
+	Type      string `json:"type"`
+	Since     int64  `json:"since"`
+	OldestSeq int64  `json:"oldest_seq"`
+}
+
+// replayEntry is one buffered event message
+type replayEntry struct {
+	seq  int64
+	data []byte
+}
+
+// replayBuffer keeps the most recent event messages so a reconnecting
+// client can resume with ?since=<seq>. mu is held across recording and
+// broadcasting so a resuming client sees every event exactly once.
+type replayBuffer struct {
+	mu      sync.Mutex
+	size    int
+	entries []replayEntry
+	evicted int64 // Seq of the newest event dropped from the buffer, 0 if none
+}
+
+// newReplayBuffer creates a buffer holding up to size events
+func newReplayBuffer(size int) *replayBuffer {
+	return &replayBuffer{size: size}
+}
+
+// add records an event message; mu must be held
+func (b *replayBuffer) add(seq int64, data []byte) {
+	if b.size <= 0 {
+		return
+	}
+	if len(b.entries) == b.size {
+		b.evicted = b.entries[0].seq
+		copy(b.entries, b.entries[1:])
+		b.entries = b.entries[:b.size-1]
+	}
+	b.entries = append(b.entries, replayEntry{seq, data})
+}
+
+// since returns the buffered messages after seq and whether events after
+// seq were already evicted. Other message types share the sequence, so gaps
+// between buffered events don't mean anything was lost; mu must be held
+func (b *replayBuffer) since(seq int64) (messages [][]byte, truncated bool, oldest int64) {
+	if len(b.entries) == 0 {
+		return nil, false, 0
+	}
+	oldest = b.entries[0].seq
+	for _, entry := range b.entries {
+		if entry.seq > seq {
+			messages = append(messages, entry.data)
+		}
+	}
+	return messages, seq < b.evicted, oldest
+}
+
+// replayTo queues the events a client missed since seq ahead of live messages;
+// mu must be held
+func (b *replayBuffer) replayTo(client *Client, seq int64) {
+	messages, truncated, oldest := b.since(seq)
+	if truncated {
+		data, _ := json.Marshal(replayTruncated{Type: "replay_truncated", Since: seq, OldestSeq: oldest})
+		messages = append([][]byte{data}, messages...)
+	}
+	// The client isn't registered yet, so nothing else is sending to it
+	if n := cap(client.send) - len(client.send); len(messages) > n {
+		messages = messages[len(messages)-n:]
+	}
+	for _, data := range messages {
+		client.send <- data
+	}
+}
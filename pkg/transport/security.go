@@ -0,0 +1,129 @@
+package transport
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// SecurityConfig configures API authentication and cross-origin access
+type SecurityConfig struct {
+	APIKeys        []string `yaml:"api_keys"`        // Empty disables authentication
+	AllowedOrigins []string `yaml:"allowed_origins"` // "*" allows any origin; same-origin requests are always allowed
+}
+
+// DefaultSecurityConfig allows unauthenticated same-origin access
+func DefaultSecurityConfig() SecurityConfig {
+	return SecurityConfig{}
+}
+
+// dashboardAssets are served without authentication so the page can load
+// and prompt the browser for a token
+var dashboardAssets = map[string]bool{
+	"/":            true,
+	"/index.html":  true,
+	"/app.js":      true,
+	"/style.css":   true,
+	"/favicon.ico": true,
+}
+
+// healthEndpoints are served without authentication so process
+// supervisors can probe them without an API key
+var healthEndpoints = map[string]bool{
+	"/healthz":             true,
+	"/readyz":              true,
+	APIPrefix + "/healthz": true,
+	APIPrefix + "/readyz":  true,
+}
+
+// Security enforces API keys and origin allowlists on the HTTP server
+type Security struct {
+	config SecurityConfig
+}
+
+// NewSecurity creates the HTTP security layer
+func NewSecurity(config SecurityConfig) *Security {
+	return &Security{config: config}
+}
+
+// Middleware applies CORS headers and API-key checks before next
+func (s *Security) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); origin != "" {
+			if !s.CheckOrigin(r) {
+				http.Error(w, "origin not allowed", http.StatusForbidden)
+				return
+			}
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Vary", "Origin")
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization, X-API-Key, Content-Type")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		}
+
+		// CORS preflight requests never carry credentials
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if !dashboardAssets[r.URL.Path] && !healthEndpoints[r.URL.Path] && !s.Authorized(r) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="vrchat-proximity"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if !s.allowDebug(r) {
+			http.Error(w, "debug endpoints are only available from localhost", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Authorized reports whether the request carries a valid API key in the
+// Authorization header, the X-API-Key header, or the token query parameter
+func (s *Security) Authorized(r *http.Request) bool {
+	if len(s.config.APIKeys) == 0 {
+		return true
+	}
+
+	token := r.Header.Get("X-API-Key")
+	if auth := r.Header.Get("Authorization"); token == "" && strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	}
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+	if token == "" {
+		return false
+	}
+
+	for _, key := range s.config.APIKeys {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(key)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckOrigin reports whether a browser origin may use the API. Requests
+// without an Origin header (native clients) are always allowed.
+func (s *Security) CheckOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+
+	for _, allowed := range s.config.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,952 @@
+// Package transport exposes the engine over HTTP, WebSocket, SSE, gRPC,
+// OSC, MQTT, and other integrations.
+package transport
+
+import (
+	"compress/flate"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"vrchat-proximity/pkg/capture"
+	"vrchat-proximity/pkg/engine"
+	"vrchat-proximity/pkg/logging"
+)
+
+// wsLog is the "ws" subsystem logger
+var wsLog = logging.For("ws")
+
+// ServerConfig configures the HTTP and WebSocket server
+type ServerConfig struct {
+	Addr             string         `yaml:"addr"`
+	Security         SecurityConfig `yaml:"security"`
+	NearestRate      float64        `yaml:"nearest_rate"`       // "nearest" messages per second; 0 disables the stream
+	DeltaKeyframe    int            `yaml:"delta_keyframe"`     // Send a full keyframe every this many delta messages
+	ReplayBuffer     int            `yaml:"replay_buffer"`      // Recent events kept for clients resuming with ?since=
+	RecentWindow     time.Duration  `yaml:"recent_window"`      // Detections kept in memory for /history/recent; 0 disables it
+	ClientRate       float64        `yaml:"client_rate"`        // Max detection and nearest messages per second per client; 0 is unlimited
+	SlowClientPolicy string         `yaml:"slow_client_policy"` // "disconnect" or "drop" when a client's send buffer fills
+	Compression      bool           `yaml:"compression"`        // Negotiate permessage-deflate with clients that offer it
+	TLS              *tls.Config    `yaml:"-"`                  // Serve HTTPS and WSS when set
+}
+
+// DefaultServerConfig listens on :8080 without authentication or TLS and
+// streams the nearest detection at 5 Hz
+func DefaultServerConfig() ServerConfig {
+	return ServerConfig{
+		Addr:             ":8080",
+		Security:         DefaultSecurityConfig(),
+		NearestRate:      5,
+		DeltaKeyframe:    30,
+		ReplayBuffer:     128,
+		RecentWindow:     time.Minute,
+		SlowClientPolicy: SlowClientDisconnect,
+		Compression:      true,
+	}
+}
+
+// Server serves the dashboard, REST API, WebSocket, and SSE streams for an engine
+type Server struct {
+	engine   *engine.ProximityEngine
+	config   ServerConfig
+	clients  sync.Map // WebSocket clients
+	sse      *sseHub
+	security *Security
+	upgrader websocket.Upgrader
+	server   *http.Server
+	mux      *http.ServeMux // Everything the server serves
+	api      *http.ServeMux // API routes, mounted under APIPrefix and at the root
+	done     chan struct{}  // Closed by Stop
+	seq      atomic.Int64   // Last message sequence number
+	replay   *replayBuffer
+	recent   *recentBuffer
+	polls    pollCache // Serialized /status and /nearest responses
+
+	clientIDs       atomic.Int64
+	dropped         atomic.Int64 // Messages dropped across all clients
+	slowDisconnects atomic.Int64
+}
+
+// Client is a connected WebSocket client
+type Client struct {
+	id          int64
+	remoteAddr  string
+	userAgent   string
+	connectedAt time.Time
+	conn        *websocket.Conn
+	send        chan []byte // Events and deltas, never skipped
+	server      *Server
+	nearestOnly atomic.Bool                // ?stream=nearest or the nearest_only feature
+	delta       atomic.Pointer[deltaState] // Set by ?delta=1 or the deltas feature
+	trails      atomic.Bool                // ?trails=1 or the trails feature
+
+	mu         sync.Mutex // Guards send against close and pending; broadcasts come from several goroutines
+	closed     bool
+	pending    map[string][]byte // Latest unsent state message per kind
+	stateReady chan struct{}
+
+	sent         atomic.Int64
+	received     atomic.Int64
+	coalesced    atomic.Int64
+	dropped      atomic.Int64
+	payloadBytes atomic.Int64  // Message bytes before compression
+	wireBytes    *atomic.Int64 // Bytes written to the socket, including framing
+	compressed   bool          // permessage-deflate negotiated
+}
+
+// APIPrefix is where the versioned API is served
+const APIPrefix = "/api/v1"
+
+// NewServer creates the HTTP server and subscribes it to the engine's output
+func NewServer(pe *engine.ProximityEngine, config ServerConfig) *Server {
+	security := NewSecurity(config.Security)
+	s := &Server{
+		engine:   pe,
+		config:   config,
+		sse:      newSSEHub(),
+		security: security,
+		upgrader: newUpgrader(security, config.Compression),
+		done:     make(chan struct{}),
+		replay:   newReplayBuffer(config.ReplayBuffer),
+		recent:   newRecentBuffer(config.RecentWindow),
+		mux:      http.NewServeMux(),
+		api:      http.NewServeMux(),
+	}
+
+	s.routes()
+
+	pe.Subscribe("websocket", engine.BusBuffer, engine.Subscriber{Detections: s.broadcastDetections, Event: s.broadcastEvent})
+	return s
+}
+
+// newUpgrader creates the WebSocket upgrader enforcing the origin allowlist
+func newUpgrader(security *Security, compression bool) websocket.Upgrader {
+	return websocket.Upgrader{
+		CheckOrigin:       security.CheckOrigin,
+		EnableCompression: compression,
+	}
+}
+
+// routes registers the API on its own mux and mounts it, the debug
+// endpoints, and the dashboard on the server's mux
+func (s *Server) routes() {
+	pe := s.engine
+	api := s.api
+
+	api.HandleFunc("/ws", s.handleWebSocket)
+	api.HandleFunc("/status", s.handleStatus)
+	api.HandleFunc("/nearest", s.handleNearest)
+	api.HandleFunc("/metrics", s.handleMetrics)
+	api.HandleFunc("/healthz", s.handleHealthz)
+	api.HandleFunc("/readyz", s.handleReadyz)
+	api.HandleFunc("/config/capture", s.handleCaptureConfig)
+	api.HandleFunc("/config/detection", s.handleDetectionConfig)
+	api.HandleFunc("/config/detector", s.handleDetectorConfig)
+	api.HandleFunc("/config/alerts", s.handleAlertConfig)
+	api.HandleFunc("/config/camera", s.handleCameraConfig)
+	api.HandleFunc("/config/categories", s.handleCategoryConfig)
+	api.HandleFunc("/profiles", s.handleColorProfiles)
+	api.HandleFunc("/players/rules", s.handlePlayerRules)
+	api.HandleFunc("/rules", s.handleRules)
+	api.HandleFunc("/instance", s.handleInstance)
+	api.HandleFunc("/sessions", s.handleSessions)
+	api.HandleFunc("/clients", s.handleClients)
+	api.HandleFunc("/clients/", s.handleClient)
+	api.HandleFunc("/heatmap.png", s.handleHeatmap)
+	api.Handle(engine.ClipURLPrefix, s.engine.ClipHandler())
+	api.HandleFunc("/history/recent", s.handleRecent)
+	api.HandleFunc("/feedback", s.handleFeedback)
+	api.HandleFunc("/calibration", s.handleCalibration)
+	api.HandleFunc("/engine/start", s.handleEngineControl(s.engine.Start))
+	api.HandleFunc("/engine/stop", s.handleEngineControl(func() error { s.engine.Stop(); return nil }))
+	api.HandleFunc("/engine/pause", s.handleEngineControl(s.engine.Pause))
+	api.HandleFunc("/actions/pause", s.handleAction(s.actionPause))
+	api.HandleFunc("/actions/toggle-haptics", s.handleAction(s.actionToggleHaptics))
+	api.HandleFunc("/actions/sensitivity/up", s.handleAction(s.actionSensitivity(1)))
+	api.HandleFunc("/actions/sensitivity/down", s.handleAction(s.actionSensitivity(-1)))
+	api.Handle("/events", s.sse)
+	api.Handle("/preview.mjpeg", pe.Preview())
+	api.HandleFunc("/config/preview", pe.Preview().HandleConfig)
+
+	s.mux.Handle(APIPrefix+"/", http.StripPrefix(APIPrefix, api))
+	registerDebug(s.mux)
+
+	// Unversioned API paths are kept as aliases; anything else is the dashboard
+	dashboard := dashboardHandler()
+	s.mux.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, pattern := api.Handler(r); pattern != "" {
+			api.ServeHTTP(w, r)
+			return
+		}
+		dashboard.ServeHTTP(w, r)
+	}))
+}
+
+// API returns the mux of the API routes, which are served under APIPrefix
+// and at their unversioned paths. Other components add their endpoints here.
+func (s *Server) API() *http.ServeMux {
+	return s.api
+}
+
+// Handler returns the server's routes behind authentication and the origin
+// allowlist, for embedding in another HTTP server
+func (s *Server) Handler() http.Handler {
+	return s.security.Middleware(s.mux)
+}
+
+// Start serves the routes in the background
+func (s *Server) Start() error {
+
+	listener, err := net.Listen("tcp", s.config.Addr)
+	if err != nil {
+		return fmt.Errorf("listen HTTP: %w", err)
+	}
+
+	s.server = &http.Server{
+		Handler:   s.Handler(),
+		TLSConfig: s.config.TLS,
+	}
+
+	go func() {
+		var err error
+		if s.config.TLS != nil {
+			wsLog.Info("WebSocket server starting", "addr", listener.Addr().String(), "tls", true)
+			err = s.server.ServeTLS(listener, "", "")
+		} else {
+			wsLog.Info("WebSocket server starting", "addr", listener.Addr().String(), "tls", false)
+			err = s.server.Serve(listener)
+		}
+		if err != nil && err != http.ErrServerClosed {
+			wsLog.Error("WebSocket server failed", "error", err)
+		}
+	}()
+	go s.engine.Supervise(s.done, engine.SubsystemBroadcast, s.streamNearest)
+	return nil
+}
+
+// Stop closes the listener and waits briefly for in-flight requests
+func (s *Server) Stop() {
+	if s.server == nil {
+		return
+	}
+	close(s.done)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s.server.Shutdown(ctx)
+}
+
+// handleWebSocket handles new WebSocket connections. With ?stream=nearest
+// the client receives only the "nearest" summaries; with ?delta=1 it gets
+// detections_delta messages instead of full detection lists. ?since=<seq>
+// first replays the buffered events after that sequence number. Every client
+// is greeted with hello and may answer with its own to pick features.
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	wire := new(atomic.Int64)
+	conn, err := s.upgrader.Upgrade(countingResponseWriter{w, wire}, r, nil)
+	if err != nil {
+		wsLog.Warn("WebSocket upgrade failed", "error", err)
+		return
+	}
+	compressed := s.config.Compression && offersDeflate(r)
+	if compressed {
+		// Favor CPU over ratio; detection JSON compresses well even at the fastest level
+		conn.SetCompressionLevel(flate.BestSpeed)
+	}
+
+	client := &Client{
+		id:          s.clientIDs.Add(1),
+		remoteAddr:  r.RemoteAddr,
+		userAgent:   r.UserAgent(),
+		connectedAt: time.Now(),
+		conn:        conn,
+		send:        make(chan []byte, 256),
+		server:      s,
+		pending:     make(map[string][]byte),
+		stateReady:  make(chan struct{}, 1),
+		compressed:  compressed,
+		wireBytes:   wire,
+	}
+	client.nearestOnly.Store(r.URL.Query().Get("stream") == "nearest")
+	if r.URL.Query().Get("delta") == "1" {
+		client.delta.Store(&deltaState{})
+	}
+	client.trails.Store(r.URL.Query().Get("trails") == "1")
+	client.send <- s.hello()
+
+	s.replay.mu.Lock()
+	if since := r.URL.Query().Get("since"); since != "" {
+		if seq, err := strconv.ParseInt(since, 10, 64); err == nil {
+			s.replay.replayTo(client, seq)
+		}
+	}
+	s.clients.Store(client, true)
+	s.replay.mu.Unlock()
+
+	// Start client goroutines
+	go client.writePump()
+	go client.readPump()
+}
+
+// writePump sends messages to WebSocket client. Queued messages go out in
+// order; state messages are coalesced and rate limited.
+func (c *Client) writePump() {
+	ticker := time.NewTicker(54 * time.Second)
+	throttle := time.NewTimer(0)
+	throttle.Stop()
+	defer func() {
+		ticker.Stop()
+		throttle.Stop()
+		c.conn.Close()
+	}()
+
+	var nextState time.Time
+	throttled := false
+	for {
+		select {
+		case message, ok := <-c.send:
+			if !ok {
+				c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+
+			if err := c.write(message); err != nil {
+				return
+			}
+
+		case <-c.stateReady:
+			if throttled {
+				continue
+			}
+			if wait := time.Until(nextState); wait > 0 {
+				throttled = true
+				throttle.Reset(wait)
+				continue
+			}
+			if !c.flushState(&nextState) {
+				return
+			}
+
+		case <-throttle.C:
+			throttled = false
+			if !c.flushState(&nextState) {
+				return
+			}
+
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// flushState sends the pending state messages and schedules the next allowed send
+func (c *Client) flushState(nextState *time.Time) bool {
+	for _, data := range c.takeState() {
+		if err := c.write(data); err != nil {
+			return false
+		}
+	}
+	*nextState = time.Now().Add(c.stateInterval())
+	return true
+}
+
+// readPump handles messages from WebSocket client
+func (c *Client) readPump() {
+	defer func() {
+		c.server.clients.Delete(c)
+		c.conn.Close()
+	}()
+
+	c.conn.SetReadLimit(512)
+	c.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+	c.conn.SetPongHandler(func(string) error {
+		c.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+		return nil
+	})
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			break
+		}
+		c.received.Add(1)
+		c.handleMessage(data)
+	}
+}
+
+// broadcastDetections sends detections to all connected clients. Delta
+// clients also hear about detections disappearing.
+func (s *Server) broadcastDetections(detections []engine.Detection) {
+	s.recent.add(detections, time.Now())
+	s.broadcastDeltas(detections)
+	if len(detections) == 0 {
+		return
+	}
+
+	status := s.engine.Status()
+	message := map[string]interface{}{
+		"type":         "detections",
+		"seq":          s.nextSeq(),
+		"timestamp":    time.Now().Unix(),
+		"count":        len(detections),
+		"detections":   detections,
+		"crowd":        s.engine.Crowd(),
+		"frame_count":  status.FramesProcessed,
+		"frame_width":  status.FrameWidth,
+		"frame_height": status.FrameHeight,
+	}
+	if sources := s.engine.Sources(); len(sources) > 1 {
+		// Where each source sits in the merged frame
+		message["sources"] = sources
+	}
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		wsLog.Error("JSON marshal failed", "error", err)
+		return
+	}
+
+	full := func(c *Client) bool { return !c.nearestOnly.Load() && c.delta.Load() == nil }
+	s.broadcastState(stateDetections, data, func(c *Client) bool { return full(c) && !c.trails.Load() })
+	s.sse.publish("detections", data)
+
+	// Clients that draw motion trails get the same message with each track's path
+	if !s.anyClient(func(c *Client) bool { return full(c) && c.trails.Load() }) {
+		return
+	}
+	message["trails"] = s.engine.Trails(detections)
+	if data, err = json.Marshal(message); err != nil {
+		wsLog.Error("JSON marshal failed", "error", err)
+		return
+	}
+	s.broadcastState(stateDetections, data, func(c *Client) bool { return full(c) && c.trails.Load() })
+}
+
+// anyClient reports whether a connected client satisfies match
+func (s *Server) anyClient(match func(*Client) bool) bool {
+	found := false
+	s.clients.Range(func(key, value interface{}) bool {
+		found = match(key.(*Client))
+		return !found
+	})
+	return found
+}
+
+// broadcastEvent sends a proximity event to WebSocket and SSE clients and
+// keeps it for clients that reconnect
+func (s *Server) broadcastEvent(event engine.ProximityEvent) {
+	s.replay.mu.Lock()
+	defer s.replay.mu.Unlock()
+
+	seq := s.nextSeq()
+	data, err := json.Marshal(sequencedEvent{Seq: seq, ProximityEvent: event})
+	if err != nil {
+		wsLog.Error("JSON marshal failed", "error", err)
+		return
+	}
+	s.replay.add(seq, data)
+	s.broadcast(data)
+	s.sse.publish(event.Type, data)
+}
+
+// nextSeq returns the sequence number for the next broadcast message
+func (s *Server) nextSeq() int64 {
+	return s.seq.Add(1)
+}
+
+// broadcast sends a message to all clients except nearest-only ones
+func (s *Server) broadcast(data []byte) {
+	s.broadcastTo(data, func(c *Client) bool { return !c.nearestOnly.Load() })
+}
+
+// broadcastDeltas sends each delta client the changes since its last message
+func (s *Server) broadcastDeltas(detections []engine.Detection) {
+	keyframeEvery := max(s.config.DeltaKeyframe, 1)
+	s.clients.Range(func(key, value interface{}) bool {
+		client := key.(*Client)
+		delta := client.delta.Load()
+		if delta == nil {
+			return true
+		}
+		if data := delta.encode(detections, keyframeEvery, s.nextSeq); data != nil && !client.trySend(data) {
+			s.clients.Delete(client)
+		}
+		return true
+	})
+}
+
+// broadcastState offers a coalescable state message to the clients include accepts
+func (s *Server) broadcastState(kind string, data []byte, include func(*Client) bool) {
+	s.clients.Range(func(key, value interface{}) bool {
+		client := key.(*Client)
+		if include(client) {
+			client.offerState(kind, data)
+		}
+		return true
+	})
+}
+
+// broadcastTo sends a message to the connected clients include accepts
+func (s *Server) broadcastTo(data []byte, include func(*Client) bool) {
+	s.clients.Range(func(key, value interface{}) bool {
+		client := key.(*Client)
+		if !include(client) {
+			return true
+		}
+		if !client.trySend(data) {
+			// Remove slow client
+			s.clients.Delete(client)
+		}
+		return true
+	})
+}
+
+// handleStatus provides status endpoint
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	s.servePolled(w, r, s.status)
+}
+
+// status builds the /status response
+func (s *Server) status() interface{} {
+	st := s.engine.Status()
+	return map[string]interface{}{
+		"running":            st.Running,
+		"state":              s.engine.State(),
+		"paused_reason":      st.PausedReason,
+		"frames_processed":   st.FramesProcessed,
+		"frames_skipped":     st.FramesSkipped,
+		"frames_dropped":     st.FramesDropped,
+		"fps":                s.engine.FPS(),
+		"detections_per_sec": s.engine.DetectionRate(),
+		"dropped_per_sec":    s.engine.DropRate(),
+		"total_detections":   st.TotalDetections,
+		"current_detections": st.CurrentDetections,
+		"avg_process_time":   st.AvgProcessTimeMS,
+		"target_fps":         st.TargetFPS,
+		"sensitivity":        st.Sensitivity,
+		"frame_width":        st.FrameWidth,
+		"frame_height":       st.FrameHeight,
+		"privacy_mode":       s.engine.PrivacyMode(),
+		"degraded_level":     st.DegradedLevel,
+		"facing_mirror":      s.engine.FacingMirror(),
+		"cpu_cores":          runtime.NumCPU(),
+		"goroutines":         runtime.NumGoroutine(),
+	}
+}
+
+// handleMetrics provides detailed metrics
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	st := s.engine.Status()
+	metrics := map[string]interface{}{
+		"memory": map[string]interface{}{
+			"alloc_mb":     float64(m.Alloc) / 1024 / 1024,
+			"sys_mb":       float64(m.Sys) / 1024 / 1024,
+			"gc_cycles":    m.NumGC,
+			"heap_objects": m.HeapObjects,
+		},
+		"performance": map[string]interface{}{
+			"frames_per_sec":     s.engine.FPS(),
+			"frames_skipped":     st.FramesSkipped,
+			"frames_dropped":     st.FramesDropped,
+			"detections_per_sec": s.engine.DetectionRate(),
+			"dropped_per_sec":    s.engine.DropRate(),
+			"avg_process_time":   st.AvgProcessTimeMS,
+			"cpu_usage":          st.CPUUsage,
+		},
+		"run":       s.engine.RunStats(),
+		"lifetime":  s.engine.LifetimeStats(),
+		"latency":   s.engine.StageLatencies(),
+		"restarts":  s.engine.Restarts(),
+		"outputs":   s.engine.BusStats(),
+		"websocket": s.clientMetrics(),
+		"system": map[string]interface{}{
+			"goroutines": runtime.NumGoroutine(),
+			"cpu_cores":  runtime.NumCPU(),
+			"os":         runtime.GOOS,
+			"arch":       runtime.GOARCH,
+		},
+	}
+
+	if buffers, ok := s.engine.BufferStats(); ok {
+		metrics["frame_buffers"] = buffers
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(metrics)
+}
+
+// captureSettings is the body of /config/capture
+type captureSettings struct {
+	TargetFPS   int `json:"target_fps"`
+	Sensitivity int `json:"sensitivity"`
+}
+
+// handleCaptureConfig reads or updates FPS and sensitivity
+func (s *Server) handleCaptureConfig(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var settings captureSettings
+		if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		if settings.TargetFPS != 0 {
+			if settings.TargetFPS < 1 || settings.TargetFPS > 120 {
+				http.Error(w, "target_fps must be between 1 and 120", http.StatusBadRequest)
+				return
+			}
+			s.engine.SetTargetFPS(settings.TargetFPS)
+		}
+		if settings.Sensitivity != 0 {
+			if settings.Sensitivity < 1 || settings.Sensitivity > 100 {
+				http.Error(w, "sensitivity must be between 1 and 100", http.StatusBadRequest)
+				return
+			}
+			s.engine.SetSensitivity(settings.Sensitivity)
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(captureSettings{
+		TargetFPS:   s.engine.TargetFPS(),
+		Sensitivity: s.engine.Sensitivity(),
+	})
+}
+
+// handleDetectionConfig reads or updates the detection filter. POST bodies
+// may set any subset of merge_iou, min_confidence, min_area, and max_detections.
+func (s *Server) handleDetectionConfig(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		config := s.engine.DetectionConfig()
+		if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		if err := config.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.engine.SetDetectionConfig(config)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.engine.DetectionConfig())
+}
+
+// handleCameraConfig reads or updates the camera model used for distance.
+// POST bodies may set any subset of enabled, fov, render_width,
+// render_height, and avatar_height.
+func (s *Server) handleCameraConfig(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		config := s.engine.CameraConfig()
+		if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		if err := config.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.engine.SetCameraConfig(config)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.engine.CameraConfig())
+}
+
+// handleCategoryConfig reads or replaces the distance category table. POST
+// bodies list every band, nearest first.
+func (s *Server) handleCategoryConfig(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var bands []engine.DistanceBand
+		if err := json.NewDecoder(r.Body).Decode(&bands); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		if err := s.engine.SetDistanceBands(bands); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(engine.DistanceBands())
+}
+
+// alertSettings is the response of /config/alerts
+type alertSettings struct {
+	engine.AlertConfig
+	QuietNow bool `json:"quiet_now"`
+}
+
+// handleAlertConfig reads or replaces the alert cooldown and quiet hours
+func (s *Server) handleAlertConfig(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		config := s.engine.AlertConfig()
+		if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		if err := config.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.engine.SetAlertConfig(config)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(alertSettings{AlertConfig: s.engine.AlertConfig(), QuietNow: s.engine.QuietHoursActive()})
+}
+
+// detectorSettings is the body of /config/detector
+type detectorSettings struct {
+	Algorithm string   `json:"algorithm"`
+	Available []string `json:"available,omitempty"`
+}
+
+// handleDetectorConfig reads or switches the motion algorithm. POST bodies
+// name one of the available algorithms.
+func (s *Server) handleDetectorConfig(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var settings detectorSettings
+		if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		if err := s.engine.SetMotionAlgorithm(settings.Algorithm); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(detectorSettings{
+		Algorithm: s.engine.MotionAlgorithm(),
+		Available: capture.Algorithms,
+	})
+}
+
+// handleColorProfiles lists the color profiles on GET, adds or replaces the
+// profile with the same name on POST, and removes ?name= on DELETE
+func (s *Server) handleColorProfiles(w http.ResponseWriter, r *http.Request) {
+	profiles := s.engine.ColorProfiles()
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var profile engine.ColorProfile
+		if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		replaced := false
+		for i := range profiles {
+			if profiles[i].Name == profile.Name {
+				profiles[i], replaced = profile, true
+			}
+		}
+		if !replaced {
+			profiles = append(profiles, profile)
+		}
+		if err := s.engine.SetColorProfiles(profiles); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	case http.MethodDelete:
+		name := r.URL.Query().Get("name")
+		kept := profiles[:0]
+		for _, p := range profiles {
+			if p.Name != name {
+				kept = append(kept, p)
+			}
+		}
+		if len(kept) == len(profiles) {
+			http.Error(w, "no profile named "+strconv.Quote(name), http.StatusNotFound)
+			return
+		}
+		s.engine.SetColorProfiles(kept)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.engine.ColorProfiles())
+}
+
+// handlePlayerRules lists the player rules on GET, adds or replaces the rule
+// for the same player on POST, and removes ?player= on DELETE
+func (s *Server) handlePlayerRules(w http.ResponseWriter, r *http.Request) {
+	rules := s.engine.PlayerRules()
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var rule engine.PlayerRule
+		if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		replaced := false
+		for i := range rules {
+			if strings.EqualFold(rules[i].Player, rule.Player) {
+				rules[i], replaced = rule, true
+			}
+		}
+		if !replaced {
+			rules = append(rules, rule)
+		}
+		if err := s.engine.SetPlayerRules(rules); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	case http.MethodDelete:
+		player := r.URL.Query().Get("player")
+		kept := rules[:0]
+		for _, rule := range rules {
+			if !strings.EqualFold(rule.Player, player) {
+				kept = append(kept, rule)
+			}
+		}
+		if len(kept) == len(rules) {
+			http.Error(w, "no rule for player "+strconv.Quote(player), http.StatusNotFound)
+			return
+		}
+		s.engine.SetPlayerRules(kept)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.engine.PlayerRules())
+}
+
+// handleRules returns the automation rules on GET and replaces them all on PUT
+func (s *Server) handleRules(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var rules []engine.Rule
+		if err := json.NewDecoder(r.Body).Decode(&rules); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		if err := s.engine.SetRules(rules); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	rules := s.engine.Rules()
+	if rules == nil {
+		rules = []engine.Rule{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rules)
+}
+
+// instanceStatus is the response of /instance
+type instanceStatus struct {
+	engine.Instance
+	AlertsMuted bool `json:"alerts_muted"`
+}
+
+// handleInstance reports the VRChat instance and whether alerts are muted in it
+func (s *Server) handleInstance(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(instanceStatus{Instance: s.engine.Instance(), AlertsMuted: s.engine.AlertsMuted()})
+}
+
+// sessionsResponse is the response of /sessions
+type sessionsResponse struct {
+	Current  *engine.Session  `json:"current,omitempty"`
+	Sessions []engine.Session `json:"sessions"` // Finished sessions, newest first
+}
+
+// handleSessions reports the current session and the finished ones
+func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
+	var resp sessionsResponse
+	if current, ok := s.engine.CurrentSession(); ok {
+		resp.Current = &current
+	}
+	resp.Sessions = s.engine.Sessions()
+	slices.Reverse(resp.Sessions)
+	if resp.Sessions == nil {
+		resp.Sessions = []engine.Session{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// engineState is the response of the /engine endpoints
+type engineState struct {
+	State string `json:"state"`
+}
+
+// handleEngineControl returns a POST handler that applies a lifecycle
+// transition and reports the resulting state. Invalid transitions, such as
+// pausing a stopped engine, return 409.
+func (s *Server) handleEngineControl(transition func() error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := transition(); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(engineState{State: s.engine.State()})
+	}
+}
@@ -0,0 +1,218 @@
+package transport
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"vrchat-proximity/pkg/engine"
+	"vrchat-proximity/pkg/logging"
+)
+
+// speechLog is the "speech" subsystem logger
+var speechLog = logging.For("speech")
+
+// Speech engines
+const (
+	SpeechSAPI   = "sapi"   // Windows System.Speech through PowerShell
+	SpeechEspeak = "espeak" // espeak-ng or espeak
+	SpeechSay    = "say"    // macOS say
+)
+
+// SpeechConfig configures spoken announcements of proximity events
+type SpeechConfig struct {
+	Enabled     bool          `yaml:"enabled"`
+	Engine      string        `yaml:"engine"`       // sapi, espeak, or say; empty picks the one for this OS
+	Voice       string        `yaml:"voice"`        // Engine voice name; empty uses the default
+	Rate        int           `yaml:"rate"`         // Words per minute
+	MinCategory string        `yaml:"min_category"` // Only speak events at least this close
+	MinInterval time.Duration `yaml:"min_interval"` // Between any two announcements
+	Repeat      time.Duration `yaml:"repeat"`       // Before the same event type and category is spoken again
+
+	// text/template phrases rendered with the event, keyed by event type;
+	// event types without a phrase are not spoken
+	Phrases map[string]string `yaml:"phrases"`
+}
+
+// DefaultSpeechConfig speaks close zone entries and fast approaches
+func DefaultSpeechConfig() SpeechConfig {
+	return SpeechConfig{
+		Rate:        180,
+		MinCategory: "Close",
+		MinInterval: 2 * time.Second,
+		Repeat:      15 * time.Second,
+		Phrases: map[string]string{
+			engine.EventZoneEnter:    `Someone {{lower .Category}}, about {{printf "%.0f" .Distance}} meters`,
+			engine.EventFastApproach: `Someone approaching fast`,
+		},
+	}
+}
+
+// Speaker reads events aloud with the platform's text-to-speech engine.
+// One phrase is spoken at a time; a newer phrase replaces one still queued.
+type Speaker struct {
+	config  SpeechConfig
+	phrases map[string]*template.Template
+	command func() *exec.Cmd
+
+	queue chan string
+	stop  chan struct{}
+	wg    sync.WaitGroup
+
+	mu         sync.Mutex
+	lastSpoken time.Time
+	lastPhrase map[string]time.Time // Keyed by event type and category
+}
+
+// NewSpeaker parses the phrases and checks that the speech engine is installed
+func NewSpeaker(config SpeechConfig) (*Speaker, error) {
+	s := &Speaker{
+		config:     config,
+		phrases:    make(map[string]*template.Template),
+		queue:      make(chan string, 1),
+		stop:       make(chan struct{}),
+		lastPhrase: make(map[string]time.Time),
+	}
+	for eventType, phrase := range config.Phrases {
+		t, err := template.New(eventType).Funcs(templateFuncs).Parse(phrase)
+		if err != nil {
+			return nil, fmt.Errorf("speech phrase %q: %w", eventType, err)
+		}
+		s.phrases[eventType] = t
+	}
+
+	command, err := speechCommand(config)
+	if err != nil {
+		return nil, err
+	}
+	s.command = command
+	return s, nil
+}
+
+// speechCommand returns a builder for the engine's command, which reads
+// the text from stdin so it never needs quoting
+func speechCommand(config SpeechConfig) (func() *exec.Cmd, error) {
+	name := config.Engine
+	if name == "" {
+		switch runtime.GOOS {
+		case "windows":
+			name = SpeechSAPI
+		case "darwin":
+			name = SpeechSay
+		default:
+			name = SpeechEspeak
+		}
+	}
+	rate := max(config.Rate, 80)
+
+	switch name {
+	case SpeechSAPI:
+		// SAPI rates run -10 to 10 with 0 at roughly 180 words per minute
+		script := fmt.Sprintf("Add-Type -AssemblyName System.Speech; $s = New-Object System.Speech.Synthesis.SpeechSynthesizer; $s.Rate = %d; ", min(max((rate-180)/20, -10), 10))
+		if config.Voice != "" {
+			script += "$s.SelectVoice('" + strings.ReplaceAll(config.Voice, "'", "''") + "'); "
+		}
+		script += "$s.Speak([Console]::In.ReadToEnd())"
+		if _, err := exec.LookPath("powershell"); err != nil {
+			return nil, fmt.Errorf("speech: sapi needs powershell: %w", err)
+		}
+		return func() *exec.Cmd {
+			return exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+		}, nil
+
+	case SpeechEspeak:
+		binary, err := exec.LookPath("espeak-ng")
+		if err != nil {
+			if binary, err = exec.LookPath("espeak"); err != nil {
+				return nil, fmt.Errorf("speech: espeak-ng or espeak is not installed")
+			}
+		}
+		args := []string{"-s", strconv.Itoa(rate), "--stdin"}
+		if config.Voice != "" {
+			args = append(args, "-v", config.Voice)
+		}
+		return func() *exec.Cmd { return exec.Command(binary, args...) }, nil
+
+	case SpeechSay:
+		args := []string{"-r", strconv.Itoa(rate)}
+		if config.Voice != "" {
+			args = append(args, "-v", config.Voice)
+		}
+		return func() *exec.Cmd { return exec.Command("say", args...) }, nil
+
+	default:
+		return nil, fmt.Errorf("speech: unknown engine %q, want %s, %s, or %s", name, SpeechSAPI, SpeechEspeak, SpeechSay)
+	}
+}
+
+// Start runs the speaking worker
+func (s *Speaker) Start() {
+	s.wg.Add(1)
+	go s.worker()
+}
+
+// Stop ends the worker after the phrase being spoken
+func (s *Speaker) Stop() {
+	close(s.stop)
+	s.wg.Wait()
+}
+
+// HandleEvent queues the event's phrase if it passes the filters and rate limits
+func (s *Speaker) HandleEvent(event engine.ProximityEvent) {
+	phrase, ok := s.phrases[event.Type]
+	if !ok {
+		return
+	}
+	if s.config.MinCategory != "" && event.Category != "" && engine.CategoryRank(event.Category) > engine.CategoryRank(s.config.MinCategory) {
+		return
+	}
+
+	var text bytes.Buffer
+	if err := phrase.Execute(&text, event); err != nil {
+		speechLog.Error("Speech phrase failed", "event", event.Type, "error", err)
+		return
+	}
+
+	s.mu.Lock()
+	now := time.Now()
+	key := event.Type + "|" + event.Category
+	if now.Sub(s.lastSpoken) < s.config.MinInterval || now.Sub(s.lastPhrase[key]) < s.config.Repeat {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSpoken, s.lastPhrase[key] = now, now
+	s.mu.Unlock()
+
+	// Replace a phrase that hasn't started yet; stale news is worse than none
+	select {
+	case <-s.queue:
+	default:
+	}
+	select {
+	case s.queue <- text.String():
+	default:
+	}
+}
+
+// worker speaks queued phrases one at a time
+func (s *Speaker) worker() {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case text := <-s.queue:
+			cmd := s.command()
+			cmd.Stdin = strings.NewReader(text)
+			if out, err := cmd.CombinedOutput(); err != nil {
+				speechLog.Warn("Speech failed", "error", err, "output", strings.TrimSpace(string(out)))
+			}
+		}
+	}
+}
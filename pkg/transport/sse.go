@@ -0,0 +1,101 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sseMessage is one server-sent event
+type sseMessage struct {
+	event string
+	data  []byte
+}
+
+// sseSubscriber is a connected /events client
+type sseSubscriber struct {
+	types map[string]bool // Empty accepts every type
+	send  chan sseMessage
+}
+
+// sseHub fans WebSocket-equivalent messages out to Server-Sent Events clients
+type sseHub struct {
+	subscribers sync.Map // *sseSubscriber -> bool
+	heartbeat   time.Duration
+}
+
+// newSSEHub creates an empty hub
+func newSSEHub() *sseHub {
+	return &sseHub{heartbeat: 15 * time.Second}
+}
+
+// publish sends a message with the given event type to matching subscribers
+func (h *sseHub) publish(eventType string, data []byte) {
+	h.subscribers.Range(func(key, _ interface{}) bool {
+		sub := key.(*sseSubscriber)
+		if len(sub.types) > 0 && !sub.types[eventType] {
+			return true
+		}
+		select {
+		case sub.send <- sseMessage{event: eventType, data: data}:
+		default:
+			// Client is behind; drop rather than block the pipeline
+		}
+		return true
+	})
+}
+
+// ServeHTTP streams events as text/event-stream.
+// Pass ?types=zone_enter,zone_exit to receive only those message types.
+func (h *sseHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	sub := &sseSubscriber{
+		types: make(map[string]bool),
+		send:  make(chan sseMessage, 64),
+	}
+	if types := r.URL.Query().Get("types"); types != "" {
+		for _, t := range strings.Split(types, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				sub.types[t] = true
+			}
+		}
+	}
+	h.subscribers.Store(sub, true)
+	defer h.subscribers.Delete(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	// Tell EventSource how long to wait before reconnecting
+	fmt.Fprint(w, "retry: 2000\n\n")
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case msg := <-sub.send:
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", msg.event, msg.data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
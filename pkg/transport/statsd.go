@@ -0,0 +1,231 @@
+package transport
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"vrchat-proximity/pkg/engine"
+	"vrchat-proximity/pkg/logging"
+)
+
+// statsdLog is the "statsd" subsystem logger
+var statsdLog = logging.For("statsd")
+
+// statsdPacketSize keeps datagrams under a typical Ethernet MTU
+const statsdPacketSize = 1432
+
+// StatsDConfig configures the StatsD metrics emitter
+type StatsDConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Addr     string        `yaml:"addr"`     // StatsD or Telegraf statsd input, host:port over UDP
+	Prefix   string        `yaml:"prefix"`   // Prepended to every metric name, e.g. vrchat_proximity.
+	Interval time.Duration `yaml:"interval"` // Between flushes
+}
+
+// DefaultStatsDConfig returns settings for a StatsD daemon on this machine
+func DefaultStatsDConfig() StatsDConfig {
+	return StatsDConfig{
+		Addr:     "127.0.0.1:8125",
+		Prefix:   "vrchat_proximity.",
+		Interval: 10 * time.Second,
+	}
+}
+
+// Validate checks the address and interval
+func (c StatsDConfig) Validate() error {
+	if c.Enabled && c.Addr == "" {
+		return fmt.Errorf("addr must be set")
+	}
+	if c.Interval < time.Second {
+		return fmt.Errorf("interval must be at least 1s")
+	}
+	return nil
+}
+
+// statsdTotals are the engine counters at the previous flush
+type statsdTotals struct {
+	frames, skipped, dropped, detections int64
+	restarts                             map[string]int64
+}
+
+// StatsDEmitter periodically sends engine gauges, counters, and stage
+// latencies to StatsD, along with a counter per event type
+type StatsDEmitter struct {
+	engine *engine.ProximityEngine
+	config StatsDConfig
+	conn   net.Conn
+
+	mu     sync.Mutex
+	events map[string]int64 // Since the last flush, by event type
+
+	last statsdTotals // Only touched by run
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewStatsDEmitter resolves the StatsD address; nothing is sent until Start
+func NewStatsDEmitter(pe *engine.ProximityEngine, config StatsDConfig) (*StatsDEmitter, error) {
+	conn, err := net.Dial("udp", config.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("statsd: %w", err)
+	}
+	st := pe.Status()
+	return &StatsDEmitter{
+		engine: pe,
+		config: config,
+		conn:   conn,
+		events: make(map[string]int64),
+		last: statsdTotals{
+			frames:     st.FramesProcessed,
+			skipped:    st.FramesSkipped,
+			dropped:    st.FramesDropped,
+			detections: st.TotalDetections,
+			restarts:   pe.Restarts(),
+		},
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}, nil
+}
+
+// Start flushes metrics at the configured interval
+func (e *StatsDEmitter) Start() {
+	statsdLog.Info("Sending metrics to StatsD", "addr", e.config.Addr, "interval", e.config.Interval)
+	go e.run()
+}
+
+// Stop sends a last flush and closes the socket
+func (e *StatsDEmitter) Stop() {
+	close(e.stop)
+	<-e.done
+	e.conn.Close()
+}
+
+// HandleEvent counts the event under events.<type>
+func (e *StatsDEmitter) HandleEvent(event engine.ProximityEvent) {
+	e.mu.Lock()
+	e.events[event.Type]++
+	e.mu.Unlock()
+}
+
+// run flushes until stopped
+func (e *StatsDEmitter) run() {
+	defer close(e.done)
+	ticker := time.NewTicker(e.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.stop:
+			e.flush()
+			return
+		case <-ticker.C:
+			e.flush()
+		}
+	}
+}
+
+// flush sends one round of metrics
+func (e *StatsDEmitter) flush() {
+	var lines []string
+	gauge := func(name string, value float64) {
+		lines = append(lines, e.config.Prefix+name+":"+strconv.FormatFloat(value, 'f', -1, 64)+"|g")
+	}
+	count := func(name string, delta int64) {
+		if delta > 0 {
+			lines = append(lines, e.config.Prefix+name+":"+strconv.FormatInt(delta, 10)+"|c")
+		}
+	}
+
+	st := e.engine.Status()
+	running := 0.0
+	if st.Running && !st.Paused {
+		running = 1
+	}
+	gauge("running", running)
+	gauge("fps", e.engine.FPS())
+	gauge("detections_per_sec", e.engine.DetectionRate())
+	gauge("dropped_per_sec", e.engine.DropRate())
+	gauge("current_detections", float64(st.CurrentDetections))
+	gauge("avg_process_time_ms", st.AvgProcessTimeMS)
+	gauge("cpu_usage", float64(st.CPUUsage))
+	gauge("memory_mb", st.MemoryUsageMB)
+	gauge("degraded_level", float64(st.DegradedLevel))
+
+	// Totals reset when the engine restarts, so a smaller total starts over
+	count("frames", statsdDelta(st.FramesProcessed, e.last.frames))
+	count("frames_skipped", statsdDelta(st.FramesSkipped, e.last.skipped))
+	count("frames_dropped", statsdDelta(st.FramesDropped, e.last.dropped))
+	count("detections", statsdDelta(st.TotalDetections, e.last.detections))
+	e.last.frames, e.last.skipped, e.last.dropped, e.last.detections = st.FramesProcessed, st.FramesSkipped, st.FramesDropped, st.TotalDetections
+
+	restarts := e.engine.Restarts()
+	for subsystem, total := range restarts {
+		count("restarts."+statsdName(subsystem), statsdDelta(total, e.last.restarts[subsystem]))
+	}
+	e.last.restarts = restarts
+
+	for stage, latency := range e.engine.StageLatencies() {
+		gauge("latency."+statsdName(stage)+".p50_ms", latency.P50MS)
+		gauge("latency."+statsdName(stage)+".p95_ms", latency.P95MS)
+		gauge("latency."+statsdName(stage)+".max_ms", latency.MaxMS)
+	}
+
+	e.mu.Lock()
+	for eventType, n := range e.events {
+		count("events."+statsdName(eventType), n)
+	}
+	e.events = make(map[string]int64)
+	e.mu.Unlock()
+
+	sort.Strings(lines)
+	e.send(lines)
+}
+
+// send packs lines into as few datagrams as fit
+func (e *StatsDEmitter) send(lines []string) {
+	var packet bytes.Buffer
+	write := func() {
+		if packet.Len() == 0 {
+			return
+		}
+		if _, err := e.conn.Write(packet.Bytes()); err != nil {
+			statsdLog.Debug("StatsD send failed", "error", err)
+		}
+		packet.Reset()
+	}
+	for _, line := range lines {
+		if packet.Len() > 0 && packet.Len()+1+len(line) > statsdPacketSize {
+			write()
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+	write()
+}
+
+// statsdDelta is how much a total grew, or the whole total after a reset
+func statsdDelta(total, last int64) int64 {
+	if total < last {
+		return total
+	}
+	return total - last
+}
+
+// statsdName makes a metric name segment safe for StatsD and Graphite
+func statsdName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		}
+		return '_'
+	}, name)
+}
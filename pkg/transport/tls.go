@@ -0,0 +1,149 @@
+package transport
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"vrchat-proximity/pkg/logging"
+)
+
+// tlsLog is the "tls" subsystem logger
+var tlsLog = logging.For("tls")
+
+// TLSConfig configures HTTPS/WSS for the HTTP server
+type TLSConfig struct {
+	Enabled      bool     `yaml:"enabled"`
+	CertFile     string   `yaml:"cert_file"`
+	KeyFile      string   `yaml:"key_file"`
+	SelfSigned   bool     `yaml:"self_signed"` // Generate a self-signed certificate if the files don't exist
+	Hosts        []string `yaml:"hosts"`       // Extra DNS names or IPs for the generated certificate
+	ValidForDays int      `yaml:"valid_for_days"`
+}
+
+// DefaultTLSConfig keeps TLS off and generates certificates next to the binary when enabled
+func DefaultTLSConfig() TLSConfig {
+	return TLSConfig{
+		CertFile:     "proximity_cert.pem",
+		KeyFile:      "proximity_key.pem",
+		SelfSigned:   true,
+		ValidForDays: 365,
+	}
+}
+
+// LoadTLS returns a server TLS config, generating a self-signed
+// certificate first if allowed and none exists
+func LoadTLS(config TLSConfig) (*tls.Config, error) {
+	_, certErr := os.Stat(config.CertFile)
+	_, keyErr := os.Stat(config.KeyFile)
+	if errors.Is(certErr, os.ErrNotExist) || errors.Is(keyErr, os.ErrNotExist) {
+		if !config.SelfSigned {
+			return nil, fmt.Errorf("TLS certificate %s or key %s not found", config.CertFile, config.KeyFile)
+		}
+		if err := generateSelfSignedCert(config); err != nil {
+			return nil, fmt.Errorf("generate self-signed certificate: %w", err)
+		}
+	}
+
+	cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load TLS certificate: %w", err)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// generateSelfSignedCert writes a certificate covering localhost, this
+// machine's hostname and LAN addresses, and any configured hosts
+func generateSelfSignedCert(config TLSConfig) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"VRChat Proximity"}, CommonName: "VRChat Proximity Engine"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(0, 0, config.ValidForDays),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+
+	if hostname, err := os.Hostname(); err == nil {
+		template.DNSNames = append(template.DNSNames, hostname)
+	}
+	if addrs, err := net.InterfaceAddrs(); err == nil {
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() {
+				template.IPAddresses = append(template.IPAddresses, ipNet.IP)
+			}
+		}
+	}
+	for _, host := range config.Hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	if err := writePEM(config.CertFile, "CERTIFICATE", der, 0o644); err != nil {
+		return err
+	}
+	if err := writePEM(config.KeyFile, "EC PRIVATE KEY", keyDER, 0o600); err != nil {
+		return err
+	}
+
+	tlsLog.Info("Generated self-signed TLS certificate", "cert", config.CertFile, "valid_days", config.ValidForDays)
+	return nil
+}
+
+// writePEM writes a single PEM block to path
+func writePEM(path, blockType string, der []byte, mode os.FileMode) error {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if err := pem.Encode(file, &pem.Block{Type: blockType, Bytes: der}); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
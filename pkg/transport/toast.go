@@ -0,0 +1,239 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+
+	"vrchat-proximity/pkg/engine"
+	"vrchat-proximity/pkg/logging"
+)
+
+// toastLog is the "toast" subsystem logger
+var toastLog = logging.For("toast")
+
+// toastAppID is the registered app ID toasts are shown under. PowerShell's
+// own ID is always registered, unlike one for an unpackaged Go binary.
+const toastAppID = `{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe`
+
+// vrRuntimes are process names that mean a headset runtime is active
+var vrRuntimes = []string{"vrserver", "vrserver.exe", "OVRServer_x64.exe", "VirtualDesktop.Streamer.exe"}
+
+// ToastConfig configures native desktop notifications
+type ToastConfig struct {
+	Enabled      bool          `yaml:"enabled"`
+	Categories   []string      `yaml:"categories"`    // zone_enter categories that raise a toast; high-priority events always do
+	PublicOnly   bool          `yaml:"public_only"`   // Only toast zone_enter in public instances, or when the instance is unknown
+	DesktopOnly  bool          `yaml:"desktop_only"`  // Only toast while the game runs in desktop mode
+	Process      string        `yaml:"process"`       // Game executable checked for desktop mode
+	MinInterval  time.Duration `yaml:"min_interval"`  // Between any two toasts
+	DashboardURL string        `yaml:"dashboard_url"` // Opened when a toast is clicked; defaults to the local server
+}
+
+// DefaultToastConfig toasts fast approaches and Very Close in public instances
+// while VRChat runs without a headset
+func DefaultToastConfig() ToastConfig {
+	return ToastConfig{
+		Categories:  []string{"Very Close"},
+		PublicOnly:  true,
+		DesktopOnly: true,
+		Process:     "VRChat.exe",
+		MinInterval: 5 * time.Second,
+	}
+}
+
+// Toaster raises native desktop notifications for high-priority events:
+// Windows toasts, notify-send on Linux, and Notification Center on macOS
+type Toaster struct {
+	engine *engine.ProximityEngine
+	config ToastConfig
+
+	desktop atomic.Bool // Game is running without a VR runtime
+	stop    chan struct{}
+
+	mu       sync.Mutex
+	lastSent time.Time
+}
+
+// NewToaster creates a toaster; call Start to begin watching for desktop mode
+func NewToaster(pe *engine.ProximityEngine, config ToastConfig) *Toaster {
+	return &Toaster{engine: pe, config: config, stop: make(chan struct{})}
+}
+
+// Start watches the game process for desktop mode
+func (t *Toaster) Start() {
+	if t.config.DesktopOnly {
+		go t.watchDesktopMode()
+	}
+}
+
+// Stop ends the desktop mode watch
+func (t *Toaster) Stop() {
+	close(t.stop)
+}
+
+// watchDesktopMode rechecks desktop mode every few seconds
+func (t *Toaster) watchDesktopMode() {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		desktop, err := desktopMode(ctx, t.config.Process)
+		cancel()
+		if err != nil {
+			toastLog.Debug("Desktop mode check failed", "error", err)
+		} else if desktop != t.desktop.Swap(desktop) {
+			toastLog.Info("Game display mode changed", "desktop", desktop)
+		}
+
+		select {
+		case <-t.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// desktopMode reports whether the game is running launched with --no-vr
+// or with no VR runtime alongside it
+func desktopMode(ctx context.Context, game string) (bool, error) {
+	processes, err := process.ProcessesWithContext(ctx)
+	if err != nil {
+		return false, err
+	}
+	running, noVR, runtimeActive := false, false, false
+	for _, p := range processes {
+		name, err := p.NameWithContext(ctx)
+		if err != nil {
+			continue
+		}
+		switch {
+		case strings.EqualFold(name, game):
+			running = true
+			if cmdline, err := p.CmdlineWithContext(ctx); err == nil && strings.Contains(cmdline, "--no-vr") {
+				noVR = true
+			}
+		default:
+			for _, vr := range vrRuntimes {
+				if strings.EqualFold(name, vr) {
+					runtimeActive = true
+				}
+			}
+		}
+	}
+	return running && (noVR || !runtimeActive), nil
+}
+
+// HandleEvent raises a toast for high-priority events and close zone entries
+func (t *Toaster) HandleEvent(event engine.ProximityEvent) {
+	if !t.wants(event) {
+		return
+	}
+
+	t.mu.Lock()
+	now := time.Now()
+	if now.Sub(t.lastSent) < t.config.MinInterval {
+		t.mu.Unlock()
+		return
+	}
+	t.lastSent = now
+	t.mu.Unlock()
+
+	title, body := toastText(event)
+	go func() {
+		if err := t.show(title, body); err != nil {
+			toastLog.Warn("Desktop notification failed", "error", err)
+		}
+	}()
+}
+
+// wants applies the priority, category, instance, and desktop mode filters
+func (t *Toaster) wants(event engine.ProximityEvent) bool {
+	if t.config.DesktopOnly && !t.desktop.Load() {
+		return false
+	}
+	if event.Priority == engine.PriorityHigh {
+		return true
+	}
+	if event.Type != engine.EventZoneEnter {
+		return false
+	}
+	found := false
+	for _, c := range t.config.Categories {
+		found = found || c == event.Category
+	}
+	if !found {
+		return false
+	}
+	access := t.engine.Instance().Access()
+	return !t.config.PublicOnly || access == "" || access == engine.AccessPublic
+}
+
+// toastText describes an event in a title and a line of body text
+func toastText(event engine.ProximityEvent) (title, body string) {
+	who := "Someone"
+	if event.Detection != nil && event.Detection.Player != "" {
+		who = event.Detection.Player
+	}
+	switch event.Type {
+	case engine.EventFastApproach:
+		return "Fast approach", fmt.Sprintf("%s is closing in at %.1f m/s (~%.0fm)", who, event.ApproachRate, event.Distance)
+	case engine.EventZoneEnter:
+		return "Proximity alert", fmt.Sprintf("%s is %s (~%.0fm)", who, strings.ToLower(event.Category), event.Distance)
+	default:
+		return "VRChat Proximity", strings.ReplaceAll(event.Type, "_", " ")
+	}
+}
+
+// show raises the notification with the platform's notifier
+func (t *Toaster) show(title, body string) error {
+	switch runtime.GOOS {
+	case "windows":
+		var escaped [3]bytes.Buffer
+		for i, s := range []string{title, body, t.config.DashboardURL} {
+			xml.EscapeText(&escaped[i], []byte(s))
+		}
+		toast := fmt.Sprintf(`<toast activationType="protocol" launch="%s"><visual><binding template="ToastGeneric"><text>%s</text><text>%s</text></binding></visual></toast>`,
+			escaped[2].String(), escaped[0].String(), escaped[1].String())
+		script := `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null; ` +
+			`[Windows.Data.Xml.Dom.XmlDocument, Windows.Data.Xml.Dom.XmlDocument, ContentType = WindowsRuntime] | Out-Null; ` +
+			`$xml = New-Object Windows.Data.Xml.Dom.XmlDocument; $xml.LoadXml([Console]::In.ReadToEnd()); ` +
+			`[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('` + toastAppID + `').Show([Windows.UI.Notifications.ToastNotification]::new($xml))`
+		cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+		cmd.Stdin = strings.NewReader(toast)
+		return runNotifier(cmd)
+
+	case "darwin":
+		return runNotifier(exec.Command("osascript",
+			"-e", "on run argv", "-e", "display notification (item 2 of argv) with title (item 1 of argv)", "-e", "end run",
+			title, body))
+
+	default:
+		// Newer notify-send waits and prints the action name when clicked
+		out, err := exec.Command("notify-send", "-a", "VRChat Proximity", "-u", "critical", "--action=default=Open dashboard", title, body).Output()
+		if err != nil {
+			return runNotifier(exec.Command("notify-send", "-a", "VRChat Proximity", "-u", "critical", title, body))
+		}
+		if strings.TrimSpace(string(out)) == "default" && t.config.DashboardURL != "" {
+			return openBrowser(t.config.DashboardURL)
+		}
+		return nil
+	}
+}
+
+// runNotifier runs a notifier command, including its output in errors
+func runNotifier(cmd *exec.Cmd) error {
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %w: %s", cmd.Path, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"vrchat-proximity/pkg/engine"
+	"vrchat-proximity/pkg/logging"
+)
+
+// tracingLog is the "tracing" subsystem logger
+var tracingLog = logging.For("tracing")
+
+// TracingConfig configures OpenTelemetry traces of the frame pipeline
+type TracingConfig struct {
+	Enabled     bool              `yaml:"enabled"`
+	Endpoint    string            `yaml:"endpoint"` // OTLP/HTTP collector host:port, e.g. Jaeger or an OpenTelemetry Collector
+	URLPath     string            `yaml:"url_path"`
+	Insecure    bool              `yaml:"insecure"` // Plain HTTP instead of HTTPS
+	Headers     map[string]string `yaml:"headers"`  // Sent with every export, e.g. an API key
+	ServiceName string            `yaml:"service_name"`
+
+	// Frames are traced at SampleRatio, plus every frame slower than
+	// SlowFrame from capture to broadcast so stalls are never sampled away
+	SampleRatio float64       `yaml:"sample_ratio"`
+	SlowFrame   time.Duration `yaml:"slow_frame"` // 0 traces slow frames only at SampleRatio
+}
+
+// DefaultTracingConfig returns settings for a collector on this machine
+func DefaultTracingConfig() TracingConfig {
+	return TracingConfig{
+		Endpoint:    "localhost:4318",
+		URLPath:     "/v1/traces",
+		Insecure:    true,
+		ServiceName: "vrchat-proximity",
+		SampleRatio: 0.05,
+		SlowFrame:   250 * time.Millisecond,
+	}
+}
+
+// Validate checks the endpoint and sampling settings
+func (c TracingConfig) Validate() error {
+	if c.Enabled && c.Endpoint == "" {
+		return fmt.Errorf("endpoint must be set")
+	}
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		return fmt.Errorf("sample_ratio must be between 0 and 1")
+	}
+	if c.SlowFrame < 0 {
+		return fmt.Errorf("slow_frame must not be negative")
+	}
+	return nil
+}
+
+// Tracer exports each sampled frame as a trace with a span per pipeline
+// stage: capture, detect, convert, queue, and broadcast
+type Tracer struct {
+	config   TracingConfig
+	provider *sdktrace.TracerProvider
+	tracer   trace.Tracer
+}
+
+// NewTracer creates an OTLP/HTTP exporter; nothing is sent until Start
+func NewTracer(config TracingConfig) (*Tracer, error) {
+	options := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(config.Endpoint),
+		otlptracehttp.WithURLPath(config.URLPath),
+	}
+	if config.Insecure {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	if len(config.Headers) > 0 {
+		options = append(options, otlptracehttp.WithHeaders(config.Headers))
+	}
+	exporter, err := otlptracehttp.New(context.Background(), options...)
+	if err != nil {
+		return nil, fmt.Errorf("otlp exporter: %w", err)
+	}
+
+	// Export failures, e.g. an unreachable collector, would otherwise go to stderr
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		tracingLog.Warn("Exporting traces failed", "error", err)
+	}))
+
+	// Frames are sampled in export, once their duration is known
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(config.ServiceName))),
+	)
+	return &Tracer{
+		config:   config,
+		provider: provider,
+		tracer:   provider.Tracer("vrchat-proximity/pkg/engine"),
+	}, nil
+}
+
+// Start begins tracing pe's frames
+func (t *Tracer) Start(pe *engine.ProximityEngine) {
+	pe.OnFrameTrace(t.export)
+	tracingLog.Info("Tracing frames", "endpoint", t.config.Endpoint, "sample_ratio", t.config.SampleRatio, "slow_frame", t.config.SlowFrame)
+}
+
+// Stop flushes queued spans and shuts down the exporter
+func (t *Tracer) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := t.provider.Shutdown(ctx); err != nil {
+		tracingLog.Warn("Flushing traces failed", "error", err)
+	}
+}
+
+// sampled reports whether a frame is exported
+func (t *Tracer) sampled(frame engine.FrameTrace) bool {
+	if t.config.SlowFrame > 0 && frame.End().Sub(frame.Start()) >= t.config.SlowFrame {
+		return true
+	}
+	return rand.Float64() < t.config.SampleRatio
+}
+
+// export turns a frame's stages into a root span with a child per stage,
+// stamped with the times they actually ran
+func (t *Tracer) export(frame engine.FrameTrace) {
+	if len(frame.Stages) == 0 || !t.sampled(frame) {
+		return
+	}
+
+	attributes := []attribute.KeyValue{
+		attribute.Int("detections", frame.Detections),
+		attribute.Bool("dropped", frame.Dropped),
+	}
+	if frame.SourceID != "" {
+		attributes = append(attributes, attribute.String("source_id", frame.SourceID))
+	}
+	ctx, root := t.tracer.Start(context.Background(), "frame",
+		trace.WithTimestamp(frame.Start()),
+		trace.WithAttributes(attributes...),
+	)
+	for _, stage := range frame.Stages {
+		_, span := t.tracer.Start(ctx, stage.Stage, trace.WithTimestamp(stage.Start))
+		span.End(trace.WithTimestamp(stage.End))
+	}
+	if frame.Dropped {
+		root.SetStatus(codes.Error, "dropped because detection processing fell behind")
+	}
+	root.End(trace.WithTimestamp(frame.End()))
+}
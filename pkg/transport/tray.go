@@ -0,0 +1,215 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os/exec"
+	"runtime"
+	"sync"
+	"time"
+
+	"fyne.io/systray"
+
+	"vrchat-proximity/pkg/engine"
+	"vrchat-proximity/pkg/logging"
+)
+
+// trayLog is the "tray" subsystem logger
+var trayLog = logging.For("tray")
+
+// trayIdleColor is the icon color with nobody in range
+var trayIdleColor = color.RGBA{0x5a, 0x5f, 0x69, 0xff}
+
+// TrayConfig configures the system tray icon
+type TrayConfig struct {
+	Enabled      bool   `yaml:"enabled"`
+	DashboardURL string `yaml:"dashboard_url"` // Opened from the menu; defaults to the local server
+}
+
+// Tray shows the current zone as a colored system tray icon with menu
+// items to pause capture, open the dashboard, and quit
+type Tray struct {
+	engine *engine.ProximityEngine
+	config TrayConfig
+
+	mu       sync.Mutex
+	category string // Current zone, empty when nobody is near
+	distance float32
+
+	stop     chan struct{}
+	quit     chan struct{}
+	quitOnce sync.Once
+}
+
+// NewTray creates a tray for the engine; call Start to show it
+func NewTray(pe *engine.ProximityEngine, config TrayConfig) *Tray {
+	return &Tray{
+		engine: pe,
+		config: config,
+		stop:   make(chan struct{}),
+		quit:   make(chan struct{}),
+	}
+}
+
+// Start shows the tray icon. The native event loop runs on its own locked
+// OS thread, which Windows and Linux accept; macOS requires the main thread.
+func (t *Tray) Start() {
+	go func() {
+		runtime.LockOSThread()
+		systray.Run(t.onReady, nil)
+	}()
+}
+
+// Stop removes the tray icon
+func (t *Tray) Stop() {
+	close(t.stop)
+	systray.Quit()
+}
+
+// Done is closed when the user picks Quit from the tray menu
+func (t *Tray) Done() <-chan struct{} {
+	return t.quit
+}
+
+// HandleEvent tracks the current zone from zone_enter and zone_exit events
+func (t *Tray) HandleEvent(event engine.ProximityEvent) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch event.Type {
+	case engine.EventZoneEnter:
+		t.category, t.distance = event.Category, event.Distance
+	case engine.EventZoneExit:
+		if event.Category == t.category {
+			t.category, t.distance = "", 0
+		}
+	}
+}
+
+// onReady builds the menu and keeps the icon in sync with the engine
+func (t *Tray) onReady() {
+	systray.SetIcon(trayIcon(trayIdleColor))
+	systray.SetTooltip("VRChat Proximity")
+
+	status := systray.AddMenuItem("Nobody nearby", "")
+	status.Disable()
+	systray.AddSeparator()
+	pause := systray.AddMenuItem("Pause capture", "Pause or resume screen capture")
+	dashboard := systray.AddMenuItem("Open dashboard", t.config.DashboardURL)
+	systray.AddSeparator()
+	quit := systray.AddMenuItem("Quit", "Stop the proximity engine")
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	shownCategory, shownState := "-", ""
+	for {
+		select {
+		case <-t.stop:
+			return
+
+		case <-pause.ClickedCh:
+			var err error
+			switch t.engine.State() {
+			case engine.StateRunning:
+				err = t.engine.Pause()
+			default:
+				// Resumes a paused engine or starts a stopped one
+				err = t.engine.Start()
+			}
+			if err != nil {
+				trayLog.Warn("Tray control failed", "error", err)
+			}
+
+		case <-dashboard.ClickedCh:
+			if err := openBrowser(t.config.DashboardURL); err != nil {
+				trayLog.Warn("Could not open dashboard", "url", t.config.DashboardURL, "error", err)
+			}
+
+		case <-quit.ClickedCh:
+			t.quitOnce.Do(func() { close(t.quit) })
+			return
+
+		case <-ticker.C:
+			t.mu.Lock()
+			category, distance := t.category, t.distance
+			t.mu.Unlock()
+			state := t.engine.State()
+
+			if category != shownCategory {
+				shownCategory = category
+				c, ok := engine.CategoryColor(category)
+				if !ok {
+					c = trayIdleColor
+				}
+				systray.SetIcon(trayIcon(c))
+				if category == "" {
+					status.SetTitle("Nobody nearby")
+					systray.SetTooltip("VRChat Proximity: nobody nearby")
+				} else {
+					status.SetTitle(fmt.Sprintf("Nearest: %s (~%.0fm)", category, distance))
+					systray.SetTooltip("VRChat Proximity: " + category)
+				}
+			}
+
+			if state != shownState {
+				shownState = state
+				switch state {
+				case engine.StateRunning:
+					pause.SetTitle("Pause capture")
+				case engine.StatePaused:
+					pause.SetTitle("Resume capture")
+				default:
+					pause.SetTitle("Start engine")
+				}
+			}
+		}
+	}
+}
+
+// trayIcon draws a filled circle in c, as PNG or, on Windows, as an ICO
+// wrapping the PNG
+func trayIcon(c color.RGBA) []byte {
+	const size = 32
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	center, radius := float64(size-1)/2, float64(size)/2-2
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			dx, dy := float64(x)-center, float64(y)-center
+			if dx*dx+dy*dy <= radius*radius {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+
+	var encoded bytes.Buffer
+	png.Encode(&encoded, img)
+	if runtime.GOOS != "windows" {
+		return encoded.Bytes()
+	}
+
+	// ICONDIR followed by a single ICONDIRENTRY pointing at the PNG
+	var ico bytes.Buffer
+	binary.Write(&ico, binary.LittleEndian, [3]uint16{0, 1, 1})
+	ico.Write([]byte{size, size, 0, 0})
+	binary.Write(&ico, binary.LittleEndian, [2]uint16{1, 32})
+	binary.Write(&ico, binary.LittleEndian, [2]uint32{uint32(encoded.Len()), 22})
+	ico.Write(encoded.Bytes())
+	return ico.Bytes()
+}
+
+// openBrowser opens url in the default browser
+func openBrowser(url string) error {
+	switch runtime.GOOS {
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
+	case "darwin":
+		return exec.Command("open", url).Start()
+	default:
+		return exec.Command("xdg-open", url).Start()
+	}
+}
@@ -0,0 +1,244 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"vrchat-proximity/pkg/engine"
+	"vrchat-proximity/pkg/logging"
+)
+
+// vrchatLog is the "vrchat" subsystem logger
+var vrchatLog = logging.For("vrchat")
+
+// vrchatUserAgent identifies the app, as the VRChat API terms require
+const vrchatUserAgent = "vrchat-proximity/1.0 (+https://github.com/Wolf-G88/vrchat-proximity-app)"
+
+// vrchatMinInterval is the fastest the API is polled, well inside its rate limits
+const vrchatMinInterval = 30 * time.Second
+
+// vrchatMaxBackoff caps the wait after rate limiting or failures
+const vrchatMaxBackoff = 10 * time.Minute
+
+// errVRChatUnauthorized means the auth cookie was rejected
+var errVRChatUnauthorized = errors.New("vrchat: auth cookie rejected, log in again and update the cookie")
+
+// VRChatConfig configures the VRChat Web API integration
+type VRChatConfig struct {
+	Enabled        bool          `yaml:"enabled"`
+	AuthCookie     string        `yaml:"auth_cookie"`     // Value of the "auth" cookie from a logged-in browser; VRCHAT_AUTH_COOKIE overrides
+	Interval       time.Duration `yaml:"interval"`        // Time between polls, at least 30s
+	RequireFriends bool          `yaml:"require_friends"` // Mute alerts in instances without friends
+	BaseURL        string        `yaml:"base_url"`
+}
+
+// DefaultVRChatConfig polls once a minute without muting alerts
+func DefaultVRChatConfig() VRChatConfig {
+	return VRChatConfig{Interval: time.Minute, BaseURL: "https://api.vrchat.cloud/api/1"}
+}
+
+// vrchatUser is the part of /auth/user the watcher needs
+type vrchatUser struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName"`
+	Presence    struct {
+		World    string `json:"world"`
+		Instance string `json:"instance"`
+	} `json:"presence"`
+}
+
+// vrchatFriend is an online friend from /auth/user/friends
+type vrchatFriend struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName"`
+	Location    string `json:"location"` // "wrld_...:instance", "private", "offline", or "traveling"
+}
+
+// vrchatInstance is the part of /instances/{location} the watcher needs
+type vrchatInstance struct {
+	UserCount int `json:"userCount"`
+	NUsers    int `json:"n_users"` // Older name for userCount
+	World     struct {
+		Name string `json:"name"`
+	} `json:"world"`
+}
+
+// VRChatWatcher polls the VRChat API for the player's instance and which
+// friends are in it, and reports it to the engine
+type VRChatWatcher struct {
+	engine *engine.ProximityEngine
+	config VRChatConfig
+	client *http.Client
+	cookie string
+	stop   chan struct{}
+}
+
+// NewVRChatWatcher creates a watcher; call Start to begin polling
+func NewVRChatWatcher(pe *engine.ProximityEngine, config VRChatConfig) (*VRChatWatcher, error) {
+	cookie := config.AuthCookie
+	if env := os.Getenv("VRCHAT_AUTH_COOKIE"); env != "" {
+		cookie = env
+	}
+	if cookie == "" {
+		return nil, errors.New("vrchat: auth_cookie or VRCHAT_AUTH_COOKIE is required")
+	}
+	// Accept a bare auth cookie value or a full Cookie header including twoFactorAuth
+	if !strings.Contains(cookie, "=") {
+		cookie = "auth=" + cookie
+	}
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultVRChatConfig().BaseURL
+	}
+	config.Interval = max(config.Interval, vrchatMinInterval)
+
+	pe.SetAlertsRequireFriends(config.RequireFriends)
+	return &VRChatWatcher{
+		engine: pe,
+		config: config,
+		client: &http.Client{Timeout: 15 * time.Second},
+		cookie: cookie,
+		stop:   make(chan struct{}),
+	}, nil
+}
+
+// Start begins polling in the background
+func (w *VRChatWatcher) Start() {
+	go w.run()
+}
+
+// Stop ends polling
+func (w *VRChatWatcher) Stop() {
+	close(w.stop)
+}
+
+// run polls until stopped, backing off on rate limits and errors
+func (w *VRChatWatcher) run() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-w.stop
+		cancel()
+	}()
+
+	backoff := w.config.Interval
+	for {
+		wait := w.config.Interval
+		instance, err := w.poll(ctx)
+		var limited *vrchatRateLimited
+		switch {
+		case ctx.Err() != nil:
+			return
+		case errors.As(err, &limited):
+			backoff = min(max(backoff*2, limited.retryAfter), vrchatMaxBackoff)
+			wait = backoff
+			vrchatLog.Warn("VRChat API rate limited, backing off", "wait", wait)
+		case errors.Is(err, errVRChatUnauthorized):
+			wait = vrchatMaxBackoff
+			vrchatLog.Error("VRChat API login failed", "error", err)
+		case err != nil:
+			backoff = min(backoff*2, vrchatMaxBackoff)
+			wait = backoff
+			vrchatLog.Warn("VRChat API poll failed", "error", err, "retry", wait)
+		default:
+			backoff = w.config.Interval
+			w.engine.SetInstance(instance)
+			vrchatLog.Debug("Instance updated", "location", instance.Location, "users", instance.Users, "friends", len(instance.Friends))
+		}
+
+		select {
+		case <-w.stop:
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// poll resolves the current instance and the friends in it
+func (w *VRChatWatcher) poll(ctx context.Context) (engine.Instance, error) {
+	var user vrchatUser
+	if err := w.get(ctx, "/auth/user", nil, &user); err != nil {
+		return engine.Instance{}, err
+	}
+	instance := engine.Instance{UpdatedAt: time.Now()}
+	world, id := user.Presence.World, user.Presence.Instance
+	if !strings.HasPrefix(world, "wrld_") || id == "" || id == "private" {
+		// Offline, traveling, or in a private instance the API won't describe
+		return instance, nil
+	}
+	instance.Known = true
+	instance.Location = world + ":" + id
+
+	// Friends are paged 100 at a time
+	for offset := 0; ; offset += 100 {
+		var friends []vrchatFriend
+		query := url.Values{"offline": {"false"}, "n": {"100"}, "offset": {strconv.Itoa(offset)}}
+		if err := w.get(ctx, "/auth/user/friends", query, &friends); err != nil {
+			return engine.Instance{}, err
+		}
+		for _, f := range friends {
+			if f.Location == instance.Location {
+				instance.Friends = append(instance.Friends, f.DisplayName)
+			}
+		}
+		if len(friends) < 100 {
+			break
+		}
+	}
+
+	var details vrchatInstance
+	if err := w.get(ctx, "/instances/"+url.PathEscape(instance.Location), nil, &details); err != nil {
+		// The friend list is still useful without the user count
+		vrchatLog.Debug("Instance lookup failed", "location", instance.Location, "error", err)
+	}
+	instance.Users = max(details.UserCount, details.NUsers)
+	instance.World = details.World.Name
+	return instance, nil
+}
+
+// vrchatRateLimited is a 429 response
+type vrchatRateLimited struct {
+	retryAfter time.Duration
+}
+
+func (e *vrchatRateLimited) Error() string {
+	return "vrchat: rate limited"
+}
+
+// get fetches an API path into out
+func (w *VRChatWatcher) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	target := w.config.BaseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", vrchatUserAgent)
+	req.Header.Set("Cookie", w.cookie)
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		retry, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		return &vrchatRateLimited{retryAfter: time.Duration(retry) * time.Second}
+	case resp.StatusCode == http.StatusUnauthorized:
+		return errVRChatUnauthorized
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("vrchat: GET %s: %s", path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,187 @@
+package transport
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"vrchat-proximity/pkg/engine"
+)
+
+// vrchatLogPoll is how often the log directory and file are checked
+const vrchatLogPoll = time.Second
+
+// VRChat log lines the watcher acts on
+const (
+	vrchatLogJoining  = "[Behaviour] Joining wrld_"
+	vrchatLogEntering = "[Behaviour] Entering Room: "
+	vrchatLogLeft     = "[Behaviour] OnLeftRoom"
+)
+
+// VRChatLogConfig configures reading the instance from VRChat's own log
+type VRChatLogConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Dir     string `yaml:"dir"` // Folder of output_log_*.txt; empty uses VRChat's default
+}
+
+// DefaultVRChatLogConfig reads the log from VRChat's default folder
+func DefaultVRChatLogConfig() VRChatLogConfig {
+	return VRChatLogConfig{}
+}
+
+// defaultVRChatLogDir is %USERPROFILE%\AppData\LocalLow\VRChat\VRChat
+func defaultVRChatLogDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, "AppData", "LocalLow", "VRChat", "VRChat")
+}
+
+// VRChatLogWatcher follows the newest VRChat output log and reports the
+// instance joined, including its access type, to the engine. Unlike the API
+// it needs no login and also sees private instances.
+type VRChatLogWatcher struct {
+	engine *engine.ProximityEngine
+	dir    string
+	stop   chan struct{}
+
+	path     string
+	file     *os.File
+	reader   *bufio.Reader
+	partial  string
+	location string // Empty while not in an instance
+	world    string
+}
+
+// NewVRChatLogWatcher creates a watcher; call Start to begin reading
+func NewVRChatLogWatcher(pe *engine.ProximityEngine, config VRChatLogConfig) *VRChatLogWatcher {
+	dir := config.Dir
+	if dir == "" {
+		dir = defaultVRChatLogDir()
+	}
+	return &VRChatLogWatcher{engine: pe, dir: dir, stop: make(chan struct{})}
+}
+
+// Start begins following the log in the background
+func (w *VRChatLogWatcher) Start() {
+	vrchatLog.Info("Following VRChat log", "dir", w.dir)
+	go w.run()
+}
+
+// Stop ends following the log
+func (w *VRChatLogWatcher) Stop() {
+	close(w.stop)
+}
+
+// run switches to each new log file as VRChat starts and reads new lines
+func (w *VRChatLogWatcher) run() {
+	ticker := time.NewTicker(vrchatLogPoll)
+	defer ticker.Stop()
+	defer func() {
+		if w.file != nil {
+			w.file.Close()
+		}
+	}()
+
+	for {
+		if newest := w.newest(); newest != "" && newest != w.path {
+			w.open(newest)
+		}
+		if w.reader != nil {
+			if w.read() {
+				w.report()
+			}
+		}
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// newest returns the most recently modified output log, if any
+func (w *VRChatLogWatcher) newest() string {
+	matches, _ := filepath.Glob(filepath.Join(w.dir, "output_log_*.txt"))
+	var newest string
+	var newestTime time.Time
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err == nil && info.ModTime().After(newestTime) {
+			newest, newestTime = match, info.ModTime()
+		}
+	}
+	return newest
+}
+
+// open switches to a log file. It is read from the start, so the instance
+// is known straight away when VRChat was already running; only where the
+// file leaves off is reported.
+func (w *VRChatLogWatcher) open(path string) {
+	file, err := os.Open(path)
+	if err != nil {
+		vrchatLog.Debug("VRChat log open failed", "path", path, "error", err)
+		return
+	}
+	if w.file != nil {
+		w.file.Close()
+	}
+	w.path, w.file, w.reader, w.partial = path, file, bufio.NewReader(file), ""
+	w.location, w.world = "", ""
+	vrchatLog.Debug("Reading VRChat log", "path", path)
+	w.read()
+	w.report()
+}
+
+// read handles the complete lines written since the last read and reports
+// whether the instance changed
+func (w *VRChatLogWatcher) read() bool {
+	changed := false
+	for {
+		chunk, err := w.reader.ReadString('\n')
+		if err != nil {
+			// Keep a line VRChat is still writing for the next read
+			w.partial += chunk
+			if err != io.EOF {
+				vrchatLog.Debug("VRChat log read failed", "error", err)
+			}
+			return changed
+		}
+		line := strings.TrimRight(w.partial+chunk, "\r\n")
+		w.partial = ""
+		changed = w.handle(line) || changed
+	}
+}
+
+// handle updates the tracked instance from one log line
+func (w *VRChatLogWatcher) handle(line string) bool {
+	switch {
+	case strings.Contains(line, vrchatLogJoining):
+		location := "wrld_" + strings.TrimSpace(line[strings.Index(line, vrchatLogJoining)+len(vrchatLogJoining):])
+		if !strings.Contains(location, ":") {
+			return false
+		}
+		w.location, w.world = location, ""
+	case strings.Contains(line, vrchatLogEntering) && w.location != "":
+		w.world = strings.TrimSpace(line[strings.Index(line, vrchatLogEntering)+len(vrchatLogEntering):])
+	case strings.Contains(line, vrchatLogLeft):
+		w.location, w.world = "", ""
+	default:
+		return false
+	}
+	return true
+}
+
+// report passes the tracked instance to the engine
+func (w *VRChatLogWatcher) report() {
+	if w.location == "" {
+		w.engine.SetInstance(engine.Instance{UpdatedAt: time.Now()})
+		return
+	}
+	w.engine.SetInstanceLocation(w.location, w.world)
+	vrchatLog.Debug("Instance from VRChat log", "location", w.location, "world", w.world, "access", w.engine.Instance().Access())
+}
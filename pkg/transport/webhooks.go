@@ -0,0 +1,244 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"vrchat-proximity/pkg/engine"
+	"vrchat-proximity/pkg/logging"
+)
+
+// webhookLog is the "webhooks" subsystem logger
+var webhookLog = logging.For("webhooks")
+
+// WebhookTarget configures one outbound webhook
+type WebhookTarget struct {
+	Name        string        `json:"name" yaml:"name"` // Referenced by rule actions
+	URL         string        `json:"url" yaml:"url"`
+	Events      []string      `json:"events" yaml:"events"`             // Event types to send; empty sends all
+	MinCategory string        `json:"min_category" yaml:"min_category"` // Only send events at least this close
+	Debounce    time.Duration `json:"debounce" yaml:"debounce"`         // Suppress repeats of the same event type and category
+	Format      string        `json:"format" yaml:"format"`             // "json" (default) or "discord"
+}
+
+// UnmarshalJSON accepts debounce as a duration string such as "30s"
+func (t *WebhookTarget) UnmarshalJSON(data []byte) error {
+	type plain WebhookTarget
+	aux := struct {
+		*plain
+		Debounce string `json:"debounce"`
+	}{plain: (*plain)(t)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if aux.Debounce != "" {
+		d, err := time.ParseDuration(aux.Debounce)
+		if err != nil {
+			return fmt.Errorf("webhook debounce: %w", err)
+		}
+		t.Debounce = d
+	}
+	return nil
+}
+
+// WebhookConfig configures the webhook dispatcher
+type WebhookConfig struct {
+	Targets     []WebhookTarget `yaml:"targets"`
+	MaxAttempts int             `yaml:"max_attempts"`
+	Timeout     time.Duration   `yaml:"timeout"`
+	QueueSize   int             `yaml:"queue_size"`
+}
+
+// DefaultWebhookConfig returns retry settings without any targets
+func DefaultWebhookConfig() WebhookConfig {
+	return WebhookConfig{
+		MaxAttempts: 5,
+		Timeout:     5 * time.Second,
+		QueueSize:   256,
+	}
+}
+
+// webhookDelivery is a queued request for a single target
+type webhookDelivery struct {
+	target *WebhookTarget
+	event  engine.ProximityEvent
+}
+
+// WebhookDispatcher sends proximity events to configured webhooks
+type WebhookDispatcher struct {
+	config WebhookConfig
+	client *http.Client
+	queue  chan webhookDelivery
+
+	lastSent map[string]time.Time // Keyed by target URL, event type, and category
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+}
+
+// NewWebhookDispatcher creates a dispatcher and starts its delivery worker
+func NewWebhookDispatcher(config WebhookConfig) *WebhookDispatcher {
+	d := &WebhookDispatcher{
+		config:   config,
+		client:   &http.Client{Timeout: config.Timeout},
+		queue:    make(chan webhookDelivery, config.QueueSize),
+		lastSent: make(map[string]time.Time),
+	}
+	d.wg.Add(1)
+	go d.worker()
+	return d
+}
+
+// HandleEvent queues the event for every matching target. Rule events also
+// go to the targets their actions name, regardless of filters.
+func (d *WebhookDispatcher) HandleEvent(event engine.ProximityEvent) {
+	named := make(map[string]bool, len(event.Actions))
+	for _, action := range event.Actions {
+		if action.Webhook != "" {
+			named[action.Webhook] = true
+		}
+	}
+
+	for i := range d.config.Targets {
+		target := &d.config.Targets[i]
+		if target.Name != "" && named[target.Name] {
+			delete(named, target.Name)
+		} else if !d.matches(target, event) {
+			continue
+		}
+		select {
+		case d.queue <- webhookDelivery{target: target, event: event}:
+		default:
+			webhookLog.Warn("Webhook queue full, dropping event", "event", event.Type, "url", target.URL)
+		}
+	}
+	for name := range named {
+		webhookLog.Warn("Rule names an unknown webhook", "rule", event.Rule, "webhook", name)
+	}
+}
+
+// matches applies the event type, category, and debounce filters
+func (d *WebhookDispatcher) matches(target *WebhookTarget, event engine.ProximityEvent) bool {
+	if len(target.Events) > 0 {
+		found := false
+		for _, t := range target.Events {
+			if t == event.Type {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if target.MinCategory != "" && event.Category != "" && engine.CategoryRank(event.Category) > engine.CategoryRank(target.MinCategory) {
+		return false
+	}
+
+	if target.Debounce > 0 {
+		key := target.URL + "|" + event.Type + "|" + event.Category
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if time.Since(d.lastSent[key]) < target.Debounce {
+			return false
+		}
+		d.lastSent[key] = time.Now()
+	}
+
+	return true
+}
+
+// worker delivers queued webhooks one at a time
+func (d *WebhookDispatcher) worker() {
+	defer d.wg.Done()
+	for delivery := range d.queue {
+		d.deliver(delivery)
+	}
+}
+
+// deliver posts the event, retrying with exponential backoff
+func (d *WebhookDispatcher) deliver(delivery webhookDelivery) {
+	body, err := webhookBody(delivery.target.Format, delivery.event)
+	if err != nil {
+		webhookLog.Error("Webhook encode failed", "error", err)
+		return
+	}
+
+	backoff := time.Second
+	for attempt := 1; attempt <= d.config.MaxAttempts; attempt++ {
+		retryAfter, err := d.post(delivery.target.URL, body)
+		if err == nil {
+			return
+		}
+		if retryAfter < 0 {
+			webhookLog.Error("Webhook failed permanently", "url", delivery.target.URL, "error", err)
+			return
+		}
+		webhookLog.Warn("Webhook attempt failed", "url", delivery.target.URL, "attempt", attempt, "error", err)
+
+		if retryAfter == 0 {
+			retryAfter = backoff
+			backoff *= 2
+		}
+		if attempt < d.config.MaxAttempts {
+			time.Sleep(retryAfter)
+		}
+	}
+}
+
+// post sends one request. It returns a negative retry delay for errors that
+// should not be retried and a positive one when the server asked for it.
+func (d *WebhookDispatcher) post(url string, body []byte) (time.Duration, error) {
+	resp, err := d.client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode < 300:
+		return 0, nil
+	case resp.StatusCode == http.StatusTooManyRequests:
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			return time.Duration(secs) * time.Second, fmt.Errorf("rate limited")
+		}
+		return 0, fmt.Errorf("rate limited")
+	case resp.StatusCode >= 500:
+		return 0, fmt.Errorf("server error %d", resp.StatusCode)
+	default:
+		return -1, fmt.Errorf("status %d", resp.StatusCode)
+	}
+}
+
+// webhookBody renders the event in the target's format
+func webhookBody(format string, event engine.ProximityEvent) ([]byte, error) {
+	switch format {
+	case "", "json":
+		return json.Marshal(event)
+	case "discord":
+		content := fmt.Sprintf("**%s**", event.Type)
+		if event.Rule != "" {
+			content += " " + event.Rule + ":"
+		}
+		if event.Category != "" {
+			content += " " + event.Category
+		}
+		if event.Distance > 0 {
+			content += fmt.Sprintf(" (~%.1fm)", event.Distance)
+		}
+		return json.Marshal(map[string]string{"content": content})
+	default:
+		return nil, fmt.Errorf("unknown webhook format %q", format)
+	}
+}
+
+// Close waits for queued deliveries to finish
+func (d *WebhookDispatcher) Close() {
+	close(d.queue)
+	d.wg.Wait()
+}
@@ -0,0 +1,217 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v25.3.0
+// source: proximity/v1/proximity.proto
+
+package proximityv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	ProximityService_StreamDetections_FullMethodName = "/proximity.v1.ProximityService/StreamDetections"
+	ProximityService_GetStatus_FullMethodName        = "/proximity.v1.ProximityService/GetStatus"
+	ProximityService_Configure_FullMethodName        = "/proximity.v1.ProximityService/Configure"
+)
+
+// ProximityServiceClient is the client API for ProximityService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ProximityServiceClient interface {
+	// StreamDetections streams detection batches and, optionally, proximity events.
+	StreamDetections(ctx context.Context, in *StreamDetectionsRequest, opts ...grpc.CallOption) (ProximityService_StreamDetectionsClient, error)
+	// GetStatus returns engine state and counters.
+	GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*GetStatusResponse, error)
+	// Configure updates capture settings. Unset fields are left unchanged.
+	Configure(ctx context.Context, in *ConfigureRequest, opts ...grpc.CallOption) (*ConfigureResponse, error)
+}
+
+type proximityServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewProximityServiceClient(cc grpc.ClientConnInterface) ProximityServiceClient {
+	return &proximityServiceClient{cc}
+}
+
+func (c *proximityServiceClient) StreamDetections(ctx context.Context, in *StreamDetectionsRequest, opts ...grpc.CallOption) (ProximityService_StreamDetectionsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ProximityService_ServiceDesc.Streams[0], ProximityService_StreamDetections_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &proximityServiceStreamDetectionsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ProximityService_StreamDetectionsClient interface {
+	Recv() (*StreamDetectionsResponse, error)
+	grpc.ClientStream
+}
+
+type proximityServiceStreamDetectionsClient struct {
+	grpc.ClientStream
+}
+
+func (x *proximityServiceStreamDetectionsClient) Recv() (*StreamDetectionsResponse, error) {
+	m := new(StreamDetectionsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *proximityServiceClient) GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*GetStatusResponse, error) {
+	out := new(GetStatusResponse)
+	err := c.cc.Invoke(ctx, ProximityService_GetStatus_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *proximityServiceClient) Configure(ctx context.Context, in *ConfigureRequest, opts ...grpc.CallOption) (*ConfigureResponse, error) {
+	out := new(ConfigureResponse)
+	err := c.cc.Invoke(ctx, ProximityService_Configure_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ProximityServiceServer is the server API for ProximityService service.
+// All implementations must embed UnimplementedProximityServiceServer
+// for forward compatibility
+type ProximityServiceServer interface {
+	// StreamDetections streams detection batches and, optionally, proximity events.
+	StreamDetections(*StreamDetectionsRequest, ProximityService_StreamDetectionsServer) error
+	// GetStatus returns engine state and counters.
+	GetStatus(context.Context, *GetStatusRequest) (*GetStatusResponse, error)
+	// Configure updates capture settings. Unset fields are left unchanged.
+	Configure(context.Context, *ConfigureRequest) (*ConfigureResponse, error)
+	mustEmbedUnimplementedProximityServiceServer()
+}
+
+// UnimplementedProximityServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedProximityServiceServer struct {
+}
+
+func (UnimplementedProximityServiceServer) StreamDetections(*StreamDetectionsRequest, ProximityService_StreamDetectionsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamDetections not implemented")
+}
+func (UnimplementedProximityServiceServer) GetStatus(context.Context, *GetStatusRequest) (*GetStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStatus not implemented")
+}
+func (UnimplementedProximityServiceServer) Configure(context.Context, *ConfigureRequest) (*ConfigureResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Configure not implemented")
+}
+func (UnimplementedProximityServiceServer) mustEmbedUnimplementedProximityServiceServer() {}
+
+// UnsafeProximityServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ProximityServiceServer will
+// result in compilation errors.
+type UnsafeProximityServiceServer interface {
+	mustEmbedUnimplementedProximityServiceServer()
+}
+
+func RegisterProximityServiceServer(s grpc.ServiceRegistrar, srv ProximityServiceServer) {
+	s.RegisterService(&ProximityService_ServiceDesc, srv)
+}
+
+func _ProximityService_StreamDetections_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamDetectionsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ProximityServiceServer).StreamDetections(m, &proximityServiceStreamDetectionsServer{stream})
+}
+
+type ProximityService_StreamDetectionsServer interface {
+	Send(*StreamDetectionsResponse) error
+	grpc.ServerStream
+}
+
+type proximityServiceStreamDetectionsServer struct {
+	grpc.ServerStream
+}
+
+func (x *proximityServiceStreamDetectionsServer) Send(m *StreamDetectionsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _ProximityService_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProximityServiceServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProximityService_GetStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProximityServiceServer).GetStatus(ctx, req.(*GetStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProximityService_Configure_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConfigureRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProximityServiceServer).Configure(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProximityService_Configure_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProximityServiceServer).Configure(ctx, req.(*ConfigureRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ProximityService_ServiceDesc is the grpc.ServiceDesc for ProximityService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ProximityService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "proximity.v1.ProximityService",
+	HandlerType: (*ProximityServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetStatus",
+			Handler:    _ProximityService_GetStatus_Handler,
+		},
+		{
+			MethodName: "Configure",
+			Handler:    _ProximityService_Configure_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamDetections",
+			Handler:       _ProximityService_StreamDetections_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "proximity/v1/proximity.proto",
+}
@@ -91,11 +91,11 @@ def try_compile_go():
             subprocess.run(["go", "mod", "tidy"], cwd=".", check=True)
         
         # Try to compile
-        result = subprocess.run(["go", "build", "fast_network.go"], capture_output=True, text=True)
+        result = subprocess.run(["go", "build", "./cmd/vrchat-proximity"], capture_output=True, text=True)
         if result.returncode == 0:
             print("  ✅ Go module compiled successfully")
             # Clean up executable
-            exe_file = Path("fast_network.exe")
+            exe_file = Path("vrchat-proximity.exe")
             if exe_file.exists():
                 exe_file.unlink()
         else: